@@ -0,0 +1,78 @@
+// Package alerts turns scan results into notifications on the channels enabled in config.Notifications.
+package alerts
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "INFO" // held for the daily digest
+	SeverityWarning  Severity = "WARNING"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+const (
+	RuleScoreThreshold   = "score_threshold"
+	RuleWhale            = "whale"
+	RuleRSIOversold      = "rsi_oversold"
+	RuleBreakout         = "breakout"
+	RuleNegativeCatalyst = "negative_catalyst"
+	RuleDigest           = "digest"
+)
+
+// Event is one alert; Key identifies it for deduplication
+type Event struct {
+	Rule     string    `json:"rule"`
+	Symbol   string    `json:"symbol"`
+	Severity Severity  `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Key      string    `json:"key"`
+	Time     time.Time `json:"time"`
+}
+
+// NewSnapshot computes RSI(14) and whale events from the series
+func NewSnapshot(symbol string, series types.BarSeries) Snapshot {
+	s := Snapshot{Symbol: symbol, Series: series}
+
+	if rsiValues, err := strategy.CalculateRSI(series.Closes(), 14); err == nil && len(rsiValues) > 0 {
+		rsi := rsiValues[len(rsiValues)-1]
+		s.RSI = &rsi
+	}
+	s.Whales = strategy.DetectWhales(symbol, series)
+	return s
+}
+
+var (
+	dispatcherMu     sync.RWMutex
+	activeDispatcher *Dispatcher
+)
+
+// SetDispatcher changes where Check sends events; nil turns alerting off
+func SetDispatcher(d *Dispatcher) {
+	dispatcherMu.Lock()
+	defer dispatcherMu.Unlock()
+	activeDispatcher = d
+}
+
+func GetDispatcher() *Dispatcher {
+	dispatcherMu.RLock()
+	defer dispatcherMu.RUnlock()
+	return activeDispatcher
+}
+
+// Check evaluates the rules and dispatches any events; it does nothing until a dispatcher is set
+func Check(ctx context.Context, rules []Rule, s Snapshot) error {
+	d := GetDispatcher()
+	if d == nil {
+		return nil
+	}
+	return d.Dispatch(ctx, Evaluate(rules, s)...)
+}
@@ -0,0 +1,480 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []Event
+	fail   error // returned instead of recording while set
+}
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Notify(ctx context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	r.events = append(r.events, e)
+	return nil
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestDispatcher(t *testing.T, c *clock, notifiers ...Notifier) *Dispatcher {
+	t.Helper()
+	d, err := NewDispatcher(notifiers, Options{
+		Cooldown:    time.Hour,
+		DedupWindow: 24 * time.Hour,
+		DigestTime:  "08:00",
+		Location:    time.UTC,
+		Now:         c.now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// a series closing at the given prices, newest first
+func barsFromCloses(closes ...float64) types.BarSeries {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]types.Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = types.Bar{
+			Timestamp: start.AddDate(0, 0, len(closes)-i).Format(time.RFC3339),
+			Open:      c,
+			High:      c,
+			Low:       c,
+			Close:     c,
+			Volume:    1000,
+		}
+	}
+	series, _ := types.NewBarSeries(bars)
+	return series
+}
+
+func TestScoreThresholdRule(t *testing.T) {
+	rule := ScoreThresholdRule{Threshold: 4.0}
+	prev := 3.8
+
+	events := rule.Evaluate(Snapshot{Symbol: "AAPL", Score: 4.2, PrevScore: &prev})
+	if len(events) != 1 || events[0].Severity != SeverityWarning {
+		t.Fatalf("expected one upward crossing warning, got %+v", events)
+	}
+
+	prev = 4.5
+	events = rule.Evaluate(Snapshot{Symbol: "AAPL", Score: 3.9, PrevScore: &prev})
+	if len(events) != 1 || events[0].Severity != SeverityInfo {
+		t.Fatalf("expected one downward crossing info, got %+v", events)
+	}
+
+	if events := rule.Evaluate(Snapshot{Symbol: "AAPL", Score: 4.6, PrevScore: &prev}); len(events) != 0 {
+		t.Errorf("staying above the threshold should not alert, got %+v", events)
+	}
+	if events := rule.Evaluate(Snapshot{Symbol: "AAPL", Score: 4.6}); len(events) != 0 {
+		t.Errorf("first score has nothing to cross from, got %+v", events)
+	}
+}
+
+func TestWhaleRuleOnlyNewHighConviction(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := Snapshot{
+		Symbol: "TSLA",
+		Since:  since,
+		Whales: []strategy.WhaleEvent{
+			{Timestamp: "2026-02-27T00:00:00Z", Conviction: "HIGH", Direction: "BUY"},
+			{Timestamp: "2026-03-02T00:00:00Z", Conviction: "MEDIUM", Direction: "BUY"},
+			{Timestamp: "2026-03-03T00:00:00Z", Conviction: "HIGH", Direction: "SELL"},
+		},
+	}
+	events := WhaleRule{}.Evaluate(s)
+	if len(events) != 1 {
+		t.Fatalf("expected only the new HIGH whale, got %+v", events)
+	}
+	if events[0].Severity != SeverityCritical || !strings.Contains(events[0].Title, "SELL") {
+		t.Errorf("unexpected whale event %+v", events[0])
+	}
+}
+
+func TestRSIOversoldRule(t *testing.T) {
+	rule := RSIOversoldRule{Level: 30}
+	low, high := 25.0, 45.0
+	if events := rule.Evaluate(Snapshot{Symbol: "AMD", RSI: &low}); len(events) != 1 {
+		t.Errorf("RSI 25 should be oversold, got %+v", events)
+	}
+	if events := rule.Evaluate(Snapshot{Symbol: "AMD", RSI: &high}); len(events) != 0 {
+		t.Errorf("RSI 45 should not alert, got %+v", events)
+	}
+	if events := rule.Evaluate(Snapshot{Symbol: "AMD"}); len(events) != 0 {
+		t.Errorf("missing RSI should not alert, got %+v", events)
+	}
+}
+
+func TestBreakoutRule(t *testing.T) {
+	if events := (BreakoutRule{}).Evaluate(Snapshot{Symbol: "NVDA", Series: barsFromCloses(110, 100, 104, 98, 101)}); len(events) != 1 {
+		t.Errorf("close 110 over a 104 high should break out, got %+v", events)
+	}
+	if events := (BreakoutRule{}).Evaluate(Snapshot{Symbol: "NVDA", Series: barsFromCloses(104.2, 100, 104, 98, 101)}); len(events) != 0 {
+		t.Errorf("close within 0.5%% of resistance is not a breakout, got %+v", events)
+	}
+}
+
+func TestNegativeCatalystRule(t *testing.T) {
+	s := Snapshot{
+		Symbol: "META",
+		News: []newsscraping.NewsArticle{
+			{Headline: "META misses earnings guidance", URL: "https://example.com/1", Sentiment: newsscraping.Negative},
+			{Headline: "META shares drift", URL: "https://example.com/2", Sentiment: newsscraping.Negative},
+			{Headline: "META beats earnings", URL: "https://example.com/3", Sentiment: newsscraping.Positive},
+		},
+	}
+	events := NegativeCatalystRule{}.Evaluate(s)
+	if len(events) != 1 || !strings.Contains(events[0].Title, string(newsscraping.Earnings)) {
+		t.Fatalf("expected one negative earnings alert, got %+v", events)
+	}
+}
+
+func TestDispatcherDedupAndCooldown(t *testing.T) {
+	c := &clock{t: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)}
+	rec := &recordingNotifier{}
+	d := newTestDispatcher(t, c, rec)
+	ctx := context.Background()
+
+	whale := Event{Rule: RuleWhale, Symbol: "TSLA", Severity: SeverityCritical, Key: "whale:TSLA:1"}
+	d.Dispatch(ctx, whale)
+	d.Dispatch(ctx, whale)
+	if len(rec.events) != 1 {
+		t.Fatalf("duplicate key should be dropped, got %d events", len(rec.events))
+	}
+
+	// a different whale for the same symbol is still inside the cooldown
+	c.t = c.t.Add(30 * time.Minute)
+	d.Dispatch(ctx, Event{Rule: RuleWhale, Symbol: "TSLA", Severity: SeverityCritical, Key: "whale:TSLA:2"})
+	if len(rec.events) != 1 {
+		t.Fatalf("event inside cooldown should be dropped, got %d events", len(rec.events))
+	}
+
+	// other symbols have their own cooldown
+	d.Dispatch(ctx, Event{Rule: RuleWhale, Symbol: "AAPL", Severity: SeverityCritical, Key: "whale:AAPL:1"})
+	if len(rec.events) != 2 {
+		t.Fatalf("other symbol should alert, got %d events", len(rec.events))
+	}
+
+	c.t = c.t.Add(time.Hour)
+	d.Dispatch(ctx, Event{Rule: RuleWhale, Symbol: "TSLA", Severity: SeverityCritical, Key: "whale:TSLA:2"})
+	d.Dispatch(ctx, whale)
+	if len(rec.events) != 3 {
+		t.Fatalf("expected the new whale after the cooldown but not the repeat, got %d events", len(rec.events))
+	}
+
+	// once the dedup window passes the same key may alert again
+	c.t = c.t.Add(25 * time.Hour)
+	d.Dispatch(ctx, whale)
+	if len(rec.events) != 4 {
+		t.Fatalf("key should be accepted again after the dedup window, got %d events", len(rec.events))
+	}
+}
+
+func TestDispatcherDigest(t *testing.T) {
+	c := &clock{t: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)}
+	rec := &recordingNotifier{}
+	d := newTestDispatcher(t, c, rec)
+	ctx := context.Background()
+
+	d.Dispatch(ctx,
+		Event{Rule: RuleRSIOversold, Symbol: "MSFT", Severity: SeverityInfo, Title: "MSFT RSI oversold", Key: "rsi:MSFT"},
+		Event{Rule: RuleRSIOversold, Symbol: "AMD", Severity: SeverityInfo, Title: "AMD RSI oversold", Key: "rsi:AMD"},
+		Event{Rule: RuleBreakout, Symbol: "AMD", Severity: SeverityWarning, Title: "AMD broke out", Key: "breakout:AMD"},
+	)
+	if len(rec.events) != 1 || len(d.Pending()) != 2 {
+		t.Fatalf("expected the warning now and two held for the digest, got %d sent and %d pending", len(rec.events), len(d.Pending()))
+	}
+
+	if err := d.FlushDigest(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 2 || len(d.Pending()) != 0 {
+		t.Fatalf("digest should be one event and empty the queue, got %d sent and %d pending", len(rec.events), len(d.Pending()))
+	}
+	digest := rec.events[1]
+	if digest.Rule != RuleDigest || !strings.Contains(digest.Title, "2 alerts") {
+		t.Errorf("unexpected digest %+v", digest)
+	}
+	if strings.Index(digest.Message, "AMD") > strings.Index(digest.Message, "MSFT") {
+		t.Errorf("digest should be grouped by symbol, got %q", digest.Message)
+	}
+
+	if err := d.FlushDigest(ctx); err != nil || len(rec.events) != 2 {
+		t.Errorf("empty digest should send nothing")
+	}
+}
+
+func TestDispatcherKeepsDigestAcrossRuns(t *testing.T) {
+	c := &clock{t: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)}
+	rec := &recordingNotifier{}
+	path := filepath.Join(t.TempDir(), "pending.json")
+	open := func() *Dispatcher {
+		d, err := NewDispatcher([]Notifier{rec}, Options{DigestTime: "08:00", Location: time.UTC, Now: c.now, PendingPath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	ctx := context.Background()
+	rsi := Event{Rule: RuleRSIOversold, Symbol: "MSFT", Severity: SeverityInfo, Title: "MSFT RSI oversold", Key: "rsi:MSFT"}
+
+	// a one-shot scan in the afternoon holds its alert for tomorrow's digest
+	d := open()
+	d.Dispatch(ctx, rsi)
+	if err := d.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 0 {
+		t.Fatalf("closing before the digest time should send nothing, got %+v", rec.events)
+	}
+
+	// the next run picks it up and still treats it as a duplicate
+	c.t = c.t.Add(2 * time.Hour)
+	d = open()
+	d.Dispatch(ctx, rsi)
+	if len(d.Pending()) != 1 || d.DigestDue() {
+		t.Fatalf("pending = %+v, want the saved alert once and no digest due yet", d.Pending())
+	}
+	if err := d.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// after 08:00 the first run to close sends the digest and clears the file
+	c.t = time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC)
+	d = open()
+	if !d.DigestDue() {
+		t.Fatal("digest should be due the morning after")
+	}
+	if err := d.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 1 || rec.events[0].Rule != RuleDigest || !strings.Contains(rec.events[0].Title, "1 alerts") {
+		t.Errorf("expected one digest, got %+v", rec.events)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pending file should be removed after the digest, stat err %v", err)
+	}
+}
+
+func TestDispatchersClosingTogetherKeepBothDigests(t *testing.T) {
+	c := &clock{t: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)}
+	rec := &recordingNotifier{}
+	path := filepath.Join(t.TempDir(), "pending.json")
+	open := func() *Dispatcher {
+		d, err := NewDispatcher([]Notifier{rec}, Options{DigestTime: "08:00", Location: time.UTC, Now: c.now, PendingPath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	ctx := context.Background()
+
+	// two runs start before either has saved, so neither loads the other's alert
+	first, second := open(), open()
+	first.Dispatch(ctx, Event{Rule: RuleRSIOversold, Symbol: "MSFT", Severity: SeverityInfo, Title: "MSFT RSI oversold", Key: "rsi:MSFT"})
+	second.Dispatch(ctx, Event{Rule: RuleRSIOversold, Symbol: "AMD", Severity: SeverityInfo, Title: "AMD RSI oversold", Key: "rsi:AMD"})
+	if err := first.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := loadPending(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("pending file = %+v, want both runs' alerts", stored)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed, stat err %v", err)
+	}
+}
+
+func TestFailedDigestKeepsAlerts(t *testing.T) {
+	c := &clock{t: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)}
+	rec := &recordingNotifier{fail: errors.New("webhook down")}
+	path := filepath.Join(t.TempDir(), "pending.json")
+	d, err := NewDispatcher([]Notifier{rec}, Options{DigestTime: "08:00", Location: time.UTC, Now: c.now, PendingPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	d.Dispatch(ctx, Event{Rule: RuleRSIOversold, Symbol: "MSFT", Severity: SeverityInfo, Title: "MSFT RSI oversold", Key: "rsi:MSFT"})
+
+	if err := d.FlushDigest(ctx); err == nil {
+		t.Fatal("expected the notifier's error")
+	}
+	if len(d.Pending()) != 1 {
+		t.Fatalf("pending = %+v, want the alert kept after a failed digest", d.Pending())
+	}
+	if stored, err := loadPending(path); err != nil || len(stored) != 1 {
+		t.Fatalf("pending file = %+v, %v; want the alert saved for the next attempt", stored, err)
+	}
+
+	rec.fail = nil
+	if err := d.FlushDigest(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 1 || !strings.Contains(rec.events[0].Title, "1 alerts") {
+		t.Errorf("retry should send the alert once, got %+v", rec.events)
+	}
+	if len(d.Pending()) != 0 {
+		t.Errorf("pending = %+v, want nothing after the digest", d.Pending())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pending file should be removed after the digest, stat err %v", err)
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	d := newTestDispatcher(t, &clock{})
+	before := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	if got := d.NextDigest(before); !got.Equal(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected today's digest, got %v", got)
+	}
+	at := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	if got := d.NextDigest(at); !got.Equal(time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected tomorrow's digest, got %v", got)
+	}
+
+	if _, err := NewDispatcher(nil, Options{DigestTime: "8am"}); err == nil {
+		t.Error("expected an error for a malformed digest time")
+	}
+}
+
+func TestFileNotifierRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "alerts.log")
+	f, err := NewFileNotifier(path, 300, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 10; i++ {
+		e := Event{Rule: RuleWhale, Symbol: "TSLA", Severity: SeverityCritical, Title: fmt.Sprintf("whale %d", i), Message: "volume spike"}
+		if err := f.Notify(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("%s is %d bytes, over the rotation size", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("only two backups should be kept")
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var last Event
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last.Title != "whale 9" {
+		t.Errorf("newest event should be the last line of the live file, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	w := NewWebhookNotifier(server.URL)
+	e := Event{Rule: RuleBreakout, Symbol: "NVDA", Severity: SeverityWarning, Title: "NVDA broke out", Message: "Close $110.00", Time: time.Now()}
+	if err := w.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.Content, "NVDA broke out") || len(got.Embeds) != 1 {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+	if got.Embeds[0].Description != "Close $110.00" || got.Embeds[0].Color != severityColors[SeverityWarning] {
+		t.Errorf("unexpected embed %+v", got.Embeds[0])
+	}
+}
+
+func TestWebhookNotifierRetriesRateLimit(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.01}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), Event{Title: "x"}); err != nil {
+		t.Fatalf("expected the retry to succeed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestDispatcherKeepsDeliveringWhenAChannelFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad webhook", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var console bytes.Buffer
+	d := newTestDispatcher(t, &clock{t: time.Now()}, NewWebhookNotifier(server.URL), NewConsoleNotifier(&console))
+	err := d.Dispatch(context.Background(), Event{Rule: RuleWhale, Symbol: "TSLA", Severity: SeverityCritical, Title: "whale", Key: "k"})
+	if err == nil || !strings.Contains(err.Error(), "discord") {
+		t.Errorf("expected the webhook error, got %v", err)
+	}
+	if !strings.Contains(console.String(), "whale") {
+		t.Errorf("console should still get the event, got %q", console.String())
+	}
+}
+
+func TestCheckWithoutDispatcherIsNoop(t *testing.T) {
+	SetDispatcher(nil)
+	low := 10.0
+	if err := Check(context.Background(), []Rule{RSIOversoldRule{Level: 30}}, Snapshot{Symbol: "X", RSI: &low}); err != nil {
+		t.Fatal(err)
+	}
+}
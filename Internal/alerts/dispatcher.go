@@ -0,0 +1,475 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+const (
+	defaultCooldown    = time.Hour
+	defaultDedupWindow = 24 * time.Hour
+	defaultDigestTime  = "08:00"
+	defaultLogPath     = "logs/alerts.log"
+	defaultPendingPath = "logs/alerts_pending.json"
+	defaultLogSizeMB   = 5
+
+	pendingLockStale = 30 * time.Second
+)
+
+// Options tune a Dispatcher; zero values fall back to the defaults
+type Options struct {
+	Cooldown    time.Duration // per rule and symbol
+	DedupWindow time.Duration // per event key
+	DigestTime  string        // HH:MM in Location
+	PendingPath string        // keeps digest events between runs; empty holds them in memory only
+	Location    *time.Location
+	Now         func() time.Time
+}
+
+// Dispatcher sends warning and critical events right away and holds info events for the daily digest.
+// Repeats of an event key inside DedupWindow, and anything from a rule and symbol still cooling down, are dropped.
+// Held events are merged into PendingPath on Close, so one-shot commands add to the next digest
+// instead of sending their own.
+type Dispatcher struct {
+	notifiers []Notifier
+	opts      Options
+	digestMin int
+
+	mu       sync.Mutex
+	seen     map[string]time.Time // event key -> last accepted
+	lastSent map[string]time.Time // rule:symbol -> last accepted
+	pending  []Event
+}
+
+func NewDispatcher(notifiers []Notifier, opts Options) (*Dispatcher, error) {
+	if opts.Cooldown == 0 {
+		opts.Cooldown = defaultCooldown
+	}
+	if opts.DedupWindow == 0 {
+		opts.DedupWindow = defaultDedupWindow
+	}
+	if opts.DigestTime == "" {
+		opts.DigestTime = defaultDigestTime
+	}
+	if opts.Location == nil {
+		opts.Location = marketLocation()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	digest, err := time.Parse("15:04", opts.DigestTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch_digest_time %q: want HH:MM", opts.DigestTime)
+	}
+	d := &Dispatcher{
+		notifiers: notifiers,
+		opts:      opts,
+		digestMin: digest.Hour()*60 + digest.Minute(),
+		seen:      map[string]time.Time{},
+		lastSent:  map[string]time.Time{},
+	}
+	if opts.PendingPath != "" {
+		pending, err := loadPending(opts.PendingPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range pending {
+			if e.Key != "" {
+				d.seen[e.Key] = e.Time
+			}
+		}
+	}
+	return d, nil
+}
+
+// loadPending reads the digest events an earlier run left behind
+func loadPending(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending alerts: %w", err)
+	}
+	var events []Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse pending alerts %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// updatePending rewrites PendingPath with what fn returns for its current contents, holding a
+// lock file so runs that close at the same time don't overwrite each other's events
+func (d *Dispatcher) updatePending(fn func(stored []Event) []Event) error {
+	path := d.opts.PendingPath
+	if path == "" {
+		fn(nil)
+		return nil
+	}
+	unlock, err := lockPending(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	stored, err := loadPending(path)
+	if err != nil {
+		return err
+	}
+	return writePending(path, fn(stored))
+}
+
+// lockPending creates path.lock, waiting for another run to remove it; a lock older than
+// pendingLockStale was left by a run that died and is taken over
+func lockPending(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pending alerts directory: %w", err)
+	}
+	lock := path + ".lock"
+	deadline := time.Now().Add(pendingLockStale)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock pending alerts: %w", err)
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > pendingLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for pending alerts lock %s", lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writePending replaces path with events through a rename, so readers never see half a file,
+// and removes it when there are none
+func writePending(path string, events []Event) error {
+	if len(events) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear pending alerts: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save pending alerts: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save pending alerts: %w", err)
+	}
+	return nil
+}
+
+// eventID tells held events apart when merging them with the pending file
+func eventID(e Event) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d", e.Rule, e.Symbol, e.Key, e.Title, e.Time.UnixNano())
+}
+
+// mergeEvents is a followed by the events of b it doesn't already hold
+func mergeEvents(a, b []Event) []Event {
+	merged := append([]Event(nil), a...)
+	have := make(map[string]bool, len(a))
+	for _, e := range a {
+		have[eventID(e)] = true
+	}
+	for _, e := range b {
+		if !have[eventID(e)] {
+			have[eventID(e)] = true
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
+
+// withoutEvents is from minus the events in drop
+func withoutEvents(from, drop []Event) []Event {
+	dropped := make(map[string]bool, len(drop))
+	for _, e := range drop {
+		dropped[eventID(e)] = true
+	}
+	var kept []Event
+	for _, e := range from {
+		if !dropped[eventID(e)] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// digest times follow the exchange clock, like CheckMarketStatus
+func marketLocation() *time.Location {
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// NewDispatcherFromConfig builds the channels enabled in cfg.Notifications.
+// Discord needs DISCORD_WEBHOOK_URL; without it the channel is skipped with a warning.
+func NewDispatcherFromConfig(cfg *config.Config, console io.Writer) (*Dispatcher, error) {
+	n := cfg.Notifications
+	var notifiers []Notifier
+	if n.Channels.Console {
+		notifiers = append(notifiers, NewConsoleNotifier(console))
+	}
+	if n.Channels.FileLog {
+		path := n.LogFile.Path
+		if path == "" {
+			path = defaultLogPath
+		}
+		sizeMB := n.LogFile.MaxSizeMB
+		if sizeMB <= 0 {
+			sizeMB = defaultLogSizeMB
+		}
+		file, err := NewFileNotifier(path, int64(sizeMB)<<20, n.LogFile.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, file)
+	}
+	if n.Channels.Discord {
+		if url := os.Getenv("DISCORD_WEBHOOK_URL"); url != "" {
+			notifiers = append(notifiers, NewWebhookNotifier(url))
+		} else {
+			logger.Component("alerts").Warn("discord alerts enabled but DISCORD_WEBHOOK_URL is not set")
+		}
+	}
+
+	pendingPath := n.PendingFile
+	if pendingPath == "" {
+		pendingPath = defaultPendingPath
+	}
+	return NewDispatcher(notifiers, Options{
+		Cooldown:    time.Duration(n.CooldownMinutes) * time.Minute,
+		DedupWindow: time.Duration(n.DedupHours) * time.Hour,
+		DigestTime:  n.BatchDigestTime,
+		PendingPath: pendingPath,
+	})
+}
+
+// accept applies deduplication and cooldowns, recording the event when it passes
+func (d *Dispatcher) accept(e Event, now time.Time) bool {
+	for key, at := range d.seen {
+		if now.Sub(at) >= d.opts.DedupWindow {
+			delete(d.seen, key)
+		}
+	}
+	if e.Key != "" {
+		if _, dup := d.seen[e.Key]; dup {
+			return false
+		}
+	}
+	cooldownKey := e.Rule + ":" + e.Symbol
+	if at, ok := d.lastSent[cooldownKey]; ok && now.Sub(at) < d.opts.Cooldown {
+		return false
+	}
+	if e.Key != "" {
+		d.seen[e.Key] = now
+	}
+	d.lastSent[cooldownKey] = now
+	return true
+}
+
+// Dispatch delivers events that pass deduplication and cooldowns.
+// A failing channel doesn't stop the others; their errors are joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...Event) error {
+	now := d.opts.Now()
+	var immediate []Event
+
+	d.mu.Lock()
+	for _, e := range events {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		if !d.accept(e, now) {
+			continue
+		}
+		if e.Severity == SeverityInfo {
+			d.pending = append(d.pending, e)
+		} else {
+			immediate = append(immediate, e)
+		}
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	for _, e := range immediate {
+		errs = append(errs, d.send(ctx, e))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) send(ctx context.Context, e Event) error {
+	var errs []error
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Pending returns the events waiting for the next digest: this run's and those saved by earlier runs
+func (d *Dispatcher) Pending() []Event {
+	d.mu.Lock()
+	own := append([]Event(nil), d.pending...)
+	d.mu.Unlock()
+	return mergeEvents(own, d.storedPending())
+}
+
+// storedPending reads PendingPath without the lock; writes go through a rename so it is never partial
+func (d *Dispatcher) storedPending() []Event {
+	if d.opts.PendingPath == "" {
+		return nil
+	}
+	stored, err := loadPending(d.opts.PendingPath)
+	if err != nil {
+		logger.Component("alerts").Warn("pending alerts unreadable", logger.Err(err))
+	}
+	return stored
+}
+
+// FlushDigest sends the held events, this run's and those saved by earlier runs, as one summary,
+// oldest first, grouped by symbol. They are cleared only once the digest is sent; if it fails they
+// stay held and saved for the next attempt.
+func (d *Dispatcher) FlushDigest(ctx context.Context) error {
+	var sendErr error
+	err := d.updatePending(func(stored []Event) []Event {
+		d.mu.Lock()
+		pending := mergeEvents(d.pending, stored)
+		d.mu.Unlock()
+		if len(pending) == 0 {
+			return nil
+		}
+
+		sort.SliceStable(pending, func(i, j int) bool {
+			if pending[i].Symbol != pending[j].Symbol {
+				return pending[i].Symbol < pending[j].Symbol
+			}
+			return pending[i].Time.Before(pending[j].Time)
+		})
+		var lines []string
+		for _, e := range pending {
+			lines = append(lines, fmt.Sprintf("• %s: %s", e.Title, e.Message))
+		}
+		now := d.opts.Now()
+		sendErr = d.send(ctx, Event{
+			Rule:     RuleDigest,
+			Severity: SeverityInfo,
+			Title:    fmt.Sprintf("Daily digest: %d alerts", len(pending)),
+			Message:  strings.Join(lines, "\n"),
+			Key:      fmt.Sprintf("%s:%s", RuleDigest, now.Format(time.RFC3339)),
+			Time:     now,
+		})
+		if sendErr != nil {
+			return pending
+		}
+		d.mu.Lock()
+		d.pending = withoutEvents(d.pending, pending)
+		d.mu.Unlock()
+		return nil
+	})
+	if sendErr != nil {
+		sendErr = fmt.Errorf("alert digest not sent, alerts kept for the next one: %w", sendErr)
+	}
+	return errors.Join(sendErr, err)
+}
+
+// DigestDue reports whether a digest time has passed since the oldest held event
+func (d *Dispatcher) DigestDue() bool {
+	pending := d.Pending()
+	if len(pending) == 0 {
+		return false
+	}
+	oldest := pending[0].Time
+	for _, e := range pending[1:] {
+		if e.Time.Before(oldest) {
+			oldest = e.Time
+		}
+	}
+	return !d.NextDigest(oldest).After(d.opts.Now())
+}
+
+// NextDigest is the first digest time strictly after t
+func (d *Dispatcher) NextDigest(t time.Time) time.Time {
+	local := t.In(d.opts.Location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), d.digestMin/60, d.digestMin%60, 0, 0, d.opts.Location)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Run flushes the digest every day at the digest time until ctx is cancelled,
+// starting with any digest an earlier run left overdue
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.DigestDue() {
+		if err := d.FlushDigest(ctx); err != nil {
+			logger.Component("alerts").Error("alert digest failed", logger.Err(err))
+		}
+	}
+	for {
+		wait := d.NextDigest(d.opts.Now()).Sub(d.opts.Now())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := d.FlushDigest(ctx); err != nil {
+				logger.Component("alerts").Error("alert digest failed", logger.Err(err))
+			}
+		}
+	}
+}
+
+// Close sends the digest if its time has passed and otherwise saves the held events for the
+// next run (without a PendingPath they are sent now rather than lost), then closes the
+// channels that hold files
+func (d *Dispatcher) Close(ctx context.Context) error {
+	var errs []error
+	if d.opts.PendingPath == "" || d.DigestDue() {
+		errs = append(errs, d.FlushDigest(ctx))
+	} else {
+		errs = append(errs, d.updatePending(func(stored []Event) []Event {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			return mergeEvents(stored, d.pending)
+		}))
+	}
+	for _, n := range d.notifiers {
+		if c, ok := n.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
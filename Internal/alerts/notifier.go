@@ -0,0 +1,248 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Notifier delivers events to one channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, e Event) error
+}
+
+var severityIcons = map[Severity]string{
+	SeverityInfo:     "ℹ️",
+	SeverityWarning:  "⚠️",
+	SeverityCritical: "🚨",
+}
+
+// ConsoleNotifier prints one line per event
+type ConsoleNotifier struct {
+	Out io.Writer
+}
+
+func NewConsoleNotifier(out io.Writer) *ConsoleNotifier {
+	return &ConsoleNotifier{Out: out}
+}
+
+func (c *ConsoleNotifier) Name() string { return "console" }
+
+func (c *ConsoleNotifier) Notify(ctx context.Context, e Event) error {
+	_, err := fmt.Fprintf(c.Out, "%s [%s] %s: %s\n", severityIcons[e.Severity], e.Severity, e.Title, e.Message)
+	return err
+}
+
+// FileNotifier appends events to a log file, rotating it to path.1..path.N once it passes maxBytes
+type FileNotifier struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileNotifier(path string, maxBytes int64, maxBackups int) (*FileNotifier, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create alert log directory: %w", err)
+	}
+	f := &FileNotifier{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileNotifier) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open alert log %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat alert log %s: %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *FileNotifier) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close alert log: %w", err)
+	}
+	if f.maxBackups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxBackups))
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate alert log: %w", err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("failed to rotate alert log: %w", err)
+	}
+	return f.open()
+}
+
+func (f *FileNotifier) Name() string { return "file_log" }
+
+func (f *FileNotifier) Notify(ctx context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return fmt.Errorf("alert log %s is closed", f.path)
+	}
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(line)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write alert log: %w", err)
+	}
+	return nil
+}
+
+func (f *FileNotifier) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// WebhookNotifier posts Discord-compatible webhook messages
+type WebhookNotifier struct {
+	URL      string
+	Client   *http.Client
+	MaxRetry time.Duration // longest rate-limit wait honored before giving up
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:      url,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		MaxRetry: 10 * time.Second,
+	}
+}
+
+var severityColors = map[Severity]int{
+	SeverityInfo:     0x3498db,
+	SeverityWarning:  0xf1c40f,
+	SeverityCritical: 0xe74c3c,
+}
+
+type webhookEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp,omitempty"`
+	Footer      struct {
+		Text string `json:"text"`
+	} `json:"footer"`
+}
+
+type webhookPayload struct {
+	Username string         `json:"username"`
+	Content  string         `json:"content"`
+	Embeds   []webhookEmbed `json:"embeds"`
+}
+
+func (w *WebhookNotifier) Name() string { return "discord" }
+
+func (w *WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	embed := webhookEmbed{
+		Title:       e.Title,
+		Description: e.Message,
+		Color:       severityColors[e.Severity],
+	}
+	if !e.Time.IsZero() {
+		embed.Timestamp = e.Time.UTC().Format(time.RFC3339)
+	}
+	embed.Footer.Text = fmt.Sprintf("%s · %s", e.Rule, e.Severity)
+	body, err := json.Marshal(webhookPayload{
+		Username: "MongelMaker",
+		Content:  fmt.Sprintf("%s %s", severityIcons[e.Severity], e.Title),
+		Embeds:   []webhookEmbed{embed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	wait, err := w.post(ctx, body)
+	if err == nil || wait == 0 {
+		return err
+	}
+	// Discord answers 429 with how long to back off; one retry is enough for a burst of alerts
+	if wait > w.MaxRetry {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+	_, err = w.post(ctx, body)
+	return err
+}
+
+// post returns the rate-limit wait when the webhook answers 429
+func (w *WebhookNotifier) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp.Header, respBody), fmt.Errorf("webhook rate limited: %s", respBody)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, respBody)
+	}
+	return 0, nil
+}
+
+func retryAfter(header http.Header, body []byte) time.Duration {
+	var limited struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &limited) == nil && limited.RetryAfter > 0 {
+		return time.Duration(limited.RetryAfter * float64(time.Second))
+	}
+	if seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return time.Second
+}
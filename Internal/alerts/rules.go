@@ -0,0 +1,198 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+// Snapshot is what one scan knows about a symbol
+type Snapshot struct {
+	Symbol    string
+	Score     float64
+	PrevScore *float64 // nil when the symbol has not been scored before
+	RSI       *float64
+	Series    types.BarSeries
+	Whales    []strategy.WhaleEvent
+	News      []newsscraping.NewsArticle
+	Since     time.Time // whales and news older than this were already seen; zero means all are new
+}
+
+// Rule turns a snapshot into zero or more events
+type Rule interface {
+	Name() string
+	Evaluate(s Snapshot) []Event
+}
+
+// ScoreThresholdRule fires when the interest score crosses the profile threshold
+type ScoreThresholdRule struct {
+	Threshold float64
+}
+
+func (r ScoreThresholdRule) Name() string { return RuleScoreThreshold }
+
+func (r ScoreThresholdRule) Evaluate(s Snapshot) []Event {
+	if s.PrevScore == nil || r.Threshold <= 0 {
+		return nil
+	}
+	prev := *s.PrevScore
+	switch {
+	case prev < r.Threshold && s.Score >= r.Threshold:
+		return []Event{{
+			Rule:     RuleScoreThreshold,
+			Symbol:   s.Symbol,
+			Severity: SeverityWarning,
+			Title:    fmt.Sprintf("%s score crossed above %.2f", s.Symbol, r.Threshold),
+			Message:  fmt.Sprintf("Interest score rose from %.2f to %.2f", prev, s.Score),
+			Key:      fmt.Sprintf("%s:%s:up", RuleScoreThreshold, s.Symbol),
+		}}
+	case prev >= r.Threshold && s.Score < r.Threshold:
+		return []Event{{
+			Rule:     RuleScoreThreshold,
+			Symbol:   s.Symbol,
+			Severity: SeverityInfo,
+			Title:    fmt.Sprintf("%s score fell below %.2f", s.Symbol, r.Threshold),
+			Message:  fmt.Sprintf("Interest score dropped from %.2f to %.2f", prev, s.Score),
+			Key:      fmt.Sprintf("%s:%s:down", RuleScoreThreshold, s.Symbol),
+		}}
+	}
+	return nil
+}
+
+// WhaleRule fires once per HIGH-conviction whale detected after Since
+type WhaleRule struct{}
+
+func (WhaleRule) Name() string { return RuleWhale }
+
+func (WhaleRule) Evaluate(s Snapshot) []Event {
+	var events []Event
+	for _, whale := range s.Whales {
+		if whale.Conviction != "HIGH" {
+			continue
+		}
+		if ts, err := time.Parse(time.RFC3339, whale.Timestamp); err == nil && !s.Since.IsZero() && !ts.After(s.Since) {
+			continue
+		}
+		events = append(events, Event{
+			Rule:     RuleWhale,
+			Symbol:   s.Symbol,
+			Severity: SeverityCritical,
+			Title:    fmt.Sprintf("HIGH conviction %s whale on %s", whale.Direction, s.Symbol),
+			Message:  fmt.Sprintf("Volume %d (z-score %.2f) at $%.2f on %s", whale.Volume, whale.ZScore, whale.ClosePrice, whale.Timestamp),
+			Key:      fmt.Sprintf("%s:%s:%s", RuleWhale, s.Symbol, whale.Timestamp),
+		})
+	}
+	return events
+}
+
+// RSIOversoldRule fires when RSI drops below the profile's min_oversold
+type RSIOversoldRule struct {
+	Level float64
+}
+
+func (r RSIOversoldRule) Name() string { return RuleRSIOversold }
+
+func (r RSIOversoldRule) Evaluate(s Snapshot) []Event {
+	if s.RSI == nil || r.Level <= 0 || *s.RSI >= r.Level {
+		return nil
+	}
+	return []Event{{
+		Rule:     RuleRSIOversold,
+		Symbol:   s.Symbol,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("%s RSI oversold", s.Symbol),
+		Message:  fmt.Sprintf("RSI %.2f is below %.0f", *s.RSI, r.Level),
+		Key:      fmt.Sprintf("%s:%s:%s", RuleRSIOversold, s.Symbol, s.Series.Latest().Timestamp),
+	}}
+}
+
+// BreakoutRule fires when the latest close clears the highest high of the bars before it
+type BreakoutRule struct{}
+
+func (BreakoutRule) Name() string { return RuleBreakout }
+
+func (BreakoutRule) Evaluate(s Snapshot) []Event {
+	if s.Series.Len() < 4 {
+		return nil
+	}
+	resistance := strategy.FindResistance(s.Series.Slice(0, s.Series.Len()-1))
+	price := s.Series.Latest().Close
+	if !strategy.IsBreakoutAboveResistance(price, resistance) {
+		return nil
+	}
+	return []Event{{
+		Rule:     RuleBreakout,
+		Symbol:   s.Symbol,
+		Severity: SeverityWarning,
+		Title:    fmt.Sprintf("%s broke out above resistance", s.Symbol),
+		Message:  fmt.Sprintf("Close $%.2f is above resistance $%.2f", price, resistance),
+		Key:      fmt.Sprintf("%s:%s:%s", RuleBreakout, s.Symbol, s.Series.Latest().Timestamp),
+	}}
+}
+
+// NegativeCatalystRule fires for negative articles that carry a catalyst
+type NegativeCatalystRule struct{}
+
+func (NegativeCatalystRule) Name() string { return RuleNegativeCatalyst }
+
+func (NegativeCatalystRule) Evaluate(s Snapshot) []Event {
+	detector := newsscraping.NewCatalystDetector()
+	var events []Event
+	for _, article := range s.News {
+		if article.Sentiment != newsscraping.Negative {
+			continue
+		}
+		if !s.Since.IsZero() && !article.PublishedAt.After(s.Since) {
+			continue
+		}
+		// stored articles don't keep their catalyst, so it is detected again from the headline
+		catalyst := article.CatalystType
+		if catalyst == "" {
+			catalyst = detector.Detect(article.Headline)
+		}
+		if catalyst == newsscraping.NoCatalyst {
+			continue
+		}
+		key := article.URL
+		if key == "" {
+			key = article.Headline
+		}
+		events = append(events, Event{
+			Rule:     RuleNegativeCatalyst,
+			Symbol:   s.Symbol,
+			Severity: SeverityWarning,
+			Title:    fmt.Sprintf("Negative %s news for %s", catalyst, s.Symbol),
+			Message:  article.Headline,
+			Key:      fmt.Sprintf("%s:%s:%s", RuleNegativeCatalyst, s.Symbol, key),
+		})
+	}
+	return events
+}
+
+// DefaultRules builds every rule with the named profile's threshold and RSI level
+func DefaultRules(cfg *config.Config, profileName string) []Rule {
+	rules := []Rule{WhaleRule{}, BreakoutRule{}, NegativeCatalystRule{}}
+	if cfg == nil {
+		return rules
+	}
+	if profile := cfg.GetProfile(profileName); profile != nil {
+		rules = append(rules,
+			ScoreThresholdRule{Threshold: profile.Threshold},
+			RSIOversoldRule{Level: profile.Indicators.RSI.MinOversold},
+		)
+	}
+	return rules
+}
+
+// Evaluate runs every rule against the snapshot
+func Evaluate(rules []Rule, s Snapshot) []Event {
+	var events []Event
+	for _, rule := range rules {
+		events = append(events, rule.Evaluate(s)...)
+	}
+	return events
+}
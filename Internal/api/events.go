@@ -0,0 +1,143 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+)
+
+type whaleEvent struct {
+	ID          int32     `json:"id"`
+	Symbol      string    `json:"symbol"`
+	Timestamp   time.Time `json:"timestamp"`
+	Direction   string    `json:"direction"`
+	Volume      int64     `json:"volume"`
+	ZScore      float64   `json:"z_score"`
+	ClosePrice  float64   `json:"close_price"`
+	PriceChange *float64  `json:"price_change,omitempty"`
+	Conviction  string    `json:"conviction"`
+	Change1     *float64  `json:"change_1,omitempty"` // follow-through, see strategy.WhaleEvent
+	Change5     *float64  `json:"change_5,omitempty"`
+	Change20    *float64  `json:"change_20,omitempty"`
+	Held        *bool     `json:"held,omitempty"`
+}
+
+type newsArticle struct {
+	ID          int32     `json:"id"`
+	Symbol      string    `json:"symbol"`
+	Headline    string    `json:"headline"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+	Source      string    `json:"source,omitempty"`
+	Sentiment   string    `json:"sentiment,omitempty"`
+}
+
+// DECIMAL columns come back from sqlc as strings
+func parseDecimal(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func optionalDecimal(value sql.NullString) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	f := parseDecimal(value.String)
+	return &f
+}
+
+func toWhaleEvent(row database.WhaleEvent) whaleEvent {
+	event := whaleEvent{
+		ID:         row.ID,
+		Symbol:     row.Symbol,
+		Timestamp:  row.Timestamp,
+		Direction:  row.Direction,
+		Volume:     row.Volume,
+		ZScore:     parseDecimal(row.ZScore),
+		ClosePrice: parseDecimal(row.ClosePrice),
+		Conviction: row.Conviction,
+	}
+	event.PriceChange = optionalDecimal(row.PriceChange)
+	event.Change1 = optionalDecimal(row.Change1)
+	event.Change5 = optionalDecimal(row.Change5)
+	event.Change20 = optionalDecimal(row.Change20)
+	if row.Held.Valid {
+		held := row.Held.Bool
+		event.Held = &held
+	}
+	return event
+}
+
+func (s *Server) handleWhales(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	p := s.queryPage(r, defaultPageSize, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	rows, err := s.store.ListWhaleEvents(r.Context(), database.ListWhaleEventsParams{
+		Symbol: symbol,
+		Limit:  int32(p.limit),
+		Offset: int32(p.offset),
+	})
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to fetch whale events for %s: %w", symbol, err))
+		return
+	}
+	total, err := s.store.CountWhaleEvents(r.Context(), symbol)
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to count whale events for %s: %w", symbol, err))
+		return
+	}
+
+	data := make([]whaleEvent, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, toWhaleEvent(row))
+	}
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), &total)})
+}
+
+func (s *Server) handleNews(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	p := s.queryPage(r, defaultPageSize, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	rows, err := s.store.ListNewsArticles(r.Context(), database.ListNewsArticlesParams{
+		Symbol: symbol,
+		Limit:  int32(p.limit),
+		Offset: int32(p.offset),
+	})
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to fetch news for %s: %w", symbol, err))
+		return
+	}
+	total, err := s.store.CountNewsArticles(r.Context(), symbol)
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to count news for %s: %w", symbol, err))
+		return
+	}
+
+	data := make([]newsArticle, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, newsArticle{
+			ID:          row.ID,
+			Symbol:      row.Symbol,
+			Headline:    row.Headline,
+			URL:         row.Url,
+			PublishedAt: row.PublishedAt,
+			Source:      row.Source.String,
+			Sentiment:   row.Sentiment.String,
+		})
+	}
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), &total)})
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/analyzer"
+)
+
+const maxWindowBars = 5000
+
+type barJSON struct {
+	Timestamp string  `json:"timestamp"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    int64   `json:"volume"`
+}
+
+func toBarJSON(bar datafeed.Bar) barJSON {
+	return barJSON{Timestamp: bar.Timestamp, Open: bar.Open, High: bar.High, Low: bar.Low, Close: bar.Close, Volume: bar.Volume}
+}
+
+// handleBars pages backwards from the newest bar: offset skips that many recent bars
+func (s *Server) handleBars(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	timeframe := queryTimeframe(r, invalid)
+	p := s.queryPage(r, 100, invalid)
+	start := r.URL.Query().Get("start")
+	if start != "" {
+		if _, ok := parseDate(start); !ok {
+			invalid.add("start", "must be RFC3339 or YYYY-MM-DD")
+		}
+	}
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	bars, err := datafeed.GetBars(r.Context(), symbol, timeframe, p.limit+p.offset, start)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to fetch bars for %s: %v", symbol, err))
+		return
+	}
+
+	data := []barJSON{}
+	for _, bar := range paginate(bars, p) {
+		data = append(data, toBarJSON(bar))
+	}
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), nil)})
+}
+
+type indicatorPoint struct {
+	Timestamp string   `json:"timestamp"`
+	Close     float64  `json:"close"`
+	RSI       *float64 `json:"rsi,omitempty"`
+	ATR       *float64 `json:"atr,omitempty"`
+	VWAP      *float64 `json:"vwap,omitempty"`
+}
+
+type indicatorSeries struct {
+	Symbol     string           `json:"symbol"`
+	Timeframe  string           `json:"timeframe"`
+	Period     int              `json:"period"`
+	Indicators []string         `json:"indicators"`
+	Points     []indicatorPoint `json:"points"` // oldest first
+}
+
+var knownIndicators = map[string]bool{"rsi": true, "atr": true, "vwap": true}
+
+// handleIndicators returns RSI, ATR and VWAP per bar, oldest first. Bars inside the
+// warm-up period have no RSI/ATR rather than a misleading zero.
+func (s *Server) handleIndicators(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	timeframe := queryTimeframe(r, invalid)
+	numBars := queryInt(r, "bars", 100, 2, maxWindowBars, invalid)
+	period := queryInt(r, "period", 14, 2, 200, invalid)
+
+	indicators := []string{"rsi", "atr", "vwap"}
+	if raw := r.URL.Query().Get("indicators"); raw != "" {
+		indicators = []string{}
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !knownIndicators[name] {
+				invalid.add("indicators", "unknown indicator %q (expected rsi, atr or vwap)", name)
+				break
+			}
+			indicators = append(indicators, name)
+		}
+	}
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	series, ok := s.fetchSeries(w, r, symbol, timeframe, numBars)
+	if !ok {
+		return
+	}
+	bars := series.Bars()
+
+	points := make([]indicatorPoint, len(bars))
+	for i, bar := range bars {
+		points[i] = indicatorPoint{Timestamp: bar.Timestamp, Close: bar.Close}
+	}
+
+	for _, name := range indicators {
+		switch name {
+		case "rsi":
+			if values, err := strategy.CalculateRSI(series.Closes(), period); err == nil {
+				for i := period; i < len(values); i++ {
+					points[i].RSI = roundPtr(values[i])
+				}
+			}
+		case "atr":
+			atrBars := make([]strategy.ATRBar, len(bars))
+			for i, bar := range bars {
+				atrBars[i] = strategy.ATRBar{High: bar.High, Low: bar.Low, Close: bar.Close}
+			}
+			if values, err := strategy.CalculateATR(atrBars, period); err == nil {
+				for i := period; i < len(values); i++ {
+					points[i].ATR = roundPtr(values[i])
+				}
+			}
+		case "vwap":
+			for i, v := range strategy.NewVWAPCalculator(series).CalculateAllValues() {
+				points[i].VWAP = roundPtr(v)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, indicatorSeries{
+		Symbol:     symbol,
+		Timeframe:  timeframe,
+		Period:     period,
+		Indicators: indicators,
+		Points:     points,
+	})
+}
+
+// fetchSeries loads the newest bars as a series, writing a 502 when the provider fails or sends bad bars
+func (s *Server) fetchSeries(w http.ResponseWriter, r *http.Request, symbol, timeframe string, limit int) (types.BarSeries, bool) {
+	bars, err := datafeed.GetBars(r.Context(), symbol, timeframe, limit, "")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to fetch bars for %s: %v", symbol, err))
+		return types.BarSeries{}, false
+	}
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("invalid bars for %s: %v", symbol, err))
+		return types.BarSeries{}, false
+	}
+	return series, true
+}
+
+func roundPtr(v float64) *float64 {
+	rounded := math.Round(v*10000) / 10000
+	return &rounded
+}
+
+func (s *Server) handleSignal(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	timeframe := queryTimeframe(r, invalid)
+	numBars := queryInt(r, "bars", 100, 14, maxWindowBars, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	series, ok := s.fetchSeries(w, r, symbol, timeframe, numBars)
+	if !ok {
+		return
+	}
+	if series.Len() == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no bars for %s", symbol))
+		return
+	}
+	report, err := analyzer.AnalyzeBars(r.Context(), &strategy.Evaluator{News: s.news, Whales: s.whales}, symbol, timeframe, series)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+type screenerCriteria struct {
+	MinOversoldRSI *float64 `json:"min_oversold_rsi"`
+	MaxRSI         *float64 `json:"max_rsi"`
+	MinATR         *float64 `json:"min_atr"`
+	MinVolumeRatio *float64 `json:"min_volume_ratio"`
+}
+
+type screenerRequest struct {
+	Symbols        []string          `json:"symbols"`
+	Profile        string            `json:"profile"`
+	Criteria       *screenerCriteria `json:"criteria"`
+	Timeframe      string            `json:"timeframe"`
+	Bars           int               `json:"bars"`
+	MinScore       float64           `json:"min_score"`
+	MultiTimeframe bool              `json:"multi_timeframe"` // adds a 1Min-1Day confluence to each result
+}
+
+type screenerResult struct {
+	Symbol     string               `json:"symbol"`
+	Score      float64              `json:"score"`
+	Action     string               `json:"action"`
+	RSI        *float64             `json:"rsi"`
+	ATR        *float64             `json:"atr"`
+	Signals    []string             `json:"signals"`
+	Confluence *strategy.Confluence `json:"confluence,omitempty"`
+}
+
+// criteria starts from the profile (or the defaults) and applies any explicit overrides
+func (s *Server) criteria(req screenerRequest, invalid validationError) strategy.ScreenerCriteria {
+	criteria := strategy.DefaultScreenerCriteria()
+	if req.Profile != "" {
+		if s.cfg == nil {
+			invalid.add("profile", "no profiles are configured")
+		} else if c, err := strategy.GetScreenerCriteriaFromProfile(s.cfg, req.Profile); err != nil {
+			invalid.add("profile", "%v", err)
+		} else {
+			criteria = c
+		}
+	}
+	if req.Criteria == nil {
+		return criteria
+	}
+
+	override := func(field string, value *float64, min, max float64, target *float64) {
+		if value == nil {
+			return
+		}
+		if *value < min || *value > max {
+			invalid.add("criteria."+field, "must be between %g and %g", min, max)
+			return
+		}
+		*target = *value
+	}
+	override("min_oversold_rsi", req.Criteria.MinOversoldRSI, 0, 100, &criteria.MinOversoldRSI)
+	override("max_rsi", req.Criteria.MaxRSI, 0, 100, &criteria.MaxRSI)
+	override("min_atr", req.Criteria.MinATR, 0, math.MaxFloat64, &criteria.MinATR)
+	override("min_volume_ratio", req.Criteria.MinVolumeRatio, 0, math.MaxFloat64, &criteria.MinVolumeRatio)
+	if criteria.MinOversoldRSI > criteria.MaxRSI {
+		invalid.add("criteria", "min_oversold_rsi must not exceed max_rsi")
+	}
+	return criteria
+}
+
+// handleScreener screens the symbols in the body; results are ranked by score and paged with limit/offset
+func (s *Server) handleScreener(w http.ResponseWriter, r *http.Request) {
+	var req screenerRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	invalid := validationError{}
+	p := s.queryPage(r, defaultPageSize, invalid)
+	if req.Timeframe == "" {
+		req.Timeframe = "1Day"
+	} else if !datafeed.IsValidTimeframe(req.Timeframe) {
+		invalid.add("timeframe", "must be one of %s", strings.Join(datafeed.Timeframes, ", "))
+	}
+	if req.Bars == 0 {
+		req.Bars = 100
+	} else if req.Bars < 2 || req.Bars > maxWindowBars {
+		invalid.add("bars", "must be between 2 and %d", maxWindowBars)
+	}
+
+	symbols := []string{}
+	seen := map[string]bool{}
+	for _, raw := range req.Symbols {
+		symbol, ok := normalizeSymbol(raw)
+		if !ok {
+			invalid.add("symbols", "invalid symbol %q", raw)
+			break
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(req.Symbols) == 0 {
+		invalid.add("symbols", "at least one symbol is required")
+	} else if len(symbols) > s.limits.ScreenerMaxSymbols {
+		invalid.add("symbols", "at most %d symbols per request", s.limits.ScreenerMaxSymbols)
+	}
+	criteria := s.criteria(req, invalid)
+	if req.MultiTimeframe {
+		criteria.MultiTimeframe = true
+	}
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	screener := &strategy.Screener{News: s.news, Whales: s.whales, Pool: s.pool}
+	scores, err := screener.Screen(r.Context(), symbols, req.Timeframe, req.Bars, criteria)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("screener failed: %v", err))
+		return
+	}
+
+	results := []screenerResult{}
+	for _, stock := range scores {
+		if stock.Score < req.MinScore {
+			continue
+		}
+		signals := stock.Signals
+		if signals == nil {
+			signals = []string{}
+		}
+		results = append(results, screenerResult{Symbol: stock.Symbol, Score: stock.Score, Action: stock.Recommendation, RSI: stock.RSI, ATR: stock.ATR, Signals: signals,
+			Confluence: stock.Confluence})
+	}
+
+	total := int64(len(results))
+	data := paginate(results, p)
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), &total)})
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+type errorResponse struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Pagination describes one page of a list; Total is omitted when the size of the list isn't known
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      *int64 `json:"total,omitempty"`
+	NextOffset *int   `json:"next_offset,omitempty"`
+}
+
+type listResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		requestLog(w).Warn("failed to write response", logger.Err(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// validationError collects every bad field so a client can fix them all at once
+type validationError map[string]string
+
+func (v validationError) Error() string {
+	fields := make([]string, 0, len(v))
+	for field, msg := range v {
+		fields = append(fields, field+": "+msg)
+	}
+	sort.Strings(fields)
+	return "invalid request: " + strings.Join(fields, "; ")
+}
+
+func (v validationError) add(field, format string, args ...interface{}) {
+	v[field] = fmt.Sprintf(format, args...)
+}
+
+func (v validationError) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// writeRequestError answers 400 for validation problems and 500 for anything else
+func writeRequestError(w http.ResponseWriter, err error) {
+	var invalid validationError
+	if errors.As(err, &invalid) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request", Fields: invalid})
+		return
+	}
+	requestLog(w).Error("request failed", logger.Err(err))
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+var symbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9.\-]{0,9}$`)
+
+func normalizeSymbol(symbol string) (string, bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	return symbol, symbolPattern.MatchString(symbol)
+}
+
+// pathSymbol validates the {symbol} path segment
+func pathSymbol(r *http.Request, invalid validationError) string {
+	symbol, ok := normalizeSymbol(r.PathValue("symbol"))
+	if !ok {
+		invalid.add("symbol", "must be 1-10 letters, digits, dots or dashes")
+	}
+	return symbol
+}
+
+func queryTimeframe(r *http.Request, invalid validationError) string {
+	tf := r.URL.Query().Get("timeframe")
+	if tf == "" {
+		return "1Day"
+	}
+	if !datafeed.IsValidTimeframe(tf) {
+		invalid.add("timeframe", "must be one of %s", strings.Join(datafeed.Timeframes, ", "))
+	}
+	return tf
+}
+
+// queryInt reads an optional integer parameter bounded by [min, max]
+func queryInt(r *http.Request, name string, def, min, max int, invalid validationError) int {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		invalid.add(name, "must be an integer between %d and %d", min, max)
+		return def
+	}
+	return n
+}
+
+type page struct {
+	limit  int
+	offset int
+}
+
+func (s *Server) queryPage(r *http.Request, defaultLimit int, invalid validationError) page {
+	return page{
+		limit:  queryInt(r, "limit", defaultLimit, 1, s.limits.MaxPageSize, invalid),
+		offset: queryInt(r, "offset", 0, 0, 1<<30, invalid),
+	}
+}
+
+// pagination fills in NextOffset when there may be more rows after this page
+func (p page) pagination(returned int, total *int64) Pagination {
+	out := Pagination{Limit: p.limit, Offset: p.offset, Total: total}
+	more := returned == p.limit
+	if total != nil {
+		more = int64(p.offset+returned) < *total
+	}
+	if more {
+		next := p.offset + returned
+		out.NextOffset = &next
+	}
+	return out
+}
+
+// paginate slices an in-memory list
+func paginate[T any](items []T, p page) []T {
+	if p.offset >= len(items) {
+		return []T{}
+	}
+	end := p.offset + p.limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[p.offset:end]
+}
+
+// decodeBody reads a JSON body of at most 1MB and rejects unknown fields
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return validationError{"body": err.Error()}
+	}
+	return nil
+}
+
+func parseDate(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, err == nil
+}
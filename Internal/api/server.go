@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/watchlist"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+// Store is what the API needs from the database; *database.Queries satisfies it
+type Store interface {
+	watchlist.Store
+	ListWatchlistHistory(ctx context.Context, arg database.ListWatchlistHistoryParams) ([]database.WatchlistHistory, error)
+	CountWatchlistHistory(ctx context.Context, watchlistID int32) (int64, error)
+	ListWhaleEvents(ctx context.Context, arg database.ListWhaleEventsParams) ([]database.WhaleEvent, error)
+	CountWhaleEvents(ctx context.Context, symbol string) (int64, error)
+	ListNewsArticles(ctx context.Context, arg database.ListNewsArticlesParams) ([]database.NewsArticle, error)
+	CountNewsArticles(ctx context.Context, symbol string) (int64, error)
+}
+
+var _ Store = (*database.Queries)(nil)
+
+const (
+	defaultPageSize           = 50
+	defaultMaxPageSize        = 500
+	defaultScreenerMaxSymbols = 200
+)
+
+// Server serves market data, signals, the screener and the watchlist as JSON.
+// Bars come from the active datafeed provider, everything stored from Store. Decisions
+// also read the news and whale history in repos, like every other path that rates a symbol.
+type Server struct {
+	cfg    *config.Config
+	store  Store
+	news   repository.NewsRepository
+	whales repository.WhaleRepository
+	pool   utils.PoolConfig
+	limits config.APIConfig
+	mux    *http.ServeMux
+}
+
+func NewServer(cfg *config.Config, store Store, repos repository.Repositories) *Server {
+	s := &Server{
+		cfg:    cfg,
+		store:  store,
+		news:   repos.News,
+		whales: repos.Whales,
+		pool:   utils.PoolConfigFromConfig(cfg),
+		limits: config.APIConfig{
+			MaxPageSize:        defaultMaxPageSize,
+			ScreenerMaxSymbols: defaultScreenerMaxSymbols,
+		},
+		mux: http.NewServeMux(),
+	}
+	if cfg != nil {
+		if cfg.API.MaxPageSize > 0 {
+			s.limits.MaxPageSize = cfg.API.MaxPageSize
+		}
+		if cfg.API.ScreenerMaxSymbols > 0 {
+			s.limits.ScreenerMaxSymbols = cfg.API.ScreenerMaxSymbols
+		}
+	}
+	s.routes()
+	return s
+}
+
+// route is one documented endpoint; the OpenAPI test checks every route appears in openapi.yaml
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+func (s *Server) routeTable() []route {
+	return []route{
+		{http.MethodGet, "/healthz", s.handleHealth},
+		{http.MethodGet, "/api/v1/openapi.yaml", s.handleOpenAPI},
+		{http.MethodGet, "/api/v1/symbols/{symbol}/bars", s.handleBars},
+		{http.MethodGet, "/api/v1/symbols/{symbol}/indicators", s.handleIndicators},
+		{http.MethodGet, "/api/v1/symbols/{symbol}/signal", s.handleSignal},
+		{http.MethodGet, "/api/v1/symbols/{symbol}/whales", s.handleWhales},
+		{http.MethodGet, "/api/v1/symbols/{symbol}/news", s.handleNews},
+		{http.MethodPost, "/api/v1/screener", s.handleScreener},
+		{http.MethodGet, "/api/v1/watchlist", s.handleListWatchlist},
+		{http.MethodPost, "/api/v1/watchlist", s.handleAddWatchlist},
+		{http.MethodGet, "/api/v1/watchlist/{symbol}", s.handleGetWatchlist},
+		{http.MethodPatch, "/api/v1/watchlist/{symbol}", s.handleUpdateWatchlist},
+		{http.MethodDelete, "/api/v1/watchlist/{symbol}", s.handleRemoveWatchlist},
+		{http.MethodGet, "/api/v1/watchlist/{symbol}/history", s.handleWatchlistHistory},
+	}
+}
+
+func (s *Server) routes() {
+	for _, rt := range s.routeTable() {
+		s.mux.HandleFunc(rt.method+" "+rt.path, rt.handler)
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.requestLogger(s.recoverer(s.mux))
+}
+
+// loggingWriter remembers the status and carries the request's logger to writeJSON
+type loggingWriter struct {
+	http.ResponseWriter
+	log    *slog.Logger
+	status int
+}
+
+func (w *loggingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// requestLogger tags each request with an id (the client's X-Request-ID or a new one),
+// echoes it back and logs the request at debug level
+func (s *Server) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		log := logger.Component("api").With(logger.KeyRequestID, id)
+		lw := &loggingWriter{ResponseWriter: w, log: log, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(lw, r.WithContext(logger.WithContext(r.Context(), log)))
+		log.Debug("request", "method", r.Method, "path", r.URL.Path, "status", lw.status, "duration", time.Since(start))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestLog is the logger requestLogger attached to w, or the api component's
+func requestLog(w http.ResponseWriter) *slog.Logger {
+	if lw, ok := w.(*loggingWriter); ok {
+		return lw.log
+	}
+	return logger.Component("api")
+}
+
+// recoverer turns a panicking handler into a 500 instead of dropping the connection
+func (s *Server) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				requestLog(w).Error("handler panicked", "method", r.Method, "path", r.URL.Path, "panic", rec)
+				writeError(w, http.StatusInternalServerError, "internal error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe runs until ctx is cancelled, then gives in-flight requests 10s to finish
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// trendProvider serves the newest limit bars of a 200-day uptrend closing at 100..299, latest first
+type trendProvider struct{}
+
+func (trendProvider) Name() string { return "trend" }
+
+func (trendProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+	const days = 200
+	if limit > days {
+		limit = days
+	}
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	bars := make([]datafeed.Bar, 0, limit)
+	for i := days - 1; i >= days-limit; i-- {
+		price := 100 + float64(i)
+		bars = append(bars, datafeed.Bar{
+			Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open:      price - 0.5, High: price + 1, Low: price - 1, Close: price, Volume: 1000 + int64(i),
+		})
+	}
+	return bars, nil
+}
+
+func (trendProvider) GetLatestQuote(ctx context.Context, symbol string) (*datafeed.LastQuote, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (trendProvider) GetLatestTrade(ctx context.Context, symbol string) (*datafeed.LastTrade, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (trendProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+type fakeItem struct {
+	row    database.GetWatchlistRow
+	active bool
+}
+
+// fakeStore keeps the watchlist, its history and whale events in memory
+type fakeStore struct {
+	items   map[string]*fakeItem
+	history []database.WatchlistHistory
+	whales  []database.WhaleEvent
+	nextID  int32
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{items: map[string]*fakeItem{}}
+}
+
+func (f *fakeStore) AddToWatchlist(ctx context.Context, arg database.AddToWatchlistParams) (int32, error) {
+	if item, ok := f.items[arg.Symbol]; ok {
+		if item.active {
+			return 0, sql.ErrNoRows
+		}
+		item.active = true
+		item.row.Score = arg.Score
+		return item.row.ID, nil
+	}
+	f.nextID++
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	f.items[arg.Symbol] = &fakeItem{active: true, row: database.GetWatchlistRow{
+		ID: f.nextID, Symbol: arg.Symbol, AssetType: arg.AssetType, Score: arg.Score, Reason: arg.Reason, AddedDate: now, LastUpdated: now,
+	}}
+	return f.nextID, nil
+}
+
+func (f *fakeStore) GetWatchlist(ctx context.Context) ([]database.GetWatchlistRow, error) {
+	rows := []database.GetWatchlistRow{}
+	for _, item := range f.items {
+		if item.active {
+			rows = append(rows, item.row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Score > rows[j].Score })
+	return rows, nil
+}
+
+func (f *fakeStore) GetWatchlistBySymbol(ctx context.Context, symbol string) (database.GetWatchlistBySymbolRow, error) {
+	item, ok := f.items[symbol]
+	if !ok || !item.active {
+		return database.GetWatchlistBySymbolRow{}, sql.ErrNoRows
+	}
+	return database.GetWatchlistBySymbolRow(item.row), nil
+}
+
+func (f *fakeStore) UpdateWatchlistScore(ctx context.Context, arg database.UpdateWatchlistScoreParams) error {
+	f.items[arg.Symbol].row.Score = arg.Score
+	return nil
+}
+
+func (f *fakeStore) AddWatchlistHistory(ctx context.Context, arg database.AddWatchlistHistoryParams) error {
+	f.history = append(f.history, database.WatchlistHistory{
+		ID: int32(len(f.history) + 1), WatchlistID: arg.WatchlistID, OldScore: arg.OldScore, NewScore: arg.NewScore, AnalysisData: arg.AnalysisData,
+	})
+	return nil
+}
+
+func (f *fakeStore) RemoveFromWatchlist(ctx context.Context, symbol string) (int64, error) {
+	item, ok := f.items[symbol]
+	if !ok || !item.active {
+		return 0, nil
+	}
+	item.active = false
+	return 1, nil
+}
+
+func (f *fakeStore) ListWatchlistHistory(ctx context.Context, arg database.ListWatchlistHistoryParams) ([]database.WatchlistHistory, error) {
+	rows := []database.WatchlistHistory{}
+	for i := len(f.history) - 1; i >= 0; i-- {
+		if f.history[i].WatchlistID == arg.WatchlistID {
+			rows = append(rows, f.history[i])
+		}
+	}
+	return paginate(rows, page{limit: int(arg.Limit), offset: int(arg.Offset)}), nil
+}
+
+func (f *fakeStore) CountWatchlistHistory(ctx context.Context, watchlistID int32) (int64, error) {
+	rows, _ := f.ListWatchlistHistory(ctx, database.ListWatchlistHistoryParams{WatchlistID: watchlistID, Limit: 1 << 20})
+	return int64(len(rows)), nil
+}
+
+func (f *fakeStore) ListWhaleEvents(ctx context.Context, arg database.ListWhaleEventsParams) ([]database.WhaleEvent, error) {
+	rows := []database.WhaleEvent{}
+	for _, e := range f.whales {
+		if e.Symbol == arg.Symbol {
+			rows = append(rows, e)
+		}
+	}
+	return paginate(rows, page{limit: int(arg.Limit), offset: int(arg.Offset)}), nil
+}
+
+func (f *fakeStore) CountWhaleEvents(ctx context.Context, symbol string) (int64, error) {
+	rows, _ := f.ListWhaleEvents(ctx, database.ListWhaleEventsParams{Symbol: symbol, Limit: 1 << 20})
+	return int64(len(rows)), nil
+}
+
+func (f *fakeStore) ListNewsArticles(ctx context.Context, arg database.ListNewsArticlesParams) ([]database.NewsArticle, error) {
+	return []database.NewsArticle{}, nil
+}
+
+func (f *fakeStore) CountNewsArticles(ctx context.Context, symbol string) (int64, error) {
+	return 0, nil
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *fakeStore) {
+	t.Helper()
+	previous := datafeed.GetProvider()
+	datafeed.SetProvider(trendProvider{})
+	t.Cleanup(func() { datafeed.SetProvider(previous) })
+
+	store := newFakeStore()
+	ts := httptest.NewServer(NewServer(nil, store, repository.Repositories{}).Handler())
+	t.Cleanup(ts.Close)
+	return ts, store
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type barsPage struct {
+	Data       []barJSON  `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+func TestBarsPagination(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	var first, second barsPage
+	if code := do(t, ts, "GET", "/api/v1/symbols/aapl/bars?limit=5", "", &first); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if code := do(t, ts, "GET", "/api/v1/symbols/AAPL/bars?limit=5&offset=5", "", &second); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(first.Data) != 5 || len(second.Data) != 5 {
+		t.Fatalf("expected two pages of 5, got %d and %d", len(first.Data), len(second.Data))
+	}
+	if first.Data[0].Close != 299 || second.Data[0].Close != 294 {
+		t.Errorf("pages should walk back from the newest bar: %v / %v", first.Data[0], second.Data[0])
+	}
+	if second.Pagination.NextOffset == nil || *second.Pagination.NextOffset != 10 {
+		t.Errorf("next_offset = %v, want 10", second.Pagination.NextOffset)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	tests := []struct {
+		method, path, body string
+		field              string
+	}{
+		{"GET", "/api/v1/symbols/NOT$VALID/bars", "", "symbol"},
+		{"GET", "/api/v1/symbols/AAPL/bars?timeframe=7Min", "", "timeframe"},
+		{"GET", "/api/v1/symbols/AAPL/bars?limit=100000", "", "limit"},
+		{"GET", "/api/v1/symbols/AAPL/indicators?indicators=rsi,macd", "", "indicators"},
+		{"GET", "/api/v1/symbols/AAPL/signal?bars=5", "", "bars"},
+		{"POST", "/api/v1/screener", `{"symbols": []}`, "symbols"},
+		{"POST", "/api/v1/screener", `{"symbols": ["AAPL"], "criteria": {"max_rsi": 150}}`, "criteria.max_rsi"},
+		{"POST", "/api/v1/screener", `{"symbols": ["AAPL"], "bogus": 1}`, "body"},
+		{"POST", "/api/v1/watchlist", `{"symbol": "AAPL", "score": -1}`, "score"},
+		{"PATCH", "/api/v1/watchlist/AAPL", `{}`, "score"},
+	}
+	for _, tt := range tests {
+		var resp errorResponse
+		code := do(t, ts, tt.method, tt.path, tt.body, &resp)
+		if code != http.StatusBadRequest || resp.Fields[tt.field] == "" {
+			t.Errorf("%s %s: got %d %+v, want 400 with field %q", tt.method, tt.path, code, resp, tt.field)
+		}
+	}
+}
+
+func TestRequestIDLogged(t *testing.T) {
+	var buf bytes.Buffer
+	previous := logger.Get()
+	logger.Set(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { logger.Set(previous) })
+	ts, _ := newTestServer(t)
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/v1/symbols/NOT$VALID/bars", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("X-Request-ID = %q, want the client's id echoed", got)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q", buf.String())
+	}
+	if entry[logger.KeyRequestID] != "req-123" || entry[logger.KeyComponent] != "api" || entry["status"] != float64(http.StatusBadRequest) {
+		t.Errorf("unexpected request log %v", entry)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/watchlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Error("requests without an id should be given one")
+	}
+}
+
+func TestIndicatorsAndSignal(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	var series indicatorSeries
+	if code := do(t, ts, "GET", "/api/v1/symbols/MSFT/indicators?bars=30&period=14", "", &series); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(series.Points) != 30 || series.Points[0].Close != 270 {
+		t.Fatalf("expected 30 points oldest first, got %d starting at %v", len(series.Points), series.Points[0])
+	}
+	if series.Points[13].RSI != nil || series.Points[14].RSI == nil || *series.Points[29].RSI != 100 {
+		t.Errorf("RSI should start after the warm-up and read 100 in a straight uptrend: %+v", series.Points[13:15])
+	}
+	if series.Points[0].VWAP == nil || series.Points[29].ATR == nil {
+		t.Errorf("missing vwap/atr values")
+	}
+
+	var report struct {
+		Symbol string `json:"symbol"`
+		Signal struct {
+			Recommendation string `json:"recommendation"`
+			Components     []struct {
+				Name string `json:"name"`
+			} `json:"components"`
+		} `json:"signal"`
+	}
+	if code := do(t, ts, "GET", "/api/v1/symbols/msft/signal", "", &report); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if report.Symbol != "MSFT" || report.Signal.Recommendation == "" || len(report.Signal.Components) == 0 {
+		t.Errorf("unexpected signal report: %+v", report)
+	}
+}
+
+func TestSignalUsesWhaleHistory(t *testing.T) {
+	previous := datafeed.GetProvider()
+	datafeed.SetProvider(trendProvider{})
+	t.Cleanup(func() { datafeed.SetProvider(previous) })
+
+	ctx := context.Background()
+	whales := repository.NewMemoryWhales()
+	held, missed := true, false
+	for i, outcome := range []*bool{&held, &held, &held, &missed} {
+		err := whales.SaveWhaleEvent(ctx, repository.WhaleEvent{Symbol: "MSFT", Timestamp: time.Now().AddDate(0, 0, -10-i), Direction: "BUY", Held: outcome})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(NewServer(nil, newFakeStore(), repository.Repositories{Whales: whales}).Handler())
+	t.Cleanup(ts.Close)
+
+	type factor struct {
+		Name    string   `json:"name"`
+		Detail  string   `json:"detail"`
+		Factors []factor `json:"factors"`
+	}
+	var report struct {
+		Decision struct {
+			Explanation factor `json:"explanation"`
+		} `json:"decision"`
+	}
+	if code := do(t, ts, "GET", "/api/v1/symbols/MSFT/signal", "", &report); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	var whale string
+	var walk func(f factor)
+	walk = func(f factor) {
+		if f.Name == "Whale" {
+			whale = f.Detail
+		}
+		for _, child := range f.Factors {
+			walk(child)
+		}
+	}
+	walk(report.Decision.Explanation)
+	if !strings.Contains(whale, "buy whales held 75% of the time") {
+		t.Errorf("whale factor = %q, want the stored track record", whale)
+	}
+}
+
+func TestWatchlistCRUDAndHistory(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	var item watchlistItem
+	if code := do(t, ts, "POST", "/api/v1/watchlist", `{"symbol": "nvda", "score": 5, "reason": "breakout"}`, &item); code != http.StatusCreated {
+		t.Fatalf("add status %d", code)
+	}
+	if item.Symbol != "NVDA" || item.Score != 5 {
+		t.Errorf("unexpected item %+v", item)
+	}
+	if code := do(t, ts, "POST", "/api/v1/watchlist", `{"symbol": "NVDA"}`, nil); code != http.StatusConflict {
+		t.Errorf("duplicate add = %d, want 409", code)
+	}
+
+	for _, score := range []string{"6", "7.5"} {
+		if code := do(t, ts, "PATCH", "/api/v1/watchlist/NVDA", `{"score": `+score+`, "analysis": {"rsi": 42}}`, &item); code != http.StatusOK {
+			t.Fatalf("patch status %d", code)
+		}
+	}
+	if item.Score != 7.5 {
+		t.Errorf("score after patch = %v", item.Score)
+	}
+
+	var history struct {
+		Data       []historyEntry `json:"data"`
+		Pagination Pagination     `json:"pagination"`
+	}
+	if code := do(t, ts, "GET", "/api/v1/watchlist/NVDA/history?limit=1", "", &history); code != http.StatusOK {
+		t.Fatalf("history status %d", code)
+	}
+	if len(history.Data) != 1 || history.Data[0].NewScore != 7.5 || *history.Data[0].OldScore != 6 {
+		t.Errorf("latest history entry wrong: %+v", history.Data)
+	}
+	if !strings.Contains(string(history.Data[0].AnalysisData), `"rsi":42`) {
+		t.Errorf("analysis data not kept: %s", history.Data[0].AnalysisData)
+	}
+	if history.Pagination.Total == nil || *history.Pagination.Total != 2 || history.Pagination.NextOffset == nil {
+		t.Errorf("pagination = %+v", history.Pagination)
+	}
+
+	if code := do(t, ts, "DELETE", "/api/v1/watchlist/NVDA", "", nil); code != http.StatusNoContent {
+		t.Errorf("delete = %d", code)
+	}
+	if code := do(t, ts, "GET", "/api/v1/watchlist/NVDA", "", nil); code != http.StatusNotFound {
+		t.Errorf("get after delete = %d, want 404", code)
+	}
+	if code := do(t, ts, "DELETE", "/api/v1/watchlist/NVDA", "", nil); code != http.StatusNotFound {
+		t.Errorf("second delete = %d, want 404", code)
+	}
+	if code := do(t, ts, "POST", "/api/v1/watchlist", `{"symbol": "NVDA", "score": 3}`, nil); code != http.StatusCreated {
+		t.Errorf("re-adding an archived symbol = %d, want 201", code)
+	}
+}
+
+func TestWhaleEventsPaginated(t *testing.T) {
+	ts, store := newTestServer(t)
+	for i := 0; i < 3; i++ {
+		store.whales = append(store.whales, database.WhaleEvent{
+			ID: int32(i + 1), Symbol: "TSLA", Direction: "BUY", Volume: 5000, ZScore: "3.10", ClosePrice: "250.50", Conviction: "HIGH",
+		})
+	}
+
+	var resp struct {
+		Data       []whaleEvent `json:"data"`
+		Pagination Pagination   `json:"pagination"`
+	}
+	if code := do(t, ts, "GET", "/api/v1/symbols/TSLA/whales?limit=2&offset=2", "", &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ZScore != 3.1 || resp.Data[0].ClosePrice != 250.5 {
+		t.Errorf("unexpected whale page %+v", resp.Data)
+	}
+	if *resp.Pagination.Total != 3 || resp.Pagination.NextOffset != nil {
+		t.Errorf("last page pagination = %+v", resp.Pagination)
+	}
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.yaml does not parse: %v", err)
+	}
+	for _, rt := range NewServer(nil, newFakeStore(), repository.Repositories{}).routeTable() {
+		ops, ok := spec.Paths[rt.path]
+		if !ok {
+			t.Errorf("%s is not documented", rt.path)
+			continue
+		}
+		if _, ok := ops[strings.ToLower(rt.method)]; !ok {
+			t.Errorf("%s %s is not documented", rt.method, rt.path)
+		}
+	}
+}
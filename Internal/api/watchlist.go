@@ -0,0 +1,276 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/watchlist"
+)
+
+type watchlistItem struct {
+	ID          int32      `json:"id"`
+	Symbol      string     `json:"symbol"`
+	AssetType   string     `json:"asset_type"`
+	Score       float64    `json:"score"`
+	Reason      string     `json:"reason,omitempty"`
+	AddedDate   *time.Time `json:"added_date,omitempty"`
+	LastUpdated *time.Time `json:"last_updated,omitempty"`
+}
+
+type historyEntry struct {
+	ID           int64           `json:"id"`
+	OldScore     *float64        `json:"old_score"`
+	NewScore     float64         `json:"new_score"`
+	AnalysisData json.RawMessage `json:"analysis_data,omitempty"`
+	Timestamp    *time.Time      `json:"timestamp,omitempty"`
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toWatchlistItem(row database.GetWatchlistRow) watchlistItem {
+	return watchlistItem{
+		ID:          row.ID,
+		Symbol:      row.Symbol,
+		AssetType:   row.AssetType,
+		Score:       float64(row.Score),
+		Reason:      row.Reason.String,
+		AddedDate:   nullTime(row.AddedDate),
+		LastUpdated: nullTime(row.LastUpdated),
+	}
+}
+
+func toHistoryEntry(row database.WatchlistHistory) historyEntry {
+	entry := historyEntry{ID: int64(row.ID), NewScore: float64(row.NewScore), Timestamp: nullTime(row.Timestamp)}
+	if row.OldScore.Valid {
+		old := row.OldScore.Float64
+		entry.OldScore = &old
+	}
+	// analysis_data is stored as JSON text; anything else is passed through as a string
+	if row.AnalysisData.Valid && row.AnalysisData.String != "" {
+		if json.Valid([]byte(row.AnalysisData.String)) {
+			entry.AnalysisData = json.RawMessage(row.AnalysisData.String)
+		} else {
+			entry.AnalysisData, _ = json.Marshal(row.AnalysisData.String)
+		}
+	}
+	return entry
+}
+
+func (s *Server) handleListWatchlist(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	p := s.queryPage(r, defaultPageSize, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	rows, err := watchlist.GetWatchlist(r.Context(), s.store)
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to fetch watchlist: %w", err))
+		return
+	}
+	items := make([]watchlistItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, toWatchlistItem(row))
+	}
+
+	total := int64(len(items))
+	data := paginate(items, p)
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), &total)})
+}
+
+// lookupWatchlist answers 404 itself when the symbol isn't on the active watchlist
+func (s *Server) lookupWatchlist(w http.ResponseWriter, r *http.Request, symbol string) (database.GetWatchlistBySymbolRow, bool) {
+	row, err := s.store.GetWatchlistBySymbol(r.Context(), symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not on the watchlist", symbol))
+		return row, false
+	}
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to fetch %s: %w", symbol, err))
+		return row, false
+	}
+	return row, true
+}
+
+func (s *Server) handleGetWatchlist(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	row, ok := s.lookupWatchlist(w, r, symbol)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toWatchlistItem(database.GetWatchlistRow(row)))
+}
+
+type addWatchlistRequest struct {
+	Symbol    string  `json:"symbol"`
+	AssetType string  `json:"asset_type"`
+	Score     float64 `json:"score"`
+	Reason    string  `json:"reason"`
+}
+
+func validScore(score float64) bool {
+	return !math.IsNaN(score) && !math.IsInf(score, 0) && score >= 0 && score <= 100
+}
+
+func (s *Server) handleAddWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req addWatchlistRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	invalid := validationError{}
+	symbol, ok := normalizeSymbol(req.Symbol)
+	if !ok {
+		invalid.add("symbol", "must be 1-10 letters, digits, dots or dashes")
+	}
+	if req.AssetType == "" {
+		req.AssetType = "stock"
+	} else if req.AssetType != "stock" && req.AssetType != "crypto" {
+		invalid.add("asset_type", "must be stock or crypto")
+	}
+	if !validScore(req.Score) {
+		invalid.add("score", "must be between 0 and 100")
+	}
+	if len(req.Reason) > 200 {
+		invalid.add("reason", "must be at most 200 characters")
+	}
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if _, err := watchlist.AddToWatchlist(r.Context(), s.store, symbol, req.AssetType, req.Score, req.Reason); err != nil {
+		if errors.Is(err, watchlist.ErrAlreadyOnWatchlist) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeRequestError(w, fmt.Errorf("failed to add %s: %w", symbol, err))
+		return
+	}
+	row, ok := s.lookupWatchlist(w, r, symbol)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, toWatchlistItem(database.GetWatchlistRow(row)))
+}
+
+type updateWatchlistRequest struct {
+	Score    *float64               `json:"score"`
+	Reason   string                 `json:"reason"`
+	Analysis map[string]interface{} `json:"analysis"`
+}
+
+// handleUpdateWatchlist rescores a symbol and records the change in watchlist_history
+func (s *Server) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req updateWatchlistRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	if req.Score == nil {
+		invalid.add("score", "is required")
+	} else if !validScore(*req.Score) {
+		invalid.add("score", "must be between 0 and 100")
+	}
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if _, ok := s.lookupWatchlist(w, r, symbol); !ok {
+		return
+	}
+
+	analysis := req.Analysis
+	if analysis == nil {
+		analysis = map[string]interface{}{}
+	}
+	if req.Reason != "" {
+		analysis["reason"] = req.Reason
+	}
+	if err := watchlist.UpdateWatchlistScoreWithHistory(r.Context(), s.store, symbol, *req.Score, req.Reason, analysis); err != nil {
+		writeRequestError(w, fmt.Errorf("failed to update %s: %w", symbol, err))
+		return
+	}
+
+	row, ok := s.lookupWatchlist(w, r, symbol)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toWatchlistItem(database.GetWatchlistRow(row)))
+}
+
+func (s *Server) handleRemoveWatchlist(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	removed, err := watchlist.RemoveFromWatchlist(r.Context(), s.store, symbol)
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to remove %s: %w", symbol, err))
+		return
+	}
+	if !removed {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not on the watchlist", symbol))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWatchlistHistory(w http.ResponseWriter, r *http.Request) {
+	invalid := validationError{}
+	symbol := pathSymbol(r, invalid)
+	p := s.queryPage(r, defaultPageSize, invalid)
+	if err := invalid.err(); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	item, ok := s.lookupWatchlist(w, r, symbol)
+	if !ok {
+		return
+	}
+
+	rows, err := s.store.ListWatchlistHistory(r.Context(), database.ListWatchlistHistoryParams{
+		WatchlistID: item.ID,
+		Limit:       int32(p.limit),
+		Offset:      int32(p.offset),
+	})
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to fetch history for %s: %w", symbol, err))
+		return
+	}
+	total, err := s.store.CountWatchlistHistory(r.Context(), item.ID)
+	if err != nil {
+		writeRequestError(w, fmt.Errorf("failed to count history for %s: %w", symbol, err))
+		return
+	}
+
+	data := make([]historyEntry, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, toHistoryEntry(row))
+	}
+	writeJSON(w, http.StatusOK, listResponse{Data: data, Pagination: p.pagination(len(data), &total)})
+}
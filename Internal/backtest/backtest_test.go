@@ -0,0 +1,208 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+func makeBars(closes []float64) types.BarSeries {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]types.Bar, len(closes))
+	for i, c := range closes {
+		open := c
+		if i > 0 {
+			open = closes[i-1]
+		}
+		bars[i] = types.Bar{
+			Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open:      open,
+			High:      utils.Max(open, c) + 1,
+			Low:       utils.Min(open, c) - 1,
+			Close:     c,
+			Volume:    1000,
+		}
+	}
+	series, _ := types.NewBarSeries(bars)
+	return series
+}
+
+// scripted returns a SignalFunc that emits the given recommendation when the window ends on that index
+func scripted(bars types.BarSeries, script map[int]string) SignalFunc {
+	index := make(map[string]int, bars.Len())
+	for i, bar := range bars.Bars() {
+		index[bar.Timestamp] = i
+	}
+	return func(symbol string, window types.BarSeries) strategy.CombinedSignal {
+		i := index[window.Latest().Timestamp]
+		if rec, ok := script[i]; ok {
+			return strategy.CombinedSignal{Recommendation: rec}
+		}
+		return strategy.CombinedSignal{Recommendation: "WAIT"}
+	}
+}
+
+func TestRunIsLookAheadFree(t *testing.T) {
+	bars := makeBars([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19})
+	cfg := DefaultConfig("TEST", "1Day")
+	cfg.WarmupBars = 2
+	cfg.Lookback = 0
+
+	step := cfg.WarmupBars
+	_, err := Run(bars, cfg, func(symbol string, window types.BarSeries) strategy.CombinedSignal {
+		if window.Len() != step+1 {
+			t.Errorf("step %d: expected window of %d bars, got %d", step, step+1, window.Len())
+		}
+		if window.Latest().Timestamp != bars.At(step).Timestamp {
+			t.Errorf("step %d: window ends at %s, want %s", step, window.Latest().Timestamp, bars.At(step).Timestamp)
+		}
+		step++
+		return strategy.CombinedSignal{Recommendation: "BUY"}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The final bar never produces a signal because there is no next bar to fill on
+	if step != bars.Len()-1 {
+		t.Errorf("expected signals up to bar %d, stopped at %d", bars.Len()-2, step-1)
+	}
+}
+
+func TestRunFillsOnNextOpen(t *testing.T) {
+	bars := makeBars([]float64{10, 10, 10, 10, 12, 14, 16, 15, 15, 15})
+	cfg := DefaultConfig("TEST", "1Day")
+	cfg.InitialCapital = 1000
+	cfg.SlippageBps = 0
+	cfg.WarmupBars = 2
+
+	result, err := Run(bars, cfg, scripted(bars, map[int]string{3: "BUY", 6: "SELL"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(result.Trades))
+	}
+
+	trade := result.Trades[0]
+	// BUY decided on close of bar 3 fills at open of bar 4 (= close of bar 3)
+	if trade.EntryPrice != bars.At(4).Open {
+		t.Errorf("entry price = %v, want %v", trade.EntryPrice, bars.At(4).Open)
+	}
+	if trade.ExitPrice != bars.At(7).Open {
+		t.Errorf("exit price = %v, want %v", trade.ExitPrice, bars.At(7).Open)
+	}
+	if trade.Quantity != 100 {
+		t.Errorf("quantity = %d, want 100", trade.Quantity)
+	}
+	if utils.Abs(trade.PnL-600) > 1e-9 {
+		t.Errorf("pnl = %v, want 600", trade.PnL)
+	}
+	if result.EndEquity != 1600 {
+		t.Errorf("end equity = %v, want 1600", result.EndEquity)
+	}
+	if result.WinRate != 100 {
+		t.Errorf("win rate = %v, want 100", result.WinRate)
+	}
+}
+
+func TestRunAppliesCostsAndClosesAtEnd(t *testing.T) {
+	bars := makeBars([]float64{10, 10, 10, 10, 10, 10})
+	cfg := DefaultConfig("TEST", "1Day")
+	cfg.InitialCapital = 1000
+	cfg.SlippageBps = 100
+	cfg.CommissionPerTrade = 1
+	cfg.WarmupBars = 1
+
+	result, err := Run(bars, cfg, scripted(bars, map[int]string{1: "ACCUMULATE"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("expected open position to be closed at end of data, got %d trades", len(result.Trades))
+	}
+
+	trade := result.Trades[0]
+	if trade.ExitSignal != "END_OF_DATA" {
+		t.Errorf("exit signal = %s, want END_OF_DATA", trade.ExitSignal)
+	}
+	if trade.PnL >= 0 {
+		t.Errorf("flat prices with slippage and commission should lose money, got pnl %v", trade.PnL)
+	}
+	if result.EndEquity >= cfg.InitialCapital {
+		t.Errorf("end equity %v should be below starting capital", result.EndEquity)
+	}
+	if result.Losses != 1 || result.WinRate != 0 {
+		t.Errorf("expected 1 loss and 0%% win rate, got %d losses, %.1f%%", result.Losses, result.WinRate)
+	}
+}
+
+func TestRunRejectsBadConfig(t *testing.T) {
+	bars := makeBars([]float64{1, 2, 3, 4, 5})
+	cfg := DefaultConfig("TEST", "1Day")
+	cfg.PositionSizePct = 1.5
+	if _, err := Run(bars, cfg, nil); err == nil {
+		t.Error("expected error for position size above 1")
+	}
+
+	cfg = DefaultConfig("TEST", "1Day")
+	if _, err := Run(bars, cfg, nil); err == nil {
+		t.Error("expected error when bars do not cover the warmup period")
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	curve := []EquityPoint{{Equity: 100}, {Equity: 120}, {Equity: 90}, {Equity: 110}, {Equity: 60}, {Equity: 130}}
+	dd := MaxDrawdown(curve)
+	if utils.Abs(dd-50) > 1e-9 {
+		t.Errorf("max drawdown = %v, want 50", dd)
+	}
+}
+
+func TestSharpeRatioFlatCurve(t *testing.T) {
+	curve := []EquityPoint{{Equity: 100}, {Equity: 100}, {Equity: 100}}
+	if s := SharpeRatio(curve, 252); s != 0 {
+		t.Errorf("flat equity should have 0 sharpe, got %v", s)
+	}
+}
+
+func TestRunAcceptsLatestFirstBars(t *testing.T) {
+	bars := makeBars([]float64{10, 10, 10, 10, 12, 14, 16, 15, 15, 15})
+	reversed, err := types.NewBarSeries(bars.LatestFirst())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig("TEST", "1Day")
+	cfg.WarmupBars = 2
+
+	want, err := Run(bars, cfg, scripted(bars, map[int]string{3: "BUY", 6: "SELL"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Run(reversed, cfg, scripted(bars, map[int]string{3: "BUY", 6: "SELL"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EndEquity != want.EndEquity || len(got.Trades) != len(want.Trades) {
+		t.Errorf("latest-first bars replayed differently: %v over %d trades, want %v over %d", got.EndEquity, len(got.Trades), want.EndEquity, len(want.Trades))
+	}
+}
+
+func TestDefaultSignalFuncRuns(t *testing.T) {
+	closes := make([]float64, 60)
+	for i := range closes {
+		closes[i] = 100 + float64(i%7) - float64(i%3)
+	}
+	bars := makeBars(closes)
+
+	result, err := Run(bars, DefaultConfig("TEST", "1Day"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.EquityCurve) != bars.Len() {
+		t.Errorf("expected %d equity points, got %d", bars.Len(), len(result.EquityCurve))
+	}
+	t.Logf("trades=%d return=%.2f%%", result.NumTrades, result.TotalReturnPct)
+}
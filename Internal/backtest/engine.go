@@ -0,0 +1,315 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/analyzer"
+	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
+)
+
+// SignalFunc produces a recommendation from the bars visible at the current step.
+// window's latest bar is the one that just closed.
+type SignalFunc func(symbol string, window types.BarSeries) strategy.CombinedSignal
+
+type Config struct {
+	Symbol             string
+	Timeframe          string
+	InitialCapital     float64
+	PositionSizePct    float64 // fraction of equity committed on each entry (0-1)
+	SlippageBps        float64 // applied against us on every fill
+	CommissionPerTrade float64 // flat fee per fill
+	CommissionPerShare float64
+	WarmupBars         int // bars required before the first signal is evaluated
+	Lookback           int // max bars handed to the signal function (0 = everything so far)
+	EntryOn            []string
+	ExitOn             []string
+}
+
+func DefaultConfig(symbol string, timeframe string) Config {
+	return Config{
+		Symbol:             symbol,
+		Timeframe:          timeframe,
+		InitialCapital:     10000,
+		PositionSizePct:    1.0,
+		SlippageBps:        5,
+		CommissionPerTrade: 0,
+		CommissionPerShare: 0,
+		WarmupBars:         20,
+		Lookback:           100,
+		EntryOn:            []string{"BUY", "ACCUMULATE"},
+		ExitOn:             []string{"SELL", "DISTRIBUTE"},
+	}
+}
+
+type Trade struct {
+	EntryTime   string
+	ExitTime    string
+	EntryPrice  float64
+	ExitPrice   float64
+	Quantity    int64
+	Commission  float64
+	PnL         float64
+	ReturnPct   float64
+	BarsHeld    int
+	EntrySignal string
+	ExitSignal  string
+}
+
+type EquityPoint struct {
+	Timestamp     string
+	Equity        float64
+	Cash          float64
+	PositionValue float64
+}
+
+type Result struct {
+	Symbol         string
+	Timeframe      string
+	StartEquity    float64
+	EndEquity      float64
+	TotalReturnPct float64
+	NumTrades      int
+	Wins           int
+	Losses         int
+	WinRate        float64
+	MaxDrawdownPct float64
+	Sharpe         float64
+	Trades         []Trade
+	EquityCurve    []EquityPoint
+}
+
+type pendingOrder struct {
+	side   string // "BUY" or "SELL"
+	reason string
+}
+
+type openPosition struct {
+	quantity    int64
+	entryPrice  float64
+	entryTime   string
+	entryIndex  int
+	commission  float64
+	entrySignal string
+}
+
+// Run replays bars one at a time. A signal generated on the close of bar i is
+// filled at the open of bar i+1, so no decision ever sees a bar it could not
+// have seen live.
+func Run(series types.BarSeries, cfg Config, signalFn SignalFunc) (*Result, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if signalFn == nil {
+		signalFn = DefaultSignalFunc
+	}
+	bars := series.Bars()
+	if len(bars) <= cfg.WarmupBars {
+		return nil, fmt.Errorf("not enough bars for backtest: have %d, need more than %d", len(bars), cfg.WarmupBars)
+	}
+
+	result := &Result{
+		Symbol:      cfg.Symbol,
+		Timeframe:   cfg.Timeframe,
+		StartEquity: cfg.InitialCapital,
+		Trades:      []Trade{},
+		EquityCurve: make([]EquityPoint, 0, len(bars)),
+	}
+
+	cash := cfg.InitialCapital
+	var position *openPosition
+	var pending *pendingOrder
+
+	for i, bar := range bars {
+		// Fill whatever was decided on the previous close at this bar's open
+		if pending != nil {
+			switch pending.side {
+			case "BUY":
+				if position == nil {
+					position, cash = openLong(cfg, bar, i, cash, pending.reason)
+				}
+			case "SELL":
+				if position != nil {
+					var trade Trade
+					trade, cash = closeLong(cfg, position, bar.Open, bar.Timestamp, i, cash, pending.reason)
+					result.Trades = append(result.Trades, trade)
+					position = nil
+				}
+			}
+			pending = nil
+		}
+
+		positionValue := 0.0
+		if position != nil {
+			positionValue = float64(position.quantity) * bar.Close
+		}
+		result.EquityCurve = append(result.EquityCurve, EquityPoint{
+			Timestamp:     bar.Timestamp,
+			Equity:        cash + positionValue,
+			Cash:          cash,
+			PositionValue: positionValue,
+		})
+
+		if i < cfg.WarmupBars || i == len(bars)-1 {
+			continue
+		}
+
+		start := 0
+		if cfg.Lookback > 0 && i+1 > cfg.Lookback {
+			start = i + 1 - cfg.Lookback
+		}
+		// the window shares no capacity past bar i, so the signal function cannot reach future bars
+		window := series.Slice(start, i+1)
+		signal := signalFn(cfg.Symbol, window)
+
+		if position == nil && contains(cfg.EntryOn, signal.Recommendation) {
+			pending = &pendingOrder{side: "BUY", reason: signal.Recommendation}
+		} else if position != nil && contains(cfg.ExitOn, signal.Recommendation) {
+			pending = &pendingOrder{side: "SELL", reason: signal.Recommendation}
+		}
+	}
+
+	// Mark any open position to market on the final close
+	if position != nil {
+		last := bars[len(bars)-1]
+		var trade Trade
+		trade, cash = closeLong(cfg, position, last.Close, last.Timestamp, len(bars)-1, cash, "END_OF_DATA")
+		result.Trades = append(result.Trades, trade)
+		result.EquityCurve[len(result.EquityCurve)-1] = EquityPoint{
+			Timestamp: last.Timestamp,
+			Equity:    cash,
+			Cash:      cash,
+		}
+	}
+
+	result.EndEquity = cash
+	computeMetrics(result, periodsPerYear(cfg.Timeframe))
+	return result, nil
+}
+
+func openLong(cfg Config, bar types.Bar, index int, cash float64, reason string) (*openPosition, float64) {
+	fillPrice := bar.Open * (1 + cfg.SlippageBps/10000)
+	if fillPrice <= 0 {
+		return nil, cash
+	}
+
+	budget := cash * cfg.PositionSizePct
+	quantity := int64((budget - cfg.CommissionPerTrade) / (fillPrice + cfg.CommissionPerShare))
+	if quantity <= 0 {
+		return nil, cash
+	}
+
+	commission := cfg.CommissionPerTrade + cfg.CommissionPerShare*float64(quantity)
+	cash -= float64(quantity)*fillPrice + commission
+
+	return &openPosition{
+		quantity:    quantity,
+		entryPrice:  fillPrice,
+		entryTime:   bar.Timestamp,
+		entryIndex:  index,
+		commission:  commission,
+		entrySignal: reason,
+	}, cash
+}
+
+func closeLong(cfg Config, pos *openPosition, rawPrice float64, timestamp string, index int, cash float64, reason string) (Trade, float64) {
+	fillPrice := rawPrice * (1 - cfg.SlippageBps/10000)
+	commission := cfg.CommissionPerTrade + cfg.CommissionPerShare*float64(pos.quantity)
+	proceeds := float64(pos.quantity)*fillPrice - commission
+	cash += proceeds
+
+	totalCommission := pos.commission + commission
+	cost := float64(pos.quantity) * pos.entryPrice
+	pnl := float64(pos.quantity)*(fillPrice-pos.entryPrice) - totalCommission
+
+	returnPct := 0.0
+	if cost > 0 {
+		returnPct = pnl / cost * 100
+	}
+
+	return Trade{
+		EntryTime:   pos.entryTime,
+		ExitTime:    timestamp,
+		EntryPrice:  pos.entryPrice,
+		ExitPrice:   fillPrice,
+		Quantity:    pos.quantity,
+		Commission:  totalCommission,
+		PnL:         pnl,
+		ReturnPct:   returnPct,
+		BarsHeld:    index - pos.entryIndex,
+		EntrySignal: pos.entrySignal,
+		ExitSignal:  reason,
+	}, cash
+}
+
+// DefaultSignalFunc mirrors what the interactive analysis does for the latest bar:
+// RSI(14), ATR and volume ratio from the window, the latest candle pattern, then the active signal config.
+func DefaultSignalFunc(symbol string, window types.BarSeries) strategy.CombinedSignal {
+	return SignalFuncWithConfig(strategy.GetSignalConfig())(symbol, window)
+}
+
+// SignalFuncWithConfig is DefaultSignalFunc scored with a specific profile's weights and thresholds
+func SignalFuncWithConfig(cfg strategy.SignalConfig) SignalFunc {
+	return func(symbol string, window types.BarSeries) strategy.CombinedSignal {
+		return windowSignal(symbol, window, cfg)
+	}
+}
+
+func windowSignal(symbol string, window types.BarSeries, cfg strategy.SignalConfig) strategy.CombinedSignal {
+	var rsiPtr *float64
+	rsiValues, err := strategy.CalculateRSI(window.Closes(), 14)
+	if err == nil && len(rsiValues) > 0 {
+		rsi := rsiValues[len(rsiValues)-1]
+		rsiPtr = &rsi
+	}
+
+	var atrPtr *float64
+	if window.Len() >= 2 {
+		atr := scoring.CalculateATRFromBars(window)
+		atrPtr = &atr
+	}
+
+	latest := window.Latest()
+	_, results := analyzer.AnalyzeCandlestick(analyzer.Candlestick{
+		Open:  latest.Open,
+		Close: latest.Close,
+		High:  latest.High,
+		Low:   latest.Low,
+	})
+
+	return strategy.CalculateSignalWithConfig(strategy.SignalInput{
+		Symbol:      symbol,
+		Series:      window,
+		RSI:         rsiPtr,
+		ATR:         atrPtr,
+		Analysis:    results["Analysis"],
+		Patterns:    analyzer.DetectPatterns(window),
+		VolumeRatio: strategy.VolumeRatio(window.Volumes(), 20),
+	}, cfg)
+}
+
+func validateConfig(cfg Config) error {
+	if cfg.InitialCapital <= 0 {
+		return fmt.Errorf("initial capital must be positive")
+	}
+	if cfg.PositionSizePct <= 0 || cfg.PositionSizePct > 1 {
+		return fmt.Errorf("position size must be between 0 and 1, got %.2f", cfg.PositionSizePct)
+	}
+	if cfg.SlippageBps < 0 || cfg.CommissionPerTrade < 0 || cfg.CommissionPerShare < 0 {
+		return fmt.Errorf("slippage and commission cannot be negative")
+	}
+	if cfg.WarmupBars < 0 || cfg.Lookback < 0 {
+		return fmt.Errorf("warmup and lookback cannot be negative")
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
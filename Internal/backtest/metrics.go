@@ -0,0 +1,98 @@
+package backtest
+
+import (
+	"math"
+
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+func computeMetrics(result *Result, periods float64) {
+	result.NumTrades = len(result.Trades)
+	for _, trade := range result.Trades {
+		if trade.PnL > 0 {
+			result.Wins++
+		} else {
+			result.Losses++
+		}
+	}
+	if result.NumTrades > 0 {
+		result.WinRate = float64(result.Wins) / float64(result.NumTrades) * 100
+	}
+
+	if result.StartEquity > 0 {
+		result.TotalReturnPct = (result.EndEquity - result.StartEquity) / result.StartEquity * 100
+	}
+
+	result.MaxDrawdownPct = MaxDrawdown(result.EquityCurve)
+	result.Sharpe = SharpeRatio(result.EquityCurve, periods)
+}
+
+// MaxDrawdown returns the largest peak-to-trough equity decline as a percentage
+func MaxDrawdown(curve []EquityPoint) float64 {
+	peak := 0.0
+	maxDD := 0.0
+	for _, point := range curve {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			dd := (peak - point.Equity) / peak * 100
+			if dd > maxDD {
+				maxDD = dd
+			}
+		}
+	}
+	return maxDD
+}
+
+// SharpeRatio annualizes the mean/stddev of per-bar equity returns (risk-free rate of 0)
+func SharpeRatio(curve []EquityPoint, periodsPerYear float64) float64 {
+	if len(curve) < 2 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(curve)-1)
+	for i := 1; i < len(curve); i++ {
+		prev := curve[i-1].Equity
+		if prev == 0 {
+			continue
+		}
+		returns = append(returns, (curve[i].Equity-prev)/prev)
+	}
+
+	stdDev := utils.StandardDeviation(returns)
+	if stdDev == 0 {
+		return 0
+	}
+	return utils.Average(returns) / stdDev * math.Sqrt(periodsPerYear)
+}
+
+func periodsPerYear(timeframe string) float64 {
+	const tradingDays = 252.0
+	const minutesPerSession = 390.0
+
+	switch timeframe {
+	case "1Min":
+		return tradingDays * minutesPerSession
+	case "3Min":
+		return tradingDays * minutesPerSession / 3
+	case "5Min":
+		return tradingDays * minutesPerSession / 5
+	case "10Min":
+		return tradingDays * minutesPerSession / 10
+	case "30Min":
+		return tradingDays * minutesPerSession / 30
+	case "1Hour":
+		return tradingDays * minutesPerSession / 60
+	case "2Hour":
+		return tradingDays * minutesPerSession / 120
+	case "4Hour":
+		return tradingDays * minutesPerSession / 240
+	case "1Week":
+		return 52
+	case "1Month":
+		return 12
+	default:
+		return tradingDays
+	}
+}
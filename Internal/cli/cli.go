@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/storage"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+// App runs one headless subcommand. Results go to Out, progress and errors to Err,
+// so `mongelmaker screen --format json > out.json` stays machine readable.
+type App struct {
+	Config  *config.Config
+	Queries *database.Queries
+	Store   *storage.Store // for migrate; Queries is built on it
+	Out     io.Writer
+	Err     io.Writer
+
+	// Menu runs the interactive numeric menu; main wires it in
+	Menu func(ctx context.Context) error
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *App, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"menu", "interactive numeric menu (default)", (*App).runMenu},
+	{"scan", "rescore the watchlist with a profile", (*App).runScan},
+	{"analyze", "indicators and combined signal for one symbol", (*App).runAnalyze},
+	{"screen", "screen a list of symbols", (*App).runScreen},
+	{"watchlist", "add, remove or list watchlist symbols", (*App).runWatchlist},
+	{"scout", "evaluate tradable assets against a profile", (*App).runScout},
+	{"serve", "run the REST/JSON API server", (*App).runServe},
+	{"stream", "live trades, quotes and minute bars with rolling indicators", (*App).runStream},
+	{"migrate", "apply, roll back or list database migrations", (*App).runMigrate},
+}
+
+// offline commands only touch the database, so startup skips the broker account check for them
+var offline = map[string]bool{"watchlist": true, "migrate": true}
+
+// usageError marks bad arguments; Run exits with ExitUsage instead of ExitFailure
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsHeadless reports whether args (without the program name) select a non-interactive command
+func IsHeadless(args []string) bool {
+	return len(args) > 0 && args[0] != "menu"
+}
+
+// NeedsBroker reports whether args select a command that trades or fetches market data,
+// which is everything but the offline commands
+func NeedsBroker(args []string) bool {
+	return len(args) == 0 || !offline[args[0]]
+}
+
+// IsHelp reports whether args only ask for usage, which needs no database or provider
+func IsHelp(args []string) bool {
+	return len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help")
+}
+
+// Run dispatches args (without the program name) and returns the process exit code
+func (a *App) Run(ctx context.Context, args []string) int {
+	name := "menu"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	if IsHelp([]string{name}) {
+		a.usage()
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(a, ctx, args)
+		var usage *usageError
+		switch {
+		case err == nil:
+			return ExitOK
+		case errors.Is(err, flag.ErrHelp):
+			return ExitOK
+		case errors.As(err, &usage):
+			fmt.Fprintf(a.Err, "%s: %v\n", name, err)
+			return ExitUsage
+		default:
+			fmt.Fprintf(a.Err, "%s: %v\n", name, err)
+			return ExitFailure
+		}
+	}
+
+	fmt.Fprintf(a.Err, "unknown command %q\n\n", name)
+	a.usage()
+	return ExitUsage
+}
+
+func (a *App) usage() {
+	fmt.Fprintln(a.Err, "Usage: mongelmaker [command] [flags]")
+	fmt.Fprintln(a.Err, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(a.Err, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(a.Err, "\nRun 'mongelmaker <command> -h' for the flags of a command.")
+}
+
+func (a *App) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Err)
+	return fs
+}
+
+// parseArgs parses flags that may come before or after positional arguments,
+// so both `analyze TSLA --bars 200` and `analyze --bars 200 TSLA` work
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			// everything after -- is positional, even if it looks like a flag
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// profileName falls back to signals.profile, then balanced
+func (a *App) profileName(name string) (string, error) {
+	if name == "" && a.Config != nil {
+		name = a.Config.Signals.Profile
+	}
+	if name == "" {
+		name = "balanced"
+	}
+	if a.Config == nil {
+		return name, nil
+	}
+	if _, ok := a.Config.Profiles[name]; !ok {
+		known := make([]string, 0, len(a.Config.Profiles))
+		for p := range a.Config.Profiles {
+			known = append(known, p)
+		}
+		sort.Strings(known)
+		return "", usagef("unknown profile %q (available: %s)", name, strings.Join(known, ", "))
+	}
+	return name, nil
+}
+
+func (a *App) runMenu(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usagef("menu takes no arguments")
+	}
+	if a.Menu == nil {
+		return fmt.Errorf("interactive menu is not available")
+	}
+	return a.Menu(ctx)
+}
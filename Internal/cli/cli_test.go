@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/utils/analyzer"
+)
+
+// trendProvider returns limit daily bars of a steady uptrend, latest first
+type trendProvider struct{}
+
+func (trendProvider) Name() string { return "trend" }
+
+func (trendProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+	if symbol == "FAIL" {
+		return nil, fmt.Errorf("no data for %s", symbol)
+	}
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	bars := make([]datafeed.Bar, limit)
+	for i := range bars {
+		price := 100 + float64(i)
+		bars[limit-1-i] = datafeed.Bar{
+			Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open:      price - 0.5, High: price + 1, Low: price - 1, Close: price, Volume: 1000,
+		}
+	}
+	return bars, nil
+}
+
+func (trendProvider) GetLatestQuote(ctx context.Context, symbol string) (*datafeed.LastQuote, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (trendProvider) GetLatestTrade(ctx context.Context, symbol string) (*datafeed.LastTrade, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (trendProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func newTestApp() (*App, *bytes.Buffer, *bytes.Buffer) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	return &App{Out: out, Err: errOut}, out, errOut
+}
+
+func TestParseArgsAllowsFlagsAfterPositionals(t *testing.T) {
+	app, _, _ := newTestApp()
+	fs := app.newFlagSet("analyze")
+	bars := fs.Int("bars", 100, "")
+	format := formatFlag(fs)
+
+	positional, err := parseArgs(fs, []string{"TSLA", "--bars", "200", "--format=json", "--", "--not-a-flag"})
+	if err != nil {
+		t.Fatalf("parseArgs() error = %v", err)
+	}
+	if !reflect.DeepEqual(positional, []string{"TSLA", "--not-a-flag"}) {
+		t.Errorf("positional = %v", positional)
+	}
+	if *bars != 200 || *format != FormatJSON {
+		t.Errorf("flags not parsed: bars=%d format=%s", *bars, *format)
+	}
+
+	if _, err := parseArgs(app.newFlagSet("x"), []string{"--nope"}); err == nil {
+		t.Error("unknown flag should be a usage error")
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"help", []string{"help"}, ExitOK},
+		{"unknown command", []string{"frobnicate"}, ExitUsage},
+		{"bad format", []string{"analyze", "TSLA", "--format", "xml"}, ExitUsage},
+		{"bad timeframe", []string{"analyze", "TSLA", "--timeframe", "7Min"}, ExitUsage},
+		{"missing symbol", []string{"analyze"}, ExitUsage},
+		{"watchlist without subcommand", []string{"watchlist"}, ExitUsage},
+		{"watchlist without database", []string{"watchlist", "list"}, ExitFailure},
+		{"menu not wired", []string{"menu"}, ExitFailure},
+		{"migrate without subcommand", []string{"migrate"}, ExitUsage},
+		{"migrate without database", []string{"migrate", "status"}, ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, _ := newTestApp()
+			if got := app.Run(context.Background(), tt.args); got != tt.want {
+				t.Errorf("Run(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsBroker(t *testing.T) {
+	for _, args := range [][]string{nil, {"menu"}, {"scan"}, {"analyze", "TSLA"}, {"serve"}} {
+		if !NeedsBroker(args) {
+			t.Errorf("NeedsBroker(%v) = false, want true", args)
+		}
+	}
+	for _, args := range [][]string{{"migrate", "status"}, {"watchlist", "list"}} {
+		if NeedsBroker(args) {
+			t.Errorf("NeedsBroker(%v) = true, want false", args)
+		}
+	}
+}
+
+func TestAnalyzeOutputsJSONAndCSV(t *testing.T) {
+	previous := datafeed.GetProvider()
+	datafeed.SetProvider(trendProvider{})
+	defer datafeed.SetProvider(previous)
+
+	app, out, errOut := newTestApp()
+	if code := app.Run(context.Background(), []string{"analyze", "tsla", "--bars", "60", "--format", "json"}); code != ExitOK {
+		t.Fatalf("analyze exited %d: %s", code, errOut)
+	}
+	var result analyzer.Report
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if result.Symbol != "TSLA" || result.Bars != 60 || result.Close != 159 || result.RSI == nil || result.Signal.Recommendation == "" {
+		t.Errorf("unexpected analysis: %+v", result)
+	}
+
+	app, out, _ = newTestApp()
+	if code := app.Run(context.Background(), []string{"analyze", "TSLA", "--format", "csv"}); code != ExitOK {
+		t.Fatalf("csv analyze exited %d", code)
+	}
+	records, err := csv.NewReader(out).ReadAll()
+	if err != nil || len(records) != 2 || records[0][0] != "symbol" || records[1][0] != "TSLA" {
+		t.Errorf("unexpected csv %v (%v)", records, err)
+	}
+
+	app, _, _ = newTestApp()
+	if code := app.Run(context.Background(), []string{"analyze", "FAIL"}); code != ExitFailure {
+		t.Errorf("failed fetch should exit %d, got %d", ExitFailure, code)
+	}
+}
+
+func TestReadSymbols(t *testing.T) {
+	input := "aapl, msft\n# comment line\nTSLA  nvda # trailing\n\nAAPL\n"
+	symbols, err := readSymbols(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"AAPL", "MSFT", "TSLA", "NVDA"}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("readSymbols() = %v, want %v", symbols, want)
+	}
+}
+
+func TestStreamReplayFiltersSymbols(t *testing.T) {
+	app, out, errOut := newTestApp()
+	args := []string{"stream", "aapl", "--replay", "../stream/testdata/replay.jsonl", "--format", "json"}
+	if code := app.Run(context.Background(), args); code != ExitOK {
+		t.Fatalf("stream exited %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per AAPL bar, got %q", out)
+	}
+	for _, line := range lines {
+		var update struct {
+			Symbol string  `json:"symbol"`
+			VWAP   float64 `json:"vwap"`
+		}
+		if err := json.Unmarshal([]byte(line), &update); err != nil || update.Symbol != "AAPL" || update.VWAP == 0 {
+			t.Errorf("unexpected update %q (%v)", line, err)
+		}
+	}
+
+	app, _, _ = newTestApp()
+	if code := app.Run(context.Background(), []string{"stream", "--replay", "x", "--record", "y"}); code != ExitUsage {
+		t.Errorf("--replay with --record should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,496 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/database/watchlist"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+	"github.com/fazecat/mongelmaker/Internal/utils/analyzer"
+	"github.com/fazecat/mongelmaker/Internal/utils/scanner"
+	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
+)
+
+func checkTimeframe(timeframe string) error {
+	if datafeed.IsValidTimeframe(timeframe) {
+		return nil
+	}
+	return usagef("unknown timeframe %q (expected one of %s)", timeframe, strings.Join(datafeed.Timeframes, ", "))
+}
+
+func (a *App) requireDatabase() error {
+	if a.Queries == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	return nil
+}
+
+// repos reads news and whale history for decisions; without a database they are left out
+func (a *App) repos() repository.Repositories {
+	if a.Store == nil {
+		return repository.Repositories{}
+	}
+	return repository.FromDB(a.Store.Conn)
+}
+
+// pool sizes the worker pool from config and reports progress on Err
+func (a *App) pool(label string) utils.PoolConfig {
+	pool := utils.PoolConfigFromConfig(a.Config)
+	pool.Progress = utils.ProgressLine(a.Err, label)
+	return pool
+}
+
+type watchlistEntry struct {
+	Symbol      string  `json:"symbol"`
+	Score       float64 `json:"score"`
+	Category    string  `json:"category"`
+	AssetType   string  `json:"asset_type"`
+	Reason      string  `json:"reason,omitempty"`
+	AddedDate   string  `json:"added_date,omitempty"`
+	LastUpdated string  `json:"last_updated,omitempty"`
+}
+
+func (a *App) loadWatchlist(ctx context.Context) ([]watchlistEntry, error) {
+	rows, err := watchlist.GetWatchlist(ctx, a.Queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watchlist: %w", err)
+	}
+	entries := make([]watchlistEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := watchlistEntry{
+			Symbol:    row.Symbol,
+			Score:     float64(row.Score),
+			Category:  scoring.ScoreCategory(float64(row.Score)),
+			AssetType: row.AssetType,
+			Reason:    row.Reason.String,
+		}
+		if row.AddedDate.Valid {
+			entry.AddedDate = row.AddedDate.Time.Format("2006-01-02")
+		}
+		if row.LastUpdated.Valid {
+			entry.LastUpdated = row.LastUpdated.Time.Format("2006-01-02")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func watchlistTable(entries []watchlistEntry) table {
+	t := table{header: []string{"symbol", "score", "category", "added", "updated", "reason"}}
+	for _, e := range entries {
+		t.rows = append(t.rows, []string{e.Symbol, formatFloat(e.Score), e.Category, e.AddedDate, e.LastUpdated, e.Reason})
+	}
+	return t
+}
+
+type scanResult struct {
+	Profile   string           `json:"profile"`
+	Scanned   int              `json:"scanned"`
+	Watchlist []watchlistEntry `json:"watchlist"`
+}
+
+func (a *App) runScan(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("scan")
+	profile := fs.String("profile", "", "profile to scan with (default signals.profile)")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	name, err := a.profileName(*profile)
+	if err != nil {
+		return err
+	}
+	if err := a.requireDatabase(); err != nil {
+		return err
+	}
+
+	scanned, err := scanner.New(a.Config, a.repos()).PerformScan(ctx, name)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	fmt.Fprintf(a.Err, "Scanned %d symbols with profile %s\n", scanned, name)
+
+	entries, err := a.loadWatchlist(ctx)
+	if err != nil {
+		return err
+	}
+	return render(a.Out, *format, scanResult{Profile: name, Scanned: scanned, Watchlist: entries}, watchlistTable(entries))
+}
+
+func (a *App) runAnalyze(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("analyze")
+	timeframe := fs.String("timeframe", "1Day", "bar timeframe, e.g. 5Min, 1Hour, 1Day")
+	numBars := fs.Int("bars", 100, "number of bars to analyze (at least 14)")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("expected exactly one symbol, e.g. analyze TSLA")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if err := checkTimeframe(*timeframe); err != nil {
+		return err
+	}
+	if *numBars < 14 {
+		return usagef("--bars must be at least 14, got %d", *numBars)
+	}
+	symbol := strings.ToUpper(positional[0])
+
+	bars, err := datafeed.GetBars(ctx, symbol, *timeframe, *numBars, "")
+	if err != nil {
+		return fmt.Errorf("failed to fetch data for %s: %w", symbol, err)
+	}
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		return fmt.Errorf("invalid bars for %s: %w", symbol, err)
+	}
+	repos := a.repos()
+	report, err := analyzer.AnalyzeBars(ctx, &strategy.Evaluator{News: repos.News, Whales: repos.Whales}, symbol, *timeframe, series)
+	if err != nil {
+		return err
+	}
+
+	d := report.Decision
+	patterns := make([]string, len(report.Patterns))
+	for i, m := range report.Patterns {
+		patterns[i] = string(m.ID)
+	}
+	t := table{header: []string{"symbol", "timeframe", "timestamp", "close", "rsi", "atr", "pattern", "patterns", "recommendation", "score", "confidence",
+		"rating", "stop", "target", "horizon"}}
+	t.rows = append(t.rows, []string{report.Symbol, report.Timeframe, report.Timestamp, formatFloat(report.Close),
+		formatOptional(report.RSI), formatOptional(report.ATR), report.Pattern, strings.Join(patterns, " "), d.Action,
+		formatFloat(d.Score), formatFloat(d.Confidence), formatFloat(d.Rating), formatLevel(d.Stop), formatLevel(d.Target), d.Horizon})
+	return render(a.Out, *format, report, t)
+}
+
+type screenResult struct {
+	Symbol         string   `json:"symbol"`
+	Score          float64  `json:"score"`
+	RSI            *float64 `json:"rsi"`
+	ATR            *float64 `json:"atr"`
+	Signals        []string `json:"signals"`
+	Recommendation string   `json:"recommendation,omitempty"`
+	// Confluence is only set with --multi-timeframe; its summary is also one of the signals
+	Confluence *strategy.Confluence `json:"confluence,omitempty"`
+}
+
+// readSymbols reads symbols separated by newlines, commas or spaces; # starts a comment
+func readSymbols(r io.Reader) ([]string, error) {
+	symbols := []string{}
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			symbol := strings.ToUpper(strings.TrimSpace(field))
+			if symbol == "" || seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols, sc.Err()
+}
+
+func readSymbolsFile(path string) ([]string, error) {
+	if path == "-" {
+		return readSymbols(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open symbols file: %w", err)
+	}
+	defer f.Close()
+	return readSymbols(f)
+}
+
+func (a *App) runScreen(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("screen")
+	symbolsFile := fs.String("symbols-file", "", "file with symbols to screen, - for stdin (default popular stocks)")
+	symbolList := fs.String("symbols", "", "comma-separated symbols to screen")
+	profile := fs.String("profile", "", "take RSI/ATR/volume criteria from this profile (default built-in criteria)")
+	timeframe := fs.String("timeframe", "1Day", "bar timeframe")
+	numBars := fs.Int("bars", 100, "bars per symbol")
+	minScore := fs.Float64("min-score", 0, "drop results scoring below this")
+	limit := fs.Int("limit", 0, "keep only the top N results (0 keeps all)")
+	multiTimeframe := fs.Bool("multi-timeframe", false, "add a 1Min-1Day confluence to every result (default from the profile)")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if err := checkTimeframe(*timeframe); err != nil {
+		return err
+	}
+
+	symbols := append([]string{}, positional...)
+	if *symbolList != "" {
+		listed, _ := readSymbols(strings.NewReader(*symbolList))
+		symbols = append(symbols, listed...)
+	}
+	if *symbolsFile != "" {
+		fromFile, err := readSymbolsFile(*symbolsFile)
+		if err != nil {
+			return err
+		}
+		symbols = append(symbols, fromFile...)
+	}
+	if len(symbols) == 0 {
+		symbols = strategy.GetPopularStocks()
+	}
+	symbols, _ = readSymbols(strings.NewReader(strings.Join(symbols, ",")))
+	if len(symbols) == 0 {
+		return fmt.Errorf("no symbols to screen")
+	}
+
+	criteria := strategy.DefaultScreenerCriteria()
+	if *profile != "" {
+		name, err := a.profileName(*profile)
+		if err != nil {
+			return err
+		}
+		if criteria, err = strategy.GetScreenerCriteriaFromProfile(a.Config, name); err != nil {
+			return err
+		}
+	}
+
+	if *multiTimeframe {
+		criteria.MultiTimeframe = true
+	}
+
+	repos := a.repos()
+	screener := &strategy.Screener{News: repos.News, Whales: repos.Whales, Pool: a.pool("Screened")}
+	scores, err := screener.Screen(ctx, symbols, *timeframe, *numBars, criteria)
+	if err != nil {
+		return fmt.Errorf("screener failed: %w", err)
+	}
+
+	results := []screenResult{}
+	for _, s := range scores {
+		if s.Score < *minScore {
+			continue
+		}
+		if *limit > 0 && len(results) >= *limit {
+			break
+		}
+		signals := s.Signals
+		if signals == nil {
+			signals = []string{}
+		}
+		results = append(results, screenResult{Symbol: s.Symbol, Score: s.Score, RSI: s.RSI, ATR: s.ATR, Signals: signals, Recommendation: s.Recommendation,
+			Confluence: s.Confluence})
+	}
+
+	t := table{header: []string{"symbol", "score", "rsi", "atr", "signals", "recommendation"}}
+	for _, r := range results {
+		t.rows = append(t.rows, []string{r.Symbol, formatFloat(r.Score), formatOptional(r.RSI), formatOptional(r.ATR),
+			strings.Join(r.Signals, "; "), r.Recommendation})
+	}
+	return render(a.Out, *format, results, t)
+}
+
+func (a *App) runWatchlist(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected a subcommand: add, remove or list")
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "add":
+		return a.runWatchlistAdd(ctx, args)
+	case "remove", "rm":
+		return a.runWatchlistRemove(ctx, args)
+	case "list", "ls":
+		return a.runWatchlistList(ctx, args)
+	default:
+		return usagef("unknown watchlist subcommand %q (expected add, remove or list)", sub)
+	}
+}
+
+func (a *App) runWatchlistAdd(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("watchlist add")
+	score := fs.Float64("score", 0, "initial score (the next scan rescores it)")
+	reason := fs.String("reason", "Added from CLI", "why the symbol is tracked")
+	assetType := fs.String("asset-type", "stock", "asset type")
+	symbols, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return usagef("expected at least one symbol, e.g. watchlist add AAPL MSFT")
+	}
+	if err := a.requireDatabase(); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if _, err := watchlist.AddToWatchlist(ctx, a.Queries, symbol, *assetType, *score, *reason); err != nil {
+			fmt.Fprintf(a.Err, "failed to add %s: %v\n", symbol, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(a.Err, "added %s\n", symbol)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d symbols not added", failed, len(symbols))
+	}
+	return nil
+}
+
+func (a *App) runWatchlistRemove(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("watchlist remove")
+	symbols, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return usagef("expected at least one symbol, e.g. watchlist remove AAPL")
+	}
+	if err := a.requireDatabase(); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		removed, err := watchlist.RemoveFromWatchlist(ctx, a.Queries, symbol)
+		switch {
+		case err != nil:
+			fmt.Fprintf(a.Err, "failed to remove %s: %v\n", symbol, err)
+			failed++
+		case !removed:
+			fmt.Fprintf(a.Err, "%s is not on the watchlist\n", symbol)
+			failed++
+		default:
+			fmt.Fprintf(a.Err, "removed %s\n", symbol)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d symbols not removed", failed, len(symbols))
+	}
+	return nil
+}
+
+func (a *App) runWatchlistList(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("watchlist list")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if err := a.requireDatabase(); err != nil {
+		return err
+	}
+
+	entries, err := a.loadWatchlist(ctx)
+	if err != nil {
+		return err
+	}
+	return render(a.Out, *format, entries, watchlistTable(entries))
+}
+
+type scoutResult struct {
+	Symbol  string  `json:"symbol"`
+	Score   float64 `json:"score"`
+	Action  string  `json:"action"`
+	RSI     float64 `json:"rsi"`
+	ATR     float64 `json:"atr"`
+	Pattern string  `json:"pattern"`
+	Added   bool    `json:"added"`
+}
+
+func (a *App) runScout(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("scout")
+	profile := fs.String("profile", "", "profile to scout with (default signals.profile)")
+	minScore := fs.Float64("min-score", 0, "minimum candidate score")
+	offset := fs.Int("offset", 0, "index of the first tradable asset to evaluate")
+	batchSize := fs.Int("batch-size", 50, "assets evaluated per batch")
+	all := fs.Bool("all", false, "keep going until every tradable asset is evaluated")
+	add := fs.Bool("add", false, "add every candidate to the watchlist")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *batchSize <= 0 || *offset < 0 {
+		return usagef("--batch-size must be positive and --offset not negative")
+	}
+	name, err := a.profileName(*profile)
+	if err != nil {
+		return err
+	}
+	if *add {
+		if err := a.requireDatabase(); err != nil {
+			return err
+		}
+	}
+
+	scout := scanner.New(a.Config, a.repos())
+	results := []scoutResult{}
+	for start := *offset; ; start += *batchSize {
+		candidates, total, err := scout.PerformProfileScanConcurrent(ctx, name, *minScore, start, *batchSize, a.pool("Evaluated"))
+		if err != nil {
+			return fmt.Errorf("scout scan failed: %w", err)
+		}
+		for _, c := range candidates {
+			r := scoutResult{Symbol: c.Symbol, Score: c.Score, Action: c.Action, RSI: c.RSI, ATR: c.ATR, Pattern: c.Analysis}
+			if *add {
+				reason := fmt.Sprintf("Scouted - Pattern: %s", c.Analysis)
+				if _, err := watchlist.AddToWatchlist(ctx, a.Queries, c.Symbol, "stock", c.Score, reason); err != nil {
+					fmt.Fprintf(a.Err, "failed to add %s: %v\n", c.Symbol, err)
+				} else {
+					r.Added = true
+				}
+			}
+			results = append(results, r)
+		}
+		if !*all || start+*batchSize >= total {
+			break
+		}
+	}
+
+	t := table{header: []string{"symbol", "score", "action", "rsi", "atr", "pattern", "added"}}
+	for _, r := range results {
+		t.rows = append(t.rows, []string{r.Symbol, formatFloat(r.Score), r.Action, formatFloat(r.RSI), formatFloat(r.ATR), r.Pattern, fmt.Sprint(r.Added)})
+	}
+	return render(a.Out, *format, results, t)
+}
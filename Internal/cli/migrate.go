@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fazecat/mongelmaker/Internal/database/migrate"
+)
+
+type migrationStatus struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+// runMigrate applies, rolls back or lists the embedded schema migrations
+func (a *App) runMigrate(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("migrate")
+	format := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return usagef("expected a subcommand: up, down, status or baseline")
+	}
+	if a.Store == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	migrator, err := a.Store.Migrator()
+	if err != nil {
+		return err
+	}
+
+	sub, rest := positional[0], positional[1:]
+	if sub != "baseline" && len(rest) > 0 {
+		return usagef("migrate %s takes no arguments", sub)
+	}
+	switch sub {
+	case "up":
+		ran, err := migrator.Up(ctx)
+		for _, m := range ran {
+			fmt.Fprintf(a.Err, "applied %s\n", m)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Fprintln(a.Err, "database is up to date")
+		}
+		return nil
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			fmt.Fprintln(a.Err, "no migrations to roll back")
+		} else {
+			fmt.Fprintf(a.Err, "rolled back %s\n", m)
+		}
+		return nil
+	case "baseline":
+		if len(rest) != 1 {
+			return usagef("usage: migrate baseline VERSION")
+		}
+		version, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return usagef("invalid version %q", rest[0])
+		}
+		marked, err := migrator.Baseline(ctx, version)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Err, "marked %d migrations as applied\n", len(marked))
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if renderErr := a.renderMigrations(*format, statuses); renderErr != nil {
+			return renderErr
+		}
+		return err
+	default:
+		return usagef("unknown migrate subcommand %q (expected up, down, status or baseline)", sub)
+	}
+}
+
+func (a *App) renderMigrations(format string, statuses []migrate.Status) error {
+	results := make([]migrationStatus, 0, len(statuses))
+	t := table{header: []string{"version", "name", "status", "applied_at"}}
+	for _, s := range statuses {
+		r := migrationStatus{Version: s.Version, Name: s.Name, Applied: s.Applied, Modified: s.Modified}
+		state := "pending"
+		if s.Applied {
+			r.AppliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			state = "applied"
+			if s.Modified {
+				state = "MODIFIED"
+			}
+		}
+		results = append(results, r)
+		t.rows = append(t.rows, []string{fmt.Sprintf("%05d", s.Version), s.Name, state, r.AppliedAt})
+	}
+	return render(a.Out, format, results, t)
+}
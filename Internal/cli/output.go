@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// table is the flat view of a result used for table and csv output; json encodes the result itself
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", FormatTable, "output format: table, json or csv")
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV:
+		return nil
+	default:
+		return usagef("unknown format %q (expected table, json or csv)", format)
+	}
+}
+
+func render(w io.Writer, format string, value interface{}, t table) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(t.header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatLevel leaves a stop or target the decision has no zone for blank instead of 0.00
+func formatLevel(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatFloat(v)
+}
+
+// formatOptional leaves missing indicators blank rather than printing a misleading 0
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fazecat/mongelmaker/Internal/api"
+)
+
+func (a *App) runServe(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("serve")
+	defaultAddr := ":8080"
+	if a.Config != nil && a.Config.API.Address != "" {
+		defaultAddr = a.Config.API.Address
+	}
+	addr := fs.String("addr", defaultAddr, "listen address")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if err := a.requireDatabase(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.Err, "API listening on %s (OpenAPI at /api/v1/openapi.yaml)\n", *addr)
+	if err := api.NewServer(a.Config, a.Queries, a.repos()).ListenAndServe(ctx, *addr); err != nil {
+		return fmt.Errorf("api server: %w", err)
+	}
+	return nil
+}
@@ -1,225 +1,367 @@
 package datafeed
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 	"github.com/fazecat/mongelmaker/Internal/types"
 	"github.com/fazecat/mongelmaker/Internal/utils"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 )
 
 type Bar = types.Bar
 
-func GetAlpacaBars(symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	secretKey := os.Getenv("ALPACA_API_SECRET")
+const (
+	defaultAlpacaDataURL    = "https://data.alpaca.markets"
+	defaultAlpacaTradingURL = "https://paper-api.alpaca.markets"
+	// Alpaca's free data plan allows 200 requests per minute
+	defaultAlpacaRequestsPerMinute = 200
+)
 
-	if startDate == "" {
-		// Compute a start time far enough in the past to return `limit` bars
-		now := time.Now().UTC()
-
-		timeframeToDur := func(tf string) time.Duration {
-			switch tf {
-			case "1Min":
-				return time.Minute
-			case "3Min":
-				return 3 * time.Minute
-			case "5Min":
-				return 5 * time.Minute
-			case "10Min":
-				return 10 * time.Minute
-			case "30Min":
-				return 30 * time.Minute
-			case "1Hour":
-				return time.Hour
-			case "2Hour":
-				return 2 * time.Hour
-			case "4Hour":
-				return 4 * time.Hour
-			case "1Day":
-				return 24 * time.Hour
-			case "1Week":
-				return 7 * 24 * time.Hour
-			case "1Month":
-				return 30 * 24 * time.Hour
-			default:
-				return 24 * time.Hour
-			}
-		}
+type AlpacaProviderConfig struct {
+	DataURL    string
+	TradingURL string
+	APIKey     string // falls back to ALPACA_API_KEY
+	APISecret  string // falls back to ALPACA_API_SECRET
+	Timeout    time.Duration
+	// RequestsPerMinute caps calls across every goroutine using this provider;
+	// 0 uses the free plan quota, negative disables the limit
+	RequestsPerMinute int
+}
+
+// AlpacaProvider serves market data from the Alpaca v2 data API
+type AlpacaProvider struct {
+	dataURL    string
+	tradingURL string
+	apiKey     string
+	apiSecret  string
+	httpClient *http.Client
+	retry      *utils.RetryConfig
+	limiter    *utils.RateLimiter
+}
+
+// creates an Alpaca provider, reading credentials from the environment once
+func NewAlpacaProvider(cfg AlpacaProviderConfig) *AlpacaProvider {
+	if cfg.DataURL == "" {
+		cfg.DataURL = defaultAlpacaDataURL
+	}
+	if cfg.TradingURL == "" {
+		cfg.TradingURL = defaultAlpacaTradingURL
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("ALPACA_API_KEY")
+	}
+	if cfg.APISecret == "" {
+		cfg.APISecret = os.Getenv("ALPACA_API_SECRET")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.RequestsPerMinute == 0 {
+		cfg.RequestsPerMinute = defaultAlpacaRequestsPerMinute
+	}
+	var limiter *utils.RateLimiter
+	if cfg.RequestsPerMinute > 0 {
+		limiter = utils.NewPerMinuteLimiter(cfg.RequestsPerMinute)
+	}
 
-		barDur := timeframeToDur(timeframe)
-		totalDur := barDur * time.Duration(limit+2)
-		start := now.Add(-totalDur)
-		startDate = start.Format(time.RFC3339)
+	return &AlpacaProvider{
+		dataURL:    cfg.DataURL,
+		tradingURL: cfg.TradingURL,
+		apiKey:     cfg.APIKey,
+		apiSecret:  cfg.APISecret,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		retry:      utils.DefaultRetryConfig(),
+		limiter:    limiter,
 	}
+}
 
-	apiURL := fmt.Sprintf(
-		"https://data.alpaca.markets/v2/stocks/%s/bars?timeframe=%s&limit=%d&start=%s",
-		symbol, timeframe, limit, startDate,
-	)
+func (p *AlpacaProvider) Name() string {
+	return ProviderAlpaca
+}
 
-	fmt.Printf("🔗 API Request: %s\n", apiURL)
+// Timeframes lists the bar timeframes the app offers, shortest first
+var Timeframes = []string{"1Min", "3Min", "5Min", "10Min", "30Min", "1Hour", "2Hour", "4Hour", "1Day", "1Week", "1Month"}
 
-	var bars []Bar
-	retryConfig := utils.DefaultRetryConfig()
+func IsValidTimeframe(tf string) bool {
+	for _, t := range Timeframes {
+		if t == tf {
+			return true
+		}
+	}
+	return false
+}
 
-	err := utils.RetryWithBackoff(func() error {
-		req, _ := http.NewRequest("GET", apiURL, nil)
-		req.Header.Set("APCA-API-KEY-ID", apiKey)
-		req.Header.Set("APCA-API-SECRET-KEY", secretKey)
+func timeframeDuration(tf string) time.Duration {
+	switch tf {
+	case "1Min":
+		return time.Minute
+	case "3Min":
+		return 3 * time.Minute
+	case "5Min":
+		return 5 * time.Minute
+	case "10Min":
+		return 10 * time.Minute
+	case "30Min":
+		return 30 * time.Minute
+	case "1Hour":
+		return time.Hour
+	case "2Hour":
+		return 2 * time.Hour
+	case "4Hour":
+		return 4 * time.Hour
+	case "1Day":
+		return 24 * time.Hour
+	case "1Week":
+		return 7 * 24 * time.Hour
+	case "1Month":
+		return 30 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+// getJSON performs an authenticated GET with retries and decodes the body into out.
+// Failures are *utils.APIError kinds, e.g. utils.ErrForbidden when the account has no access to that feed.
+func (p *AlpacaProvider) getJSON(ctx context.Context, apiURL string, out interface{}) error {
+	return utils.RetryWithBackoff(ctx, func() error {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
+		req.Header.Set("APCA-API-KEY-ID", p.apiKey)
+		req.Header.Set("APCA-API-SECRET-KEY", p.apiSecret)
 
-		fmt.Printf("📡 API Response Status: %s\n", resp.Status)
-
-		if resp.StatusCode == 403 {
-			fmt.Printf("⚠️  403 Forbidden - Your account may not have access to %s data\n", timeframe)
-			bars = []Bar{}
-			return nil
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return utils.NetworkError(err)
 		}
+		defer resp.Body.Close()
 
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("API returned status %d", resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			return utils.HTTPError(resp)
 		}
+		return utils.DecodeError(json.NewDecoder(resp.Body).Decode(out))
+	}, p.retry)
+}
 
-		type Response struct {
-			Bars []Bar `json:"bars"`
+func (p *AlpacaProvider) GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	startDate = defaultStartDate(timeframe, limit, startDate)
+
+	type Response struct {
+		Bars          []Bar  `json:"bars"`
+		NextPageToken string `json:"next_page_token"`
+	}
+
+	var bars []Bar
+	pageToken := ""
+	for {
+		apiURL := fmt.Sprintf(
+			"%s/v2/stocks/%s/bars?timeframe=%s&limit=%d&start=%s",
+			p.dataURL, url.PathEscape(symbol), timeframe, limit-len(bars), url.QueryEscape(startDate),
+		)
+		if pageToken != "" {
+			apiURL += "&page_token=" + url.QueryEscape(pageToken)
 		}
 
+		logger.Component("datafeed").Debug("alpaca request", logger.KeySymbol, symbol, "url", apiURL)
+
 		var r Response
-		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-			return err
+		if err := p.getJSON(ctx, apiURL, &r); err != nil {
+			return nil, fmt.Errorf("failed to get %s bars for %s: %w", timeframe, symbol, err)
 		}
 
-		bars = r.Bars
-		return nil
-	}, retryConfig)
-
-	if err != nil {
-		return nil, err
+		bars = append(bars, r.Bars...)
+		// a page holds at most 10000 bars, keep following the token until we have limit
+		if r.NextPageToken == "" || len(bars) >= limit {
+			break
+		}
+		pageToken = r.NextPageToken
 	}
 
-	fmt.Printf("📊 Received %d bars\n", len(bars))
+	logger.Component("datafeed").Debug("received bars", logger.KeySymbol, symbol, "count", len(bars))
 
 	// Reverse bars to latest-first (most recent data first)
-	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
-		bars[i], bars[j] = bars[j], bars[i]
-	}
-
-	return bars, nil
+	return latestFirst(bars, 0), nil
 }
 
-type LastQuote struct {
-	Price float64 `json:"ap"`
-}
+// symbols per multi-bar request; keeps the query string well under URL limits
+const alpacaBatchSize = 100
 
-func GetLastQuote(symbol string) (*LastQuote, error) {
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	secretKey := os.Getenv("ALPACA_API_SECRET")
+// GetBarsBatch fetches bars for many symbols through /v2/stocks/bars, a request per
+// alpacaBatchSize symbols instead of one per symbol. Every page is followed. Symbols in a
+// chunk that fails are reported in the returned *BatchError; the rest are still returned.
+func (p *AlpacaProvider) GetBarsBatch(ctx context.Context, symbols []string, timeframe string, limit int, startDate string) (map[string][]Bar, error) {
+	startDate = defaultStartDate(timeframe, limit, startDate)
+	result := make(map[string][]Bar, len(symbols))
+	failed := map[string]error{}
+
+	for i := 0; i < len(symbols); i += alpacaBatchSize {
+		chunk := symbols[i:min(i+alpacaBatchSize, len(symbols))]
+		bars, err := p.getMultiBars(ctx, chunk, timeframe, startDate)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			for _, symbol := range chunk {
+				failed[symbol] = err
+			}
+			continue
+		}
+		for _, symbol := range chunk {
+			symbolBars := bars[symbol]
+			if len(symbolBars) > limit {
+				symbolBars = symbolBars[:limit]
+			}
+			result[symbol] = latestFirst(symbolBars, 0)
+		}
+	}
 
-	url := fmt.Sprintf("https://data.alpaca.markets/v2/stocks/%s/quotes/latest", url.PathEscape(symbol))
+	logger.Component("datafeed").Debug("received batch bars", "symbols", len(result), "requested", len(symbols))
 
-	var quote *LastQuote
-	retryConfig := utils.DefaultRetryConfig()
+	if len(failed) > 0 {
+		return result, &BatchError{Failed: failed}
+	}
+	return result, nil
+}
 
-	err := utils.RetryWithBackoff(func() error {
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("APCA-API-KEY-ID", apiKey)
-		req.Header.Set("APCA-API-SECRET-KEY", secretKey)
+// getMultiBars pages through one multi-symbol request, returning chronological bars per symbol
+func (p *AlpacaProvider) getMultiBars(ctx context.Context, symbols []string, timeframe string, startDate string) (map[string][]Bar, error) {
+	type Response struct {
+		Bars          map[string][]Bar `json:"bars"`
+		NextPageToken string           `json:"next_page_token"`
+	}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
+	bars := make(map[string][]Bar, len(symbols))
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("symbols", strings.Join(symbols, ","))
+		query.Set("timeframe", timeframe)
+		query.Set("start", startDate)
+		query.Set("limit", "10000")
+		if pageToken != "" {
+			query.Set("page_token", pageToken)
 		}
-		defer resp.Body.Close()
+		apiURL := fmt.Sprintf("%s/v2/stocks/bars?%s", p.dataURL, query.Encode())
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("failed to get last quote: %s", resp.Status)
+		var r Response
+		if err := p.getJSON(ctx, apiURL, &r); err != nil {
+			return nil, fmt.Errorf("failed to get %s bars: %w", timeframe, err)
 		}
 
-		type Response struct {
-			Quote LastQuote `json:"quote"`
+		for symbol, page := range r.Bars {
+			bars[symbol] = append(bars[symbol], page...)
 		}
-
-		var r Response
-		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-			return err
+		if r.NextPageToken == "" {
+			return bars, nil
 		}
+		pageToken = r.NextPageToken
+	}
+}
 
-		quote = &r.Quote
-		return nil
-	}, retryConfig)
+// defaultStartDate computes a start time far enough in the past to return limit bars
+func defaultStartDate(timeframe string, limit int, startDate string) string {
+	if startDate != "" {
+		return startDate
+	}
+	totalDur := timeframeDuration(timeframe) * time.Duration(limit+2)
+	return time.Now().UTC().Add(-totalDur).Format(time.RFC3339)
+}
 
-	return quote, err
+func (p *AlpacaProvider) GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	apiURL := fmt.Sprintf("%s/v2/stocks/%s/quotes/latest", p.dataURL, url.PathEscape(symbol))
+
+	type Response struct {
+		Quote LastQuote `json:"quote"`
+	}
+
+	var r Response
+	if err := p.getJSON(ctx, apiURL, &r); err != nil {
+		return nil, fmt.Errorf("failed to get last quote: %w", err)
+	}
+	return &r.Quote, nil
 }
 
-func GetLastTrade(symbol string) (*Bar, error) {
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	secretKey := os.Getenv("ALPACA_API_SECRET")
+func (p *AlpacaProvider) GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	apiURL := fmt.Sprintf("%s/v2/stocks/%s/trades/latest", p.dataURL, url.PathEscape(symbol))
 
-	url := fmt.Sprintf("https://data.alpaca.markets/v2/stocks/%s/trades/latest", url.PathEscape(symbol))
+	type Response struct {
+		Trade LastTrade `json:"trade"`
+	}
 
-	var trade *Bar
-	retryConfig := utils.DefaultRetryConfig()
+	var r Response
+	if err := p.getJSON(ctx, apiURL, &r); err != nil {
+		return nil, fmt.Errorf("failed to get last trade: %w", err)
+	}
+	return &r.Trade, nil
+}
 
-	err := utils.RetryWithBackoff(func() error {
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("APCA-API-KEY-ID", apiKey)
-		req.Header.Set("APCA-API-SECRET-KEY", secretKey)
+// GetTradableAssets lists active, tradable US equities from the trading API
+func (p *AlpacaProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	if p.apiKey == "" || p.apiSecret == "" {
+		return nil, fmt.Errorf("ALPACA_API_KEY or ALPACA_API_SECRET not set")
+	}
+	client := alpaca.NewClient(alpaca.ClientOpts{
+		APIKey:    p.apiKey,
+		APISecret: p.apiSecret,
+		BaseURL:   p.tradingURL,
+	})
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
+	assets, err := client.GetAssets(alpaca.GetAssetsRequest{
+		Status: "active",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assets from Alpaca: %v", err)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("failed to get last trade: %s", resp.Status)
+	symbols := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if asset.Class == "us_equity" && asset.Tradable {
+			symbols = append(symbols, asset.Symbol)
 		}
+	}
 
-		var r Bar
-		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-			return err
-		}
+	logger.Component("datafeed").Info("fetched tradeable assets from alpaca", "count", len(symbols))
+	return symbols, nil
+}
 
-		trade = &r
-		return nil
-	}, retryConfig)
+// GetAlpacaBars is kept for older callers; it fetches through the configured provider so the
+// shared rate limiter, URLs and timeout apply
+func GetAlpacaBars(symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	return GetProvider().GetBars(context.Background(), symbol, timeframe, limit, startDate)
+}
 
-	return trade, err
+func GetLastQuote(symbol string) (*LastQuote, error) {
+	return GetLatestQuote(context.Background(), symbol)
 }
 
-var alpacaClient *alpaca.Client
+func GetLastTrade(symbol string) (*LastTrade, error) {
+	return GetLatestTrade(context.Background(), symbol)
+}
 
-func InitAlpacaClient() error {
+// NewAlpacaTradingClient creates a paper trading client from ALPACA_API_KEY and ALPACA_API_SECRET
+func NewAlpacaTradingClient() (*alpaca.Client, error) {
 	apiKey := os.Getenv("ALPACA_API_KEY")
 	secretKey := os.Getenv("ALPACA_API_SECRET")
 
 	if apiKey == "" || secretKey == "" {
-		return fmt.Errorf("ALPACA_API_KEY or ALPACA_API_SECRET not set")
+		return nil, fmt.Errorf("ALPACA_API_KEY or ALPACA_API_SECRET not set")
 	}
 
-	alpacaClient = alpaca.NewClient(alpaca.ClientOpts{
+	return alpaca.NewClient(alpaca.ClientOpts{
 		APIKey:    apiKey,
 		APISecret: secretKey,
-		BaseURL:   "https://paper-api.alpaca.markets",
-	})
-
-	return nil
-}
-
-func GetAlpacaClient() *alpaca.Client {
-	return alpacaClient
+		BaseURL:   defaultAlpacaTradingURL,
+	}), nil
 }
@@ -0,0 +1,457 @@
+package datafeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+// CachedProvider serves bars from historical_bars and only asks the upstream provider
+// for the tail that isn't stored yet, plus older bars when fewer than limit are stored.
+// Everything fetched is written back. Quotes, trades and assets go straight to upstream.
+type CachedProvider struct {
+	upstream MarketDataProvider
+	store    repository.BarRepository
+	now      func() time.Time
+
+	mu      sync.Mutex
+	noOlder map[string]time.Time // symbol/timeframe -> oldest bar upstream had nothing before
+}
+
+// wraps upstream with the bar cache; with a nil store every call goes straight to upstream
+func NewCachedProvider(upstream MarketDataProvider, store repository.BarRepository) *CachedProvider {
+	return &CachedProvider{upstream: upstream, store: store, now: time.Now, noOlder: map[string]time.Time{}}
+}
+
+// Name reports the upstream so callers checking for Alpaca keep working
+func (c *CachedProvider) Name() string {
+	return c.upstream.Name()
+}
+
+func (c *CachedProvider) Upstream() MarketDataProvider {
+	return c.upstream
+}
+
+// cacheState is what historical_bars already holds for one request
+type cacheState struct {
+	stored []Bar     // latest first
+	cold   bool      // nothing stored, needs a full fetch
+	from   time.Time // otherwise refetch from here (the newest stored bar)
+}
+
+func (c *CachedProvider) inspect(ctx context.Context, store repository.BarRepository, symbol, timeframe string, limit int) (cacheState, error) {
+	stored, err := store.RecentBars(ctx, symbol, timeframe, limit)
+	if err != nil {
+		return cacheState{}, err
+	}
+
+	state := cacheState{stored: stored}
+	if len(state.stored) == 0 {
+		state.cold = true
+		return state, nil
+	}
+
+	// the newest stored bar may have been partial when it was saved, so it is always refetched.
+	// Fewer than limit stored bars are topped up afterwards by backfillOlder.
+	state.from, err = time.Parse(time.RFC3339, state.stored[0].Timestamp)
+	if err != nil {
+		return cacheState{}, err
+	}
+	return state, nil
+}
+
+// tailLimit is how many bars upstream needs to return to cover from..now
+func (c *CachedProvider) tailLimit(timeframe string, from time.Time) int {
+	return int(c.now().Sub(from)/timeframeDuration(timeframe)) + 2
+}
+
+func (c *CachedProvider) GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	store := c.store
+	if store == nil {
+		return c.upstream.GetBars(ctx, symbol, timeframe, limit, startDate)
+	}
+
+	// explicit ranges are passed through and written back
+	if startDate != "" || limit <= 0 {
+		bars, err := c.upstream.GetBars(ctx, symbol, timeframe, limit, startDate)
+		if err != nil {
+			return nil, err
+		}
+		c.storeQuietly(ctx, store, symbol, timeframe, bars)
+		return bars, nil
+	}
+
+	state, err := c.inspect(ctx, store, symbol, timeframe, limit)
+	if err != nil {
+		logger.Component("datafeed").Warn("bar cache read failed", logger.KeySymbol, symbol, "upstream", c.upstream.Name(), logger.Err(err))
+		return c.upstream.GetBars(ctx, symbol, timeframe, limit, startDate)
+	}
+
+	if state.cold {
+		bars, err := c.upstream.GetBars(ctx, symbol, timeframe, limit, "")
+		if err != nil {
+			return nil, err
+		}
+		c.storeQuietly(ctx, store, symbol, timeframe, bars)
+		return c.backfillOlder(ctx, store, symbol, timeframe, mergeBars(state.stored, bars, limit), limit), nil
+	}
+
+	tail, err := c.upstream.GetBars(ctx, symbol, timeframe, c.tailLimit(timeframe, state.from), state.from.Format(time.RFC3339))
+	if err != nil {
+		logger.Component("datafeed").Warn("could not refresh cached bars, serving stored bars", logger.KeySymbol, symbol, "timeframe", timeframe, "upstream", c.upstream.Name(), logger.Err(err))
+		return state.stored, nil
+	}
+	c.storeQuietly(ctx, store, symbol, timeframe, tail)
+	return c.backfillOlder(ctx, store, symbol, timeframe, mergeBars(state.stored, tail, limit), limit), nil
+}
+
+// how many ever wider windows one request tries when backfilling older bars
+const maxBackfillRounds = 6
+
+// earliestStart is before any provider's history; a one-bar request from it returns the
+// oldest bar upstream has
+const earliestStart = "1970-01-01T00:00:00Z"
+
+// backfillOlder fetches the bars before the oldest of bars (latest first) until there are
+// limit of them or upstream has nothing older. That last answer is remembered for the oldest
+// bar it was given, so a recent listing isn't asked again on every request.
+func (c *CachedProvider) backfillOlder(ctx context.Context, store repository.BarRepository, symbol, timeframe string, bars []Bar, limit int) []Bar {
+	step := timeframeDuration(timeframe)
+	for round := 0; len(bars) > 0 && len(bars) < limit && round < maxBackfillRounds; round++ {
+		oldest, err := time.Parse(time.RFC3339, bars[len(bars)-1].Timestamp)
+		if err != nil || c.hasNoOlder(symbol, timeframe, oldest) {
+			return bars
+		}
+
+		// weekends, nights and holidays hold no bars, so the window doubles every round
+		window := min((limit-len(bars)+2)<<round, int(oldest.Sub(time.Unix(0, 0))/step))
+		start := oldest.Add(-step * time.Duration(window))
+		fetched, err := c.upstream.GetBars(ctx, symbol, timeframe, window+2, start.Format(time.RFC3339))
+		if err != nil {
+			logger.Component("datafeed").Warn("could not backfill older bars", logger.KeySymbol, symbol, "timeframe", timeframe, "upstream", c.upstream.Name(), logger.Err(err))
+			return bars
+		}
+		older := barsBetween(fetched, start, oldest.Add(-time.Second))
+		if len(older) > 0 {
+			c.storeQuietly(ctx, store, symbol, timeframe, older)
+			bars = mergeBars(bars, older, limit)
+			continue
+		}
+
+		// an empty window may just be a long market closure; only stop when upstream's
+		// very first bar isn't older than ours
+		first, err := c.upstream.GetBars(ctx, symbol, timeframe, 1, earliestStart)
+		if err != nil {
+			logger.Component("datafeed").Warn("could not backfill older bars", logger.KeySymbol, symbol, "timeframe", timeframe, "upstream", c.upstream.Name(), logger.Err(err))
+			return bars
+		}
+		if len(barsBetween(first, time.Time{}, oldest.Add(-time.Second))) == 0 {
+			c.markNoOlder(symbol, timeframe, oldest)
+			return bars
+		}
+	}
+	return bars
+}
+
+func (c *CachedProvider) hasNoOlder(symbol, timeframe string, oldest time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	first, ok := c.noOlder[symbol+" "+timeframe]
+	return ok && !oldest.After(first)
+}
+
+func (c *CachedProvider) markNoOlder(symbol, timeframe string, oldest time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noOlder[symbol+" "+timeframe] = oldest
+}
+
+// GetBarsBatch answers from historical_bars where it can and fetches the rest with at
+// most two upstream batch calls: one for symbols with no history, one for stale tails
+func (c *CachedProvider) GetBarsBatch(ctx context.Context, symbols []string, timeframe string, limit int, startDate string) (map[string][]Bar, error) {
+	store := c.store
+	if store == nil || startDate != "" || limit <= 0 {
+		return getBarsBatch(ctx, c.upstream, symbols, timeframe, limit, startDate)
+	}
+
+	result := make(map[string][]Bar, len(symbols))
+	states := make(map[string]cacheState, len(symbols))
+	failed := map[string]error{}
+	var cold, stale []string
+	var oldest time.Time
+
+	for _, symbol := range symbols {
+		state, err := c.inspect(ctx, store, symbol, timeframe, limit)
+		switch {
+		case err != nil || state.cold:
+			cold = append(cold, symbol)
+		default:
+			stale = append(stale, symbol)
+			if oldest.IsZero() || state.from.Before(oldest) {
+				oldest = state.from
+			}
+		}
+		states[symbol] = state
+	}
+
+	if len(cold) > 0 {
+		fetched, err := getBarsBatch(ctx, c.upstream, cold, timeframe, limit, "")
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		collectBatchErrors(err, cold, fetched, failed)
+		for symbol, bars := range fetched {
+			c.storeQuietly(ctx, store, symbol, timeframe, bars)
+			result[symbol] = mergeBars(states[symbol].stored, bars, limit)
+		}
+	}
+
+	if len(stale) > 0 {
+		fetched, err := getBarsBatch(ctx, c.upstream, stale, timeframe, c.tailLimit(timeframe, oldest), oldest.Format(time.RFC3339))
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if err != nil {
+			logger.Component("datafeed").Warn("could not refresh some cached bars, serving stored bars", "timeframe", timeframe, "upstream", c.upstream.Name(), logger.Err(err))
+		}
+		for _, symbol := range stale {
+			bars, ok := fetched[symbol]
+			if !ok {
+				result[symbol] = states[symbol].stored
+				continue
+			}
+			c.storeQuietly(ctx, store, symbol, timeframe, bars)
+			result[symbol] = mergeBars(states[symbol].stored, bars, limit)
+		}
+	}
+
+	// short histories are topped up one symbol at a time; most symbols never need it
+	for symbol, bars := range result {
+		if len(bars) < limit {
+			result[symbol] = c.backfillOlder(ctx, store, symbol, timeframe, bars, limit)
+		}
+	}
+
+	if len(failed) > 0 {
+		return result, &BatchError{Failed: failed}
+	}
+	return result, nil
+}
+
+// collectBatchErrors records every requested symbol that didn't come back
+func collectBatchErrors(err error, requested []string, fetched map[string][]Bar, failed map[string]error) {
+	for _, symbol := range requested {
+		if _, ok := fetched[symbol]; ok {
+			continue
+		}
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) && batchErr.Failed[symbol] != nil {
+			failed[symbol] = batchErr.Failed[symbol]
+		} else if err != nil {
+			failed[symbol] = err
+		} else {
+			failed[symbol] = fmt.Errorf("no bars returned for %s", symbol)
+		}
+	}
+}
+
+func (c *CachedProvider) GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	return c.upstream.GetLatestQuote(ctx, symbol)
+}
+
+func (c *CachedProvider) GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	return c.upstream.GetLatestTrade(ctx, symbol)
+}
+
+func (c *CachedProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return c.upstream.GetTradableAssets(ctx)
+}
+
+// caching is best effort: a failed write shouldn't fail the read that triggered it
+func (c *CachedProvider) storeQuietly(ctx context.Context, store repository.BarRepository, symbol, timeframe string, bars []Bar) {
+	if err := store.StoreBars(ctx, symbol, timeframe, bars); err != nil {
+		logger.Component("datafeed").Warn("bar cache write failed", logger.KeySymbol, symbol, "timeframe", timeframe, logger.Err(err))
+	}
+}
+
+// SyncBars backfills symbols into store from the active provider's upstream
+func SyncBars(ctx context.Context, store repository.BarRepository, symbols []string, timeframe string, start, end time.Time) ([]SyncResult, error) {
+	p := GetProvider()
+	if cache, ok := p.(*CachedProvider); ok {
+		p = cache.upstream
+	}
+	if p.Name() == ProviderDatabase {
+		return nil, fmt.Errorf("the database provider has nothing to sync from - set data_provider.source to alpaca or file")
+	}
+	return NewCachedProvider(p, store).SyncBars(ctx, symbols, timeframe, start, end)
+}
+
+// SyncResult reports one symbol of a SyncBars run
+type SyncResult struct {
+	Symbol string
+	Stored int
+	Gaps   []Gap // still missing after the backfill
+	Err    error
+}
+
+// SyncBars backfills stored bars for symbols between start and end, then looks for
+// gaps in what is stored and tries to fill each one from upstream
+func (c *CachedProvider) SyncBars(ctx context.Context, symbols []string, timeframe string, start, end time.Time) ([]SyncResult, error) {
+	store := c.store
+	if store == nil {
+		return nil, fmt.Errorf("the bar cache has no database to sync into")
+	}
+
+	results := make([]SyncResult, 0, len(symbols))
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := SyncResult{Symbol: symbol}
+		result.Stored, result.Err = c.backfill(ctx, store, symbol, timeframe, start, end)
+		if result.Err == nil {
+			var filled int
+			filled, result.Gaps, result.Err = c.FillGaps(ctx, symbol, timeframe, start, end)
+			result.Stored += filled
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *CachedProvider) backfill(ctx context.Context, store repository.BarRepository, symbol, timeframe string, start, end time.Time) (int, error) {
+	expected := int(end.Sub(start)/timeframeDuration(timeframe)) + 2
+	bars, err := c.upstream.GetBars(ctx, symbol, timeframe, expected, start.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	bars = barsBetween(bars, start, end)
+	if err := store.StoreBars(ctx, symbol, timeframe, bars); err != nil {
+		return 0, err
+	}
+	return len(bars), nil
+}
+
+// FillGaps finds missing bars in the stored range and refetches around each gap.
+// It returns how many bars were written and the gaps upstream couldn't fill
+// (market holidays show up here, since upstream has nothing for them).
+func (c *CachedProvider) FillGaps(ctx context.Context, symbol, timeframe string, start, end time.Time) (int, []Gap, error) {
+	store := c.store
+	if store == nil {
+		return 0, nil, fmt.Errorf("the bar cache has no database to sync into")
+	}
+
+	stored, err := store.BarsBetween(ctx, symbol, timeframe, start, end)
+	if err != nil {
+		return 0, nil, err
+	}
+	gaps := FindGaps(stored, timeframe)
+
+	filled := 0
+	for _, gap := range gaps {
+		bars, err := c.upstream.GetBars(ctx, symbol, timeframe, gap.Missing+2, gap.After.UTC().Format(time.RFC3339))
+		if err != nil {
+			return filled, gaps, err
+		}
+		bars = barsBetween(bars, gap.After.Add(time.Second), gap.Before.Add(-time.Second))
+		if err := store.StoreBars(ctx, symbol, timeframe, bars); err != nil {
+			return filled, gaps, err
+		}
+		filled += len(bars)
+	}
+
+	if filled == 0 {
+		return 0, gaps, nil
+	}
+	stored, err = store.BarsBetween(ctx, symbol, timeframe, start, end)
+	if err != nil {
+		return filled, gaps, err
+	}
+	return filled, FindGaps(stored, timeframe), nil
+}
+
+// Gap is a run of missing bars strictly between two stored bars
+type Gap struct {
+	After   time.Time
+	Before  time.Time
+	Missing int
+}
+
+// FindGaps looks for missing bars in chronological bars. Daily bars expect every
+// weekday; intraday bars only count gaps inside the same trading day, so nights
+// and weekends aren't reported.
+func FindGaps(bars []Bar, timeframe string) []Gap {
+	step := timeframeDuration(timeframe)
+	gaps := []Gap{}
+
+	for i := 1; i < len(bars); i++ {
+		prev, err1 := time.Parse(time.RFC3339, bars[i-1].Timestamp)
+		curr, err2 := time.Parse(time.RFC3339, bars[i].Timestamp)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		missing := 0
+		switch {
+		case step >= 7*24*time.Hour:
+			missing = int(curr.Sub(prev)/step) - 1
+		case step >= 24*time.Hour:
+			for d := prev.AddDate(0, 0, 1); d.Before(curr); d = d.AddDate(0, 0, 1) {
+				if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+					missing++
+				}
+			}
+		default:
+			if prev.YearDay() == curr.YearDay() && prev.Year() == curr.Year() {
+				missing = int(curr.Sub(prev)/step) - 1
+			}
+		}
+
+		if missing > 0 {
+			gaps = append(gaps, Gap{After: prev, Before: curr, Missing: missing})
+		}
+	}
+	return gaps
+}
+
+// mergeBars combines stored and fetched bars (either order), newer data winning on the
+// same timestamp, and returns the newest limit bars latest-first
+func mergeBars(stored, fetched []Bar, limit int) []Bar {
+	byTime := make(map[string]Bar, len(stored)+len(fetched))
+	for _, set := range [][]Bar{stored, fetched} {
+		for _, bar := range set {
+			if t, err := time.Parse(time.RFC3339, bar.Timestamp); err == nil {
+				bar.Timestamp = t.UTC().Format(time.RFC3339)
+			}
+			byTime[bar.Timestamp] = bar
+		}
+	}
+
+	merged := make([]Bar, 0, len(byTime))
+	for _, bar := range byTime {
+		merged = append(merged, bar)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Timestamp < merged[j].Timestamp
+	})
+	return latestFirst(merged, limit)
+}
+
+// barsBetween keeps bars with from <= timestamp <= to, in whatever order they came
+func barsBetween(bars []Bar, from, to time.Time) []Bar {
+	kept := make([]Bar, 0, len(bars))
+	for _, bar := range bars {
+		t, err := time.Parse(time.RFC3339, bar.Timestamp)
+		if err != nil || t.Before(from) || t.After(to) {
+			continue
+		}
+		kept = append(kept, bar)
+	}
+	return kept
+}
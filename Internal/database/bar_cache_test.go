@@ -0,0 +1,212 @@
+package datafeed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+)
+
+// seriesProvider serves chronological daily bars and records every GetBars call
+type seriesProvider struct {
+	bars  []Bar
+	calls []string
+}
+
+func (p *seriesProvider) Name() string { return "series" }
+
+func (p *seriesProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]Bar, error) {
+	p.calls = append(p.calls, fmt.Sprintf("%d@%s", limit, startDate))
+	selected := []Bar{}
+	for _, bar := range p.bars {
+		if startDate == "" || bar.Timestamp >= startDate {
+			selected = append(selected, bar)
+		}
+	}
+	if startDate != "" && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return latestFirst(append([]Bar(nil), selected...), limit), nil
+}
+
+func (p *seriesProvider) GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (p *seriesProvider) GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (p *seriesProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// weekdays returns n daily bars on consecutive weekdays starting at from
+func weekdays(from time.Time, n int) []Bar {
+	bars := []Bar{}
+	for d := from; len(bars) < n; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		price := float64(100 + len(bars))
+		bars = append(bars, Bar{Timestamp: d.Format(time.RFC3339), Open: price - 1, High: price + 1, Low: price - 2, Close: price, Volume: 1000})
+	}
+	return bars
+}
+
+func TestCachedProviderFetchesOnlyMissingTail(t *testing.T) {
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC) // a Monday
+	upstream := &seriesProvider{bars: weekdays(start, 20)}
+	store := repository.NewMemoryBars()
+	cache := NewCachedProvider(upstream, store)
+
+	last, _ := time.Parse(time.RFC3339, upstream.bars[len(upstream.bars)-1].Timestamp)
+	cache.now = func() time.Time { return last.Add(time.Hour) }
+	ctx := context.Background()
+
+	bars, err := cache.GetBars(ctx, "AAPL", "1Day", 10, "")
+	if err != nil || len(bars) != 10 || bars[0].Close != 119 {
+		t.Fatalf("cold GetBars() = %d bars (%v), err %v", len(bars), bars, err)
+	}
+	if store.Len() != 10 {
+		t.Fatalf("expected 10 stored bars, got %d", store.Len())
+	}
+
+	// same day again: the newest bar was partial when stored, so only it is refetched
+	upstream.calls = nil
+	upstream.bars[len(upstream.bars)-1].Close = 125
+	bars, err = cache.GetBars(ctx, "AAPL", "1Day", 10, "")
+	if err != nil || len(bars) != 10 || bars[0].Close != 125 {
+		t.Fatalf("warm GetBars() = %+v, %v, want the refreshed close", bars, err)
+	}
+	if want := "2@" + last.Format(time.RFC3339); len(upstream.calls) != 1 || upstream.calls[0] != want {
+		t.Errorf("expected only %s upstream, got %v", want, upstream.calls)
+	}
+	upstream.bars[len(upstream.bars)-1].Close = 119
+	upstream.calls = nil
+
+	// three new sessions later only the tail is requested
+	upstream.bars = weekdays(start, 23)
+	newLast, _ := time.Parse(time.RFC3339, upstream.bars[22].Timestamp)
+	cache.now = func() time.Time { return newLast.Add(time.Hour) }
+	bars, err = cache.GetBars(ctx, "AAPL", "1Day", 10, "")
+	if err != nil || bars[0].Close != 122 || bars[9].Close != 113 {
+		t.Fatalf("merged bars wrong: %+v, %v", bars, err)
+	}
+	if len(upstream.calls) != 1 || upstream.calls[0] == "10@" {
+		t.Errorf("expected one tail request from the newest stored bar, got %v", upstream.calls)
+	}
+	if store.Len() != 13 {
+		t.Errorf("expected 13 stored bars after the tail sync, got %d", store.Len())
+	}
+}
+
+func TestCachedProviderBackfillsOlderBars(t *testing.T) {
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	upstream := &seriesProvider{bars: weekdays(start, 30)}
+	store := repository.NewMemoryBars()
+	cache := NewCachedProvider(upstream, store)
+	last, _ := time.Parse(time.RFC3339, upstream.bars[29].Timestamp)
+	cache.now = func() time.Time { return last.Add(time.Hour) }
+	ctx := context.Background()
+
+	if bars, err := cache.GetBars(ctx, "AAPL", "1Day", 5, ""); err != nil || len(bars) != 5 {
+		t.Fatalf("GetBars(5) = %d bars, %v", len(bars), err)
+	}
+
+	// asking for more than is cached fetches the bars before the oldest stored one
+	bars, err := cache.GetBars(ctx, "AAPL", "1Day", 20, "")
+	if err != nil || len(bars) != 20 || bars[0].Close != 129 || bars[19].Close != 110 {
+		t.Fatalf("GetBars(20) = %d bars (%+v), %v, want closes 129 down to 110", len(bars), bars, err)
+	}
+	if store.Len() < 20 {
+		t.Errorf("expected the backfilled bars stored, got %d rows", store.Len())
+	}
+}
+
+func TestCachedProviderShortHistoryStopsBackfilling(t *testing.T) {
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	upstream := &seriesProvider{bars: weekdays(start, 5)}
+	cache := NewCachedProvider(upstream, repository.NewMemoryBars())
+	last, _ := time.Parse(time.RFC3339, upstream.bars[4].Timestamp)
+	cache.now = func() time.Time { return last.Add(time.Hour) }
+	ctx := context.Background()
+
+	if bars, err := cache.GetBars(ctx, "IPO", "1Day", 100, ""); err != nil || len(bars) != 5 {
+		t.Fatalf("GetBars() = %d bars, %v", len(bars), err)
+	}
+	// a recent listing has fewer bars than asked for: the backfill finds nothing older and
+	// checks upstream's first bar before giving up
+	if n := len(upstream.calls); n < 3 || upstream.calls[0] != "100@" || upstream.calls[n-1] != "1@"+earliestStart {
+		t.Fatalf("upstream calls = %v, want a full fetch, a backfill and a first-bar check", upstream.calls)
+	}
+
+	// the next request only syncs the tail
+	upstream.calls = nil
+	if bars, err := cache.GetBars(ctx, "IPO", "1Day", 100, ""); err != nil || len(bars) != 5 {
+		t.Fatalf("GetBars() = %d bars, %v", len(bars), err)
+	}
+	if want := "2@" + last.Format(time.RFC3339); len(upstream.calls) != 1 || upstream.calls[0] != want {
+		t.Errorf("upstream calls = %v, want only %s", upstream.calls, want)
+	}
+}
+
+func TestFindGapsSkipsWeekends(t *testing.T) {
+	bars := weekdays(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC), 10)
+	if gaps := FindGaps(bars, "1Day"); len(gaps) != 0 {
+		t.Fatalf("weekends are not gaps, got %+v", gaps)
+	}
+
+	// drop Wednesday and Thursday of the first week
+	withHole := append(append([]Bar{}, bars[:2]...), bars[4:]...)
+	gaps := FindGaps(withHole, "1Day")
+	if len(gaps) != 1 || gaps[0].Missing != 2 {
+		t.Fatalf("expected one 2-day gap, got %+v", gaps)
+	}
+
+	intraday := []Bar{
+		{Timestamp: "2024-01-02T15:00:00Z"},
+		{Timestamp: "2024-01-02T15:05:00Z"},
+		{Timestamp: "2024-01-02T15:20:00Z"}, // 15:10 and 15:15 missing
+		{Timestamp: "2024-01-03T14:30:00Z"}, // overnight is fine
+	}
+	gaps = FindGaps(intraday, "5Min")
+	if len(gaps) != 1 || gaps[0].Missing != 2 {
+		t.Errorf("expected one intraday gap of 2 bars, got %+v", gaps)
+	}
+}
+
+func TestSyncBarsBackfillsAndFillsGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	upstream := &seriesProvider{bars: weekdays(start, 15)}
+	store := repository.NewMemoryBars()
+	cache := NewCachedProvider(upstream, store)
+	ctx := context.Background()
+
+	// pre-store a few bars with a hole, as an older partial sync would have left them
+	if err := store.StoreBars(ctx, "MSFT", "1Day", []Bar{upstream.bars[0], upstream.bars[5]}); err != nil {
+		t.Fatal(err)
+	}
+	_, gaps, err := cache.FillGaps(ctx, "MSFT", "1Day", start, start.AddDate(0, 1, 0))
+	if err != nil || len(gaps) != 0 {
+		t.Fatalf("FillGaps() left %+v, %v", gaps, err)
+	}
+	if len(store.All("MSFT", "1Day")) != 6 {
+		t.Fatalf("expected the 4 missing days filled, got %d rows", len(store.All("MSFT", "1Day")))
+	}
+
+	end, _ := time.Parse(time.RFC3339, upstream.bars[14].Timestamp)
+	results, err := cache.SyncBars(ctx, []string{"MSFT"}, "1Day", start, end)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("SyncBars() = %+v, %v", results, err)
+	}
+	rows := store.All("MSFT", "1Day")
+	if len(rows) != 15 {
+		t.Errorf("expected 15 stored bars, got %d", len(rows))
+	}
+	if c := rows[14].Close; c != 114 {
+		t.Errorf("last stored close = %v, want 114", c)
+	}
+}
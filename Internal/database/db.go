@@ -1,74 +1,20 @@
 package datafeed
 
 import (
-	"database/sql"
-	"fmt"
-	"os"
+	"context"
 
-	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
-	_ "github.com/lib/pq"
+	"github.com/fazecat/mongelmaker/Internal/database/storage"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 )
 
-var Queries *database.Queries
-var DB *sql.DB
-
-// DatabaseConfig holds database connection configuration
-type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
-// InitDatabase initializes the database connection
-func InitDatabase() error {
-	config := DatabaseConfig{
-		Host:     getEnvOrDefault("DB_HOST", "localhost"),
-		Port:     getEnvOrDefault("DB_PORT", "5432"),
-		User:     getEnvOrDefault("DB_USER", "postgres"),
-		Password: os.Getenv("DB_PASSWORD"), // Required - no default
-		DBName:   getEnvOrDefault("DB_NAME", "mongelmaker"),
-		SSLMode:  getEnvOrDefault("DB_SSLMODE", "disable"),
-	}
-
-	// Build connection string
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
-
-	var err error
-	DB, err = sql.Open("postgres", connStr)
+// InitDatabase opens the backend chosen by cfg.Database (Postgres when cfg is nil).
+// The caller owns the store and hands its queries or repositories to whatever needs them.
+func InitDatabase(cfg *config.Config) (*storage.Store, error) {
+	store, err := storage.Open(context.Background(), storage.ConfigFromConfig(cfg))
 	if err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
-	}
-
-	if err = DB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
-	}
-	Queries = database.New(DB)
-
-	fmt.Println("✅ Database connected successfully!")
-	return nil
-}
-
-func CloseDatabase() error {
-	if DB != nil {
-		return DB.Close()
-	}
-	return nil
-}
-
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func HealthCheck() error {
-	if DB == nil {
-		return fmt.Errorf("database connection is nil")
+		return nil, err
 	}
-	return DB.Ping()
+	logger.Component("datafeed").Info("database connected", "backend", store.Backend)
+	return store, nil
 }
@@ -0,0 +1,82 @@
+package datafeed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+)
+
+// DBProvider serves bars that were previously stored in historical_bars
+type DBProvider struct {
+	bars repository.BarRepository
+}
+
+func NewDBProvider(bars repository.BarRepository) *DBProvider {
+	return &DBProvider{bars: bars}
+}
+
+func (p *DBProvider) Name() string {
+	return ProviderDatabase
+}
+
+func (p *DBProvider) GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	start, err := parseStartDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if start.IsZero() && limit > 0 {
+		bars, err := p.bars.RecentBars(ctx, symbol, timeframe, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stored bars for %s: %w", symbol, err)
+		}
+		return bars, nil
+	}
+
+	bars, err := p.bars.BarsBetween(ctx, symbol, timeframe, start, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stored bars for %s: %w", symbol, err)
+	}
+	if limit > 0 && len(bars) > limit {
+		bars = bars[:limit]
+	}
+	return latestFirst(bars, 0), nil
+}
+
+func (p *DBProvider) latestBar(ctx context.Context, symbol string) (*Bar, error) {
+	bars, err := p.GetBars(ctx, symbol, "1Day", 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no stored 1Day bars for %s", symbol)
+	}
+	return &bars[0], nil
+}
+
+func (p *DBProvider) GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	bar, err := p.latestBar(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &LastQuote{Price: bar.Close, BidPrice: bar.Close, Timestamp: bar.Timestamp}, nil
+}
+
+func (p *DBProvider) GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	bar, err := p.latestBar(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &LastTrade{Price: bar.Close, Timestamp: bar.Timestamp}, nil
+}
+
+// GetTradableAssets lists every symbol that has stored bars
+func (p *DBProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	symbols, err := p.bars.Symbols(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored symbols: %w", err)
+	}
+	return symbols, nil
+}
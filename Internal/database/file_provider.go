@@ -0,0 +1,386 @@
+package datafeed
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileProvider serves bars from CSV or JSON files on disk, e.g. the files written by the export package.
+// A request resolves to an explicit files entry for SYMBOL_<timeframe> or SYMBOL, then to
+// <dir>/<SYMBOL>_<timeframe>.{json,csv} and finally <dir>/<SYMBOL>.{json,csv} (case-insensitive
+// on the symbol). Files not named for a timeframe are only served for the timeframe their bar
+// spacing shows, so a daily file never answers a 1Min request.
+type FileProvider struct {
+	dir   string
+	files map[string]string
+}
+
+// creates a file provider rooted at dir with optional per-symbol file overrides
+func NewFileProvider(dir string, files map[string]string) (*FileProvider, error) {
+	if dir == "" && len(files) == 0 {
+		return nil, fmt.Errorf("file provider needs a directory or at least one file mapping")
+	}
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("file provider directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("file provider directory %s is not a directory", dir)
+		}
+	}
+
+	normalized := make(map[string]string, len(files))
+	for symbol, path := range files {
+		normalized[strings.ToUpper(symbol)] = path
+	}
+	return &FileProvider{dir: dir, files: normalized}, nil
+}
+
+func (p *FileProvider) Name() string {
+	return ProviderFile
+}
+
+// resolve finds the file for a request; tagged is false when the file isn't named for the timeframe
+func (p *FileProvider) resolve(symbol string, timeframe string) (path string, tagged bool, err error) {
+	symbol = strings.ToUpper(symbol)
+	if path, ok := p.files[symbol+"_"+strings.ToUpper(timeframe)]; ok {
+		return path, true, nil
+	}
+	if path, ok := p.files[symbol]; ok {
+		return path, false, nil
+	}
+	if p.dir == "" {
+		return "", false, fmt.Errorf("no data file configured for %s %s", symbol, timeframe)
+	}
+
+	for _, suffix := range []string{"_" + timeframe, ""} {
+		for _, name := range []string{symbol, strings.ToLower(symbol)} {
+			for _, ext := range []string{".json", ".csv"} {
+				path := filepath.Join(p.dir, name+suffix+ext)
+				if _, err := os.Stat(path); err == nil {
+					return path, suffix != "", nil
+				}
+			}
+		}
+	}
+	return "", false, fmt.Errorf("no data file for %s %s in %s", symbol, timeframe, p.dir)
+}
+
+// checkSpacing makes sure an untagged file holds timeframe bars: its closest two bars must be
+// one timeframe apart (within 10%, so calendar months pass)
+func checkSpacing(path string, bars []Bar, timeframe string) error {
+	if !IsValidTimeframe(timeframe) {
+		return fmt.Errorf("unknown timeframe %q", timeframe)
+	}
+	want := timeframeDuration(timeframe)
+	var gap time.Duration
+	for i := 1; i < len(bars); i++ {
+		prev, err := time.Parse(time.RFC3339, bars[i-1].Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp in %s: %w", path, err)
+		}
+		cur, err := time.Parse(time.RFC3339, bars[i].Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp in %s: %w", path, err)
+		}
+		if d := cur.Sub(prev); d > 0 && (gap == 0 || d < gap) {
+			gap = d
+		}
+	}
+	if gap == 0 {
+		return fmt.Errorf("cannot tell the timeframe of %s; name it <SYMBOL>_%s", path, timeframe)
+	}
+	if gap < want*9/10 || gap > want*11/10 {
+		return fmt.Errorf("%s holds %s bars, not %s", path, gap, timeframe)
+	}
+	return nil
+}
+
+func (p *FileProvider) GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, tagged, err := p.resolve(symbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	bars, err := LoadBarsFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !tagged {
+		if err := checkSpacing(path, bars, timeframe); err != nil {
+			return nil, err
+		}
+	}
+
+	start, err := parseStartDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	if !start.IsZero() {
+		filtered := bars[:0]
+		for _, bar := range bars {
+			t, err := time.Parse(time.RFC3339, bar.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in %s: %w", path, err)
+			}
+			if !t.Before(start) {
+				filtered = append(filtered, bar)
+			}
+		}
+		bars = filtered
+		// like Alpaca, a start date with a limit returns the first bars after start
+		if limit > 0 && len(bars) > limit {
+			bars = bars[:limit]
+		}
+	}
+
+	return latestFirst(bars, limit), nil
+}
+
+// latest quote/trade are synthesized from the close of the newest bar in the file
+func (p *FileProvider) latestBar(ctx context.Context, symbol string) (*Bar, error) {
+	bars, err := p.GetBars(ctx, symbol, "1Day", 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no bars in data file for %s", symbol)
+	}
+	return &bars[0], nil
+}
+
+func (p *FileProvider) GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	bar, err := p.latestBar(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &LastQuote{Price: bar.Close, BidPrice: bar.Close, Timestamp: bar.Timestamp}, nil
+}
+
+func (p *FileProvider) GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	bar, err := p.latestBar(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &LastTrade{Price: bar.Close, Timestamp: bar.Timestamp}, nil
+}
+
+// GetTradableAssets lists every symbol there is a data file for
+func (p *FileProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	for symbol := range p.files {
+		seen[symbol] = true
+	}
+
+	if p.dir != "" {
+		entries, err := os.ReadDir(p.dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", p.dir, err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".json" && ext != ".csv") {
+				continue
+			}
+			base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			symbol, _, _ := strings.Cut(base, "_")
+			if symbol != "" {
+				seen[strings.ToUpper(symbol)] = true
+			}
+		}
+	}
+
+	symbols := make([]string, 0, len(seen))
+	for symbol := range seen {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// LoadBarsFromFile reads a CSV or JSON bar file and returns the bars oldest first.
+// JSON may be an array of export records, an array of Alpaca bars, or an Alpaca {"bars": [...]} response.
+func LoadBarsFromFile(path string) ([]Bar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var bars []Bar
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		bars, err = parseJSONBars(data)
+	case ".csv":
+		bars, err = parseCSVBars(data)
+	default:
+		return nil, fmt.Errorf("unsupported data file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	times := make(map[string]time.Time, len(bars))
+	for _, bar := range bars {
+		t, err := time.Parse(time.RFC3339, bar.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp in %s: %w", path, err)
+		}
+		times[bar.Timestamp] = t
+	}
+	sort.SliceStable(bars, func(i, j int) bool {
+		return times[bars[i].Timestamp].Before(times[bars[j].Timestamp])
+	})
+	return bars, nil
+}
+
+// fileBar accepts both the export package's field names and Alpaca's short keys
+type fileBar struct {
+	Timestamp string  `json:"Timestamp"`
+	Open      float64 `json:"Open"`
+	High      float64 `json:"High"`
+	Low       float64 `json:"Low"`
+	Close     float64 `json:"Close"`
+	Volume    float64 `json:"Volume"`
+	T         string  `json:"t"`
+	O         float64 `json:"o"`
+	H         float64 `json:"h"`
+	L         float64 `json:"l"`
+	C         float64 `json:"c"`
+	V         float64 `json:"v"`
+}
+
+func parseJSONBars(data []byte) ([]Bar, error) {
+	var records []fileBar
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Bars []fileBar `json:"bars"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return nil, err
+		}
+		records = wrapped.Bars
+	} else if err := json.Unmarshal(trimmed, &records); err != nil {
+		return nil, err
+	}
+
+	bars := make([]Bar, 0, len(records))
+	for _, r := range records {
+		if r.Timestamp == "" {
+			r.Timestamp, r.Open, r.High, r.Low, r.Close, r.Volume = r.T, r.O, r.H, r.L, r.C, r.V
+		}
+		ts, err := normalizeTimestamp(r.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, Bar{
+			Timestamp: ts,
+			Open:      r.Open,
+			High:      r.High,
+			Low:       r.Low,
+			Close:     r.Close,
+			Volume:    int64(r.Volume),
+		})
+	}
+	return bars, nil
+}
+
+func parseCSVBars(data []byte) ([]Bar, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []Bar{}, nil
+	}
+
+	columns := map[string]int{}
+	aliases := map[string]string{
+		"timestamp": "timestamp", "time": "timestamp", "date": "timestamp", "t": "timestamp",
+		"open": "open", "o": "open",
+		"high": "high", "h": "high",
+		"low": "low", "l": "low",
+		"close": "close", "c": "close",
+		"volume": "volume", "v": "volume",
+	}
+	for i, name := range rows[0] {
+		if col, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[col] = i
+		}
+	}
+	for _, required := range []string{"timestamp", "open", "high", "low", "close"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	bars := make([]Bar, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		field := func(col string) string {
+			i, ok := columns[col]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		number := func(col string) (float64, error) {
+			value := field(col)
+			if value == "" {
+				return 0, nil
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid %s %q", line+2, col, value)
+			}
+			return f, nil
+		}
+
+		ts, err := normalizeTimestamp(field("timestamp"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		bar := Bar{Timestamp: ts}
+		if bar.Open, err = number("open"); err != nil {
+			return nil, err
+		}
+		if bar.High, err = number("high"); err != nil {
+			return nil, err
+		}
+		if bar.Low, err = number("low"); err != nil {
+			return nil, err
+		}
+		if bar.Close, err = number("close"); err != nil {
+			return nil, err
+		}
+		volume, err := number("volume")
+		if err != nil {
+			return nil, err
+		}
+		bar.Volume = int64(volume)
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// normalizeTimestamp converts the layouts we write or receive into RFC3339 UTC
+func normalizeTimestamp(value string) (string, error) {
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized timestamp %q", value)
+}
@@ -1,7 +1,10 @@
 package datafeed
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/fazecat/mongelmaker/Internal/utils"
 )
 
 //a lot of redudant code might remove it later
@@ -19,6 +22,22 @@ type MultiTimeframeData struct {
 	FiveMinData   []Bar
 	OneHourData   []Bar
 	OneDayData    []Bar
+	// Errors holds the fixed timeframes that failed to load; their slices are left nil
+	Errors map[string]error
+}
+
+// MultiTimeframes are the fixed timeframes FetchAllTimeframes loads, shortest first
+var MultiTimeframes = []string{"1Min", "5Min", "1Hour", "1Day"}
+
+// ByTimeframe returns the fixed timeframes that loaded, keyed like MultiTimeframes
+func (m *MultiTimeframeData) ByTimeframe() map[string][]Bar {
+	frames := map[string][]Bar{}
+	for timeframe, bars := range map[string][]Bar{"1Min": m.OneMinData, "5Min": m.FiveMinData, "1Hour": m.OneHourData, "1Day": m.OneDayData} {
+		if _, failed := m.Errors[timeframe]; !failed && bars != nil {
+			frames[timeframe] = bars
+		}
+	}
+	return frames
 }
 
 func GetCurrentPrice(symbol string) (float64, error) {
@@ -29,50 +48,48 @@ func GetCurrentPrice(symbol string) (float64, error) {
 	return Quote.Price, nil
 }
 
-// visit later
-func FetchAllTimeframes(symbol string, timeframe string, limit int) (*MultiTimeframeData, error) {
+// FetchAllTimeframes loads limit bars of timeframe plus 100 bars of each of MultiTimeframes
+// from the active provider, see FetchAllTimeframesFrom
+func FetchAllTimeframes(ctx context.Context, symbol string, timeframe string, limit int) (*MultiTimeframeData, error) {
+	return FetchAllTimeframesFrom(ctx, GetProvider(), symbol, timeframe, limit)
+}
 
-	limitbars, err := GetAlpacaBars(symbol, timeframe, limit, "")
+// FetchAllTimeframesFrom is FetchAllTimeframes against a given provider. Only a failure on
+// the requested timeframe is returned; the others are recorded in Errors, since intraday
+// bars are often missing outside market hours or from file-backed providers.
+func FetchAllTimeframesFrom(ctx context.Context, provider MarketDataProvider, symbol string, timeframe string, limit int) (*MultiTimeframeData, error) {
+	requested, err := provider.GetBars(ctx, symbol, timeframe, limit, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get historical data for %s: %w", symbol, err)
 	}
-	oneminutebars, err := GetAlpacaBars(symbol, "1Min", 100, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 1Min bars for %s: %w", symbol, err)
-	}
-	fiveminutebars, err := GetAlpacaBars(symbol, "5Min", 100, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 5Min bars for %s: %w", symbol, err)
-	}
-
-	onehourbars, err := GetAlpacaBars(symbol, "1Hour", 100, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 1Hour bars for %s: %w", symbol, err)
-	}
 
-	onedaybars, err := GetAlpacaBars(symbol, "1Day", 100, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 1Day bars for %s: %w", symbol, err)
+	data := &MultiTimeframeData{RequestedData: requested, Errors: map[string]error{}}
+	targets := map[string]*[]Bar{"1Min": &data.OneMinData, "5Min": &data.FiveMinData, "1Hour": &data.OneHourData, "1Day": &data.OneDayData}
+	for _, tf := range MultiTimeframes {
+		if tf == timeframe && limit == 100 {
+			*targets[tf] = requested
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		bars, err := provider.GetBars(ctx, symbol, tf, 100, "")
+		if err != nil {
+			data.Errors[tf] = fmt.Errorf("failed to get %s bars for %s: %w", tf, symbol, err)
+			continue
+		}
+		*targets[tf] = bars
 	}
-	return &MultiTimeframeData{
-		RequestedData: limitbars,
-		OneMinData:    oneminutebars,
-		FiveMinData:   fiveminutebars,
-		OneHourData:   onehourbars,
-		OneDayData:    onedaybars,
-	}, nil
+	return data, nil
 }
 
-// visit later
+// strategy.SMA has the full moving-average family, but strategy imports this package
 func calculateSMA(bars []Bar) float64 {
-	if len(bars) == 0 {
-		return 0
+	closes := make([]float64, len(bars))
+	for i, bar := range bars {
+		closes[i] = bar.Close
 	}
-	sum := 0.0
-	for _, bar := range bars {
-		sum += bar.Close
-	}
-	return sum / float64(len(bars))
+	return utils.Average(closes)
 }
 
 // visit later a lot of repetitive code
@@ -82,10 +99,13 @@ func GenerateSignal(symbol string) (*Signal, error) {
 		return nil, fmt.Errorf("failed to get current price for %s: %w", symbol, err)
 	}
 
-	historicalData, err := FetchAllTimeframes(symbol, "1Day", 100)
+	historicalData, err := FetchAllTimeframes(context.Background(), symbol, "1Day", 100)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get historical data for %s: %w", symbol, err)
 	}
+	if err := historicalData.Errors["1Day"]; err != nil {
+		return nil, err
+	}
 
 	sma := calculateSMA(historicalData.OneDayData)
 
@@ -0,0 +1,401 @@
+// Package migrate applies numbered SQL migrations (Internal/sql/migrations, or its
+// sqlite directory for the embedded backend) and records each one, with a checksum of its file, in the schema_migrations table.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrChecksumMismatch means an applied migration's file was edited afterwards
+	ErrChecksumMismatch = errors.New("migration changed after it was applied")
+	// ErrUnknownVersion means the database has a migration this build doesn't ship
+	ErrUnknownVersion = errors.New("database has a migration this build does not know")
+	// ErrUnversioned means the database already has the schema's tables but no recorded migrations
+	ErrUnversioned = errors.New("database has tables but no recorded migrations")
+)
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Migration is one NNNNN_name.sql file split into its up and down sections
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 of the whole file
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%05d_%s", m.Version, m.Name)
+}
+
+// Status is a migration and whether (and when) the database applied it.
+// Modified is set when the file no longer matches the checksum recorded at the time.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool
+}
+
+var (
+	fileName    = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.sql$`)
+	createTable = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Load reads every migration in fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var result []Migration
+	seen := map[int]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named NNNNN_name.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		up, down, err := split(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		sum := sha256.Sum256(content)
+		result = append(result, Migration{
+			Version:  version,
+			Name:     match[2],
+			Up:       up,
+			Down:     down,
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// split separates the "-- +goose Up" and "-- +goose Down" sections (markers are case-insensitive)
+func split(content string) (up, down string, err error) {
+	var sections [2]strings.Builder
+	current := -1
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.ToLower(strings.Join(strings.Fields(line), " ")) {
+		case "-- +goose up":
+			current = 0
+			continue
+		case "-- +goose down":
+			current = 1
+			continue
+		}
+		if current >= 0 {
+			sections[current].WriteString(line)
+			sections[current].WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	up, down = strings.TrimSpace(sections[0].String()), strings.TrimSpace(sections[1].String())
+	if up == "" {
+		return "", "", fmt.Errorf("no -- +goose Up section")
+	}
+	return up, down, nil
+}
+
+// Migrator runs migrations against one database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	loaded, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: loaded}, nil
+}
+
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// applied creates the version table if needed and returns what it records. A database
+// set up with goose has its goose_db_version history copied over the first time.
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedRow, error) {
+	if _, err := m.db.ExecContext(ctx, createVersionTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	result := map[int]appliedRow{}
+	for rows.Next() {
+		var version int
+		var row appliedRow
+		if err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		result[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return m.adoptGoose(ctx)
+	}
+	return result, nil
+}
+
+// adoptGoose records the versions goose applied, trusting the current files' checksums
+func (m *Migrator) adoptGoose(ctx context.Context) (map[int]appliedRow, error) {
+	result := map[int]appliedRow{}
+	rows, err := m.db.QueryContext(ctx, `SELECT version_id, is_applied FROM goose_db_version ORDER BY id`)
+	if err != nil {
+		return result, nil // not a goose database
+	}
+	state := map[int]bool{}
+	for rows.Next() {
+		var version int
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read goose_db_version: %w", err)
+		}
+		state[version] = isApplied
+	}
+	rows.Close()
+
+	var adopt []Migration
+	for _, migration := range m.migrations {
+		if state[migration.Version] {
+			adopt = append(adopt, migration)
+		}
+	}
+	if err := m.record(ctx, adopt); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, migration := range adopt {
+		result[migration.Version] = appliedRow{name: migration.Name, checksum: migration.Checksum, appliedAt: now}
+	}
+	return result, nil
+}
+
+// record marks migrations as applied without running them
+func (m *Migrator) record(ctx context.Context, list []Migration) error {
+	for _, migration := range list {
+		if _, err := m.db.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, migration.Checksum); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration, err)
+		}
+	}
+	return nil
+}
+
+// Status lists every known migration, then fails if the database has versions this build lacks
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status(m.migrations, applied)
+}
+
+func status(list []Migration, applied map[int]appliedRow) ([]Status, error) {
+	result := make([]Status, 0, len(list))
+	known := map[int]bool{}
+	for _, migration := range list {
+		known[migration.Version] = true
+		s := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = row.appliedAt
+			s.Modified = row.checksum != migration.Checksum
+		}
+		result = append(result, s)
+	}
+	for version, row := range applied {
+		if !known[version] {
+			return result, fmt.Errorf("%w: %05d_%s", ErrUnknownVersion, version, row.name)
+		}
+	}
+	return result, nil
+}
+
+// verify fails on the first applied migration whose file was edited
+func verify(statuses []Status) error {
+	for _, s := range statuses {
+		if s.Modified {
+			return fmt.Errorf("%w: %s (add a new migration instead of editing it)", ErrChecksumMismatch, s.Migration)
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in version order, each in its own transaction,
+// and returns the ones it ran. Nothing runs if an applied migration was edited.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(statuses); err != nil {
+		return nil, err
+	}
+	if err := m.checkVersioned(ctx, statuses); err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, s := range statuses {
+		if s.Applied {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, s.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				s.Version, s.Name, s.Checksum)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %s failed: %w", s.Migration, err)
+		}
+		ran = append(ran, s.Migration)
+	}
+	return ran, nil
+}
+
+// checkVersioned stops Up from replaying the first migration over a database that was
+// created by hand from the old schema files, which would fail halfway through on its tables
+func (m *Migrator) checkVersioned(ctx context.Context, statuses []Status) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			return nil
+		}
+	}
+	match := createTable.FindStringSubmatch(statuses[0].Up)
+	if match == nil {
+		return nil
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT 1 FROM "+match[1]+" WHERE 1 = 0")
+	if err != nil {
+		return nil // the table doesn't exist yet
+	}
+	rows.Close()
+	return fmt.Errorf("%w (found table %s): run `mongelmaker migrate baseline VERSION` with the newest migration its schema already has, then migrate again",
+		ErrUnversioned, match[1])
+}
+
+// Down rolls back the newest applied migration; it returns nil when nothing is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(statuses); err != nil {
+		return nil, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		s := statuses[i]
+		if !s.Applied {
+			continue
+		}
+		if s.Down == "" {
+			return nil, fmt.Errorf("migration %s has no -- +goose Down section", s.Migration)
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, s.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, s.Version)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rolling back %s failed: %w", s.Migration, err)
+		}
+		return &s.Migration, nil
+	}
+	return nil, nil
+}
+
+// Baseline marks every migration up to version as applied without running it, for
+// databases created by hand from the old schema files
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var marked []Migration
+	for _, s := range statuses {
+		if s.Version <= version && !s.Applied {
+			marked = append(marked, s.Migration)
+		}
+	}
+	if err := m.record(ctx, marked); err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,256 @@
+package datafeed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+// MarketDataProvider is the single source of bars and prices for the rest of the app.
+// Bars are returned latest-first, matching what the Alpaca implementation has always done.
+type MarketDataProvider interface {
+	Name() string
+	GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error)
+	GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error)
+	GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error)
+	GetTradableAssets(ctx context.Context) ([]string, error)
+}
+
+// BatchBarProvider is implemented by providers that can fetch many symbols in one call
+type BatchBarProvider interface {
+	GetBarsBatch(ctx context.Context, symbols []string, timeframe string, limit int, startDate string) (map[string][]Bar, error)
+}
+
+// BatchError lists the symbols a batch fetch could not load; the others are still returned
+type BatchError struct {
+	Failed map[string]error
+}
+
+func (e *BatchError) Error() string {
+	symbols := make([]string, 0, len(e.Failed))
+	for symbol := range e.Failed {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	if len(symbols) > 5 {
+		symbols = append(symbols[:5], "...")
+	}
+	return fmt.Sprintf("failed to fetch bars for %d symbols: %s", len(e.Failed), strings.Join(symbols, ", "))
+}
+
+type LastQuote struct {
+	Price     float64 `json:"ap"`
+	AskSize   float64 `json:"as"`
+	BidPrice  float64 `json:"bp"`
+	BidSize   float64 `json:"bs"`
+	Timestamp string  `json:"t"`
+}
+
+type LastTrade struct {
+	Price     float64 `json:"p"`
+	Size      float64 `json:"s"`
+	Timestamp string  `json:"t"`
+}
+
+const (
+	ProviderAlpaca   = "alpaca"
+	ProviderFile     = "file"
+	ProviderDatabase = "database"
+)
+
+var (
+	providerMu     sync.RWMutex
+	activeProvider MarketDataProvider
+)
+
+// SetProvider swaps the provider used by GetBars, GetLatestQuote and friends
+func SetProvider(p MarketDataProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	activeProvider = p
+}
+
+// GetProvider returns the active provider, falling back to Alpaca if none was configured
+func GetProvider() MarketDataProvider {
+	providerMu.RLock()
+	p := activeProvider
+	providerMu.RUnlock()
+	if p != nil {
+		return p
+	}
+
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	if activeProvider == nil {
+		activeProvider = NewAlpacaProvider(AlpacaProviderConfig{})
+	}
+	return activeProvider
+}
+
+// NewProviderFromConfig builds the provider named by data_provider.source; bars backs the
+// database provider
+func NewProviderFromConfig(cfg config.DataProviderConfig, bars repository.BarRepository) (MarketDataProvider, error) {
+	switch cfg.Source {
+	case "", ProviderAlpaca:
+		return NewAlpacaProvider(AlpacaProviderConfig{
+			DataURL:           cfg.Alpaca.DataURL,
+			TradingURL:        cfg.Alpaca.TradingURL,
+			Timeout:           time.Duration(cfg.Alpaca.TimeoutSeconds) * time.Second,
+			RequestsPerMinute: cfg.Alpaca.RequestsPerMinute,
+		}), nil
+	case ProviderFile:
+		return NewFileProvider(cfg.File.Directory, cfg.File.Files)
+	case ProviderDatabase:
+		if bars == nil {
+			return nil, fmt.Errorf("the database provider needs a database")
+		}
+		return NewDBProvider(bars), nil
+	default:
+		return nil, fmt.Errorf("unknown data provider %q (expected alpaca, file or database)", cfg.Source)
+	}
+}
+
+// newConfiguredProvider is NewProviderFromConfig plus the historical_bars cache when enabled.
+// The database provider already reads historical_bars, so it is never wrapped.
+func newConfiguredProvider(cfg config.DataProviderConfig, bars repository.BarRepository) (MarketDataProvider, error) {
+	p, err := NewProviderFromConfig(cfg, bars)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Cache && bars != nil && p.Name() != ProviderDatabase {
+		return NewCachedProvider(p, bars), nil
+	}
+	return p, nil
+}
+
+// InitProvider builds the configured provider, storing bars in bars (which may be nil
+// when there is no database), and makes it the active one
+func InitProvider(cfg *config.Config, bars repository.BarRepository) error {
+	if cfg == nil {
+		SetProvider(NewAlpacaProvider(AlpacaProviderConfig{}))
+		return nil
+	}
+	p, err := newConfiguredProvider(cfg.DataProvider, bars)
+	if err != nil {
+		return err
+	}
+	SetProvider(p)
+	return nil
+}
+
+func GetBars(ctx context.Context, symbol string, timeframe string, limit int, startDate string) ([]Bar, error) {
+	return GetProvider().GetBars(ctx, symbol, timeframe, limit, startDate)
+}
+
+// GetBarsBatch returns latest-first bars for every symbol it could load. Providers without
+// a batch endpoint are called once per symbol. A *BatchError reports the symbols that failed.
+func GetBarsBatch(ctx context.Context, symbols []string, timeframe string, limit int, startDate string) (map[string][]Bar, error) {
+	return getBarsBatch(ctx, GetProvider(), symbols, timeframe, limit, startDate)
+}
+
+func getBarsBatch(ctx context.Context, provider MarketDataProvider, symbols []string, timeframe string, limit int, startDate string) (map[string][]Bar, error) {
+	if batch, ok := provider.(BatchBarProvider); ok {
+		return batch.GetBarsBatch(ctx, symbols, timeframe, limit, startDate)
+	}
+
+	result := make(map[string][]Bar, len(symbols))
+	failed := map[string]error{}
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		bars, err := provider.GetBars(ctx, symbol, timeframe, limit, startDate)
+		if err != nil {
+			failed[symbol] = err
+			continue
+		}
+		result[symbol] = bars
+	}
+	if len(failed) > 0 {
+		return result, &BatchError{Failed: failed}
+	}
+	return result, nil
+}
+
+// PrefetchedBars holds the result of one GetBarsBatch call so a worker pool can pick
+// bars up per symbol. Symbols the batch missed fall back to a single GetBars call.
+type PrefetchedBars struct {
+	provider  MarketDataProvider
+	bars      map[string][]Bar
+	timeframe string
+	limit     int
+	startDate string
+}
+
+// PrefetchBars batch-loads symbols; only a cancelled ctx is returned as an error,
+// partial failures are logged and retried per symbol by Get
+func PrefetchBars(ctx context.Context, symbols []string, timeframe string, limit int, startDate string) (*PrefetchedBars, error) {
+	return PrefetchBarsFrom(ctx, GetProvider(), symbols, timeframe, limit, startDate)
+}
+
+// PrefetchBarsFrom is PrefetchBars against a given provider instead of the active one
+func PrefetchBarsFrom(ctx context.Context, provider MarketDataProvider, symbols []string, timeframe string, limit int, startDate string) (*PrefetchedBars, error) {
+	bars, err := getBarsBatch(ctx, provider, symbols, timeframe, limit, startDate)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.Component("datafeed").Warn("batch bar fetch incomplete, falling back to per-symbol requests", logger.Err(err))
+	}
+	if bars == nil {
+		bars = map[string][]Bar{}
+	}
+	return &PrefetchedBars{provider: provider, bars: bars, timeframe: timeframe, limit: limit, startDate: startDate}, nil
+}
+
+func (p *PrefetchedBars) Get(ctx context.Context, symbol string) ([]Bar, error) {
+	if bars, ok := p.bars[symbol]; ok {
+		return bars, nil
+	}
+	return p.provider.GetBars(ctx, symbol, p.timeframe, p.limit, p.startDate)
+}
+
+func GetLatestQuote(ctx context.Context, symbol string) (*LastQuote, error) {
+	return GetProvider().GetLatestQuote(ctx, symbol)
+}
+
+func GetLatestTrade(ctx context.Context, symbol string) (*LastTrade, error) {
+	return GetProvider().GetLatestTrade(ctx, symbol)
+}
+
+func GetTradableAssets(ctx context.Context) ([]string, error) {
+	return GetProvider().GetTradableAssets(ctx)
+}
+
+// latestFirst reverses chronological bars in place and trims to the newest limit bars
+func latestFirst(bars []Bar, limit int) []Bar {
+	if limit > 0 && len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
+	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+		bars[i], bars[j] = bars[j], bars[i]
+	}
+	return bars
+}
+
+// parseStartDate accepts the same start values GetAlpacaBars always took (RFC3339 or a plain date)
+func parseStartDate(startDate string) (time.Time, error) {
+	if startDate == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, startDate); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start date %q: %w", startDate, err)
+	}
+	return t, nil
+}
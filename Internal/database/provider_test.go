@@ -0,0 +1,253 @@
+package datafeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+func TestFileProviderReadsExportedJSON(t *testing.T) {
+	p, err := NewFileProvider("", map[string]string{"tsla": "../../exported_data/tsla_test.json"})
+	if err != nil {
+		t.Fatalf("NewFileProvider() error = %v", err)
+	}
+
+	bars, err := p.GetBars(context.Background(), "TSLA", "1Day", 10, "")
+	if err != nil {
+		t.Fatalf("GetBars() error = %v", err)
+	}
+	if len(bars) != 10 {
+		t.Fatalf("expected 10 bars, got %d", len(bars))
+	}
+	// latest-first, same as the Alpaca provider
+	if bars[0].Timestamp <= bars[len(bars)-1].Timestamp {
+		t.Errorf("bars not latest-first: %s .. %s", bars[0].Timestamp, bars[len(bars)-1].Timestamp)
+	}
+
+	all, err := LoadBarsFromFile("../../exported_data/tsla_test.json")
+	if err != nil {
+		t.Fatalf("LoadBarsFromFile() error = %v", err)
+	}
+	if all[0].Timestamp != "2025-08-05T00:00:00Z" || all[0].Close != 308.72 {
+		t.Errorf("unexpected first bar %+v", all[0])
+	}
+
+	quote, err := p.GetLatestQuote(context.Background(), "tsla")
+	if err != nil {
+		t.Fatalf("GetLatestQuote() error = %v", err)
+	}
+	if quote.Price != all[len(all)-1].Close {
+		t.Errorf("quote price = %v, want latest close %v", quote.Price, all[len(all)-1].Close)
+	}
+}
+
+func TestFileProviderCSVAndStartDate(t *testing.T) {
+	dir := t.TempDir()
+	csv := "Timestamp,Open,High,Low,Close,Volume\n" +
+		"2024-01-02 00:00:00,10,11,9,10.5,100\n" +
+		"2024-01-03 00:00:00,10.5,12,10,11.5,200\n" +
+		"2024-01-04 00:00:00,11.5,13,11,12.5,300\n" +
+		"2024-01-05 00:00:00,12.5,14,12,13.5,400\n"
+	if err := os.WriteFile(filepath.Join(dir, "AAPL_1Day.csv"), []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "msft.json"), []byte(`{"bars":[{"t":"2024-01-02T15:00:00Z","o":1,"h":2,"l":0.5,"c":1.5,"v":20},{"t":"2024-01-02T14:00:00Z","o":1,"h":2,"l":0.5,"c":1.5,"v":10}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewFileProvider(dir, nil)
+	if err != nil {
+		t.Fatalf("NewFileProvider() error = %v", err)
+	}
+
+	bars, err := p.GetBars(context.Background(), "AAPL", "1Day", 2, "2024-01-03")
+	if err != nil {
+		t.Fatalf("GetBars() error = %v", err)
+	}
+	if len(bars) != 2 || bars[0].Close != 12.5 || bars[1].Close != 11.5 {
+		t.Errorf("expected the two bars after the start date latest-first, got %+v", bars)
+	}
+
+	msft, err := p.GetBars(context.Background(), "MSFT", "1Hour", 100, "")
+	if err != nil || len(msft) != 2 || msft[0].Volume != 20 {
+		t.Errorf("expected fallback to the hourly msft.json, got %+v, %v", msft, err)
+	}
+	if _, err := p.GetBars(context.Background(), "MSFT", "1Day", 100, ""); err == nil {
+		t.Error("an hourly file should not answer a 1Day request")
+	}
+	if _, err := p.GetBars(context.Background(), "AAPL", "1Min", 100, ""); err == nil {
+		t.Error("AAPL_1Day.csv should not answer a 1Min request")
+	}
+
+	if _, err := p.GetBars(context.Background(), "NVDA", "1Day", 10, ""); err == nil {
+		t.Error("expected error for symbol without a file")
+	}
+
+	symbols, err := p.GetTradableAssets(context.Background())
+	if err != nil {
+		t.Fatalf("GetTradableAssets() error = %v", err)
+	}
+	if len(symbols) != 2 || symbols[0] != "AAPL" || symbols[1] != "MSFT" {
+		t.Errorf("unexpected symbols %v", symbols)
+	}
+}
+
+func TestAlpacaProviderUsesConfiguredURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("APCA-API-KEY-ID") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/stocks/AAPL/bars":
+			fmt.Fprint(w, `{"bars":[{"t":"2024-01-02T00:00:00Z","c":1},{"t":"2024-01-03T00:00:00Z","c":2}]}`)
+		case "/v2/stocks/AAPL/quotes/latest":
+			fmt.Fprint(w, `{"symbol":"AAPL","quote":{"ap":101.5,"bp":101.4,"t":"2024-01-03T15:00:00Z"}}`)
+		case "/v2/stocks/AAPL/trades/latest":
+			fmt.Fprint(w, `{"symbol":"AAPL","trade":{"p":101.45,"s":100,"t":"2024-01-03T15:00:00Z"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewAlpacaProvider(AlpacaProviderConfig{DataURL: server.URL, APIKey: "key", APISecret: "secret"})
+	ctx := context.Background()
+
+	bars, err := p.GetBars(ctx, "AAPL", "1Day", 2, "")
+	if err != nil {
+		t.Fatalf("GetBars() error = %v", err)
+	}
+	if len(bars) != 2 || bars[0].Close != 2 {
+		t.Errorf("expected latest-first bars, got %+v", bars)
+	}
+
+	quote, err := p.GetLatestQuote(ctx, "AAPL")
+	if err != nil || quote.Price != 101.5 || quote.BidPrice != 101.4 {
+		t.Errorf("GetLatestQuote() = %+v, %v", quote, err)
+	}
+
+	trade, err := p.GetLatestTrade(ctx, "AAPL")
+	if err != nil || trade.Price != 101.45 || trade.Size != 100 {
+		t.Errorf("GetLatestTrade() = %+v, %v", trade, err)
+	}
+}
+
+func TestSetProviderRoutesPackageHelpers(t *testing.T) {
+	previous := GetProvider()
+	defer SetProvider(previous)
+
+	p, err := NewFileProvider("", map[string]string{"TSLA": "../../exported_data/tsla_test.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProvider(p)
+
+	bars, err := GetBars(context.Background(), "TSLA", "1Day", 5, "")
+	if err != nil || len(bars) != 5 {
+		t.Errorf("GetBars() through active provider = %d bars, %v", len(bars), err)
+	}
+}
+
+func TestAlpacaBarsFollowPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/stocks/AAPL/bars":
+			if r.URL.Query().Get("page_token") == "" {
+				fmt.Fprint(w, `{"bars":[{"t":"2024-01-02T00:00:00Z","c":1}],"next_page_token":"p2"}`)
+				return
+			}
+			fmt.Fprint(w, `{"bars":[{"t":"2024-01-03T00:00:00Z","c":2}],"next_page_token":null}`)
+		case "/v2/stocks/LOCKED/bars":
+			w.WriteHeader(http.StatusForbidden)
+		case "/v2/stocks/bars":
+			switch {
+			case strings.Contains(r.URL.Query().Get("symbols"), "BAD"):
+				w.WriteHeader(http.StatusBadRequest)
+			case r.URL.Query().Get("page_token") == "":
+				fmt.Fprint(w, `{"bars":{"AAPL":[{"t":"2024-01-02T00:00:00Z","c":1},{"t":"2024-01-03T00:00:00Z","c":2}],
+					"MSFT":[{"t":"2024-01-02T00:00:00Z","c":10}]},"next_page_token":"p2"}`)
+			default:
+				fmt.Fprint(w, `{"bars":{"MSFT":[{"t":"2024-01-03T00:00:00Z","c":11}]},"next_page_token":""}`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewAlpacaProvider(AlpacaProviderConfig{DataURL: server.URL, APIKey: "key", APISecret: "secret"})
+	p.retry.MaxRetries = 1
+	ctx := context.Background()
+
+	bars, err := p.GetBars(ctx, "AAPL", "1Day", 5, "")
+	if err != nil || len(bars) != 2 || bars[0].Close != 2 {
+		t.Fatalf("GetBars() should join both pages latest-first, got %+v, %v", bars, err)
+	}
+
+	if _, err := p.GetBars(ctx, "LOCKED", "1Day", 5, ""); !errors.Is(err, utils.ErrForbidden) {
+		t.Errorf("a 403 should surface as ErrForbidden, got %v", err)
+	}
+
+	batch, err := p.GetBarsBatch(ctx, []string{"AAPL", "MSFT", "TSLA"}, "1Day", 5, "")
+	if err != nil {
+		t.Fatalf("GetBarsBatch() error = %v", err)
+	}
+	if len(batch["MSFT"]) != 2 || batch["MSFT"][0].Close != 11 || batch["AAPL"][0].Close != 2 {
+		t.Errorf("pages not merged latest-first: %+v", batch)
+	}
+	if bars, ok := batch["TSLA"]; !ok || len(bars) != 0 {
+		t.Errorf("symbol without bars should map to an empty slice, got %v, %v", bars, ok)
+	}
+
+	// one chunk per 100 symbols, so BAD in the second chunk only fails that chunk
+	symbols := []string{"AAPL"}
+	for i := 1; i < alpacaBatchSize; i++ {
+		symbols = append(symbols, fmt.Sprintf("S%d", i))
+	}
+	symbols = append(symbols, "BAD")
+	batch, err = p.GetBarsBatch(ctx, symbols, "1Day", 1, "")
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failed) != 1 || batchErr.Failed["BAD"] == nil {
+		t.Fatalf("expected BAD to fail alone, got %v", err)
+	}
+	if len(batch["AAPL"]) != 1 || batch["AAPL"][0].Close != 1 {
+		t.Errorf("per-symbol limit not applied to the successful chunk: %+v", batch["AAPL"])
+	}
+}
+
+func TestGetBarsBatchFallsBackPerSymbol(t *testing.T) {
+	previous := GetProvider()
+	defer SetProvider(previous)
+
+	p, err := NewFileProvider(t.TempDir(), map[string]string{"TSLA": "../../exported_data/tsla_test.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProvider(p)
+
+	batch, err := GetBarsBatch(context.Background(), []string{"TSLA", "NOPE"}, "1Day", 3, "")
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed["NOPE"] == nil {
+		t.Fatalf("expected NOPE to be reported, got %v", err)
+	}
+	if len(batch["TSLA"]) != 3 {
+		t.Errorf("TSLA should still load, got %d bars", len(batch["TSLA"]))
+	}
+
+	prefetched, err := PrefetchBars(context.Background(), []string{"TSLA"}, "1Day", 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bars, err := prefetched.Get(context.Background(), "TSLA"); err != nil || len(bars) != 3 {
+		t.Errorf("Get() = %d bars, %v", len(bars), err)
+	}
+}
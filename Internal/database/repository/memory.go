@@ -0,0 +1,528 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// Memory holds an in-memory version of every repository, for tests and dry runs.
+// The fakes follow the same ordering and upsert rules as the SQL versions.
+type Memory struct {
+	Bars       *MemoryBars
+	Indicators *MemoryIndicators
+	Watchlist  *MemoryWatchlist
+	News       *MemoryNews
+	Whales     *MemoryWhales
+	Levels     *MemoryLevels
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		Bars:       NewMemoryBars(),
+		Indicators: NewMemoryIndicators(),
+		Watchlist:  NewMemoryWatchlist(),
+		News:       NewMemoryNews(),
+		Whales:     NewMemoryWhales(),
+		Levels:     NewMemoryLevels(),
+	}
+}
+
+func (m *Memory) Repositories() Repositories {
+	return Repositories{
+		Bars:       m.Bars,
+		Indicators: m.Indicators,
+		Watchlist:  m.Watchlist,
+		News:       m.News,
+		Whales:     m.Whales,
+		Levels:     m.Levels,
+	}
+}
+
+type barKey struct {
+	symbol    string
+	timeframe string
+}
+
+type MemoryBars struct {
+	mu   sync.RWMutex
+	bars map[barKey]map[time.Time]types.Bar
+}
+
+func NewMemoryBars() *MemoryBars {
+	return &MemoryBars{bars: map[barKey]map[time.Time]types.Bar{}}
+}
+
+func (r *MemoryBars) StoreBars(ctx context.Context, symbol, timeframe string, bars []types.Bar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := barKey{symbol, timeframe}
+	if r.bars[key] == nil {
+		r.bars[key] = map[time.Time]types.Bar{}
+	}
+	for _, bar := range bars {
+		timestamp, err := time.Parse(time.RFC3339, bar.Timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to parse timestamp %s: %w", bar.Timestamp, err)
+		}
+		bar.Timestamp = timestamp.UTC().Format(time.RFC3339)
+		r.bars[key][timestamp.UTC()] = bar
+	}
+	return nil
+}
+
+// All returns every stored bar for symbol and timeframe, oldest first
+func (r *MemoryBars) All(symbol, timeframe string) []types.Bar {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored := r.bars[barKey{symbol, timeframe}]
+	times := make([]time.Time, 0, len(stored))
+	for t := range stored {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	bars := make([]types.Bar, len(times))
+	for i, t := range times {
+		bars[i] = stored[t]
+	}
+	return bars
+}
+
+// Len counts the stored bars across every symbol and timeframe
+func (r *MemoryBars) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := 0
+	for _, stored := range r.bars {
+		n += len(stored)
+	}
+	return n
+}
+
+func (r *MemoryBars) RecentBars(ctx context.Context, symbol, timeframe string, limit int) ([]types.Bar, error) {
+	all := r.All(symbol, timeframe)
+	bars := []types.Bar{}
+	for i := len(all) - 1; i >= 0 && len(bars) < limit; i-- {
+		bars = append(bars, all[i])
+	}
+	return bars, nil
+}
+
+func (r *MemoryBars) BarsBetween(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]types.Bar, error) {
+	bars := []types.Bar{}
+	for _, bar := range r.All(symbol, timeframe) {
+		timestamp, _ := time.Parse(time.RFC3339, bar.Timestamp)
+		if timestamp.Before(start) || timestamp.After(end) {
+			continue
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+func (r *MemoryBars) Symbols(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[string]bool{}
+	symbols := []string{}
+	for key, stored := range r.bars {
+		if len(stored) > 0 && !seen[key.symbol] {
+			seen[key.symbol] = true
+			symbols = append(symbols, key.symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+type MemoryIndicators struct {
+	mu        sync.RWMutex
+	rsi       map[string]map[time.Time]float64
+	atr       map[string]map[time.Time]float64
+	bollinger map[string][]BollingerPoint
+}
+
+func NewMemoryIndicators() *MemoryIndicators {
+	return &MemoryIndicators{
+		rsi:       map[string]map[time.Time]float64{},
+		atr:       map[string]map[time.Time]float64{},
+		bollinger: map[string][]BollingerPoint{},
+	}
+}
+
+func saveIndicator(values map[string]map[time.Time]float64, symbol string, timestamp time.Time, value float64) {
+	if values[symbol] == nil {
+		values[symbol] = map[time.Time]float64{}
+	}
+	values[symbol][timestamp.UTC()] = value
+}
+
+// indicatorPoints returns a symbol's values oldest first
+func indicatorPoints(values map[time.Time]float64) []IndicatorPoint {
+	points := make([]IndicatorPoint, 0, len(values))
+	for t, v := range values {
+		points = append(points, IndicatorPoint{Timestamp: t, Value: v})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points
+}
+
+func latestPoints(values map[time.Time]float64, limit int) []IndicatorPoint {
+	all := indicatorPoints(values)
+	points := []IndicatorPoint{}
+	for i := len(all) - 1; i >= 0 && len(points) < limit; i-- {
+		points = append(points, all[i])
+	}
+	return points
+}
+
+func pointsBetween(values map[time.Time]float64, start, end time.Time) []IndicatorPoint {
+	points := []IndicatorPoint{}
+	for _, p := range indicatorPoints(values) {
+		if !p.Timestamp.Before(start) && !p.Timestamp.After(end) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+func (r *MemoryIndicators) SaveRSI(ctx context.Context, symbol string, timestamp time.Time, value float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saveIndicator(r.rsi, symbol, timestamp, value)
+	return nil
+}
+
+func (r *MemoryIndicators) SaveATR(ctx context.Context, symbol string, timestamp time.Time, value float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saveIndicator(r.atr, symbol, timestamp, value)
+	return nil
+}
+
+func (r *MemoryIndicators) SaveBollinger(ctx context.Context, symbol string, point BollingerPoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	point.Date = point.Date.UTC().Truncate(24 * time.Hour)
+	stored := r.bollinger[symbol]
+	for i, existing := range stored {
+		if existing.Date.Equal(point.Date) && existing.Period == point.Period {
+			stored[i] = point
+			return nil
+		}
+	}
+	r.bollinger[symbol] = append(stored, point)
+	return nil
+}
+
+// Bollinger returns the bands saved for symbol in the order they were first saved
+func (r *MemoryIndicators) Bollinger(symbol string) []BollingerPoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]BollingerPoint(nil), r.bollinger[symbol]...)
+}
+
+func (r *MemoryIndicators) LatestRSI(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return latestPoints(r.rsi[symbol], limit), nil
+}
+
+func (r *MemoryIndicators) LatestATR(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return latestPoints(r.atr[symbol], limit), nil
+}
+
+func (r *MemoryIndicators) RSIBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return pointsBetween(r.rsi[symbol], start, end), nil
+}
+
+func (r *MemoryIndicators) ATRBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return pointsBetween(r.atr[symbol], start, end), nil
+}
+
+type MemoryWatchlist struct {
+	mu     sync.RWMutex
+	items  map[string]WatchlistItem
+	nextID int32
+	scans  map[string]ScanLog
+	now    func() time.Time
+}
+
+func NewMemoryWatchlist() *MemoryWatchlist {
+	return &MemoryWatchlist{items: map[string]WatchlistItem{}, scans: map[string]ScanLog{}, now: time.Now}
+}
+
+// Add puts symbol on the active watchlist, replacing any existing entry, and returns its id
+func (r *MemoryWatchlist) Add(symbol, assetType string, score float64, reason string) int32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	now := r.now()
+	r.items[symbol] = WatchlistItem{
+		ID:          r.nextID,
+		Symbol:      symbol,
+		AssetType:   assetType,
+		Score:       score,
+		Reason:      reason,
+		AddedDate:   now,
+		LastUpdated: now,
+	}
+	return r.nextID
+}
+
+func (r *MemoryWatchlist) Active(ctx context.Context) ([]WatchlistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	items := make([]WatchlistItem, 0, len(r.items))
+	for _, item := range r.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Symbol < items[j].Symbol
+	})
+	return items, nil
+}
+
+// UpdateScore ignores symbols that aren't on the watchlist, like the SQL UPDATE
+func (r *MemoryWatchlist) UpdateScore(ctx context.Context, symbol string, score float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item, ok := r.items[symbol]
+	if !ok {
+		return nil
+	}
+	item.Score = score
+	item.LastUpdated = r.now()
+	r.items[symbol] = item
+	return nil
+}
+
+func (r *MemoryWatchlist) ScanLog(ctx context.Context, profile string) (ScanLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	log, ok := r.scans[profile]
+	if !ok {
+		return ScanLog{}, fmt.Errorf("scan log for %s: %w", profile, ErrNotFound)
+	}
+	return log, nil
+}
+
+func (r *MemoryWatchlist) RecordScan(ctx context.Context, log ScanLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scans[log.Profile] = log
+	return nil
+}
+
+type MemoryNews struct {
+	mu       sync.RWMutex
+	articles []newsscraping.NewsArticle
+}
+
+func NewMemoryNews() *MemoryNews {
+	return &MemoryNews{}
+}
+
+// SaveArticle ignores an article whose URL is already stored
+func (r *MemoryNews) SaveArticle(ctx context.Context, article newsscraping.NewsArticle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.articles {
+		if existing.URL == article.URL {
+			return nil
+		}
+	}
+	article.ID = int64(len(r.articles) + 1)
+	if article.CreatedAt.IsZero() {
+		article.CreatedAt = time.Now()
+	}
+	r.articles = append(r.articles, article)
+	return nil
+}
+
+// newest returns the articles matching keep, newest first
+func (r *MemoryNews) newest(keep func(newsscraping.NewsArticle) bool) []newsscraping.NewsArticle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	articles := []newsscraping.NewsArticle{}
+	for _, article := range r.articles {
+		if keep(article) {
+			articles = append(articles, article)
+		}
+	}
+	sort.SliceStable(articles, func(i, j int) bool { return articles[i].PublishedAt.After(articles[j].PublishedAt) })
+	return articles
+}
+
+func (r *MemoryNews) GetLatestNews(ctx context.Context, symbol string, limit int32) ([]newsscraping.NewsArticle, error) {
+	articles := r.newest(func(a newsscraping.NewsArticle) bool { return a.Symbol == symbol })
+	if len(articles) > int(limit) {
+		articles = articles[:limit]
+	}
+	return articles, nil
+}
+
+func (r *MemoryNews) GetNewsForScreener(ctx context.Context, symbols []string) ([]newsscraping.NewsArticle, error) {
+	wanted := map[string]bool{}
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+	return r.newest(func(a newsscraping.NewsArticle) bool { return wanted[a.Symbol] }), nil
+}
+
+type MemoryWhales struct {
+	mu     sync.RWMutex
+	events []WhaleEvent
+	alerts []WhaleAlert
+	now    func() time.Time
+}
+
+func NewMemoryWhales() *MemoryWhales {
+	return &MemoryWhales{now: time.Now}
+}
+
+func (r *MemoryWhales) SaveWhaleEvent(ctx context.Context, event WhaleEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.events {
+		if stored.Symbol != event.Symbol || !stored.Timestamp.Equal(event.Timestamp) {
+			continue
+		}
+		if event.Change1 == nil {
+			event.Change1 = stored.Change1
+		}
+		if event.Change5 == nil {
+			event.Change5 = stored.Change5
+		}
+		if event.Change20 == nil {
+			event.Change20 = stored.Change20
+		}
+		if event.Held == nil {
+			event.Held = stored.Held
+		}
+		r.events[i] = event
+		return nil
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *MemoryWhales) WhaleHistory(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := []WhaleEvent{}
+	for _, event := range r.events {
+		if event.Symbol == symbol {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.After(events[j].Timestamp) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryWhales) SaveWhaleAlert(ctx context.Context, alert WhaleAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.alerts {
+		if stored.Symbol == alert.Symbol && stored.Type == alert.Type && stored.Timestamp.Equal(alert.Timestamp) {
+			return nil
+		}
+	}
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *MemoryWhales) WhaleAlerts(ctx context.Context, symbol string, limit int) ([]WhaleAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	alerts := []WhaleAlert{}
+	for _, alert := range r.alerts {
+		if alert.Symbol == symbol {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].Timestamp.After(alerts[j].Timestamp) })
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+func (r *MemoryWhales) RecentWhales(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cutoff := r.now().Add(-7 * 24 * time.Hour)
+	events := []WhaleEvent{}
+	for _, event := range r.events {
+		if event.Symbol == symbol && event.Timestamp.After(cutoff) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.After(events[j].Timestamp) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryWhales) HighConvictionWhales(ctx context.Context, symbol string) ([]WhaleEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := []WhaleEvent{}
+	for _, event := range r.events {
+		if event.Symbol == symbol && event.Conviction == "HIGH" {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ZScore > events[j].ZScore })
+	if len(events) > 10 {
+		events = events[:10]
+	}
+	return events, nil
+}
+
+type MemoryLevels struct {
+	mu     sync.RWMutex
+	levels map[string][]SupportLevel
+}
+
+func NewMemoryLevels() *MemoryLevels {
+	return &MemoryLevels{levels: map[string][]SupportLevel{}}
+}
+
+func (r *MemoryLevels) ReplaceLevels(ctx context.Context, symbol string, levels []SupportLevel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := make([]SupportLevel, len(levels))
+	for i, level := range levels {
+		level.Symbol = symbol
+		stored[i] = level
+	}
+	r.levels[symbol] = stored
+	return nil
+}
+
+func (r *MemoryLevels) Levels(ctx context.Context, symbol string) ([]SupportLevel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	levels := append([]SupportLevel{}, r.levels[symbol]...)
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Price < levels[j].Price })
+	return levels, nil
+}
@@ -0,0 +1,176 @@
+// Package repository is how the rest of the app reads and writes stored data. Each
+// repository speaks in domain types (bars, indicator points, watchlist items) rather
+// than sqlc rows, and is passed in by the caller instead of living in a package global,
+// so the scanner and screener can run against the database or the in-memory fakes in
+// memory.go.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// ErrNotFound is returned when a single record that was asked for doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// BarRepository stores bars in historical_bars
+type BarRepository interface {
+	// StoreBars upserts bars along with their price change columns
+	StoreBars(ctx context.Context, symbol, timeframe string, bars []types.Bar) error
+	// RecentBars returns up to limit of the newest stored bars, latest first
+	RecentBars(ctx context.Context, symbol, timeframe string, limit int) ([]types.Bar, error)
+	// BarsBetween returns the stored bars from start to end inclusive, oldest first
+	BarsBetween(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]types.Bar, error)
+	// Symbols lists every symbol with stored bars
+	Symbols(ctx context.Context) ([]string, error)
+}
+
+// IndicatorPoint is one stored indicator value
+type IndicatorPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// BollingerPoint is one day's bands as stored in candle_daily_bollinger
+type BollingerPoint struct {
+	Date   time.Time
+	Period int
+	Middle float64
+	StdDev float64
+	Upper  float64
+	Lower  float64
+	Close  float64
+}
+
+// IndicatorRepository stores calculated RSI, ATR and Bollinger values. Saving a
+// value for a timestamp that already has one replaces it.
+type IndicatorRepository interface {
+	SaveRSI(ctx context.Context, symbol string, timestamp time.Time, value float64) error
+	SaveATR(ctx context.Context, symbol string, timestamp time.Time, value float64) error
+	SaveBollinger(ctx context.Context, symbol string, point BollingerPoint) error
+	// LatestRSI and LatestATR return up to limit of the newest values, latest first
+	LatestRSI(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error)
+	LatestATR(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error)
+	// RSIBetween and ATRBetween return the values from start to end inclusive, oldest first
+	RSIBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error)
+	ATRBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error)
+}
+
+// WatchlistItem is an active watchlist entry
+type WatchlistItem struct {
+	ID          int32
+	Symbol      string
+	AssetType   string
+	Score       float64
+	Reason      string
+	AddedDate   time.Time
+	LastUpdated time.Time
+}
+
+// ScanLog records when a profile's watchlist scan last ran
+type ScanLog struct {
+	Profile        string
+	LastScan       time.Time
+	NextScanDue    time.Time
+	SymbolsScanned int
+}
+
+// WatchlistRepository reads the active watchlist, rescoring it and the scan log that schedules rescans
+type WatchlistRepository interface {
+	// Active lists the active watchlist, highest score first
+	Active(ctx context.Context) ([]WatchlistItem, error)
+	UpdateScore(ctx context.Context, symbol string, score float64) error
+	// ScanLog returns ErrNotFound for a profile that was never scanned
+	ScanLog(ctx context.Context, profile string) (ScanLog, error)
+	RecordScan(ctx context.Context, log ScanLog) error
+}
+
+// NewsRepository stores scraped articles; *newsscraping.NewsStorage is the database version
+type NewsRepository interface {
+	SaveArticle(ctx context.Context, article newsscraping.NewsArticle) error
+	// GetLatestNews returns up to limit articles for symbol, newest first
+	GetLatestNews(ctx context.Context, symbol string, limit int32) ([]newsscraping.NewsArticle, error)
+	// GetNewsForScreener returns every article for the symbols, newest first
+	GetNewsForScreener(ctx context.Context, symbols []string) ([]newsscraping.NewsArticle, error)
+}
+
+var _ NewsRepository = (*newsscraping.NewsStorage)(nil)
+
+// WhaleEvent is a stored volume anomaly. The follow-through fields are nil until
+// enough bars after the event have been seen.
+type WhaleEvent struct {
+	Symbol      string
+	Timestamp   time.Time
+	Direction   string
+	Volume      int64
+	ZScore      float64
+	ClosePrice  float64
+	PriceChange float64 // percent move of the event bar from the previous close
+	Conviction  string
+	Change1     *float64 // percent move of the close 1, 5 and 20 bars later
+	Change5     *float64
+	Change20    *float64
+	Held        *bool // the move was still in the whale's direction 5 bars later
+}
+
+// WhaleAlert is a stored whale_alerts row, one per HIGH conviction event
+type WhaleAlert struct {
+	Symbol    string
+	Type      string // LARGE_BUY or LARGE_SELL
+	Amount    float64
+	Price     float64
+	Timestamp time.Time
+}
+
+// WhaleRepository stores detected whale events
+type WhaleRepository interface {
+	// SaveWhaleEvent upserts the event by symbol and timestamp; follow-through already
+	// stored is kept when event doesn't have it
+	SaveWhaleEvent(ctx context.Context, event WhaleEvent) error
+	// RecentWhales returns up to limit events from the last 7 days, newest first
+	RecentWhales(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error)
+	// HighConvictionWhales returns the 10 strongest HIGH conviction events
+	HighConvictionWhales(ctx context.Context, symbol string) ([]WhaleEvent, error)
+	// WhaleHistory returns up to limit events of any age, newest first
+	WhaleHistory(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error)
+	// SaveWhaleAlert records alert once per symbol, type and timestamp
+	SaveWhaleAlert(ctx context.Context, alert WhaleAlert) error
+	// WhaleAlerts returns up to limit alerts, newest first
+	WhaleAlerts(ctx context.Context, symbol string, limit int) ([]WhaleAlert, error)
+}
+
+// SupportLevel is a stored support or resistance zone
+type SupportLevel struct {
+	Symbol      string
+	Price       float64
+	Type        string // SUPPORT or RESISTANCE
+	ZoneLow     float64
+	ZoneHigh    float64
+	Touches     int
+	Strength    float64
+	LastTouched time.Time
+	Flipped     bool // the zone has changed role, e.g. broken resistance now acting as support
+	DetectedAt  time.Time
+}
+
+// LevelRepository stores the latest support and resistance zones per symbol in support_levels
+type LevelRepository interface {
+	// ReplaceLevels swaps every stored zone for symbol with levels
+	ReplaceLevels(ctx context.Context, symbol string, levels []SupportLevel) error
+	// Levels returns the stored zones for symbol, lowest price first
+	Levels(ctx context.Context, symbol string) ([]SupportLevel, error)
+}
+
+// Repositories groups one implementation of each repository, e.g. everything backed by one database
+type Repositories struct {
+	Bars       BarRepository
+	Indicators IndicatorRepository
+	Watchlist  WatchlistRepository
+	News       NewsRepository
+	Whales     WhaleRepository
+	Levels     LevelRepository
+}
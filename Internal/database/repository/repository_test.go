@@ -0,0 +1,318 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/storage"
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// The contract suite holds the in-memory fakes to the same behaviour as the database
+// repositories, so tests written against the fakes say something about production.
+
+func TestMemoryContract(t *testing.T) {
+	mem := repository.NewMemory()
+	runContract(t, mem.Repositories(), func(symbol string, score float64) {
+		mem.Watchlist.Add(symbol, "stock", score, "test")
+	})
+}
+
+// openSQLite returns a migrated SQLite store in a temp dir
+func openSQLite(t *testing.T) *storage.Store {
+	t.Helper()
+	ctx := context.Background()
+	store, err := storage.Open(ctx, storage.Config{Backend: storage.BackendSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	migrator, err := store.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSQLiteContract(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLite(t)
+	runContract(t, repository.FromDB(store.Conn), func(symbol string, score float64) {
+		_, err := store.Queries.AddToWatchlist(ctx, database.AddToWatchlistParams{
+			Symbol:    symbol,
+			AssetType: "stock",
+			Score:     float32(score),
+			Reason:    sql.NullString{String: "test", Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSQLiteReplaceLevelsIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLite(t)
+	// make the second insert of the replacement fail
+	_, err := store.DB.ExecContext(ctx, `CREATE TRIGGER fail_level BEFORE INSERT ON support_levels
+		WHEN NEW.level_price = 66.6 BEGIN SELECT RAISE(ABORT, 'boom'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := repository.FromDB(store.Conn).Levels
+	now := time.Now().UTC().Truncate(time.Second)
+
+	old := []repository.SupportLevel{{Price: 90, Type: "SUPPORT", Touches: 2, DetectedAt: now}}
+	if err := levels.ReplaceLevels(ctx, "AAPL", old); err != nil {
+		t.Fatal(err)
+	}
+	replacement := []repository.SupportLevel{
+		{Price: 110, Type: "RESISTANCE", Touches: 2, DetectedAt: now},
+		{Price: 66.6, Type: "SUPPORT", Touches: 2, DetectedAt: now},
+	}
+	if err := levels.ReplaceLevels(ctx, "AAPL", replacement); err == nil {
+		t.Fatal("expected the failing insert to surface")
+	}
+	got, err := levels.Levels(ctx, "AAPL")
+	if err != nil || len(got) != 1 || got[0].Price != 90 {
+		t.Errorf("Levels() after a failed replace = %+v, %v, want the old zone untouched", got, err)
+	}
+}
+
+func runContract(t *testing.T, repos repository.Repositories, addToWatchlist func(symbol string, score float64)) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("bars", func(t *testing.T) {
+		day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		var bars []types.Bar
+		for i := 0; i < 5; i++ {
+			price := 100 + float64(i)
+			bars = append(bars, types.Bar{
+				Timestamp: day.AddDate(0, 0, i).Format(time.RFC3339),
+				Open:      price, High: price + 1, Low: price - 1, Close: price, Volume: int64(1000 + i),
+			})
+		}
+		if err := repos.Bars.StoreBars(ctx, "AAPL", "1Day", bars); err != nil {
+			t.Fatal(err)
+		}
+		// storing a bar again replaces it
+		bars[4].Close = 110
+		if err := repos.Bars.StoreBars(ctx, "AAPL", "1Day", bars[4:]); err != nil {
+			t.Fatal(err)
+		}
+
+		recent, err := repos.Bars.RecentBars(ctx, "AAPL", "1Day", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 2 || recent[0].Timestamp != bars[4].Timestamp || recent[0].Close != 110 {
+			t.Errorf("RecentBars() = %+v, want the 2 newest bars latest first", recent)
+		}
+
+		between, err := repos.Bars.BarsBetween(ctx, "AAPL", "1Day", day.AddDate(0, 0, 1), day.AddDate(0, 0, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(between) != 3 || between[0].Timestamp != bars[1].Timestamp || between[2].Timestamp != bars[3].Timestamp {
+			t.Errorf("BarsBetween() = %+v, want days 2-4 oldest first", between)
+		}
+
+		symbols, err := repos.Bars.Symbols(ctx)
+		if err != nil || len(symbols) != 1 || symbols[0] != "AAPL" {
+			t.Errorf("Symbols() = %v, %v", symbols, err)
+		}
+	})
+
+	t.Run("indicators", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			ts := now.Add(time.Duration(i-3) * time.Hour)
+			if err := repos.Indicators.SaveRSI(ctx, "AAPL", ts, 40+float64(i)); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Indicators.SaveATR(ctx, "AAPL", ts, 1+float64(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		latest, err := repos.Indicators.LatestRSI(ctx, "AAPL", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(latest) != 2 || latest[0].Value != 42 || latest[1].Value != 41 {
+			t.Errorf("LatestRSI() = %+v, want 42 then 41", latest)
+		}
+		atr, err := repos.Indicators.ATRBetween(ctx, "AAPL", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(atr) != 2 || atr[0].Value != 1 || atr[1].Value != 2 {
+			t.Errorf("ATRBetween() = %+v, want 1 then 2", atr)
+		}
+
+		band := repository.BollingerPoint{Date: now, Period: 20, Middle: 100, StdDev: 2, Upper: 104, Lower: 96, Close: 101}
+		if err := repos.Indicators.SaveBollinger(ctx, "AAPL", band); err != nil {
+			t.Fatal(err)
+		}
+		band.Close = 102
+		if err := repos.Indicators.SaveBollinger(ctx, "AAPL", band); err != nil {
+			t.Fatalf("saving the same day's bands again should replace them: %v", err)
+		}
+	})
+
+	t.Run("watchlist", func(t *testing.T) {
+		if _, err := repos.Watchlist.ScanLog(ctx, "default"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("ScanLog() for an unscanned profile = %v, want ErrNotFound", err)
+		}
+
+		addToWatchlist("MSFT", 5)
+		addToWatchlist("NVDA", 7)
+		if err := repos.Watchlist.UpdateScore(ctx, "MSFT", 9); err != nil {
+			t.Fatal(err)
+		}
+		items, err := repos.Watchlist.Active(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || items[0].Symbol != "MSFT" || items[0].Score != 9 || items[1].Symbol != "NVDA" {
+			t.Errorf("Active() = %+v, want MSFT (9) then NVDA (7)", items)
+		}
+
+		scan := repository.ScanLog{Profile: "default", LastScan: now, NextScanDue: now.Add(24 * time.Hour), SymbolsScanned: 2}
+		if err := repos.Watchlist.RecordScan(ctx, scan); err != nil {
+			t.Fatal(err)
+		}
+		got, err := repos.Watchlist.ScanLog(ctx, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.LastScan.Equal(now) || !got.NextScanDue.Equal(scan.NextScanDue) || got.SymbolsScanned != 2 {
+			t.Errorf("ScanLog() = %+v, want %+v", got, scan)
+		}
+	})
+
+	t.Run("news", func(t *testing.T) {
+		for i, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/a"} {
+			err := repos.News.SaveArticle(ctx, newsscraping.NewsArticle{
+				Symbol:      "AAPL",
+				Headline:    "headline",
+				URL:         url,
+				PublishedAt: now.Add(time.Duration(i) * time.Minute),
+				Sentiment:   newsscraping.Positive,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		latest, err := repos.News.GetLatestNews(ctx, "AAPL", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(latest) != 2 {
+			t.Errorf("GetLatestNews() returned %d articles, want 2 after the duplicate URL", len(latest))
+		}
+		screener, err := repos.News.GetNewsForScreener(ctx, []string{"AAPL", "MSFT"})
+		if err != nil || len(screener) != 2 {
+			t.Errorf("GetNewsForScreener() = %d articles, %v", len(screener), err)
+		}
+	})
+
+	t.Run("whales", func(t *testing.T) {
+		events := []repository.WhaleEvent{
+			{Symbol: "AAPL", Timestamp: now.Add(-2 * time.Hour), Direction: "BUY", Volume: 5_000_000, ZScore: 3.5, ClosePrice: 180.25, PriceChange: 1.5, Conviction: "HIGH"},
+			{Symbol: "AAPL", Timestamp: now.Add(-time.Hour), Direction: "SELL", Volume: 3_000_000, ZScore: 2.1, ClosePrice: 179, PriceChange: -1.25, Conviction: "MEDIUM"},
+			{Symbol: "AAPL", Timestamp: now.AddDate(0, 0, -10), Direction: "BUY", Volume: 4_000_000, ZScore: 3.0, ClosePrice: 170, Conviction: "HIGH"},
+		}
+		for _, event := range events {
+			if err := repos.Whales.SaveWhaleEvent(ctx, event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		recent, err := repos.Whales.RecentWhales(ctx, "AAPL", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 2 || recent[0].Direction != "SELL" || recent[1].ZScore != 3.5 || recent[1].ClosePrice != 180.25 {
+			t.Errorf("RecentWhales() = %+v, want the 2 events from this week newest first", recent)
+		}
+		high, err := repos.Whales.HighConvictionWhales(ctx, "AAPL")
+		if err != nil || len(high) != 2 {
+			t.Errorf("HighConvictionWhales() = %+v, %v", high, err)
+		}
+
+		// re-saving an event fills in follow-through without forgetting what was measured
+		change1, change5, held := 0.5, 2.25, true
+		first := events[0]
+		first.Change1 = &change1
+		if err := repos.Whales.SaveWhaleEvent(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		first.Change1, first.Change5, first.Held = nil, &change5, &held
+		if err := repos.Whales.SaveWhaleEvent(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		history, err := repos.Whales.WhaleHistory(ctx, "AAPL", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 3 {
+			t.Fatalf("WhaleHistory() = %d events, want 3 after re-saving one", len(history))
+		}
+		got := history[1]
+		if got.Change1 == nil || *got.Change1 != 0.5 || got.Change5 == nil || *got.Change5 != 2.25 ||
+			got.Change20 != nil || got.Held == nil || !*got.Held {
+			t.Errorf("follow-through after re-save = %+v", got)
+		}
+
+		alert := repository.WhaleAlert{Symbol: "AAPL", Type: "LARGE_BUY", Amount: 5_000_000, Price: 180.25, Timestamp: first.Timestamp}
+		for i := 0; i < 2; i++ {
+			if err := repos.Whales.SaveWhaleAlert(ctx, alert); err != nil {
+				t.Fatal(err)
+			}
+		}
+		alerts, err := repos.Whales.WhaleAlerts(ctx, "AAPL", 10)
+		if err != nil || len(alerts) != 1 || alerts[0].Type != "LARGE_BUY" || alerts[0].Price != 180.25 {
+			t.Errorf("WhaleAlerts() = %+v, %v, want the one alert", alerts, err)
+		}
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		old := []repository.SupportLevel{{Price: 90, Type: "SUPPORT", ZoneLow: 89, ZoneHigh: 91, Touches: 2, DetectedAt: now.Add(-time.Hour)}}
+		if err := repos.Levels.ReplaceLevels(ctx, "AAPL", old); err != nil {
+			t.Fatal(err)
+		}
+		levels := []repository.SupportLevel{
+			{Price: 110.5, Type: "RESISTANCE", ZoneLow: 110, ZoneHigh: 111, Touches: 3, Strength: 2.25, LastTouched: now.Add(-2 * time.Hour), DetectedAt: now},
+			{Price: 100.25, Type: "SUPPORT", ZoneLow: 100, ZoneHigh: 100.5, Touches: 1, Strength: 0.5, Flipped: true, DetectedAt: now},
+		}
+		if err := repos.Levels.ReplaceLevels(ctx, "AAPL", levels); err != nil {
+			t.Fatal(err)
+		}
+		got, err := repos.Levels.Levels(ctx, "AAPL")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("Levels() = %+v, want the 2 replacement levels", got)
+		}
+		support, resistance := got[0], got[1]
+		if support.Price != 100.25 || !support.Flipped || support.ZoneHigh != 100.5 || !support.LastTouched.IsZero() {
+			t.Errorf("support = %+v", support)
+		}
+		if resistance.Touches != 3 || resistance.Strength != 2.25 || !resistance.LastTouched.Equal(now.Add(-2*time.Hour)) || !resistance.DetectedAt.Equal(now) {
+			t.Errorf("resistance = %+v", resistance)
+		}
+		if other, err := repos.Levels.Levels(ctx, "MSFT"); err != nil || len(other) != 0 {
+			t.Errorf("Levels(MSFT) = %+v, %v", other, err)
+		}
+	})
+}
@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// FromDB backs every repository with one connection (a storage.Store's Conn, Postgres or SQLite)
+func FromDB(db database.DBTX) Repositories {
+	q := database.New(db)
+	return Repositories{
+		Bars:       NewSQLBars(q),
+		Indicators: NewSQLIndicators(q),
+		Watchlist:  NewSQLWatchlist(q),
+		News:       newsscraping.NewNewsStorage(q),
+		Whales:     NewSQLWhales(q),
+		Levels:     NewSQLLevels(db),
+	}
+}
+
+// SQLBars is the BarRepository over historical_bars
+type SQLBars struct {
+	q *database.Queries
+}
+
+func NewSQLBars(q *database.Queries) *SQLBars {
+	return &SQLBars{q: q}
+}
+
+func (r *SQLBars) StoreBars(ctx context.Context, symbol, timeframe string, bars []types.Bar) error {
+	for _, bar := range bars {
+		timestamp, err := time.Parse(time.RFC3339, bar.Timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to parse timestamp %s: %w", bar.Timestamp, err)
+		}
+
+		priceChange := bar.Close - bar.Open
+		priceChangePercent := 0.0
+		if bar.Open != 0 {
+			priceChangePercent = priceChange / bar.Open * 100
+		}
+
+		err = r.q.UpsertHistoricalBar(ctx, database.UpsertHistoricalBarParams{
+			Symbol:             symbol,
+			Timeframe:          timeframe,
+			Timestamp:          timestamp.UTC(),
+			OpenPrice:          fmt.Sprintf("%.4f", bar.Open),
+			HighPrice:          fmt.Sprintf("%.4f", bar.High),
+			LowPrice:           fmt.Sprintf("%.4f", bar.Low),
+			ClosePrice:         fmt.Sprintf("%.4f", bar.Close),
+			Volume:             bar.Volume,
+			PriceChange:        sql.NullString{String: fmt.Sprintf("%.4f", priceChange), Valid: true},
+			PriceChangePercent: sql.NullString{String: fmt.Sprintf("%.4f", priceChangePercent), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert bar %s %s: %w", symbol, bar.Timestamp, err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLBars) RecentBars(ctx context.Context, symbol, timeframe string, limit int) ([]types.Bar, error) {
+	rows, err := r.q.GetRecentHistoricalBars(ctx, database.GetRecentHistoricalBarsParams{
+		Symbol:    symbol,
+		Timeframe: timeframe,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	bars := make([]types.Bar, 0, len(rows))
+	for _, row := range rows {
+		bar, err := rowToBar(row.Timestamp, row.OpenPrice, row.HighPrice, row.LowPrice, row.ClosePrice, row.Volume)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+func (r *SQLBars) BarsBetween(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]types.Bar, error) {
+	rows, err := r.q.GetHistoricalBars(ctx, database.GetHistoricalBarsParams{
+		Symbol:      symbol,
+		Timeframe:   timeframe,
+		Timestamp:   start,
+		Timestamp_2: end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bars := make([]types.Bar, 0, len(rows))
+	for _, row := range rows {
+		bar, err := rowToBar(row.Timestamp, row.OpenPrice, row.HighPrice, row.LowPrice, row.ClosePrice, row.Volume)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+func (r *SQLBars) Symbols(ctx context.Context) ([]string, error) {
+	return r.q.ListHistoricalBarSymbols(ctx)
+}
+
+// historical_bars stores prices as DECIMAL, which sqlc hands back as strings
+func rowToBar(timestamp time.Time, open, high, low, closePrice string, volume int64) (types.Bar, error) {
+	prices := make([]float64, 4)
+	for i, raw := range []string{open, high, low, closePrice} {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return types.Bar{}, fmt.Errorf("invalid price %q at %s: %w", raw, timestamp, err)
+		}
+		prices[i] = value
+	}
+
+	return types.Bar{
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+		Open:      prices[0],
+		High:      prices[1],
+		Low:       prices[2],
+		Close:     prices[3],
+		Volume:    volume,
+	}, nil
+}
+
+// SQLIndicators is the IndicatorRepository over rsi_calculation, atr_calculation and candle_daily_bollinger
+type SQLIndicators struct {
+	q *database.Queries
+}
+
+func NewSQLIndicators(q *database.Queries) *SQLIndicators {
+	return &SQLIndicators{q: q}
+}
+
+func (r *SQLIndicators) SaveRSI(ctx context.Context, symbol string, timestamp time.Time, value float64) error {
+	return r.q.SaveRSI(ctx, database.SaveRSIParams{
+		Symbol:               symbol,
+		CalculationTimestamp: timestamp,
+		RsiValue:             float32(value),
+	})
+}
+
+func (r *SQLIndicators) SaveATR(ctx context.Context, symbol string, timestamp time.Time, value float64) error {
+	return r.q.SaveATR(ctx, database.SaveATRParams{
+		Symbol:               symbol,
+		CalculationTimestamp: timestamp,
+		AtrValue:             float32(value),
+	})
+}
+
+// SaveBollinger upserts the bands for a symbol's day and period; deviation_from_avg is the close minus the middle band
+func (r *SQLIndicators) SaveBollinger(ctx context.Context, symbol string, point BollingerPoint) error {
+	params := database.SaveBollingerParams{
+		Symbol:            symbol,
+		CalculationDate:   point.Date.UTC().Truncate(24 * time.Hour),
+		TimePeriod:        int32(point.Period),
+		AveragePrice:      fmt.Sprintf("%.4f", point.Middle),
+		StandardDeviation: fmt.Sprintf("%.4f", point.StdDev),
+		UpperBand:         fmt.Sprintf("%.4f", point.Upper),
+		LowerBand:         fmt.Sprintf("%.4f", point.Lower),
+		CurrentPrice:      fmt.Sprintf("%.4f", point.Close),
+		DeviationFromAvg:  fmt.Sprintf("%.4f", point.Close-point.Middle),
+	}
+	if err := r.q.SaveBollinger(ctx, params); err != nil {
+		return fmt.Errorf("failed to save bollinger bands for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+func (r *SQLIndicators) LatestRSI(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error) {
+	rows, err := r.q.GetRSIForDateRange(ctx, database.GetRSIForDateRangeParams{Symbol: symbol, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	points := make([]IndicatorPoint, len(rows))
+	for i, row := range rows {
+		points[i] = IndicatorPoint{Timestamp: row.CalculationTimestamp, Value: float64(row.RsiValue)}
+	}
+	return points, nil
+}
+
+func (r *SQLIndicators) LatestATR(ctx context.Context, symbol string, limit int) ([]IndicatorPoint, error) {
+	rows, err := r.q.GetATRForDateRange(ctx, database.GetATRForDateRangeParams{Symbol: symbol, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	points := make([]IndicatorPoint, len(rows))
+	for i, row := range rows {
+		points[i] = IndicatorPoint{Timestamp: row.CalculationTimestamp, Value: float64(row.AtrValue)}
+	}
+	return points, nil
+}
+
+func (r *SQLIndicators) RSIBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error) {
+	rows, err := r.q.GetRSIByTimestampRange(ctx, database.GetRSIByTimestampRangeParams{
+		Symbol:                 symbol,
+		CalculationTimestamp:   start,
+		CalculationTimestamp_2: end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	points := make([]IndicatorPoint, len(rows))
+	for i, row := range rows {
+		points[i] = IndicatorPoint{Timestamp: row.CalculationTimestamp, Value: float64(row.RsiValue)}
+	}
+	return points, nil
+}
+
+func (r *SQLIndicators) ATRBetween(ctx context.Context, symbol string, start, end time.Time) ([]IndicatorPoint, error) {
+	rows, err := r.q.GetATRByTimestampRange(ctx, database.GetATRByTimestampRangeParams{
+		Symbol:                 symbol,
+		CalculationTimestamp:   start,
+		CalculationTimestamp_2: end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	points := make([]IndicatorPoint, len(rows))
+	for i, row := range rows {
+		points[i] = IndicatorPoint{Timestamp: row.CalculationTimestamp, Value: float64(row.AtrValue)}
+	}
+	return points, nil
+}
+
+// SQLWatchlist is the WatchlistRepository over watchlist and scan_log
+type SQLWatchlist struct {
+	q *database.Queries
+}
+
+func NewSQLWatchlist(q *database.Queries) *SQLWatchlist {
+	return &SQLWatchlist{q: q}
+}
+
+func (r *SQLWatchlist) Active(ctx context.Context) ([]WatchlistItem, error) {
+	rows, err := r.q.GetWatchlist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]WatchlistItem, len(rows))
+	for i, row := range rows {
+		items[i] = WatchlistItem{
+			ID:          row.ID,
+			Symbol:      row.Symbol,
+			AssetType:   row.AssetType,
+			Score:       float64(row.Score),
+			Reason:      row.Reason.String,
+			AddedDate:   row.AddedDate.Time,
+			LastUpdated: row.LastUpdated.Time,
+		}
+	}
+	return items, nil
+}
+
+func (r *SQLWatchlist) UpdateScore(ctx context.Context, symbol string, score float64) error {
+	return r.q.UpdateWatchlistScore(ctx, database.UpdateWatchlistScoreParams{
+		Score:  float32(score),
+		Symbol: symbol,
+	})
+}
+
+func (r *SQLWatchlist) ScanLog(ctx context.Context, profile string) (ScanLog, error) {
+	row, err := r.q.GetScanLog(ctx, profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ScanLog{}, fmt.Errorf("scan log for %s: %w", profile, ErrNotFound)
+	}
+	if err != nil {
+		return ScanLog{}, err
+	}
+	return ScanLog{
+		Profile:        row.ProfileName,
+		LastScan:       row.LastScanTimestamp,
+		NextScanDue:    row.NextScanDue,
+		SymbolsScanned: int(row.SymbolsScanned.Int32),
+	}, nil
+}
+
+func (r *SQLWatchlist) RecordScan(ctx context.Context, log ScanLog) error {
+	return r.q.UpsertScanLog(ctx, database.UpsertScanLogParams{
+		ProfileName:       log.Profile,
+		LastScanTimestamp: log.LastScan,
+		NextScanDue:       log.NextScanDue,
+		SymbolsScanned:    sql.NullInt32{Int32: int32(log.SymbolsScanned), Valid: true},
+	})
+}
+
+// SQLWhales is the WhaleRepository over whale_events
+type SQLWhales struct {
+	q *database.Queries
+}
+
+func NewSQLWhales(q *database.Queries) *SQLWhales {
+	return &SQLWhales{q: q}
+}
+
+func (r *SQLWhales) SaveWhaleEvent(ctx context.Context, event WhaleEvent) error {
+	params := database.UpsertWhaleEventParams{
+		Symbol:      event.Symbol,
+		Timestamp:   event.Timestamp,
+		Direction:   event.Direction,
+		Volume:      event.Volume,
+		ZScore:      fmt.Sprintf("%.2f", event.ZScore),
+		ClosePrice:  fmt.Sprintf("%.4f", event.ClosePrice),
+		PriceChange: sql.NullString{String: fmt.Sprintf("%.4f", event.PriceChange), Valid: true},
+		Conviction:  event.Conviction,
+		Change1:     nullDecimal(event.Change1),
+		Change5:     nullDecimal(event.Change5),
+		Change20:    nullDecimal(event.Change20),
+	}
+	if event.Held != nil {
+		params.Held = sql.NullBool{Bool: *event.Held, Valid: true}
+	}
+	return r.q.UpsertWhaleEvent(ctx, params)
+}
+
+func (r *SQLWhales) WhaleHistory(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error) {
+	rows, err := r.q.ListWhaleEvents(ctx, database.ListWhaleEventsParams{
+		Symbol: symbol,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWhaleEvents(rows)
+}
+
+func (r *SQLWhales) SaveWhaleAlert(ctx context.Context, alert WhaleAlert) error {
+	return r.q.CreateWhaleAlert(ctx, database.CreateWhaleAlertParams{
+		Symbol:         alert.Symbol,
+		AlertType:      alert.Type,
+		Amount:         fmt.Sprintf("%.4f", alert.Amount),
+		Price:          fmt.Sprintf("%.4f", alert.Price),
+		AlertTimestamp: alert.Timestamp,
+	})
+}
+
+func (r *SQLWhales) WhaleAlerts(ctx context.Context, symbol string, limit int) ([]WhaleAlert, error) {
+	rows, err := r.q.GetWhaleAlerts(ctx, database.GetWhaleAlertsParams{Symbol: symbol, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	alerts := make([]WhaleAlert, 0, len(rows))
+	for _, row := range rows {
+		alert := WhaleAlert{Symbol: row.Symbol, Type: row.AlertType, Timestamp: row.AlertTimestamp}
+		var err error
+		if alert.Amount, err = strconv.ParseFloat(row.Amount, 64); err != nil {
+			return nil, fmt.Errorf("invalid alert amount %q for %s: %w", row.Amount, row.Symbol, err)
+		}
+		if alert.Price, err = strconv.ParseFloat(row.Price, 64); err != nil {
+			return nil, fmt.Errorf("invalid alert price %q for %s: %w", row.Price, row.Symbol, err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+func nullDecimal(value *float64) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: fmt.Sprintf("%.4f", *value), Valid: true}
+}
+
+func parseNullDecimal(value sql.NullString) (*float64, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value.String, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *SQLWhales) RecentWhales(ctx context.Context, symbol string, limit int) ([]WhaleEvent, error) {
+	rows, err := r.q.GetWhaleEventsBySymbol(ctx, database.GetWhaleEventsBySymbolParams{
+		Symbol: symbol,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWhaleEvents(rows)
+}
+
+func (r *SQLWhales) HighConvictionWhales(ctx context.Context, symbol string) ([]WhaleEvent, error) {
+	rows, err := r.q.GetHighConvictionWhales(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return toWhaleEvents(rows)
+}
+
+func toWhaleEvents(rows []database.WhaleEvent) ([]WhaleEvent, error) {
+	events := make([]WhaleEvent, 0, len(rows))
+	for _, row := range rows {
+		event := WhaleEvent{
+			Symbol:     row.Symbol,
+			Timestamp:  row.Timestamp,
+			Direction:  row.Direction,
+			Volume:     row.Volume,
+			Conviction: row.Conviction,
+		}
+		var err error
+		if event.ZScore, err = strconv.ParseFloat(row.ZScore, 64); err != nil {
+			return nil, fmt.Errorf("invalid z-score %q for %s: %w", row.ZScore, row.Symbol, err)
+		}
+		if event.ClosePrice, err = strconv.ParseFloat(row.ClosePrice, 64); err != nil {
+			return nil, fmt.Errorf("invalid close price %q for %s: %w", row.ClosePrice, row.Symbol, err)
+		}
+		if row.PriceChange.Valid {
+			if event.PriceChange, err = strconv.ParseFloat(row.PriceChange.String, 64); err != nil {
+				return nil, fmt.Errorf("invalid price change %q for %s: %w", row.PriceChange.String, row.Symbol, err)
+			}
+		}
+		if event.Change1, err = parseNullDecimal(row.Change1); err != nil {
+			return nil, fmt.Errorf("invalid 1-bar change %q for %s: %w", row.Change1.String, row.Symbol, err)
+		}
+		if event.Change5, err = parseNullDecimal(row.Change5); err != nil {
+			return nil, fmt.Errorf("invalid 5-bar change %q for %s: %w", row.Change5.String, row.Symbol, err)
+		}
+		if event.Change20, err = parseNullDecimal(row.Change20); err != nil {
+			return nil, fmt.Errorf("invalid 20-bar change %q for %s: %w", row.Change20.String, row.Symbol, err)
+		}
+		if row.Held.Valid {
+			held := row.Held.Bool
+			event.Held = &held
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// SQLLevels is the LevelRepository over support_levels
+type SQLLevels struct {
+	db database.DBTX
+	q  *database.Queries
+}
+
+func NewSQLLevels(db database.DBTX) *SQLLevels {
+	return &SQLLevels{db: db, q: database.New(db)}
+}
+
+// ReplaceLevels swaps the symbol's zones in one transaction, so a failed insert keeps the old ones
+func (r *SQLLevels) ReplaceLevels(ctx context.Context, symbol string, levels []SupportLevel) error {
+	return InTx(ctx, r.db, func(q *database.Queries) error {
+		return replaceLevels(ctx, q, symbol, levels)
+	})
+}
+
+func replaceLevels(ctx context.Context, q *database.Queries, symbol string, levels []SupportLevel) error {
+	if err := q.DeleteSupportLevels(ctx, symbol); err != nil {
+		return fmt.Errorf("failed to clear levels for %s: %w", symbol, err)
+	}
+	for _, level := range levels {
+		err := q.CreateSupportLevel(ctx, database.CreateSupportLevelParams{
+			Symbol:      symbol,
+			LevelPrice:  fmt.Sprintf("%.4f", level.Price),
+			LevelType:   level.Type,
+			DetectedAt:  level.DetectedAt.UTC(),
+			ZoneLow:     sql.NullString{String: fmt.Sprintf("%.4f", level.ZoneLow), Valid: true},
+			ZoneHigh:    sql.NullString{String: fmt.Sprintf("%.4f", level.ZoneHigh), Valid: true},
+			Touches:     int32(level.Touches),
+			Strength:    fmt.Sprintf("%.4f", level.Strength),
+			LastTouched: sql.NullTime{Time: level.LastTouched.UTC(), Valid: !level.LastTouched.IsZero()},
+			Flipped:     level.Flipped,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save %s level %.4f for %s: %w", level.Type, level.Price, symbol, err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLLevels) Levels(ctx context.Context, symbol string) ([]SupportLevel, error) {
+	rows, err := r.q.GetSupportLevels(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	levels := make([]SupportLevel, 0, len(rows))
+	for _, row := range rows {
+		level := SupportLevel{
+			Symbol:     row.Symbol,
+			Type:       row.LevelType,
+			Touches:    int(row.Touches),
+			Flipped:    row.Flipped,
+			DetectedAt: row.DetectedAt,
+		}
+		if row.LastTouched.Valid {
+			level.LastTouched = row.LastTouched.Time
+		}
+		var err error
+		if level.Price, err = strconv.ParseFloat(row.LevelPrice, 64); err != nil {
+			return nil, fmt.Errorf("invalid level price %q for %s: %w", row.LevelPrice, row.Symbol, err)
+		}
+		if level.Strength, err = strconv.ParseFloat(row.Strength, 64); err != nil {
+			return nil, fmt.Errorf("invalid level strength %q for %s: %w", row.Strength, row.Symbol, err)
+		}
+		level.ZoneLow, level.ZoneHigh = level.Price, level.Price
+		if row.ZoneLow.Valid {
+			if level.ZoneLow, err = strconv.ParseFloat(row.ZoneLow.String, 64); err != nil {
+				return nil, fmt.Errorf("invalid zone low %q for %s: %w", row.ZoneLow.String, row.Symbol, err)
+			}
+		}
+		if row.ZoneHigh.Valid {
+			if level.ZoneHigh, err = strconv.ParseFloat(row.ZoneHigh.String, 64); err != nil {
+				return nil, fmt.Errorf("invalid zone high %q for %s: %w", row.ZoneHigh.String, row.Symbol, err)
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+)
+
+// TxBeginner is a connection wrapper that can open a transaction and wrap it the same way
+// it wraps its connection, so queries keep working inside the transaction
+type TxBeginner interface {
+	BeginQueriesTx(ctx context.Context) (*sql.Tx, database.DBTX, error)
+}
+
+// InTx runs fn with Queries bound to one transaction on db, committing if fn succeeds and
+// rolling back otherwise. A db that is already a transaction runs fn directly.
+func InTx(ctx context.Context, db database.DBTX, fn func(*database.Queries) error) error {
+	var (
+		tx   *sql.Tx
+		txDB database.DBTX
+		err  error
+	)
+	switch conn := db.(type) {
+	case *sql.DB:
+		tx, err = conn.BeginTx(ctx, nil)
+		txDB = tx
+	case TxBeginner:
+		tx, txDB, err = conn.BeginQueriesTx(ctx)
+	default:
+		return fn(database.New(db))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(database.New(txDB)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -3,122 +3,36 @@ package datafeed
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
-	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	"github.com/fazecat/mongelmaker/Internal/types"
 	"github.com/fazecat/mongelmaker/Internal/utils"
 )
 
-type PricePoint struct {
-	Price     float64
-	Timestamp time.Time
-}
-
-func FetchClosingPrices(symbol string, days int, timeframe string) ([]float64, error) {
-	params := database.GetClosingPricesParams{
-		Symbol:    symbol,
-		Timeframe: timeframe,
-		Limit:     int32(days),
-	}
-
-	ctx := context.Background()
-	rows, err := Queries.GetClosingPrices(ctx, params)
+// FetchRSIForDisplay returns the newest limit stored RSI values keyed by "2006-01-02 15:04:05"
+func FetchRSIForDisplay(ctx context.Context, repo repository.IndicatorRepository, symbol string, limit int) (map[string]float64, error) {
+	points, err := repo.LatestRSI(ctx, symbol, limit)
 	if err != nil {
 		return nil, err
 	}
-
-	var closingPrices []float64
-	for _, row := range rows {
-		price, err := strconv.ParseFloat(row.ClosePrice, 64)
-		if err != nil {
-			return nil, err
-		}
-		closingPrices = append(closingPrices, price)
-	}
-
-	return closingPrices, nil
-}
-func SaveRSI(symbol string, timestamp time.Time, rsiValue float64) error {
-	params := database.SaveRSIParams{
-		Symbol:               symbol,
-		CalculationTimestamp: timestamp,
-		RsiValue:             float32(rsiValue),
-	}
-	ctx := context.Background()
-	err := Queries.SaveRSI(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return displayMap(points), nil
 }
 
-func FetchPricePoints(symbol string, days int, timeframe string) ([]PricePoint, error) {
-	params := database.GetClosingPricesParams{
-		Symbol:    symbol,
-		Timeframe: timeframe,
-		Limit:     int32(days),
-	}
-	ctx := context.Background()
-	rows, err := Queries.GetClosingPrices(ctx, params)
+func FetchRSIByTimestampRange(ctx context.Context, repo repository.IndicatorRepository, symbol string, startTime, endTime time.Time) (map[string]float64, error) {
+	points, err := repo.RSIBetween(ctx, symbol, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
-
-	var pricePoints []PricePoint
-	for _, row := range rows {
-		price, err := strconv.ParseFloat(row.ClosePrice, 64)
-		if err != nil {
-			return nil, err
-		}
-		pricePoints = append(pricePoints, PricePoint{
-			Price:     price,
-			Timestamp: row.Timestamp,
-		})
-	}
-
-	return pricePoints, nil
+	return displayMap(points), nil
 }
 
-func FetchRSIForDisplay(symbol string, limit int) (map[string]float64, error) {
-	params := database.GetRSIForDateRangeParams{
-		Symbol: symbol,
-		Limit:  int32(limit),
-	}
-	ctx := context.Background()
-	rows, err := Queries.GetRSIForDateRange(ctx, params)
-	if err != nil {
-		return nil, err
-	}
-
-	rsiMap := make(map[string]float64)
-	for _, row := range rows {
-		dateStr := row.CalculationTimestamp.Format("2006-01-02 15:04:05")
-		rsiMap[dateStr] = float64(row.RsiValue)
+func displayMap(points []repository.IndicatorPoint) map[string]float64 {
+	values := make(map[string]float64, len(points))
+	for _, point := range points {
+		values[point.Timestamp.Format("2006-01-02 15:04:05")] = point.Value
 	}
-	return rsiMap, nil
-}
-
-func FetchRSIByTimestampRange(symbol string, startTime, endTime time.Time) (map[string]float64, error) {
-	params := database.GetRSIByTimestampRangeParams{
-		Symbol:                 symbol,
-		CalculationTimestamp:   startTime,
-		CalculationTimestamp_2: endTime,
-	}
-	ctx := context.Background()
-	rows, err := Queries.GetRSIByTimestampRange(ctx, params)
-	if err != nil {
-		return nil, err
-	}
-
-	rsiMap := make(map[string]float64)
-	for _, row := range rows {
-		dateStr := row.CalculationTimestamp.Format("2006-01-02 15:04:05")
-		rsiMap[dateStr] = float64(row.RsiValue)
-	}
-	return rsiMap, nil
+	return values
 }
 
 // calculateRSI calculates RSI values locally to avoid import cycle
@@ -160,25 +74,17 @@ func calculateRSI(closes []float64, period int) ([]float64, error) {
 	return rsi, nil
 }
 
-func CalculateAndStoreRSI(symbol string, bars []types.Bar) error {
-	if len(bars) == 0 {
+func CalculateAndStoreRSI(ctx context.Context, repo repository.IndicatorRepository, symbol string, series types.BarSeries) error {
+	if series.Len() == 0 {
 		return nil
 	}
-	closingPrices := make([]float64, len(bars))
-	for i, bar := range bars {
-		closingPrices[i] = bar.Close
-	}
 
-	rsiValues, err := calculateRSI(closingPrices, 14)
+	rsiValues, err := calculateRSI(series.Closes(), 14)
 	if err != nil {
 		return err
 	}
-	for i, bar := range bars {
-		timestamp, err := time.Parse(time.RFC3339, bar.Timestamp)
-		if err != nil {
-			return err
-		}
-		err = SaveRSI(symbol, timestamp, rsiValues[i])
+	for i := range rsiValues {
+		err = repo.SaveRSI(ctx, symbol, series.Time(i), rsiValues[i])
 		if err != nil {
 			return err
 		}
@@ -97,6 +97,7 @@ type Position struct {
 	MarketValue   sql.NullString `json:"market_value"`
 	UnrealizedPnl sql.NullString `json:"unrealized_pnl"`
 	UpdatedAt     sql.NullTime   `json:"updated_at"`
+	OpenedAt      sql.NullTime   `json:"opened_at"`
 }
 
 type RsiCalculation struct {
@@ -157,12 +158,18 @@ type SkipBacklog struct {
 }
 
 type SupportLevel struct {
-	ID         int32        `json:"id"`
-	Symbol     string       `json:"symbol"`
-	LevelPrice string       `json:"level_price"`
-	LevelType  string       `json:"level_type"`
-	DetectedAt time.Time    `json:"detected_at"`
-	CreatedAt  sql.NullTime `json:"created_at"`
+	ID          int32          `json:"id"`
+	Symbol      string         `json:"symbol"`
+	LevelPrice  string         `json:"level_price"`
+	LevelType   string         `json:"level_type"`
+	DetectedAt  time.Time      `json:"detected_at"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	ZoneLow     sql.NullString `json:"zone_low"`
+	ZoneHigh    sql.NullString `json:"zone_high"`
+	Touches     int32          `json:"touches"`
+	Strength    string         `json:"strength"`
+	LastTouched sql.NullTime   `json:"last_touched"`
+	Flipped     bool           `json:"flipped"`
 }
 
 type Trade struct {
@@ -221,4 +228,8 @@ type WhaleEvent struct {
 	PriceChange sql.NullString `json:"price_change"`
 	Conviction  string         `json:"conviction"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
+	Change1     sql.NullString `json:"change_1"`
+	Change5     sql.NullString `json:"change_5"`
+	Change20    sql.NullString `json:"change_20"`
+	Held        sql.NullBool   `json:"held"`
 }
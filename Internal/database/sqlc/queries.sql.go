@@ -38,6 +38,14 @@ func (q *Queries) AddToScoutSkipList(ctx context.Context, arg AddToScoutSkipList
 const addToWatchlist = `-- name: AddToWatchlist :one
 INSERT INTO watchlist (symbol, asset_type, score, reason, added_date, last_updated, status)
 VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'active')
+ON CONFLICT (symbol) DO UPDATE SET
+    asset_type = EXCLUDED.asset_type,
+    score = EXCLUDED.score,
+    reason = EXCLUDED.reason,
+    added_date = CURRENT_TIMESTAMP,
+    last_updated = CURRENT_TIMESTAMP,
+    status = 'active'
+WHERE watchlist.status = 'archived'
 RETURNING id
 `
 
@@ -48,7 +56,7 @@ type AddToWatchlistParams struct {
 	Reason    sql.NullString `json:"reason"`
 }
 
-// Add a new candidate to watchlist and return the ID
+// Add a new candidate to watchlist and return the ID (re-activates an archived symbol)
 func (q *Queries) AddToWatchlist(ctx context.Context, arg AddToWatchlistParams) (int32, error) {
 	row := q.db.QueryRowContext(ctx, addToWatchlist,
 		arg.Symbol,
@@ -90,7 +98,7 @@ SET status = 'archived'
 WHERE id IN (
   SELECT w.id FROM watchlist w
   WHERE w.status = 'active'
-  AND datetime(w.last_updated) < datetime('now', '-30 days')
+  AND w.last_updated < NOW() - INTERVAL '30 days'
 )
 `
 
@@ -100,37 +108,179 @@ func (q *Queries) ArchiveOldWatchlist(ctx context.Context) error {
 	return err
 }
 
-const createWhaleEvent = `-- name: CreateWhaleEvent :exec
-INSERT INTO whale_events (
-    symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+const countNewsArticles = `-- name: CountNewsArticles :one
+SELECT COUNT(*) FROM news_articles WHERE symbol = $1
+`
+
+func (q *Queries) CountNewsArticles(ctx context.Context, symbol string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNewsArticles, symbol)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWatchlistHistory = `-- name: CountWatchlistHistory :one
+SELECT COUNT(*) FROM watchlist_history WHERE watchlist_id = $1
+`
+
+func (q *Queries) CountWatchlistHistory(ctx context.Context, watchlistID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWatchlistHistory, watchlistID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWhaleEvents = `-- name: CountWhaleEvents :one
+SELECT COUNT(*) FROM whale_events WHERE symbol = $1
+`
+
+func (q *Queries) CountWhaleEvents(ctx context.Context, symbol string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWhaleEvents, symbol)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createSignal = `-- name: CreateSignal :one
+INSERT INTO signals (symbol, signal_type, current_price, confidence)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+
+type CreateSignalParams struct {
+	Symbol       string         `json:"symbol"`
+	SignalType   string         `json:"signal_type"`
+	CurrentPrice string         `json:"current_price"`
+	Confidence   sql.NullString `json:"confidence"`
+}
+
+func (q *Queries) CreateSignal(ctx context.Context, arg CreateSignalParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createSignal,
+		arg.Symbol,
+		arg.SignalType,
+		arg.CurrentPrice,
+		arg.Confidence,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSupportLevel = `-- name: CreateSupportLevel :exec
+INSERT INTO support_levels (
+    symbol, level_price, level_type, detected_at, zone_low, zone_high, touches, strength, last_touched, flipped
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 `
 
-type CreateWhaleEventParams struct {
+type CreateSupportLevelParams struct {
 	Symbol      string         `json:"symbol"`
-	Timestamp   time.Time      `json:"timestamp"`
-	Direction   string         `json:"direction"`
-	Volume      int64          `json:"volume"`
-	ZScore      string         `json:"z_score"`
-	ClosePrice  string         `json:"close_price"`
-	PriceChange sql.NullString `json:"price_change"`
-	Conviction  string         `json:"conviction"`
+	LevelPrice  string         `json:"level_price"`
+	LevelType   string         `json:"level_type"`
+	DetectedAt  time.Time      `json:"detected_at"`
+	ZoneLow     sql.NullString `json:"zone_low"`
+	ZoneHigh    sql.NullString `json:"zone_high"`
+	Touches     int32          `json:"touches"`
+	Strength    string         `json:"strength"`
+	LastTouched sql.NullTime   `json:"last_touched"`
+	Flipped     bool           `json:"flipped"`
 }
 
-func (q *Queries) CreateWhaleEvent(ctx context.Context, arg CreateWhaleEventParams) error {
-	_, err := q.db.ExecContext(ctx, createWhaleEvent,
+func (q *Queries) CreateSupportLevel(ctx context.Context, arg CreateSupportLevelParams) error {
+	_, err := q.db.ExecContext(ctx, createSupportLevel,
 		arg.Symbol,
-		arg.Timestamp,
-		arg.Direction,
-		arg.Volume,
-		arg.ZScore,
-		arg.ClosePrice,
-		arg.PriceChange,
-		arg.Conviction,
+		arg.LevelPrice,
+		arg.LevelType,
+		arg.DetectedAt,
+		arg.ZoneLow,
+		arg.ZoneHigh,
+		arg.Touches,
+		arg.Strength,
+		arg.LastTouched,
+		arg.Flipped,
 	)
 	return err
 }
 
+const createTrade = `-- name: CreateTrade :one
+INSERT INTO trades (signal_id, symbol, side, quantity, price, total_value, commission, alpaca_order_id, status, filled_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id
+`
+
+type CreateTradeParams struct {
+	SignalID      sql.NullInt32  `json:"signal_id"`
+	Symbol        string         `json:"symbol"`
+	Side          string         `json:"side"`
+	Quantity      string         `json:"quantity"`
+	Price         string         `json:"price"`
+	TotalValue    string         `json:"total_value"`
+	Commission    sql.NullString `json:"commission"`
+	AlpacaOrderID sql.NullString `json:"alpaca_order_id"`
+	Status        sql.NullString `json:"status"`
+	FilledAt      sql.NullTime   `json:"filled_at"`
+}
+
+func (q *Queries) CreateTrade(ctx context.Context, arg CreateTradeParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createTrade,
+		arg.SignalID,
+		arg.Symbol,
+		arg.Side,
+		arg.Quantity,
+		arg.Price,
+		arg.TotalValue,
+		arg.Commission,
+		arg.AlpacaOrderID,
+		arg.Status,
+		arg.FilledAt,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createWhaleAlert = `-- name: CreateWhaleAlert :exec
+INSERT INTO whale_alerts (symbol, alert_type, amount, price, alert_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (symbol, alert_type, alert_timestamp) DO NOTHING
+`
+
+type CreateWhaleAlertParams struct {
+	Symbol         string    `json:"symbol"`
+	AlertType      string    `json:"alert_type"`
+	Amount         string    `json:"amount"`
+	Price          string    `json:"price"`
+	AlertTimestamp time.Time `json:"alert_timestamp"`
+}
+
+func (q *Queries) CreateWhaleAlert(ctx context.Context, arg CreateWhaleAlertParams) error {
+	_, err := q.db.ExecContext(ctx, createWhaleAlert,
+		arg.Symbol,
+		arg.AlertType,
+		arg.Amount,
+		arg.Price,
+		arg.AlertTimestamp,
+	)
+	return err
+}
+
+const deletePosition = `-- name: DeletePosition :exec
+DELETE FROM positions WHERE symbol = $1
+`
+
+func (q *Queries) DeletePosition(ctx context.Context, symbol string) error {
+	_, err := q.db.ExecContext(ctx, deletePosition, symbol)
+	return err
+}
+
+const deleteSupportLevels = `-- name: DeleteSupportLevels :exec
+DELETE FROM support_levels WHERE symbol = $1
+`
+
+func (q *Queries) DeleteSupportLevels(ctx context.Context, symbol string) error {
+	_, err := q.db.ExecContext(ctx, deleteSupportLevels, symbol)
+	return err
+}
+
 const getATR = `-- name: GetATR :one
 SELECT atr_value, calculation_timestamp
 FROM atr_calculation
@@ -373,7 +523,7 @@ func (q *Queries) GetClosingPrices(ctx context.Context, arg GetClosingPricesPara
 }
 
 const getHighConvictionWhales = `-- name: GetHighConvictionWhales :many
-SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at FROM whale_events
+SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at, change_1, change_5, change_20, held FROM whale_events
 WHERE symbol = $1 AND conviction = 'HIGH'
 ORDER BY z_score DESC
 LIMIT 10
@@ -399,6 +549,71 @@ func (q *Queries) GetHighConvictionWhales(ctx context.Context, symbol string) ([
 			&i.PriceChange,
 			&i.Conviction,
 			&i.CreatedAt,
+			&i.Change1,
+			&i.Change5,
+			&i.Change20,
+			&i.Held,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHistoricalBars = `-- name: GetHistoricalBars :many
+SELECT timestamp, open_price, high_price, low_price, close_price, volume
+FROM historical_bars
+WHERE symbol = $1
+  AND timeframe = $2
+  AND timestamp >= $3
+  AND timestamp <= $4
+ORDER BY timestamp ASC
+`
+
+type GetHistoricalBarsParams struct {
+	Symbol      string    `json:"symbol"`
+	Timeframe   string    `json:"timeframe"`
+	Timestamp   time.Time `json:"timestamp"`
+	Timestamp_2 time.Time `json:"timestamp_2"`
+}
+
+type GetHistoricalBarsRow struct {
+	Timestamp  time.Time `json:"timestamp"`
+	OpenPrice  string    `json:"open_price"`
+	HighPrice  string    `json:"high_price"`
+	LowPrice   string    `json:"low_price"`
+	ClosePrice string    `json:"close_price"`
+	Volume     int64     `json:"volume"`
+}
+
+func (q *Queries) GetHistoricalBars(ctx context.Context, arg GetHistoricalBarsParams) ([]GetHistoricalBarsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getHistoricalBars,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.Timestamp,
+		arg.Timestamp_2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetHistoricalBarsRow
+	for rows.Next() {
+		var i GetHistoricalBarsRow
+		if err := rows.Scan(
+			&i.Timestamp,
+			&i.OpenPrice,
+			&i.HighPrice,
+			&i.LowPrice,
+			&i.ClosePrice,
+			&i.Volume,
 		); err != nil {
 			return nil, err
 		}
@@ -557,6 +772,106 @@ func (q *Queries) GetNewsForScreener(ctx context.Context, dollar_1 []string) ([]
 	return items, nil
 }
 
+const getPendingTrades = `-- name: GetPendingTrades :many
+SELECT id, signal_id, symbol, side, quantity, price, total_value, commission, alpaca_order_id, status, created_at, filled_at FROM trades
+WHERE status IN ('PENDING', 'PARTIALLY_FILLED')
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetPendingTrades(ctx context.Context) ([]Trade, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingTrades)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trade
+	for rows.Next() {
+		var i Trade
+		if err := rows.Scan(
+			&i.ID,
+			&i.SignalID,
+			&i.Symbol,
+			&i.Side,
+			&i.Quantity,
+			&i.Price,
+			&i.TotalValue,
+			&i.Commission,
+			&i.AlpacaOrderID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.FilledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPosition = `-- name: GetPosition :one
+SELECT id, symbol, quantity, avg_entry_price, current_price, market_value, unrealized_pnl, updated_at, opened_at FROM positions WHERE symbol = $1
+`
+
+func (q *Queries) GetPosition(ctx context.Context, symbol string) (Position, error) {
+	row := q.db.QueryRowContext(ctx, getPosition, symbol)
+	var i Position
+	err := row.Scan(
+		&i.ID,
+		&i.Symbol,
+		&i.Quantity,
+		&i.AvgEntryPrice,
+		&i.CurrentPrice,
+		&i.MarketValue,
+		&i.UnrealizedPnl,
+		&i.UpdatedAt,
+		&i.OpenedAt,
+	)
+	return i, err
+}
+
+const getPositions = `-- name: GetPositions :many
+SELECT id, symbol, quantity, avg_entry_price, current_price, market_value, unrealized_pnl, updated_at, opened_at FROM positions ORDER BY symbol
+`
+
+func (q *Queries) GetPositions(ctx context.Context) ([]Position, error) {
+	rows, err := q.db.QueryContext(ctx, getPositions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Position
+	for rows.Next() {
+		var i Position
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.Quantity,
+			&i.AvgEntryPrice,
+			&i.CurrentPrice,
+			&i.MarketValue,
+			&i.UnrealizedPnl,
+			&i.UpdatedAt,
+			&i.OpenedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRSIByTimestampRange = `-- name: GetRSIByTimestampRange :many
 SELECT calculation_timestamp, rsi_value
 FROM rsi_calculation
@@ -641,6 +956,102 @@ func (q *Queries) GetRSIForDateRange(ctx context.Context, arg GetRSIForDateRange
 	return items, nil
 }
 
+const getRecentHistoricalBars = `-- name: GetRecentHistoricalBars :many
+SELECT timestamp, open_price, high_price, low_price, close_price, volume
+FROM historical_bars
+WHERE symbol = $1
+  AND timeframe = $2
+ORDER BY timestamp DESC
+LIMIT $3
+`
+
+type GetRecentHistoricalBarsParams struct {
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+	Limit     int32  `json:"limit"`
+}
+
+type GetRecentHistoricalBarsRow struct {
+	Timestamp  time.Time `json:"timestamp"`
+	OpenPrice  string    `json:"open_price"`
+	HighPrice  string    `json:"high_price"`
+	LowPrice   string    `json:"low_price"`
+	ClosePrice string    `json:"close_price"`
+	Volume     int64     `json:"volume"`
+}
+
+func (q *Queries) GetRecentHistoricalBars(ctx context.Context, arg GetRecentHistoricalBarsParams) ([]GetRecentHistoricalBarsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentHistoricalBars, arg.Symbol, arg.Timeframe, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentHistoricalBarsRow
+	for rows.Next() {
+		var i GetRecentHistoricalBarsRow
+		if err := rows.Scan(
+			&i.Timestamp,
+			&i.OpenPrice,
+			&i.HighPrice,
+			&i.LowPrice,
+			&i.ClosePrice,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentTrades = `-- name: GetRecentTrades :many
+SELECT id, signal_id, symbol, side, quantity, price, total_value, commission, alpaca_order_id, status, created_at, filled_at FROM trades
+ORDER BY created_at DESC
+LIMIT $1
+`
+
+func (q *Queries) GetRecentTrades(ctx context.Context, limit int32) ([]Trade, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentTrades, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trade
+	for rows.Next() {
+		var i Trade
+		if err := rows.Scan(
+			&i.ID,
+			&i.SignalID,
+			&i.Symbol,
+			&i.Side,
+			&i.Quantity,
+			&i.Price,
+			&i.TotalValue,
+			&i.Commission,
+			&i.AlpacaOrderID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.FilledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRecheckableSymbols = `-- name: GetRecheckableSymbols :many
 SELECT symbol, asset_type, reason FROM skip_backlog
 WHERE recheck_after <= CURRENT_TIMESTAMP
@@ -706,6 +1117,48 @@ func (q *Queries) GetScanLog(ctx context.Context, profileName string) (GetScanLo
 	return i, err
 }
 
+const getSupportLevels = `-- name: GetSupportLevels :many
+SELECT id, symbol, level_price, level_type, detected_at, created_at, zone_low, zone_high, touches, strength, last_touched, flipped FROM support_levels
+WHERE symbol = $1
+ORDER BY level_price
+`
+
+func (q *Queries) GetSupportLevels(ctx context.Context, symbol string) ([]SupportLevel, error) {
+	rows, err := q.db.QueryContext(ctx, getSupportLevels, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SupportLevel
+	for rows.Next() {
+		var i SupportLevel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.LevelPrice,
+			&i.LevelType,
+			&i.DetectedAt,
+			&i.CreatedAt,
+			&i.ZoneLow,
+			&i.ZoneHigh,
+			&i.Touches,
+			&i.Strength,
+			&i.LastTouched,
+			&i.Flipped,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWatchlist = `-- name: GetWatchlist :many
 SELECT id, symbol, asset_type, score, reason, added_date, last_updated
 FROM watchlist
@@ -787,8 +1240,51 @@ func (q *Queries) GetWatchlistBySymbol(ctx context.Context, symbol string) (GetW
 	return i, err
 }
 
+const getWhaleAlerts = `-- name: GetWhaleAlerts :many
+SELECT id, symbol, alert_type, amount, price, alert_timestamp, created_at FROM whale_alerts
+WHERE symbol = $1
+ORDER BY alert_timestamp DESC
+LIMIT $2
+`
+
+type GetWhaleAlertsParams struct {
+	Symbol string `json:"symbol"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) GetWhaleAlerts(ctx context.Context, arg GetWhaleAlertsParams) ([]WhaleAlert, error) {
+	rows, err := q.db.QueryContext(ctx, getWhaleAlerts, arg.Symbol, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WhaleAlert
+	for rows.Next() {
+		var i WhaleAlert
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.AlertType,
+			&i.Amount,
+			&i.Price,
+			&i.AlertTimestamp,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWhaleEventsBySymbol = `-- name: GetWhaleEventsBySymbol :many
-SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at FROM whale_events
+SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at, change_1, change_5, change_20, held FROM whale_events
 WHERE symbol = $1 AND timestamp > NOW() - INTERVAL '7 days'
 ORDER BY timestamp DESC
 LIMIT $2
@@ -819,6 +1315,10 @@ func (q *Queries) GetWhaleEventsBySymbol(ctx context.Context, arg GetWhaleEvents
 			&i.PriceChange,
 			&i.Conviction,
 			&i.CreatedAt,
+			&i.Change1,
+			&i.Change5,
+			&i.Change20,
+			&i.Held,
 		); err != nil {
 			return nil, err
 		}
@@ -853,6 +1353,186 @@ func (q *Queries) IsSymbolSkipped(ctx context.Context, arg IsSymbolSkippedParams
 	return is_skipped, err
 }
 
+const listHistoricalBarSymbols = `-- name: ListHistoricalBarSymbols :many
+SELECT DISTINCT symbol
+FROM historical_bars
+ORDER BY symbol
+`
+
+func (q *Queries) ListHistoricalBarSymbols(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoricalBarSymbols)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		items = append(items, symbol)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNewsArticles = `-- name: ListNewsArticles :many
+SELECT id, symbol, headline, url, published_at, source, sentiment, created_at
+FROM news_articles
+WHERE symbol = $1
+ORDER BY published_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListNewsArticlesParams struct {
+	Symbol string `json:"symbol"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListNewsArticles(ctx context.Context, arg ListNewsArticlesParams) ([]NewsArticle, error) {
+	rows, err := q.db.QueryContext(ctx, listNewsArticles, arg.Symbol, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NewsArticle
+	for rows.Next() {
+		var i NewsArticle
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.Headline,
+			&i.Url,
+			&i.PublishedAt,
+			&i.Source,
+			&i.Sentiment,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchlistHistory = `-- name: ListWatchlistHistory :many
+SELECT id, watchlist_id, old_score, new_score, analysis_data, timestamp
+FROM watchlist_history
+WHERE watchlist_id = $1
+ORDER BY timestamp DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListWatchlistHistoryParams struct {
+	WatchlistID int32 `json:"watchlist_id"`
+	Limit       int32 `json:"limit"`
+	Offset      int32 `json:"offset"`
+}
+
+// Score changes for one watchlist item, newest first
+func (q *Queries) ListWatchlistHistory(ctx context.Context, arg ListWatchlistHistoryParams) ([]WatchlistHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchlistHistory, arg.WatchlistID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WatchlistHistory
+	for rows.Next() {
+		var i WatchlistHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.WatchlistID,
+			&i.OldScore,
+			&i.NewScore,
+			&i.AnalysisData,
+			&i.Timestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWhaleEvents = `-- name: ListWhaleEvents :many
+SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at, change_1, change_5, change_20, held FROM whale_events
+WHERE symbol = $1
+ORDER BY timestamp DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListWhaleEventsParams struct {
+	Symbol string `json:"symbol"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListWhaleEvents(ctx context.Context, arg ListWhaleEventsParams) ([]WhaleEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listWhaleEvents, arg.Symbol, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WhaleEvent
+	for rows.Next() {
+		var i WhaleEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.Timestamp,
+			&i.Direction,
+			&i.Volume,
+			&i.ZScore,
+			&i.ClosePrice,
+			&i.PriceChange,
+			&i.Conviction,
+			&i.CreatedAt,
+			&i.Change1,
+			&i.Change5,
+			&i.Change20,
+			&i.Held,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSignalExecuted = `-- name: MarkSignalExecuted :exec
+UPDATE signals SET executed = TRUE WHERE id = $1
+`
+
+func (q *Queries) MarkSignalExecuted(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, markSignalExecuted, id)
+	return err
+}
+
 const removeFromSkipBacklog = `-- name: RemoveFromSkipBacklog :exec
 DELETE FROM skip_backlog WHERE symbol = $1
 `
@@ -863,6 +1543,21 @@ func (q *Queries) RemoveFromSkipBacklog(ctx context.Context, symbol string) erro
 	return err
 }
 
+const removeFromWatchlist = `-- name: RemoveFromWatchlist :execrows
+UPDATE watchlist
+SET status = 'archived', last_updated = CURRENT_TIMESTAMP
+WHERE symbol = $1 AND status = 'active'
+`
+
+// Archive an active symbol; history rows keep pointing at it
+func (q *Queries) RemoveFromWatchlist(ctx context.Context, symbol string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeFromWatchlist, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const saveATR = `-- name: SaveATR :exec
 INSERT INTO atr_calculation (symbol, calculation_timestamp, atr_value)
 VALUES ($1, $2, $3)
@@ -881,6 +1576,46 @@ func (q *Queries) SaveATR(ctx context.Context, arg SaveATRParams) error {
 	return err
 }
 
+const saveBollinger = `-- name: SaveBollinger :exec
+INSERT INTO candle_daily_bollinger (symbol, calculation_date, time_period, average_price, standard_deviation,
+    upper_band, lower_band, current_price, deviation_from_avg)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (symbol, calculation_date, time_period) DO UPDATE SET
+    average_price = EXCLUDED.average_price,
+    standard_deviation = EXCLUDED.standard_deviation,
+    upper_band = EXCLUDED.upper_band,
+    lower_band = EXCLUDED.lower_band,
+    current_price = EXCLUDED.current_price,
+    deviation_from_avg = EXCLUDED.deviation_from_avg
+`
+
+type SaveBollingerParams struct {
+	Symbol            string    `json:"symbol"`
+	CalculationDate   time.Time `json:"calculation_date"`
+	TimePeriod        int32     `json:"time_period"`
+	AveragePrice      string    `json:"average_price"`
+	StandardDeviation string    `json:"standard_deviation"`
+	UpperBand         string    `json:"upper_band"`
+	LowerBand         string    `json:"lower_band"`
+	CurrentPrice      string    `json:"current_price"`
+	DeviationFromAvg  string    `json:"deviation_from_avg"`
+}
+
+func (q *Queries) SaveBollinger(ctx context.Context, arg SaveBollingerParams) error {
+	_, err := q.db.ExecContext(ctx, saveBollinger,
+		arg.Symbol,
+		arg.CalculationDate,
+		arg.TimePeriod,
+		arg.AveragePrice,
+		arg.StandardDeviation,
+		arg.UpperBand,
+		arg.LowerBand,
+		arg.CurrentPrice,
+		arg.DeviationFromAvg,
+	)
+	return err
+}
+
 const saveNewsArticle = `-- name: SaveNewsArticle :exec
 INSERT INTO news_articles (symbol, headline, url, published_at, source, sentiment)
 VALUES ($1, $2, $3, $4, $5, $6)
@@ -928,7 +1663,7 @@ func (q *Queries) SaveRSI(ctx context.Context, arg SaveRSIParams) error {
 
 const skipSymbol = `-- name: SkipSymbol :exec
 INSERT INTO skip_backlog (symbol, asset_type, reason, timestamp, recheck_after)
-VALUES ($1, $2, $3, CURRENT_TIMESTAMP, datetime('now', '+30 days'))
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, NOW() + INTERVAL '30 days')
 `
 
 type SkipSymbolParams struct {
@@ -943,6 +1678,33 @@ func (q *Queries) SkipSymbol(ctx context.Context, arg SkipSymbolParams) error {
 	return err
 }
 
+const updateTradeFill = `-- name: UpdateTradeFill :exec
+UPDATE trades
+SET status = $2, quantity = $3, price = $4, total_value = $5, filled_at = $6
+WHERE id = $1
+`
+
+type UpdateTradeFillParams struct {
+	ID         int32          `json:"id"`
+	Status     sql.NullString `json:"status"`
+	Quantity   string         `json:"quantity"`
+	Price      string         `json:"price"`
+	TotalValue string         `json:"total_value"`
+	FilledAt   sql.NullTime   `json:"filled_at"`
+}
+
+func (q *Queries) UpdateTradeFill(ctx context.Context, arg UpdateTradeFillParams) error {
+	_, err := q.db.ExecContext(ctx, updateTradeFill,
+		arg.ID,
+		arg.Status,
+		arg.Quantity,
+		arg.Price,
+		arg.TotalValue,
+		arg.FilledAt,
+	)
+	return err
+}
+
 const updateWatchlistScore = `-- name: UpdateWatchlistScore :exec
 UPDATE watchlist
 SET score = $1, last_updated = CURRENT_TIMESTAMP
@@ -960,6 +1722,85 @@ func (q *Queries) UpdateWatchlistScore(ctx context.Context, arg UpdateWatchlistS
 	return err
 }
 
+const upsertHistoricalBar = `-- name: UpsertHistoricalBar :exec
+INSERT INTO historical_bars (symbol, timeframe, timestamp, open_price, high_price, low_price, close_price,
+    volume, price_change, price_change_percent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (symbol, timeframe, timestamp) DO UPDATE SET
+    open_price = EXCLUDED.open_price,
+    high_price = EXCLUDED.high_price,
+    low_price = EXCLUDED.low_price,
+    close_price = EXCLUDED.close_price,
+    volume = EXCLUDED.volume,
+    price_change = EXCLUDED.price_change,
+    price_change_percent = EXCLUDED.price_change_percent
+`
+
+type UpsertHistoricalBarParams struct {
+	Symbol             string         `json:"symbol"`
+	Timeframe          string         `json:"timeframe"`
+	Timestamp          time.Time      `json:"timestamp"`
+	OpenPrice          string         `json:"open_price"`
+	HighPrice          string         `json:"high_price"`
+	LowPrice           string         `json:"low_price"`
+	ClosePrice         string         `json:"close_price"`
+	Volume             int64          `json:"volume"`
+	PriceChange        sql.NullString `json:"price_change"`
+	PriceChangePercent sql.NullString `json:"price_change_percent"`
+}
+
+func (q *Queries) UpsertHistoricalBar(ctx context.Context, arg UpsertHistoricalBarParams) error {
+	_, err := q.db.ExecContext(ctx, upsertHistoricalBar,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.Timestamp,
+		arg.OpenPrice,
+		arg.HighPrice,
+		arg.LowPrice,
+		arg.ClosePrice,
+		arg.Volume,
+		arg.PriceChange,
+		arg.PriceChangePercent,
+	)
+	return err
+}
+
+const upsertPosition = `-- name: UpsertPosition :exec
+INSERT INTO positions (symbol, quantity, avg_entry_price, current_price, market_value, unrealized_pnl, updated_at, opened_at)
+VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
+ON CONFLICT (symbol) DO UPDATE SET
+    quantity = EXCLUDED.quantity,
+    avg_entry_price = EXCLUDED.avg_entry_price,
+    current_price = EXCLUDED.current_price,
+    market_value = EXCLUDED.market_value,
+    unrealized_pnl = EXCLUDED.unrealized_pnl,
+    updated_at = CURRENT_TIMESTAMP,
+    opened_at = EXCLUDED.opened_at
+`
+
+type UpsertPositionParams struct {
+	Symbol        string         `json:"symbol"`
+	Quantity      string         `json:"quantity"`
+	AvgEntryPrice string         `json:"avg_entry_price"`
+	CurrentPrice  sql.NullString `json:"current_price"`
+	MarketValue   sql.NullString `json:"market_value"`
+	UnrealizedPnl sql.NullString `json:"unrealized_pnl"`
+	OpenedAt      sql.NullTime   `json:"opened_at"`
+}
+
+func (q *Queries) UpsertPosition(ctx context.Context, arg UpsertPositionParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPosition,
+		arg.Symbol,
+		arg.Quantity,
+		arg.AvgEntryPrice,
+		arg.CurrentPrice,
+		arg.MarketValue,
+		arg.UnrealizedPnl,
+		arg.OpenedAt,
+	)
+	return err
+}
+
 const upsertScanLog = `-- name: UpsertScanLog :exec
 INSERT INTO scan_log (profile_name, last_scan_timestamp, next_scan_due, symbols_scanned)
 VALUES ($1, $2, $3, $4)
@@ -987,3 +1828,54 @@ func (q *Queries) UpsertScanLog(ctx context.Context, arg UpsertScanLogParams) er
 	)
 	return err
 }
+
+const upsertWhaleEvent = `-- name: UpsertWhaleEvent :exec
+INSERT INTO whale_events (
+    symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, change_1, change_5, change_20, held
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (symbol, timestamp) DO UPDATE SET
+    direction = EXCLUDED.direction,
+    volume = EXCLUDED.volume,
+    z_score = EXCLUDED.z_score,
+    close_price = EXCLUDED.close_price,
+    price_change = EXCLUDED.price_change,
+    conviction = EXCLUDED.conviction,
+    change_1 = COALESCE(EXCLUDED.change_1, whale_events.change_1),
+    change_5 = COALESCE(EXCLUDED.change_5, whale_events.change_5),
+    change_20 = COALESCE(EXCLUDED.change_20, whale_events.change_20),
+    held = COALESCE(EXCLUDED.held, whale_events.held)
+`
+
+type UpsertWhaleEventParams struct {
+	Symbol      string         `json:"symbol"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Direction   string         `json:"direction"`
+	Volume      int64          `json:"volume"`
+	ZScore      string         `json:"z_score"`
+	ClosePrice  string         `json:"close_price"`
+	PriceChange sql.NullString `json:"price_change"`
+	Conviction  string         `json:"conviction"`
+	Change1     sql.NullString `json:"change_1"`
+	Change5     sql.NullString `json:"change_5"`
+	Change20    sql.NullString `json:"change_20"`
+	Held        sql.NullBool   `json:"held"`
+}
+
+// Follow-through already measured is kept when a later window can't see that far
+func (q *Queries) UpsertWhaleEvent(ctx context.Context, arg UpsertWhaleEventParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWhaleEvent,
+		arg.Symbol,
+		arg.Timestamp,
+		arg.Direction,
+		arg.Volume,
+		arg.ZScore,
+		arg.ClosePrice,
+		arg.PriceChange,
+		arg.Conviction,
+		arg.Change1,
+		arg.Change5,
+		arg.Change20,
+		arg.Held,
+	)
+	return err
+}
@@ -0,0 +1,413 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+)
+
+// The conformance suite runs the same queries against every backend, so a query that
+// only works on one of them fails here rather than at runtime.
+
+func TestConformanceSQLite(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, Config{Backend: BackendSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	runConformance(t, store)
+}
+
+// TestConformancePostgres needs MONGELMAKER_TEST_DATABASE_URL (see the migrate tests);
+// it works in a temporary schema that is dropped afterwards.
+func TestConformancePostgres(t *testing.T) {
+	url := os.Getenv("MONGELMAKER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("MONGELMAKER_TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	store, err := Open(ctx, Config{Backend: BackendPostgres, DSN: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	// one connection, so the search_path below applies to every statement
+	store.DB.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("storage_test_%d", time.Now().UnixNano())
+	if _, err := store.DB.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer store.DB.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+	if _, err := store.DB.ExecContext(ctx, "SET search_path TO "+schema); err != nil {
+		t.Fatal(err)
+	}
+	runConformance(t, store)
+}
+
+func TestOpenUnknownBackend(t *testing.T) {
+	if _, err := Open(context.Background(), Config{Backend: "mysql"}); err == nil {
+		t.Fatal("expected an error for an unknown backend")
+	}
+}
+
+func runConformance(t *testing.T, store *Store) {
+	ctx := context.Background()
+	migrator, err := store.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrations failed on %s: %v", store.Backend, err)
+	}
+
+	q := store.Queries
+	now := time.Now().UTC().Truncate(time.Second)
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, q *database.Queries, now time.Time)
+	}{
+		{"watchlist", conformWatchlist},
+		{"scan log", conformScanLog},
+		{"indicators", conformIndicators},
+		{"whales", conformWhales},
+		{"news", conformNews},
+		{"skip lists", conformSkipLists},
+		{"historical bars", conformBars},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { tt.run(t, ctx, q, now) })
+	}
+
+	if _, err := migrator.Down(ctx); err != nil {
+		t.Errorf("rolling back on %s: %v", store.Backend, err)
+	}
+}
+
+func conformWatchlist(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	id, err := q.AddToWatchlist(ctx, database.AddToWatchlistParams{
+		Symbol: "AAPL", AssetType: "stock", Score: 7.5, Reason: sql.NullString{String: "breakout", Valid: true},
+	})
+	if err != nil || id == 0 {
+		t.Fatalf("AddToWatchlist() = %d, %v", id, err)
+	}
+	if _, err := q.AddToWatchlist(ctx, database.AddToWatchlistParams{Symbol: "MSFT", AssetType: "stock", Score: 9}); err != nil {
+		t.Fatal(err)
+	}
+	// an active symbol is not re-added
+	if _, err := q.AddToWatchlist(ctx, database.AddToWatchlistParams{Symbol: "AAPL", AssetType: "stock", Score: 1}); err != sql.ErrNoRows {
+		t.Errorf("re-adding an active symbol returned %v, want sql.ErrNoRows", err)
+	}
+
+	if err := q.UpdateWatchlistScore(ctx, database.UpdateWatchlistScoreParams{Score: 8, Symbol: "AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	item, err := q.GetWatchlistBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != id || item.Score != 8 || item.Reason.String != "breakout" || !item.AddedDate.Valid {
+		t.Errorf("unexpected watchlist item %+v", item)
+	}
+	if d := item.LastUpdated.Time.Sub(now); d < -time.Minute || d > time.Minute {
+		t.Errorf("last_updated %v is not close to %v", item.LastUpdated.Time, now)
+	}
+
+	list, err := q.GetWatchlist(ctx)
+	if err != nil || len(list) != 2 || list[0].Symbol != "MSFT" {
+		t.Fatalf("GetWatchlist() = %+v, %v", list, err)
+	}
+
+	for _, score := range []float32{7.5, 8} {
+		err := q.AddWatchlistHistory(ctx, database.AddWatchlistHistoryParams{
+			WatchlistID: id, NewScore: score, AnalysisData: sql.NullString{String: `{"rsi":55}`, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	count, err := q.CountWatchlistHistory(ctx, id)
+	if err != nil || count != 2 {
+		t.Errorf("CountWatchlistHistory() = %d, %v", count, err)
+	}
+	history, err := q.ListWatchlistHistory(ctx, database.ListWatchlistHistoryParams{WatchlistID: id, Limit: 10})
+	if err != nil || len(history) != 2 || history[0].AnalysisData.String != `{"rsi":55}` {
+		t.Errorf("ListWatchlistHistory() = %+v, %v", history, err)
+	}
+
+	// fresh entries are not archived
+	if err := q.ArchiveOldWatchlist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := q.RemoveFromWatchlist(ctx, "AAPL")
+	if err != nil || removed != 1 {
+		t.Fatalf("RemoveFromWatchlist() = %d, %v", removed, err)
+	}
+	if _, err := q.GetWatchlistBySymbol(ctx, "AAPL"); err != sql.ErrNoRows {
+		t.Errorf("removed symbol still listed: %v", err)
+	}
+	// an archived symbol comes back with the same id
+	again, err := q.AddToWatchlist(ctx, database.AddToWatchlistParams{Symbol: "AAPL", AssetType: "stock", Score: 6})
+	if err != nil || again != id {
+		t.Errorf("re-adding an archived symbol = %d, %v; want id %d", again, err, id)
+	}
+}
+
+func conformScanLog(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	for _, scanned := range []int32{10, 25} {
+		err := q.UpsertScanLog(ctx, database.UpsertScanLogParams{
+			ProfileName:       "swing",
+			LastScanTimestamp: now,
+			NextScanDue:       now.Add(4 * time.Hour),
+			SymbolsScanned:    sql.NullInt32{Int32: scanned, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entry, err := q.GetScanLog(ctx, "swing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.SymbolsScanned.Int32 != 25 || !entry.LastScanTimestamp.Equal(now) || !entry.NextScanDue.Equal(now.Add(4*time.Hour)) {
+		t.Errorf("unexpected scan log %+v", entry)
+	}
+	all, err := q.GetAllScanLogs(ctx)
+	if err != nil || len(all) != 1 {
+		t.Errorf("GetAllScanLogs() = %+v, %v", all, err)
+	}
+}
+
+func conformIndicators(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	for i := 0; i < 3; i++ {
+		at := now.Add(time.Duration(i-3) * 24 * time.Hour)
+		if err := q.SaveRSI(ctx, database.SaveRSIParams{Symbol: "AAPL", CalculationTimestamp: at, RsiValue: float32(40 + i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.SaveATR(ctx, database.SaveATRParams{Symbol: "AAPL", CalculationTimestamp: at, AtrValue: float32(2 + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// saving the same timestamp again updates the value
+	last := now.Add(-24 * time.Hour)
+	if err := q.SaveRSI(ctx, database.SaveRSIParams{Symbol: "AAPL", CalculationTimestamp: last, RsiValue: 70}); err != nil {
+		t.Fatal(err)
+	}
+
+	rsi, err := q.GetLatestRSI(ctx, "AAPL")
+	if err != nil || rsi.RsiValue != 70 || !rsi.CalculationTimestamp.Equal(last) {
+		t.Errorf("GetLatestRSI() = %+v, %v", rsi, err)
+	}
+	atr, err := q.GetATR(ctx, "AAPL")
+	if err != nil || atr.AtrValue != 4 {
+		t.Errorf("GetATR() = %+v, %v", atr, err)
+	}
+
+	rsiRange, err := q.GetRSIByTimestampRange(ctx, database.GetRSIByTimestampRangeParams{
+		Symbol: "AAPL", CalculationTimestamp: now.Add(-50 * time.Hour), CalculationTimestamp_2: now,
+	})
+	if err != nil || len(rsiRange) != 2 || rsiRange[0].RsiValue != 41 {
+		t.Errorf("GetRSIByTimestampRange() = %+v, %v", rsiRange, err)
+	}
+	atrRange, err := q.GetATRByTimestampRange(ctx, database.GetATRByTimestampRangeParams{
+		Symbol: "AAPL", CalculationTimestamp: now.Add(-72 * time.Hour), CalculationTimestamp_2: now,
+	})
+	if err != nil || len(atrRange) != 3 {
+		t.Errorf("GetATRByTimestampRange() = %+v, %v", atrRange, err)
+	}
+	recent, err := q.GetRSIForDateRange(ctx, database.GetRSIForDateRangeParams{Symbol: "AAPL", Limit: 2})
+	if err != nil || len(recent) != 2 {
+		t.Errorf("GetRSIForDateRange() = %+v, %v", recent, err)
+	}
+
+	err = q.SaveBollinger(ctx, database.SaveBollingerParams{
+		Symbol: "AAPL", CalculationDate: now, TimePeriod: 20,
+		AveragePrice: "150.0000", StandardDeviation: "2.5000", UpperBand: "155.0000", LowerBand: "145.0000",
+		CurrentPrice: "151.0000", DeviationFromAvg: "0.4000",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func conformWhales(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	events := []database.UpsertWhaleEventParams{
+		{Symbol: "TSLA", Timestamp: now.Add(-2 * time.Hour), Direction: "BUY", Volume: 900000, ZScore: "4.20", ClosePrice: "250.5000", Conviction: "HIGH"},
+		{Symbol: "TSLA", Timestamp: now.Add(-26 * time.Hour), Direction: "SELL", Volume: 500000, ZScore: "2.60", ClosePrice: "248.0000", Conviction: "MEDIUM"},
+		{Symbol: "TSLA", Timestamp: now.Add(-10 * 24 * time.Hour), Direction: "BUY", Volume: 800000, ZScore: "3.90", ClosePrice: "230.0000",
+			PriceChange: sql.NullString{String: "1.5000", Valid: true}, Conviction: "HIGH"},
+	}
+	for _, e := range events {
+		if err := q.UpsertWhaleEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a later scan measures follow-through; one after that can no longer see the 20-bar move
+	measured := events[2]
+	measured.Change5 = sql.NullString{String: "2.5000", Valid: true}
+	measured.Change20 = sql.NullString{String: "4.0000", Valid: true}
+	measured.Held = sql.NullBool{Bool: true, Valid: true}
+	rescanned := events[2]
+	rescanned.Change5 = sql.NullString{String: "2.7500", Valid: true}
+	for _, e := range []database.UpsertWhaleEventParams{measured, rescanned} {
+		if err := q.UpsertWhaleEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := q.CountWhaleEvents(ctx, "TSLA")
+	if err != nil || count != 3 {
+		t.Errorf("CountWhaleEvents() = %d, %v", count, err)
+	}
+	listed, err := q.ListWhaleEvents(ctx, database.ListWhaleEventsParams{Symbol: "TSLA", Limit: 2, Offset: 1})
+	if err != nil || len(listed) != 2 || listed[0].Direction != "SELL" {
+		t.Errorf("ListWhaleEvents() = %+v, %v", listed, err)
+	}
+	recent, err := q.GetWhaleEventsBySymbol(ctx, database.GetWhaleEventsBySymbolParams{Symbol: "TSLA", Limit: 10})
+	if err != nil || len(recent) != 2 {
+		t.Errorf("GetWhaleEventsBySymbol() should return the 2 events from the last 7 days, got %+v, %v", recent, err)
+	} else if !recent[0].Timestamp.Equal(events[0].Timestamp) || recent[0].Volume != 900000 {
+		t.Errorf("unexpected newest whale event %+v", recent[0])
+	}
+	high, err := q.GetHighConvictionWhales(ctx, "TSLA")
+	if err != nil || len(high) != 2 || high[0].ClosePrice != "250.5000" && high[0].ClosePrice != "250.5" {
+		t.Errorf("GetHighConvictionWhales() = %+v, %v", high, err)
+	}
+	oldest := listed[1]
+	if !strings.HasPrefix(oldest.Change5.String, "2.75") || !strings.HasPrefix(oldest.Change20.String, "4") || !oldest.Held.Valid || !oldest.Held.Bool || oldest.Change1.Valid {
+		t.Errorf("upsert should refresh the 5-bar change and keep the 20-bar one, got %+v", oldest)
+	}
+
+	alert := database.CreateWhaleAlertParams{Symbol: "TSLA", AlertType: "LARGE_BUY", Amount: "900000.0000", Price: "250.5000", AlertTimestamp: now.Add(-2 * time.Hour)}
+	for i := 0; i < 2; i++ {
+		if err := q.CreateWhaleAlert(ctx, alert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts, err := q.GetWhaleAlerts(ctx, database.GetWhaleAlertsParams{Symbol: "TSLA", Limit: 10})
+	if err != nil || len(alerts) != 1 || alerts[0].AlertType != "LARGE_BUY" {
+		t.Errorf("GetWhaleAlerts() = %+v, %v, want the alert once", alerts, err)
+	}
+}
+
+func conformNews(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	articles := []database.SaveNewsArticleParams{
+		{Symbol: "NVDA", Headline: "Chips up", Url: "https://example.com/1", PublishedAt: now.Add(-time.Hour), Source: sql.NullString{String: "wire", Valid: true}},
+		{Symbol: "NVDA", Headline: "Old news", Url: "https://example.com/2", PublishedAt: now.Add(-30 * 24 * time.Hour)},
+		{Symbol: "AMD", Headline: "Rival", Url: "https://example.com/3", PublishedAt: now.Add(-2 * time.Hour)},
+	}
+	for _, a := range articles {
+		if err := q.SaveNewsArticle(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// duplicate urls are ignored
+	if err := q.SaveNewsArticle(ctx, articles[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := q.CountNewsArticles(ctx, "NVDA")
+	if err != nil || count != 2 {
+		t.Errorf("CountNewsArticles() = %d, %v", count, err)
+	}
+	latest, err := q.GetLatestNews(ctx, database.GetLatestNewsParams{Symbol: "NVDA", Limit: 1})
+	if err != nil || len(latest) != 1 || latest[0].Headline != "Chips up" || latest[0].Source.String != "wire" {
+		t.Errorf("GetLatestNews() = %+v, %v", latest, err)
+	}
+	week, err := q.GetNewsBySymbol(ctx, "NVDA")
+	if err != nil || len(week) != 1 {
+		t.Errorf("GetNewsBySymbol() should skip news older than 7 days, got %+v, %v", week, err)
+	}
+	page, err := q.ListNewsArticles(ctx, database.ListNewsArticlesParams{Symbol: "NVDA", Limit: 10, Offset: 1})
+	if err != nil || len(page) != 1 || page[0].Headline != "Old news" {
+		t.Errorf("ListNewsArticles() = %+v, %v", page, err)
+	}
+	screener, err := q.GetNewsForScreener(ctx, []string{"NVDA", "AMD"})
+	if err != nil || len(screener) != 3 || screener[0].Headline != "Chips up" {
+		t.Errorf("GetNewsForScreener() = %+v, %v", screener, err)
+	}
+	none, err := q.GetNewsForScreener(ctx, nil)
+	if err != nil || len(none) != 0 {
+		t.Errorf("GetNewsForScreener(nil) = %+v, %v", none, err)
+	}
+}
+
+func conformSkipLists(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	err := q.AddToScoutSkipList(ctx, database.AddToScoutSkipListParams{
+		Symbol: "GME", ProfileName: "swing", AssetType: "stock", Reason: sql.NullString{String: "low score", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped, err := q.IsSymbolSkipped(ctx, database.IsSymbolSkippedParams{Symbol: "GME", ProfileName: "swing"})
+	if err != nil || !skipped {
+		t.Errorf("IsSymbolSkipped(GME, swing) = %v, %v", skipped, err)
+	}
+	skipped, err = q.IsSymbolSkipped(ctx, database.IsSymbolSkippedParams{Symbol: "GME", ProfileName: "day"})
+	if err != nil || skipped {
+		t.Errorf("IsSymbolSkipped(GME, day) = %v, %v", skipped, err)
+	}
+
+	if err := q.SkipSymbol(ctx, database.SkipSymbolParams{Symbol: "AMC", AssetType: "stock"}); err != nil {
+		t.Fatal(err)
+	}
+	// the recheck is 30 days out, so nothing is due yet
+	due, err := q.GetRecheckableSymbols(ctx)
+	if err != nil || len(due) != 0 {
+		t.Errorf("GetRecheckableSymbols() = %+v, %v", due, err)
+	}
+	if err := q.RemoveFromSkipBacklog(ctx, "AMC"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func conformBars(t *testing.T, ctx context.Context, q *database.Queries, now time.Time) {
+	day := now.Truncate(24 * time.Hour)
+	for i := 0; i < 5; i++ {
+		err := q.UpsertHistoricalBar(ctx, database.UpsertHistoricalBarParams{
+			Symbol: "SPY", Timeframe: "1Day", Timestamp: day.Add(time.Duration(i-5) * 24 * time.Hour),
+			OpenPrice: "500.0000", HighPrice: "505.0000", LowPrice: "495.0000",
+			ClosePrice: fmt.Sprintf("%d.0000", 500+i), Volume: int64(1000 * (i + 1)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// upserting the same bar replaces it
+	err := q.UpsertHistoricalBar(ctx, database.UpsertHistoricalBarParams{
+		Symbol: "SPY", Timeframe: "1Day", Timestamp: day.Add(-24 * time.Hour),
+		OpenPrice: "500.0000", HighPrice: "510.0000", LowPrice: "495.0000", ClosePrice: "509.0000", Volume: 9999,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bars, err := q.GetHistoricalBars(ctx, database.GetHistoricalBarsParams{
+		Symbol: "SPY", Timeframe: "1Day", Timestamp: day.Add(-3 * 24 * time.Hour), Timestamp_2: day,
+	})
+	if err != nil || len(bars) != 3 {
+		t.Fatalf("GetHistoricalBars() = %+v, %v", bars, err)
+	}
+	if !bars[0].Timestamp.Equal(day.Add(-3*24*time.Hour)) || bars[2].Volume != 9999 {
+		t.Errorf("unexpected bars %+v", bars)
+	}
+	recent, err := q.GetRecentHistoricalBars(ctx, database.GetRecentHistoricalBarsParams{Symbol: "SPY", Timeframe: "1Day", Limit: 2})
+	if err != nil || len(recent) != 2 {
+		t.Errorf("GetRecentHistoricalBars() = %+v, %v", recent, err)
+	}
+	closes, err := q.GetClosingPrices(ctx, database.GetClosingPricesParams{Symbol: "SPY", Timeframe: "1Day", Limit: 10})
+	if err != nil || len(closes) != 5 {
+		t.Errorf("GetClosingPrices() = %+v, %v", closes, err)
+	}
+	symbols, err := q.ListHistoricalBarSymbols(ctx)
+	if err != nil || len(symbols) != 1 || symbols[0] != "SPY" {
+		t.Errorf("ListHistoricalBarSymbols() = %v, %v", symbols, err)
+	}
+}
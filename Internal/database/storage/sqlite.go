@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// openSQLite opens (creating if needed) a SQLite file; ":memory:" gives a private in-memory database
+func openSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		path = DefaultSQLitePath
+	}
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// times are written as "2006-01-02 15:04:05.999999999-07:00" text, which SQLite's date functions understand
+	params := url.Values{}
+	params.Set("_time_format", "sqlite")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// one writer at a time is all SQLite allows, and an in-memory database lives in a single connection
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// sqliteDB runs the Postgres-flavoured sqlc queries on SQLite. Most statements work as
+// written ($N parameters, ON CONFLICT, RETURNING); the ones using NOW(), INTERVAL or
+// arrays are swapped for the versions in sqliteQueries. Times are stored in UTC so
+// text comparisons order them correctly.
+type sqliteDB struct {
+	db database.DBTX
+}
+
+func (s sqliteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
+
+func (s sqliteDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.db.PrepareContext(ctx, sqliteQuery(query))
+}
+
+func (s sqliteDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
+
+func (s sqliteDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
+
+// BeginQueriesTx lets repository.InTx run the rewritten queries inside a transaction
+func (s sqliteDB) BeginQueriesTx(ctx context.Context) (*sql.Tx, database.DBTX, error) {
+	db, ok := s.db.(*sql.DB)
+	if !ok {
+		return nil, nil, fmt.Errorf("sqlite connection cannot begin a transaction")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, sqliteDB{tx}, nil
+}
+
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+func sqliteQuery(query string) string {
+	if match := queryName.FindStringSubmatch(query); match != nil {
+		if rewritten, ok := sqliteQueries[match[1]]; ok {
+			return rewritten
+		}
+	}
+	return query
+}
+
+func sqliteArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				converted[i] = v.Time.UTC()
+			}
+		case *pq.StringArray:
+			converted[i] = jsonArray([]string(*v))
+		case pq.StringArray:
+			converted[i] = jsonArray([]string(v))
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// jsonArray encodes an array parameter for json_each
+func jsonArray(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+// sqliteQueries replaces queries by sqlc name; each must return the same columns in the same order
+var sqliteQueries = map[string]string{
+	"GetNewsForScreener": `SELECT id, symbol, headline, url, published_at, source, sentiment, created_at
+FROM news_articles
+WHERE symbol IN (SELECT value FROM json_each($1))
+ORDER BY published_at DESC`,
+
+	"GetNewsBySymbol": `SELECT id, symbol, headline, url, published_at, source, sentiment, created_at
+FROM news_articles
+WHERE symbol = $1
+AND datetime(published_at) > datetime('now', '-7 days')
+ORDER BY published_at DESC`,
+
+	"GetWhaleEventsBySymbol": `SELECT id, symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, created_at, change_1, change_5, change_20, held
+FROM whale_events
+WHERE symbol = $1 AND datetime(timestamp) > datetime('now', '-7 days')
+ORDER BY timestamp DESC
+LIMIT $2`,
+
+	"ArchiveOldWatchlist": `UPDATE watchlist
+SET status = 'archived'
+WHERE id IN (
+  SELECT w.id FROM watchlist w
+  WHERE w.status = 'active'
+  AND datetime(w.last_updated) < datetime('now', '-30 days')
+)`,
+
+	"SkipSymbol": `INSERT INTO skip_backlog (symbol, asset_type, reason, timestamp, recheck_after)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, datetime('now', '+30 days'))`,
+
+	"GetRecheckableSymbols": `SELECT symbol, asset_type, reason FROM skip_backlog
+WHERE datetime(recheck_after) <= datetime('now')`,
+
+	"AddToScoutSkipList": `INSERT INTO scout_skip_list (symbol, profile_name, asset_type, reason, recheck_after)
+VALUES ($1, $2, $3, $4, datetime('now', '+2 days'))`,
+
+	"IsSymbolSkipped": `SELECT COUNT(*) > 0 as is_skipped
+FROM scout_skip_list
+WHERE symbol = $1
+  AND profile_name = $2
+  AND datetime(recheck_after) > datetime('now')`,
+}
@@ -0,0 +1,111 @@
+// Package storage opens the database behind the sqlc queries: Postgres, or an embedded
+// SQLite file for laptops and tests. Both backends hand out the same *database.Queries,
+// so the watchlist, scan log, indicator, whale and news code doesn't know which it has.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/fazecat/mongelmaker/Internal/database/migrate"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/sql/migrations"
+	sqlitemigrations "github.com/fazecat/mongelmaker/Internal/sql/migrations/sqlite"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	_ "github.com/lib/pq"
+)
+
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+
+	DefaultSQLitePath = "data/mongelmaker.db"
+)
+
+// Config picks the backend. DSN is a Postgres connection string or the SQLite file path.
+type Config struct {
+	Backend string
+	DSN     string
+}
+
+// ConfigFromConfig reads database.backend; Postgres connects with the DB_* variables
+func ConfigFromConfig(cfg *config.Config) Config {
+	if cfg != nil && cfg.Database.Backend == BackendSQLite {
+		path := cfg.Database.SQLitePath
+		if path == "" {
+			path = DefaultSQLitePath
+		}
+		return Config{Backend: BackendSQLite, DSN: path}
+	}
+	return Config{Backend: BackendPostgres, DSN: PostgresDSNFromEnv()}
+}
+
+// PostgresDSNFromEnv builds the connection string from DB_HOST, DB_PORT, DB_USER,
+// DB_PASSWORD (required, no default), DB_NAME and DB_SSLMODE
+func PostgresDSNFromEnv() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		getEnvOrDefault("DB_HOST", "localhost"),
+		getEnvOrDefault("DB_PORT", "5432"),
+		getEnvOrDefault("DB_USER", "postgres"),
+		os.Getenv("DB_PASSWORD"),
+		getEnvOrDefault("DB_NAME", "mongelmaker"),
+		getEnvOrDefault("DB_SSLMODE", "disable"))
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Store is an open database and the queries bound to it
+type Store struct {
+	Backend string
+	DB      *sql.DB
+	Conn    database.DBTX // the connection Queries runs on; repository.FromDB needs it to open transactions
+	Queries *database.Queries
+}
+
+// Open connects to the configured backend and checks the connection
+func Open(ctx context.Context, c Config) (*Store, error) {
+	switch c.Backend {
+	case "", BackendPostgres:
+		db, err := sql.Open("postgres", c.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database connection: %w", err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database: %w", err)
+		}
+		return &Store{Backend: BackendPostgres, DB: db, Conn: db, Queries: database.New(db)}, nil
+	case BackendSQLite:
+		db, err := openSQLite(ctx, c.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return &Store{Backend: BackendSQLite, DB: db, Conn: sqliteDB{db}, Queries: database.New(sqliteDB{db})}, nil
+	default:
+		return nil, fmt.Errorf("unknown database backend %q (expected postgres or sqlite)", c.Backend)
+	}
+}
+
+// Migrations are the embedded schema migrations for the store's backend
+func (s *Store) Migrations() fs.FS {
+	if s.Backend == BackendSQLite {
+		return sqlitemigrations.FS
+	}
+	return migrations.FS
+}
+
+func (s *Store) Migrator() (*migrate.Migrator, error) {
+	return migrate.New(s.DB, s.Migrations())
+}
+
+func (s *Store) Close() error {
+	return s.DB.Close()
+}
@@ -4,11 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
 )
 
-func AddToWatchlist(ctx context.Context, q *database.Queries, symbol string, assetType string, score float64, reason string) (int32, error) {
+// Store is the part of *database.Queries the watchlist reads and writes, so the API can be tested with a fake
+type Store interface {
+	AddToWatchlist(ctx context.Context, arg database.AddToWatchlistParams) (int32, error)
+	GetWatchlist(ctx context.Context) ([]database.GetWatchlistRow, error)
+	GetWatchlistBySymbol(ctx context.Context, symbol string) (database.GetWatchlistBySymbolRow, error)
+	UpdateWatchlistScore(ctx context.Context, arg database.UpdateWatchlistScoreParams) error
+	AddWatchlistHistory(ctx context.Context, arg database.AddWatchlistHistoryParams) error
+	RemoveFromWatchlist(ctx context.Context, symbol string) (int64, error)
+}
+
+var _ Store = (*database.Queries)(nil)
+
+var ErrAlreadyOnWatchlist = errors.New("already on the watchlist")
+
+func AddToWatchlist(ctx context.Context, q Store, symbol string, assetType string, score float64, reason string) (int32, error) {
 	params := database.AddToWatchlistParams{
 		Symbol:    symbol,
 		AssetType: assetType,
@@ -17,6 +33,10 @@ func AddToWatchlist(ctx context.Context, q *database.Queries, symbol string, ass
 	}
 
 	id, err := q.AddToWatchlist(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the upsert only touches archived rows, so an active symbol returns nothing
+		return 0, fmt.Errorf("%s is %w", symbol, ErrAlreadyOnWatchlist)
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -24,7 +44,16 @@ func AddToWatchlist(ctx context.Context, q *database.Queries, symbol string, ass
 	return id, nil
 }
 
-func UpdateWatchlistScoreWithHistory(ctx context.Context, q *database.Queries, symbol string, newScore float64, reason string, analysisData map[string]interface{}) error {
+// RemoveFromWatchlist archives symbol; false means it wasn't on the active watchlist
+func RemoveFromWatchlist(ctx context.Context, q Store, symbol string) (bool, error) {
+	removed, err := q.RemoveFromWatchlist(ctx, symbol)
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+func UpdateWatchlistScoreWithHistory(ctx context.Context, q Store, symbol string, newScore float64, reason string, analysisData map[string]interface{}) error {
 	//Get current watchlist item by symbol
 	watchlistItem, err := q.GetWatchlistBySymbol(ctx, symbol)
 	if err != nil {
@@ -57,7 +86,7 @@ func UpdateWatchlistScoreWithHistory(ctx context.Context, q *database.Queries, s
 	return nil
 }
 
-func GetWatchlist(ctx context.Context, q *database.Queries) ([]database.GetWatchlistRow, error) {
+func GetWatchlist(ctx context.Context, q Store) ([]database.GetWatchlistRow, error) {
 	getwatchlist, err := q.GetWatchlist(ctx)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,164 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// Broker places and tracks orders. AlpacaBroker talks to the paper/live API,
+// SimulatedBroker fills locally for dry runs and tests.
+type Broker interface {
+	Name() string
+	SubmitOrder(ctx context.Context, req OrderRequest) (*Order, error)
+	GetOrder(ctx context.Context, orderID string) (*Order, error)
+	CancelOrder(ctx context.Context, orderID string) error
+}
+
+type AlpacaBroker struct {
+	client *alpaca.Client
+}
+
+// creates a broker on top of an already configured Alpaca trading client
+func NewAlpacaBroker(client *alpaca.Client) (*AlpacaBroker, error) {
+	if client == nil {
+		return nil, fmt.Errorf("alpaca client is nil - create one with NewAlpacaTradingClient()")
+	}
+	return &AlpacaBroker{client: client}, nil
+}
+
+func (b *AlpacaBroker) Name() string {
+	return "alpaca"
+}
+
+func (b *AlpacaBroker) SubmitOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	qty := decimal.NewFromFloat(req.Quantity)
+	tif := alpaca.Day
+	if strings.EqualFold(req.TimeInForce, "gtc") {
+		tif = alpaca.GTC
+	}
+
+	placeReq := alpaca.PlaceOrderRequest{
+		Symbol:        req.Symbol,
+		Qty:           &qty,
+		Side:          alpaca.Buy,
+		Type:          alpaca.Market,
+		TimeInForce:   tif,
+		ClientOrderID: req.ClientOrderID,
+	}
+	if req.Side == Sell {
+		placeReq.Side = alpaca.Sell
+	}
+
+	switch req.Type {
+	case LimitOrder:
+		placeReq.Type = alpaca.Limit
+		placeReq.LimitPrice = decimalPtr(req.LimitPrice)
+	case StopOrder:
+		placeReq.Type = alpaca.Stop
+		placeReq.StopPrice = decimalPtr(req.StopPrice)
+	case BracketOrder:
+		placeReq.OrderClass = alpaca.Bracket
+		if req.LimitPrice > 0 {
+			placeReq.Type = alpaca.Limit
+			placeReq.LimitPrice = decimalPtr(req.LimitPrice)
+		}
+		placeReq.TakeProfit = &alpaca.TakeProfit{LimitPrice: decimalPtr(req.TakeProfit)}
+		placeReq.StopLoss = &alpaca.StopLoss{StopPrice: decimalPtr(req.StopLoss)}
+	}
+
+	order, err := b.client.PlaceOrder(placeReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place %s order for %s: %w", req.Type, req.Symbol, err)
+	}
+	converted := fromAlpacaOrder(*order)
+	if req.Type == BracketOrder {
+		converted.Type = BracketOrder
+	}
+	return &converted, nil
+}
+
+func (b *AlpacaBroker) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	order, err := b.client.GetOrder(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %s: %w", orderID, err)
+	}
+	converted := fromAlpacaOrder(*order)
+	return &converted, nil
+}
+
+func (b *AlpacaBroker) CancelOrder(ctx context.Context, orderID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := b.client.CancelOrder(orderID); err != nil {
+		return fmt.Errorf("failed to cancel order %s: %w", orderID, err)
+	}
+	return nil
+}
+
+func decimalPtr(value float64) *decimal.Decimal {
+	d := decimal.NewFromFloat(value).Round(2)
+	return &d
+}
+
+func decimalValue(d *decimal.Decimal) float64 {
+	if d == nil {
+		return 0
+	}
+	return d.InexactFloat64()
+}
+
+func fromAlpacaOrder(o alpaca.Order) Order {
+	order := Order{
+		ID:             o.ID,
+		ClientOrderID:  o.ClientOrderID,
+		Symbol:         o.Symbol,
+		Side:           Buy,
+		Type:           OrderType(o.Type),
+		Quantity:       decimalValue(o.Qty),
+		LimitPrice:     decimalValue(o.LimitPrice),
+		StopPrice:      decimalValue(o.StopPrice),
+		Status:         alpacaStatus(o.Status),
+		FilledQty:      o.FilledQty.InexactFloat64(),
+		FilledAvgPrice: decimalValue(o.FilledAvgPrice),
+		SubmittedAt:    o.SubmittedAt,
+		FilledAt:       o.FilledAt,
+	}
+	if o.Side == alpaca.Sell {
+		order.Side = Sell
+	}
+	for _, leg := range o.Legs {
+		order.Legs = append(order.Legs, fromAlpacaOrder(leg))
+	}
+	return order
+}
+
+// alpacaStatus collapses Alpaca's order lifecycle into the statuses stored in trades
+func alpacaStatus(status string) OrderStatus {
+	switch status {
+	case "filled":
+		return StatusFilled
+	case "partially_filled":
+		return StatusPartiallyFilled
+	case "canceled", "expired", "replaced", "done_for_day":
+		return StatusCancelled
+	case "rejected", "suspended":
+		return StatusRejected
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,263 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"testing"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/risk"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+)
+
+type memStore struct {
+	signals   map[int32]bool
+	trades    []database.Trade
+	positions map[string]database.Position
+}
+
+func newMemStore() *memStore {
+	return &memStore{signals: map[int32]bool{}, positions: map[string]database.Position{}}
+}
+
+func (m *memStore) CreateSignal(ctx context.Context, arg database.CreateSignalParams) (int32, error) {
+	id := int32(len(m.signals) + 1)
+	m.signals[id] = false
+	return id, nil
+}
+
+func (m *memStore) MarkSignalExecuted(ctx context.Context, id int32) error {
+	m.signals[id] = true
+	return nil
+}
+
+func (m *memStore) CreateTrade(ctx context.Context, arg database.CreateTradeParams) (int32, error) {
+	id := int32(len(m.trades) + 1)
+	m.trades = append(m.trades, database.Trade{
+		ID: id, SignalID: arg.SignalID, Symbol: arg.Symbol, Side: arg.Side, Quantity: arg.Quantity,
+		Price: arg.Price, TotalValue: arg.TotalValue, Commission: arg.Commission,
+		AlpacaOrderID: arg.AlpacaOrderID, Status: arg.Status, FilledAt: arg.FilledAt,
+	})
+	return id, nil
+}
+
+func (m *memStore) UpdateTradeFill(ctx context.Context, arg database.UpdateTradeFillParams) error {
+	t := &m.trades[arg.ID-1]
+	t.Status, t.Quantity, t.Price, t.TotalValue, t.FilledAt = arg.Status, arg.Quantity, arg.Price, arg.TotalValue, arg.FilledAt
+	return nil
+}
+
+func (m *memStore) GetPendingTrades(ctx context.Context) ([]database.Trade, error) {
+	pending := []database.Trade{}
+	for _, t := range m.trades {
+		if t.Status.String == string(StatusPending) || t.Status.String == string(StatusPartiallyFilled) {
+			pending = append(pending, t)
+		}
+	}
+	return pending, nil
+}
+
+func (m *memStore) GetPosition(ctx context.Context, symbol string) (database.Position, error) {
+	p, ok := m.positions[symbol]
+	if !ok {
+		return database.Position{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (m *memStore) GetPositions(ctx context.Context) ([]database.Position, error) {
+	positions := []database.Position{}
+	for _, p := range m.positions {
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+
+func (m *memStore) UpsertPosition(ctx context.Context, arg database.UpsertPositionParams) error {
+	m.positions[arg.Symbol] = database.Position{
+		Symbol: arg.Symbol, Quantity: arg.Quantity, AvgEntryPrice: arg.AvgEntryPrice,
+		CurrentPrice: arg.CurrentPrice, MarketValue: arg.MarketValue, UnrealizedPnl: arg.UnrealizedPnl,
+		OpenedAt: arg.OpenedAt,
+	}
+	return nil
+}
+
+func (m *memStore) DeletePosition(ctx context.Context, symbol string) error {
+	delete(m.positions, symbol)
+	return nil
+}
+
+func (m *memStore) quantity(symbol string) float64 {
+	q, _ := strconv.ParseFloat(m.positions[symbol].Quantity, 64)
+	return q
+}
+
+type priceBoard map[string]float64
+
+func (p priceBoard) price(ctx context.Context, symbol string) (float64, error) {
+	return p[symbol], nil
+}
+
+func TestMarketBuyThenSellKeepsPositionsInSync(t *testing.T) {
+	prices := priceBoard{"AAPL": 100}
+	store := newMemStore()
+	cfg := DefaultConfig()
+	cfg.PositionSizeUSD = 1050
+	exec := NewExecutor(NewSimulatedBroker(prices.price), store, cfg)
+	ctx := context.Background()
+
+	buy, err := exec.ExecuteCombinedSignal(ctx, "AAPL", strategy.CombinedSignal{Recommendation: "BUY", Confidence: 60}, 100)
+	if err != nil {
+		t.Fatalf("buy failed: %v", err)
+	}
+	if buy.Order.Status != StatusFilled || buy.Order.FilledQty != 10 {
+		t.Fatalf("expected 10 shares filled, got %+v", buy.Order)
+	}
+	if !store.signals[buy.SignalID] {
+		t.Error("signal should be marked executed")
+	}
+	if store.trades[0].AlpacaOrderID.String != buy.Order.ID || store.trades[0].Status.String != "FILLED" {
+		t.Errorf("trade not recorded with order id and status: %+v", store.trades[0])
+	}
+	if store.quantity("AAPL") != 10 {
+		t.Errorf("position quantity = %v, want 10", store.quantity("AAPL"))
+	}
+	if !store.positions["AAPL"].OpenedAt.Valid {
+		t.Error("a new position should record when it was opened")
+	}
+
+	prices["AAPL"] = 110
+	if _, err := exec.ExecuteCombinedSignal(ctx, "AAPL", strategy.CombinedSignal{Recommendation: "DISTRIBUTE", Confidence: 30}, 110); err != nil {
+		t.Fatalf("sell failed: %v", err)
+	}
+	if _, ok := store.positions["AAPL"]; ok {
+		t.Errorf("position should be closed, got %+v", store.positions["AAPL"])
+	}
+}
+
+func TestSellWithoutPositionIsRejected(t *testing.T) {
+	exec := NewExecutor(NewSimulatedBroker(priceBoard{"AAPL": 100}.price), newMemStore(), DefaultConfig())
+
+	_, err := exec.ExecuteCombinedSignal(context.Background(), "AAPL", strategy.CombinedSignal{Recommendation: "SELL", Confidence: 80}, 100)
+	if !errors.Is(err, ErrNoPosition) {
+		t.Errorf("expected ErrNoPosition, got %v", err)
+	}
+
+	_, err = exec.ExecuteCombinedSignal(context.Background(), "AAPL", strategy.CombinedSignal{Recommendation: "WAIT"}, 100)
+	if !errors.Is(err, ErrNotActionable) {
+		t.Errorf("expected ErrNotActionable, got %v", err)
+	}
+}
+
+func TestLimitOrderFillsOnSync(t *testing.T) {
+	prices := priceBoard{"MSFT": 200}
+	store := newMemStore()
+	cfg := DefaultConfig()
+	cfg.OrderType = LimitOrder
+	cfg.LimitOffsetPct = -1 // bid 1% under the market
+	exec := NewExecutor(NewSimulatedBroker(prices.price), store, cfg)
+	ctx := context.Background()
+
+	result, err := exec.ExecuteTradeSignal(ctx, "MSFT", &strategy.TradeSignal{Direction: "LONG", Confidence: 90}, 200)
+	if err != nil {
+		t.Fatalf("limit order failed: %v", err)
+	}
+	if result.Order.Status != StatusPending || result.Order.LimitPrice != 198 {
+		t.Fatalf("expected resting limit at 198, got %+v", result.Order)
+	}
+	if _, ok := store.positions["MSFT"]; ok {
+		t.Fatal("pending order must not create a position")
+	}
+
+	if n, err := exec.SyncFills(ctx); err != nil || n != 0 {
+		t.Fatalf("nothing should settle above the limit, got %d, %v", n, err)
+	}
+
+	prices["MSFT"] = 197
+	if n, err := exec.SyncFills(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 settled trade, got %d, %v", n, err)
+	}
+	if store.trades[0].Status.String != "FILLED" || store.trades[0].Price != "197.0000" {
+		t.Errorf("trade not updated with fill: %+v", store.trades[0])
+	}
+	if store.quantity("MSFT") != 5 {
+		t.Errorf("position quantity = %v, want 5", store.quantity("MSFT"))
+	}
+}
+
+func TestBracketStopLossClosesPosition(t *testing.T) {
+	prices := priceBoard{"TSLA": 250}
+	store := newMemStore()
+	cfg := DefaultConfig()
+	cfg.OrderType = BracketOrder
+	exec := NewExecutor(NewSimulatedBroker(prices.price), store, cfg)
+	ctx := context.Background()
+
+	result, err := exec.ExecuteCombinedSignal(ctx, "TSLA", strategy.CombinedSignal{Recommendation: "BUY", Confidence: 70}, 250)
+	if err != nil {
+		t.Fatalf("bracket order failed: %v", err)
+	}
+	if len(result.Order.Legs) != 2 || len(result.TradeIDs) != 3 {
+		t.Fatalf("expected entry plus two legs, got %d legs and %d trades", len(result.Order.Legs), len(result.TradeIDs))
+	}
+	if store.quantity("TSLA") != 4 {
+		t.Fatalf("position quantity = %v, want 4", store.quantity("TSLA"))
+	}
+
+	prices["TSLA"] = 240 // below the 245 stop
+	if _, err := exec.SyncFills(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if _, ok := store.positions["TSLA"]; ok {
+		t.Errorf("stop loss should have closed the position")
+	}
+
+	statuses := map[string]int{}
+	for _, trade := range store.trades[1:] {
+		statuses[trade.Status.String]++
+	}
+	if statuses["FILLED"] != 1 || statuses["CANCELLED"] != 1 {
+		t.Errorf("expected one filled and one cancelled leg, got %v", statuses)
+	}
+}
+
+func TestOrderRequestValidation(t *testing.T) {
+	bad := []OrderRequest{
+		{Symbol: "", Side: Buy, Type: MarketOrder, Quantity: 1},
+		{Symbol: "A", Side: Buy, Type: MarketOrder, Quantity: 0},
+		{Symbol: "A", Side: Buy, Type: LimitOrder, Quantity: 1},
+		{Symbol: "A", Side: Buy, Type: BracketOrder, Quantity: 1, TakeProfit: 90, StopLoss: 95},
+	}
+	for i, req := range bad {
+		if err := req.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error", i)
+		}
+	}
+}
+
+func TestRiskManagerTrimsAndRejectsEntries(t *testing.T) {
+	store := newMemStore()
+	cfg := DefaultConfig()
+	cfg.PositionSizeUSD = 5000
+	exec := NewExecutor(NewSimulatedBroker(priceBoard{"AMD": 100, "INTC": 100}.price), store, cfg)
+
+	limits := risk.DefaultLimits()
+	limits.MaxPositionPct = 2 // $200 of a $10k account
+	limits.MaxOpenPositions = 1
+	exec.SetRiskManager(risk.NewManager(limits, risk.StorePortfolio(store, 10000, 0, nil, nil), nil))
+
+	result, err := exec.ExecuteCombinedSignal(context.Background(), "AMD", strategy.CombinedSignal{Recommendation: "BUY", Confidence: 50}, 100)
+	if err != nil {
+		t.Fatalf("expected trimmed order, got %v", err)
+	}
+	if result.Risk == nil || result.Risk.Action != risk.Trim || result.Order.Quantity != 2 {
+		t.Errorf("expected order trimmed to 2 shares, got %+v / %+v", result.Risk, result.Order)
+	}
+
+	_, err = exec.ExecuteCombinedSignal(context.Background(), "INTC", strategy.CombinedSignal{Recommendation: "BUY", Confidence: 50}, 100)
+	if !errors.Is(err, ErrRiskRejected) {
+		t.Errorf("second symbol should hit the open position limit, got %v", err)
+	}
+}
@@ -0,0 +1,473 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/risk"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+var (
+	ErrNotActionable = errors.New("signal is not actionable")
+	ErrNoPosition    = errors.New("no open position to sell")
+	ErrLowConfidence = errors.New("signal confidence below minimum")
+	ErrOrderNotFound = errors.New("order not found")
+	ErrRiskRejected  = errors.New("rejected by risk manager")
+)
+
+type Config struct {
+	DryRun          bool
+	OrderType       OrderType
+	PositionSizeUSD float64
+	LimitOffsetPct  float64
+	StopLossPct     float64
+	TakeProfitPct   float64
+	TimeInForce     string
+	MinConfidence   float64
+	AllowShorts     bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		DryRun:          true,
+		OrderType:       MarketOrder,
+		PositionSizeUSD: 1000,
+		StopLossPct:     2,
+		TakeProfitPct:   4,
+		TimeInForce:     "day",
+	}
+}
+
+// ConfigFromSettings maps the execution section of config.yaml onto Config
+func ConfigFromSettings(cfg *config.Config) Config {
+	c := DefaultConfig()
+	if cfg == nil {
+		return c
+	}
+	settings := cfg.Execution
+	c.DryRun = settings.DryRun
+	c.AllowShorts = cfg.Features.EnableShortSignals
+	if settings.OrderType != "" {
+		c.OrderType = OrderType(strings.ToLower(settings.OrderType))
+	}
+	if settings.PositionSizeUSD > 0 {
+		c.PositionSizeUSD = settings.PositionSizeUSD
+	}
+	if settings.StopLossPct > 0 {
+		c.StopLossPct = settings.StopLossPct
+	}
+	if settings.TakeProfitPct > 0 {
+		c.TakeProfitPct = settings.TakeProfitPct
+	}
+	if settings.TimeInForce != "" {
+		c.TimeInForce = settings.TimeInForce
+	}
+	c.LimitOffsetPct = settings.LimitOffsetPct
+	c.MinConfidence = settings.MinConfidence
+	return c
+}
+
+// NewBroker returns a simulated broker in dry-run mode, otherwise the Alpaca paper/live client
+func NewBroker(cfg Config) (Broker, error) {
+	if cfg.DryRun {
+		return NewSimulatedBroker(QuotePrice), nil
+	}
+	client, err := datafeed.NewAlpacaTradingClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewAlpacaBroker(client)
+}
+
+type Executor struct {
+	broker Broker
+	store  TradeStore
+	cfg    Config
+	risk   *risk.Manager
+}
+
+// Execution is what was submitted for one signal: the entry and any bracket legs
+type Execution struct {
+	SignalID int32
+	TradeIDs []int32
+	Order    *Order
+	Risk     *risk.Decision // nil when no risk manager is attached or the order only reduces a position
+}
+
+func NewExecutor(broker Broker, store TradeStore, cfg Config) *Executor {
+	return &Executor{broker: broker, store: store, cfg: cfg}
+}
+
+func (e *Executor) Broker() Broker {
+	return e.broker
+}
+
+// SetRiskManager makes every new entry pass the manager's rules before it is submitted
+func (e *Executor) SetRiskManager(m *risk.Manager) {
+	e.risk = m
+}
+
+// ExecuteCombinedSignal turns a BUY/ACCUMULATE into an entry and a SELL/DISTRIBUTE into an
+// exit of the current long (or a short entry when shorts are enabled). price is the reference
+// price used for sizing, limits and bracket levels.
+func (e *Executor) ExecuteCombinedSignal(ctx context.Context, symbol string, signal strategy.CombinedSignal, price float64) (*Execution, error) {
+	switch signal.Recommendation {
+	case "BUY", "ACCUMULATE":
+		return e.execute(ctx, symbol, Buy, signal.Confidence, price)
+	case "SELL", "DISTRIBUTE":
+		return e.execute(ctx, symbol, Sell, signal.Confidence, price)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotActionable, signal.Recommendation)
+	}
+}
+
+// ExecuteTradeSignal handles the LONG/SHORT output of AnalyzeForLongs/AnalyzeForShorts
+func (e *Executor) ExecuteTradeSignal(ctx context.Context, symbol string, signal *strategy.TradeSignal, price float64) (*Execution, error) {
+	if signal == nil {
+		return nil, fmt.Errorf("%w: no trade signal", ErrNotActionable)
+	}
+	switch signal.Direction {
+	case "LONG":
+		return e.execute(ctx, symbol, Buy, signal.Confidence, price)
+	case "SHORT":
+		return e.execute(ctx, symbol, Sell, signal.Confidence, price)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotActionable, signal.Direction)
+	}
+}
+
+func (e *Executor) execute(ctx context.Context, symbol string, side Side, confidence float64, price float64) (*Execution, error) {
+	if price <= 0 {
+		return nil, fmt.Errorf("invalid reference price %.4f for %s", price, symbol)
+	}
+	if confidence < e.cfg.MinConfidence {
+		return nil, fmt.Errorf("%w: %.1f < %.1f", ErrLowConfidence, confidence, e.cfg.MinConfidence)
+	}
+
+	current, err := e.positionQuantity(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var req OrderRequest
+	var decision *risk.Decision
+	switch {
+	case side == Sell && current > 0:
+		// closing a long is always a plain market order for the full position
+		req = OrderRequest{Symbol: symbol, Side: Sell, Type: MarketOrder, Quantity: current, TimeInForce: e.cfg.TimeInForce}
+	case side == Buy && current < 0:
+		req = OrderRequest{Symbol: symbol, Side: Buy, Type: MarketOrder, Quantity: -current, TimeInForce: e.cfg.TimeInForce}
+	case side == Sell && !e.cfg.AllowShorts:
+		return nil, fmt.Errorf("%w: %s", ErrNoPosition, symbol)
+	case current != 0:
+		return nil, fmt.Errorf("already holding %.4f %s", current, symbol)
+	default:
+		req, err = e.entryOrder(symbol, side, price)
+		if err != nil {
+			return nil, err
+		}
+		if decision, err = e.checkRisk(ctx, req, price); err != nil {
+			return nil, err
+		}
+		if decision != nil {
+			req.Quantity = decision.Quantity
+		}
+	}
+
+	signalID, err := e.store.CreateSignal(ctx, database.CreateSignalParams{
+		Symbol:       symbol,
+		SignalType:   string(side),
+		CurrentPrice: formatDecimal(price),
+		Confidence:   sql.NullString{String: formatDecimal(math.Min(confidence, 100) / 100), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record signal for %s: %w", symbol, err)
+	}
+
+	execution, err := e.PlaceOrder(ctx, req, price, signalID)
+	if err != nil {
+		return nil, err
+	}
+	execution.Risk = decision
+	if err := e.store.MarkSignalExecuted(ctx, signalID); err != nil {
+		return execution, fmt.Errorf("order placed but failed to mark signal %d executed: %w", signalID, err)
+	}
+	return execution, nil
+}
+
+func (e *Executor) entryOrder(symbol string, side Side, price float64) (OrderRequest, error) {
+	quantity := math.Floor(e.cfg.PositionSizeUSD / price)
+	if quantity < 1 {
+		return OrderRequest{}, fmt.Errorf("position size $%.2f buys less than one share of %s at %.2f", e.cfg.PositionSizeUSD, symbol, price)
+	}
+
+	req := OrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        e.cfg.OrderType,
+		Quantity:    quantity,
+		TimeInForce: e.cfg.TimeInForce,
+	}
+
+	direction := 1.0
+	if side == Sell {
+		direction = -1.0
+	}
+
+	switch e.cfg.OrderType {
+	case LimitOrder:
+		req.LimitPrice = roundPrice(price * (1 + direction*e.cfg.LimitOffsetPct/100))
+	case BracketOrder:
+		if e.cfg.LimitOffsetPct > 0 {
+			req.LimitPrice = roundPrice(price * (1 + direction*e.cfg.LimitOffsetPct/100))
+		}
+		req.TakeProfit = roundPrice(price * (1 + direction*e.cfg.TakeProfitPct/100))
+		req.StopLoss = roundPrice(price * (1 - direction*e.cfg.StopLossPct/100))
+	}
+	return req, req.Validate()
+}
+
+func (e *Executor) checkRisk(ctx context.Context, req OrderRequest, price float64) (*risk.Decision, error) {
+	if e.risk == nil {
+		return nil, nil
+	}
+	decision, err := e.risk.Check(ctx, risk.Proposal{
+		Symbol:   req.Symbol,
+		Side:     string(req.Side),
+		Quantity: req.Quantity,
+		Price:    price,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Approved() {
+		return &decision, fmt.Errorf("%w: %s", ErrRiskRejected, strings.Join(decision.Reasons, "; "))
+	}
+	return &decision, nil
+}
+
+// PlaceOrder submits req and records the order (plus any bracket legs) in trades.
+// Fills that are already known are applied to positions immediately.
+func (e *Executor) PlaceOrder(ctx context.Context, req OrderRequest, referencePrice float64, signalID int32) (*Execution, error) {
+	order, err := e.broker.SubmitOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	execution := &Execution{SignalID: signalID, Order: order}
+
+	tradeID, err := e.recordOrder(ctx, *order, referencePrice, signalID)
+	if err != nil {
+		return execution, err
+	}
+	execution.TradeIDs = append(execution.TradeIDs, tradeID)
+
+	for _, leg := range order.Legs {
+		legPrice := leg.LimitPrice
+		if legPrice == 0 {
+			legPrice = leg.StopPrice
+		}
+		legID, err := e.recordOrder(ctx, leg, legPrice, signalID)
+		if err != nil {
+			return execution, err
+		}
+		execution.TradeIDs = append(execution.TradeIDs, legID)
+	}
+	return execution, nil
+}
+
+func (e *Executor) recordOrder(ctx context.Context, order Order, referencePrice float64, signalID int32) (int32, error) {
+	price := referencePrice
+	quantity := order.Quantity
+	var filledAt sql.NullTime
+	if order.Status == StatusFilled {
+		price = order.FilledAvgPrice
+		quantity = order.FilledQty
+		filledAt = nullTime(order.FilledAt)
+	}
+
+	tradeID, err := e.store.CreateTrade(ctx, database.CreateTradeParams{
+		SignalID:      sql.NullInt32{Int32: signalID, Valid: signalID != 0},
+		Symbol:        order.Symbol,
+		Side:          string(order.Side),
+		Quantity:      formatDecimal(quantity),
+		Price:         formatDecimal(price),
+		TotalValue:    formatDecimal(quantity * price),
+		Commission:    sql.NullString{String: formatDecimal(order.Commission), Valid: true},
+		AlpacaOrderID: sql.NullString{String: order.ID, Valid: order.ID != ""},
+		Status:        sql.NullString{String: string(order.Status), Valid: true},
+		FilledAt:      filledAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record trade for order %s: %w", order.ID, err)
+	}
+
+	if order.Status == StatusFilled {
+		if err := e.applyFill(ctx, order.Symbol, order.Side, order.FilledQty, order.FilledAvgPrice); err != nil {
+			return tradeID, err
+		}
+	}
+	return tradeID, nil
+}
+
+// SyncFills polls the broker for every pending trade, records fills and updates positions.
+// It returns how many trades reached a final state.
+func (e *Executor) SyncFills(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		// a settled bracket leg cancels its sibling, so keep going until a pass changes nothing
+		settled, err := e.syncPass(ctx)
+		total += settled
+		if err != nil || settled == 0 {
+			return total, err
+		}
+	}
+}
+
+func (e *Executor) syncPass(ctx context.Context) (int, error) {
+	pending, err := e.store.GetPendingTrades(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending trades: %w", err)
+	}
+
+	settled := 0
+	for _, trade := range pending {
+		if err := ctx.Err(); err != nil {
+			return settled, err
+		}
+		if !trade.AlpacaOrderID.Valid {
+			continue
+		}
+
+		order, err := e.broker.GetOrder(ctx, trade.AlpacaOrderID.String)
+		if errors.Is(err, ErrOrderNotFound) {
+			// e.g. a simulated order from an earlier session
+			continue
+		}
+		if err != nil {
+			return settled, err
+		}
+		if order.Status == StatusPending || (order.Status == StatusPartiallyFilled && trade.Status.String == string(order.Status)) {
+			continue
+		}
+
+		quantity, _ := strconv.ParseFloat(trade.Quantity, 64)
+		price, _ := strconv.ParseFloat(trade.Price, 64)
+		if order.Status.IsTerminal() && order.FilledQty > 0 {
+			quantity = order.FilledQty
+			price = order.FilledAvgPrice
+		}
+
+		err = e.store.UpdateTradeFill(ctx, database.UpdateTradeFillParams{
+			ID:         trade.ID,
+			Status:     sql.NullString{String: string(order.Status), Valid: true},
+			Quantity:   formatDecimal(quantity),
+			Price:      formatDecimal(price),
+			TotalValue: formatDecimal(quantity * price),
+			FilledAt:   nullTime(order.FilledAt),
+		})
+		if err != nil {
+			return settled, fmt.Errorf("failed to update trade %d: %w", trade.ID, err)
+		}
+
+		// positions only move once the order is final, so partial fills are not double counted
+		if order.Status.IsTerminal() {
+			settled++
+			if order.FilledQty > 0 {
+				if err := e.applyFill(ctx, order.Symbol, order.Side, order.FilledQty, order.FilledAvgPrice); err != nil {
+					return settled, err
+				}
+			}
+		}
+	}
+	return settled, nil
+}
+
+func (e *Executor) positionQuantity(ctx context.Context, symbol string) (float64, error) {
+	position, err := e.store.GetPosition(ctx, symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load position for %s: %w", symbol, err)
+	}
+	return strconv.ParseFloat(position.Quantity, 64)
+}
+
+// applyFill folds a fill into positions: quantity is signed (negative = short) and the
+// average entry only moves when the position grows. OpenedAt is reset when the position
+// opens or flips through zero.
+func (e *Executor) applyFill(ctx context.Context, symbol string, side Side, quantity float64, price float64) error {
+	current := 0.0
+	avgEntry := 0.0
+	position, err := e.store.GetPosition(ctx, symbol)
+	switch {
+	case err == nil:
+		current, _ = strconv.ParseFloat(position.Quantity, 64)
+		avgEntry, _ = strconv.ParseFloat(position.AvgEntryPrice, 64)
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("failed to load position for %s: %w", symbol, err)
+	}
+
+	delta := quantity
+	if side == Sell {
+		delta = -quantity
+	}
+	updated := current + delta
+
+	if math.Abs(updated) < 1e-9 {
+		if err := e.store.DeletePosition(ctx, symbol); err != nil {
+			return fmt.Errorf("failed to close position for %s: %w", symbol, err)
+		}
+		return nil
+	}
+
+	openedAt := position.OpenedAt
+	switch {
+	case current == 0 || (current > 0) != (updated > 0):
+		// new position or flipped through zero
+		avgEntry = price
+		openedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	case math.Abs(updated) > math.Abs(current):
+		avgEntry = (math.Abs(current)*avgEntry + quantity*price) / math.Abs(updated)
+	}
+
+	err = e.store.UpsertPosition(ctx, database.UpsertPositionParams{
+		Symbol:        symbol,
+		Quantity:      formatDecimal(updated),
+		AvgEntryPrice: formatDecimal(avgEntry),
+		CurrentPrice:  sql.NullString{String: formatDecimal(price), Valid: true},
+		MarketValue:   sql.NullString{String: formatDecimal(updated * price), Valid: true},
+		UnrealizedPnl: sql.NullString{String: formatDecimal((price - avgEntry) * updated), Valid: true},
+		OpenedAt:      openedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update position for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,113 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+)
+
+type Side string
+
+const (
+	Buy  Side = "BUY"
+	Sell Side = "SELL"
+)
+
+type OrderType string
+
+const (
+	MarketOrder  OrderType = "market"
+	LimitOrder   OrderType = "limit"
+	StopOrder    OrderType = "stop"
+	BracketOrder OrderType = "bracket"
+)
+
+// OrderStatus values are what we write to trades.status
+type OrderStatus string
+
+const (
+	StatusPending         OrderStatus = "PENDING"
+	StatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	StatusFilled          OrderStatus = "FILLED"
+	StatusCancelled       OrderStatus = "CANCELLED"
+	StatusRejected        OrderStatus = "REJECTED"
+)
+
+// IsTerminal reports whether the order can no longer change
+func (s OrderStatus) IsTerminal() bool {
+	return s == StatusFilled || s == StatusCancelled || s == StatusRejected
+}
+
+type OrderRequest struct {
+	Symbol        string
+	Side          Side
+	Type          OrderType
+	Quantity      float64
+	LimitPrice    float64 // limit orders, and the entry leg of a bracket when set
+	StopPrice     float64 // stop orders
+	TakeProfit    float64 // bracket only
+	StopLoss      float64 // bracket only
+	TimeInForce   string  // "day" or "gtc"
+	ClientOrderID string
+}
+
+type Order struct {
+	ID             string
+	ClientOrderID  string
+	Symbol         string
+	Side           Side
+	Type           OrderType
+	Quantity       float64
+	LimitPrice     float64
+	StopPrice      float64
+	Status         OrderStatus
+	FilledQty      float64
+	FilledAvgPrice float64
+	Commission     float64
+	SubmittedAt    time.Time
+	FilledAt       *time.Time
+	Legs           []Order // take-profit and stop-loss children of a bracket order
+}
+
+func (r OrderRequest) Validate() error {
+	if r.Symbol == "" {
+		return fmt.Errorf("order symbol is required")
+	}
+	if r.Side != Buy && r.Side != Sell {
+		return fmt.Errorf("invalid order side %q", r.Side)
+	}
+	if r.Quantity <= 0 {
+		return fmt.Errorf("order quantity must be positive, got %.4f", r.Quantity)
+	}
+
+	switch r.Type {
+	case MarketOrder:
+	case LimitOrder:
+		if r.LimitPrice <= 0 {
+			return fmt.Errorf("limit order requires a limit price")
+		}
+	case StopOrder:
+		if r.StopPrice <= 0 {
+			return fmt.Errorf("stop order requires a stop price")
+		}
+	case BracketOrder:
+		if r.TakeProfit <= 0 || r.StopLoss <= 0 {
+			return fmt.Errorf("bracket order requires take profit and stop loss prices")
+		}
+		if r.Side == Buy && !(r.StopLoss < r.TakeProfit) {
+			return fmt.Errorf("long bracket needs stop loss (%.2f) below take profit (%.2f)", r.StopLoss, r.TakeProfit)
+		}
+		if r.Side == Sell && !(r.StopLoss > r.TakeProfit) {
+			return fmt.Errorf("short bracket needs stop loss (%.2f) above take profit (%.2f)", r.StopLoss, r.TakeProfit)
+		}
+	default:
+		return fmt.Errorf("unsupported order type %q", r.Type)
+	}
+	return nil
+}
+
+func opposite(side Side) Side {
+	if side == Buy {
+		return Sell
+	}
+	return Buy
+}
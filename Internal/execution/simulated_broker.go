@@ -0,0 +1,232 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+)
+
+// PriceFunc returns the price the simulated broker should fill against right now
+type PriceFunc func(ctx context.Context, symbol string) (float64, error)
+
+// QuotePrice uses the active market data provider's latest quote
+func QuotePrice(ctx context.Context, symbol string) (float64, error) {
+	quote, err := datafeed.GetLatestQuote(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if quote.Price <= 0 {
+		return 0, fmt.Errorf("no ask price for %s", symbol)
+	}
+	return quote.Price, nil
+}
+
+// SimulatedBroker fills orders locally against PriceFunc. Market orders fill on submit,
+// limit/stop orders and bracket legs are re-checked whenever the order is looked up.
+type SimulatedBroker struct {
+	mu          sync.Mutex
+	prices      PriceFunc
+	SlippageBps float64
+	Commission  float64 // flat fee per fill
+	orders      map[string]*simOrder
+	seq         int
+	session     int64 // keeps order ids unique across runs sharing one trades table
+	now         func() time.Time
+}
+
+type simOrder struct {
+	order    Order
+	parentID string
+	legIDs   []string
+}
+
+// creates a simulated broker; a nil prices func falls back to QuotePrice
+func NewSimulatedBroker(prices PriceFunc) *SimulatedBroker {
+	if prices == nil {
+		prices = QuotePrice
+	}
+	return &SimulatedBroker{
+		prices:  prices,
+		orders:  make(map[string]*simOrder),
+		session: time.Now().Unix(),
+		now:     time.Now,
+	}
+}
+
+func (b *SimulatedBroker) Name() string {
+	return "simulated"
+}
+
+func (b *SimulatedBroker) nextID() string {
+	b.seq++
+	return fmt.Sprintf("SIM-%d-%06d", b.session, b.seq)
+}
+
+func (b *SimulatedBroker) SubmitOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parent := &simOrder{order: Order{
+		ID:            b.nextID(),
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		Type:          req.Type,
+		Quantity:      req.Quantity,
+		LimitPrice:    req.LimitPrice,
+		StopPrice:     req.StopPrice,
+		Status:        StatusPending,
+		SubmittedAt:   b.now(),
+	}}
+	b.orders[parent.order.ID] = parent
+
+	if req.Type == BracketOrder {
+		exitSide := opposite(req.Side)
+		takeProfit := &simOrder{parentID: parent.order.ID, order: Order{
+			ID: b.nextID(), Symbol: req.Symbol, Side: exitSide, Type: LimitOrder,
+			Quantity: req.Quantity, LimitPrice: req.TakeProfit, Status: StatusPending, SubmittedAt: b.now(),
+		}}
+		stopLoss := &simOrder{parentID: parent.order.ID, order: Order{
+			ID: b.nextID(), Symbol: req.Symbol, Side: exitSide, Type: StopOrder,
+			Quantity: req.Quantity, StopPrice: req.StopLoss, Status: StatusPending, SubmittedAt: b.now(),
+		}}
+		b.orders[takeProfit.order.ID] = takeProfit
+		b.orders[stopLoss.order.ID] = stopLoss
+		parent.legIDs = []string{takeProfit.order.ID, stopLoss.order.ID}
+	}
+
+	if err := b.evaluate(ctx, parent); err != nil {
+		parent.order.Status = StatusRejected
+		return nil, err
+	}
+	return b.snapshot(parent), nil
+}
+
+func (b *SimulatedBroker) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o, ok := b.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+	if err := b.evaluate(ctx, o); err != nil {
+		return nil, err
+	}
+	return b.snapshot(o), nil
+}
+
+func (b *SimulatedBroker) CancelOrder(ctx context.Context, orderID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o, ok := b.orders[orderID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+	if o.order.Status.IsTerminal() {
+		return fmt.Errorf("order %s is already %s", orderID, o.order.Status)
+	}
+	o.order.Status = StatusCancelled
+	for _, id := range o.legIDs {
+		b.orders[id].order.Status = StatusCancelled
+	}
+	return nil
+}
+
+// evaluate tries to fill o (and, for a filled bracket parent, its legs) at the current price
+func (b *SimulatedBroker) evaluate(ctx context.Context, o *simOrder) error {
+	if o.order.Status.IsTerminal() {
+		if o.order.Status == StatusFilled {
+			for _, id := range o.legIDs {
+				if err := b.evaluate(ctx, b.orders[id]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// legs only become live once the entry has filled
+	if o.parentID != "" && b.orders[o.parentID].order.Status != StatusFilled {
+		return nil
+	}
+
+	price, err := b.prices(ctx, o.order.Symbol)
+	if err != nil {
+		return fmt.Errorf("simulated broker has no price for %s: %w", o.order.Symbol, err)
+	}
+
+	fillPrice, ok := b.fillPrice(o.order, price)
+	if !ok {
+		return nil
+	}
+
+	now := b.now()
+	o.order.Status = StatusFilled
+	o.order.FilledQty = o.order.Quantity
+	o.order.FilledAvgPrice = fillPrice
+	o.order.Commission = b.Commission
+	o.order.FilledAt = &now
+
+	if o.parentID != "" {
+		// one-cancels-other between take profit and stop loss
+		for _, id := range b.orders[o.parentID].legIDs {
+			if id != o.order.ID && !b.orders[id].order.Status.IsTerminal() {
+				b.orders[id].order.Status = StatusCancelled
+			}
+		}
+		return nil
+	}
+	for _, id := range o.legIDs {
+		if err := b.evaluate(ctx, b.orders[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *SimulatedBroker) fillPrice(o Order, price float64) (float64, bool) {
+	slip := price * b.SlippageBps / 10000
+	adverse := price + slip
+	if o.Side == Sell {
+		adverse = price - slip
+	}
+
+	switch o.Type {
+	case MarketOrder:
+		return adverse, true
+	case LimitOrder:
+		if (o.Side == Buy && price <= o.LimitPrice) || (o.Side == Sell && price >= o.LimitPrice) {
+			return price, true
+		}
+	case StopOrder:
+		if (o.Side == Sell && price <= o.StopPrice) || (o.Side == Buy && price >= o.StopPrice) {
+			return adverse, true
+		}
+	case BracketOrder:
+		if o.LimitPrice <= 0 {
+			return adverse, true
+		}
+		if (o.Side == Buy && price <= o.LimitPrice) || (o.Side == Sell && price >= o.LimitPrice) {
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+func (b *SimulatedBroker) snapshot(o *simOrder) *Order {
+	order := o.order
+	order.Legs = nil
+	for _, id := range o.legIDs {
+		order.Legs = append(order.Legs, b.orders[id].order)
+	}
+	return &order
+}
@@ -0,0 +1,23 @@
+package execution
+
+import (
+	"context"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+)
+
+// TradeStore is the slice of the generated queries the executor needs.
+// *database.Queries satisfies it; tests use an in-memory version.
+type TradeStore interface {
+	CreateSignal(ctx context.Context, arg database.CreateSignalParams) (int32, error)
+	MarkSignalExecuted(ctx context.Context, id int32) error
+	CreateTrade(ctx context.Context, arg database.CreateTradeParams) (int32, error)
+	UpdateTradeFill(ctx context.Context, arg database.UpdateTradeFillParams) error
+	GetPendingTrades(ctx context.Context) ([]database.Trade, error)
+	GetPosition(ctx context.Context, symbol string) (database.Position, error)
+	GetPositions(ctx context.Context) ([]database.Position, error)
+	UpsertPosition(ctx context.Context, arg database.UpsertPositionParams) error
+	DeletePosition(ctx context.Context, symbol string) error
+}
+
+var _ TradeStore = (*database.Queries)(nil)
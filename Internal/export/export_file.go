@@ -21,8 +21,21 @@ type ExportRecord struct {
 	ATR       *float64
 	Analysis  string
 	Signals   []string
+
+	// trend indicators, nil until their lookback is filled
+	MACD               *float64
+	MACDSignal         *float64
+	MACDHistogram      *float64
+	BollingerUpper     *float64
+	BollingerMiddle    *float64
+	BollingerLower     *float64
+	BollingerPercentB  *float64
+	BollingerBandwidth *float64
 }
 
+var csvHeader = []string{"Timestamp", "Open", "High", "Low", "Close", "Volume", "RSI", "ATR", "Analysis", "Signals",
+	"MACD", "MACD Signal", "MACD Histogram", "BB Upper", "BB Middle", "BB Lower", "BB %B", "BB Bandwidth"}
+
 func ExportRecordsToCSV(filename string, bars []ExportRecord) error {
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -36,7 +49,7 @@ func ExportRecordsToCSV(filename string, bars []ExportRecord) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	if err := writer.Write([]string{"Timestamp", "Open", "High", "Low", "Close", "Volume", "RSI", "ATR", "Analysis", "Signals"}); err != nil {
+	if err := writer.Write(csvHeader); err != nil {
 		return err
 	}
 
@@ -59,21 +72,30 @@ func RecordToRow(record ExportRecord) []string {
 		strconv.FormatFloat(record.Close, 'f', 2, 64),
 		strconv.FormatInt(record.Volume, 10),
 	}
-	if record.RSI != nil {
-		row = append(row, strconv.FormatFloat(*record.RSI, 'f', 2, 64))
-	} else {
-		row = append(row, "")
-	}
-	if record.ATR != nil {
-		row = append(row, strconv.FormatFloat(*record.ATR, 'f', 2, 64))
-	} else {
-		row = append(row, "")
-	}
+	row = append(row, formatOptional(record.RSI, 2), formatOptional(record.ATR, 2))
 	row = append(row, record.Analysis)
 	row = append(row, strings.Join(record.Signals, "; "))
+	row = append(row,
+		formatOptional(record.MACD, 4),
+		formatOptional(record.MACDSignal, 4),
+		formatOptional(record.MACDHistogram, 4),
+		formatOptional(record.BollingerUpper, 2),
+		formatOptional(record.BollingerMiddle, 2),
+		formatOptional(record.BollingerLower, 2),
+		formatOptional(record.BollingerPercentB, 4),
+		formatOptional(record.BollingerBandwidth, 4),
+	)
 	return row
 }
 
+// formatOptional leaves the cell empty for a missing value
+func formatOptional(value *float64, precision int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', precision, 64)
+}
+
 func ExportRecordsToJSON(filename string, records []ExportRecord) error {
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
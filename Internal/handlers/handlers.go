@@ -7,12 +7,19 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/fazecat/mongelmaker/Internal/backtest"
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
 	"github.com/fazecat/mongelmaker/Internal/database/watchlist"
+	"github.com/fazecat/mongelmaker/Internal/execution"
 	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/risk"
 	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
 	"github.com/fazecat/mongelmaker/Internal/utils/config"
 	"github.com/fazecat/mongelmaker/Internal/utils/scanner"
 	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
@@ -30,7 +37,7 @@ func clearInputBuffer() {
 	}
 }
 
-func HandleScan(ctx context.Context, cfg *config.Config, q *database.Queries) {
+func HandleScan(ctx context.Context, cfg *config.Config, repos repository.Repositories) {
 	if len(cfg.Profiles) == 0 {
 		fmt.Println("❌ No profiles configured")
 		return
@@ -58,7 +65,7 @@ func HandleScan(ctx context.Context, cfg *config.Config, q *database.Queries) {
 	selectedProfile := profiles[choice-1]
 
 	fmt.Printf("🔄 Scanning profile: %s\n", selectedProfile)
-	scannedCount, err := scanner.PerformScan(ctx, selectedProfile, cfg, q)
+	scannedCount, err := scanner.New(cfg, repos).PerformScan(ctx, selectedProfile)
 	if err != nil {
 		fmt.Printf("❌ Scan failed: %v\n", err)
 		return
@@ -67,7 +74,7 @@ func HandleScan(ctx context.Context, cfg *config.Config, q *database.Queries) {
 	fmt.Printf("✅ Scan complete! Updated %d symbols\n", scannedCount)
 }
 
-func HandleAnalyzeSingle(ctx context.Context, q *database.Queries) {
+func HandleAnalyzeSingle(ctx context.Context, repos repository.Repositories) {
 	fmt.Print("Enter stock symbol (e.g., AAPL): ")
 	var symbol string
 	_, err := fmt.Scanln(&symbol)
@@ -95,18 +102,25 @@ func HandleAnalyzeSingle(ctx context.Context, q *database.Queries) {
 		return
 	}
 
-	err = datafeed.CalculateAndStoreRSI(symbol, bars)
+	err = datafeed.CalculateAndStoreRSI(ctx, repos.Indicators, symbol, bars)
 	if err != nil {
 		fmt.Printf("❌ Failed to calculate and store RSI: %v\n", err)
 		return
 	}
 
-	err = datafeed.CalculateAndStoreATR(symbol, bars)
+	err = datafeed.CalculateAndStoreATR(ctx, repos.Indicators, symbol, bars)
 	if err != nil {
 		fmt.Printf("❌ Failed to calculate and store ATR: %v\n", err)
 		return
 	}
 
+	if err := strategy.StoreLevels(ctx, repos.Levels, symbol, bars); err != nil {
+		fmt.Printf("⚠️  Failed to store support/resistance levels: %v\n", err)
+	}
+	if err := strategy.RecordWhales(ctx, repos.Whales, symbol, bars); err != nil {
+		fmt.Printf("⚠️  Failed to store whale events: %v\n", err)
+	}
+
 	displayChoice, _ := interactive.ShowDisplayMenu()
 	clearInputBuffer()
 
@@ -118,7 +132,7 @@ func HandleAnalyzeSingle(ctx context.Context, q *database.Queries) {
 	case "analytics":
 		tz, _ := interactive.ShowTimezoneMenu()
 		clearInputBuffer()
-		interactive.DisplayAnalyticsData(bars, symbol, timeframe, tz, q)
+		interactive.DisplayAnalyticsData(bars, symbol, timeframe, tz, repos)
 		fmt.Println("\n--- Press Enter to continue ---")
 		bufio.NewReader(os.Stdin).ReadBytes('\n')
 	case "vwap":
@@ -128,7 +142,7 @@ func HandleAnalyzeSingle(ctx context.Context, q *database.Queries) {
 	}
 }
 
-func HandleScreener(ctx context.Context, cfg *config.Config, q *database.Queries) {
+func HandleScreener(ctx context.Context, cfg *config.Config, q *database.Queries, repos repository.Repositories) {
 	symbols := strategy.GetPopularStocks()
 	if len(symbols) == 0 {
 		fmt.Println("❌ Could not get popular stocks")
@@ -138,7 +152,10 @@ func HandleScreener(ctx context.Context, cfg *config.Config, q *database.Queries
 	criteria := strategy.DefaultScreenerCriteria()
 
 	fmt.Println("🔍 Screening stocks...")
-	results, err := strategy.ScreenStocks(symbols, "1Day", 100, criteria, nil)
+	pool := utils.PoolConfigFromConfig(cfg)
+	pool.Progress = utils.ProgressLine(os.Stdout, "   Screened")
+	screener := &strategy.Screener{News: repos.News, Whales: repos.Whales, Pool: pool}
+	results, err := screener.Screen(ctx, symbols, "1Day", 100, criteria)
 	if err != nil {
 		fmt.Printf("❌ Screener failed: %v\n", err)
 		return
@@ -239,7 +256,11 @@ func HandleScreener(ctx context.Context, cfg *config.Config, q *database.Queries
 	}
 
 	if selectedStock.Recommendation != "" {
-		fmt.Printf("\n📝 Recommendation: %s\n", selectedStock.Recommendation)
+		fmt.Printf("\n📝 Recommendation: %s\n", strategy.FormatDecision(selectedStock.Decision))
+		fmt.Println("\n🧭 Why:")
+		for _, line := range selectedStock.Decision.Explanation.Lines() {
+			fmt.Printf("   %s\n", line)
+		}
 	}
 
 	fmt.Println("\n📰 Fetching recent news...")
@@ -342,7 +363,7 @@ func HandleWatchlist(ctx context.Context, q *database.Queries) {
 	}
 }
 
-func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries) {
+func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries, repos repository.Repositories) {
 	profiles := make([]string, 0)
 	for name := range cfg.Profiles {
 		profiles = append(profiles, name)
@@ -381,9 +402,13 @@ func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries) {
 	offset := 0
 	batchNum := 1
 
+	pool := utils.PoolConfigFromConfig(cfg)
+	pool.Progress = utils.ProgressLine(os.Stdout, "   Evaluated")
+	scout := scanner.New(cfg, repos)
+
 	for {
 		fmt.Printf("\n🔄 Scanning batch %d (evaluating %d symbols)...\n", batchNum, batchSize)
-		candidates, totalSymbols, err := scanner.PerformProfileScan(ctx, selectedProfile, minScore, offset, batchSize)
+		candidates, totalSymbols, err := scout.PerformProfileScanConcurrent(ctx, selectedProfile, minScore, offset, batchSize, pool)
 		if err != nil {
 			fmt.Printf("❌ Scout scan failed: %v\n", err)
 			return
@@ -401,7 +426,7 @@ func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries) {
 
 			for _, candidate := range candidates {
 				fmt.Printf("\n   %s\n", candidate.Symbol)
-				fmt.Printf("      Score: %.2f | Pattern: %s\n", candidate.Score, candidate.Analysis)
+				fmt.Printf("      Score: %.2f | Action: %s | Pattern: %s\n", candidate.Score, candidate.Action, candidate.Analysis)
 
 				for {
 					fmt.Print("      (e)xpand / (y)es / (n)o / (i)gnore: ")
@@ -412,7 +437,7 @@ func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries) {
 					if choice == "e" {
 						tz, _ := interactive.ShowTimezoneMenu()
 						clearInputBuffer()
-						interactive.DisplayAnalyticsData(candidate.Bars, candidate.Symbol, "1Day", tz, q)
+						interactive.DisplayAnalyticsData(candidate.Bars, candidate.Symbol, "1Day", tz, repos)
 						continue
 					}
 
@@ -476,3 +501,326 @@ func HandleScout(ctx context.Context, cfg *config.Config, q *database.Queries) {
 	fmt.Println("\n--- Press Enter to continue ---")
 	bufio.NewReader(os.Stdin).ReadBytes('\n')
 }
+
+func HandleBacktest(ctx context.Context, q *database.Queries) {
+	fmt.Print("Enter stock symbol (e.g., AAPL): ")
+	var symbol string
+	_, err := fmt.Scanln(&symbol)
+	if err != nil || symbol == "" {
+		fmt.Println("❌ Invalid symbol")
+		return
+	}
+	symbol = strings.ToUpper(symbol)
+
+	timeframe, err := interactive.ShowTimeframeMenu()
+	if err != nil {
+		fmt.Println("❌ Invalid timeframe")
+		return
+	}
+	clearInputBuffer()
+
+	fmt.Print("Enter number of bars to replay (default 500): ")
+	var numBars int
+	_, err = fmt.Scanln(&numBars)
+	if err != nil || numBars < 50 {
+		numBars = 500
+	}
+
+	fmt.Println("Data source:")
+	fmt.Println("1. Stored bars (historical_bars)")
+	fmt.Printf("2. Market data provider (%s)\n", datafeed.GetProvider().Name())
+	fmt.Print("Enter choice (default 2): ")
+	var source int
+	fmt.Scanln(&source)
+
+	cfg := backtest.DefaultConfig(symbol, timeframe)
+
+	fmt.Printf("Starting capital (default %.0f): ", cfg.InitialCapital)
+	var capital float64
+	if _, err := fmt.Scanln(&capital); err == nil && capital > 0 {
+		cfg.InitialCapital = capital
+	}
+
+	fmt.Printf("Slippage in bps (default %.0f): ", cfg.SlippageBps)
+	var slippage float64
+	if _, err := fmt.Scanln(&slippage); err == nil && slippage >= 0 {
+		cfg.SlippageBps = slippage
+	}
+
+	fmt.Printf("Commission per trade (default %.2f): ", cfg.CommissionPerTrade)
+	var commission float64
+	if _, err := fmt.Scanln(&commission); err == nil && commission >= 0 {
+		cfg.CommissionPerTrade = commission
+	}
+
+	var bars []datafeed.Bar
+	if source == 1 {
+		bars, err = repository.NewSQLBars(q).BarsBetween(ctx, symbol, timeframe, time.Time{}, time.Now())
+	} else {
+		bars, err = datafeed.GetBars(ctx, symbol, timeframe, numBars, "")
+	}
+	var series types.BarSeries
+	if err == nil {
+		series, err = types.NewBarSeries(bars)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to load bars: %v\n", err)
+		return
+	}
+	series = series.Window(numBars)
+
+	fmt.Printf("🧪 Replaying %d bars for %s...\n", series.Len(), symbol)
+	result, err := backtest.Run(series, cfg, backtest.DefaultSignalFunc)
+	if err != nil {
+		fmt.Printf("❌ Backtest failed: %v\n", err)
+		return
+	}
+
+	interactive.DisplayBacktestResult(result)
+
+	fmt.Println("\n--- Press Enter to continue ---")
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
+
+func HandleSync(ctx context.Context, q *database.Queries) {
+	fmt.Println("Symbols to sync:")
+	fmt.Println("1. Watchlist")
+	fmt.Println("2. Enter symbols")
+	fmt.Print("Enter choice (default 1): ")
+	var source int
+	fmt.Scanln(&source)
+
+	var symbols []string
+	if source == 2 {
+		fmt.Print("Symbols (comma separated): ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		for _, symbol := range strings.Split(line, ",") {
+			if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	} else {
+		watchlist, err := q.GetWatchlist(ctx)
+		if err != nil {
+			fmt.Printf("❌ Failed to load watchlist: %v\n", err)
+			return
+		}
+		for _, item := range watchlist {
+			symbols = append(symbols, item.Symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		fmt.Println("📭 Nothing to sync")
+		return
+	}
+
+	timeframe, err := interactive.ShowTimeframeMenu()
+	if err != nil {
+		fmt.Println("❌ Invalid timeframe")
+		return
+	}
+	clearInputBuffer()
+
+	fmt.Print("Days of history to backfill (default 365): ")
+	var days int
+	if _, err := fmt.Scanln(&days); err != nil || days <= 0 {
+		days = 365
+	}
+
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -days)
+
+	fmt.Printf("🔄 Syncing %d symbols (%s, %s to %s)...\n", len(symbols), timeframe, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	results, err := datafeed.SyncBars(ctx, repository.NewSQLBars(q), symbols, timeframe, start, end)
+	if err != nil {
+		fmt.Printf("❌ Sync failed: %v\n", err)
+		if len(results) == 0 {
+			return
+		}
+	}
+
+	fmt.Println("\nSymbol | Bars stored | Gaps left")
+	fmt.Println("-------|-------------|----------")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%-6s | ❌ %v\n", r.Symbol, r.Err)
+			continue
+		}
+		missing := 0
+		for _, gap := range r.Gaps {
+			missing += gap.Missing
+		}
+		fmt.Printf("%-6s | %11d | %d gaps (%d bars, usually holidays)\n", r.Symbol, r.Stored, len(r.Gaps), missing)
+	}
+
+	fmt.Println("\n--- Press Enter to continue ---")
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
+
+// kept for the whole session so simulated orders can still fill on later visits
+var tradeBroker execution.Broker
+
+func HandleTrade(ctx context.Context, cfg *config.Config, q *database.Queries) {
+	execCfg := execution.ConfigFromSettings(cfg)
+	if tradeBroker == nil {
+		broker, err := execution.NewBroker(execCfg)
+		if err != nil {
+			fmt.Printf("❌ Broker unavailable: %v\n", err)
+			return
+		}
+		tradeBroker = broker
+	}
+	broker := tradeBroker
+	executor := execution.NewExecutor(broker, q, execCfg)
+
+	manager, err := newRiskManager(cfg, q, execCfg)
+	if err != nil {
+		fmt.Printf("❌ Risk manager unavailable: %v\n", err)
+		return
+	}
+	executor.SetRiskManager(manager)
+
+	mode := "🧪 DRY RUN (simulated fills)"
+	if !execCfg.DryRun {
+		mode = "📡 ALPACA " + strings.ToUpper(broker.Name())
+	}
+	fmt.Printf("\n💼 Order Execution - %s\n", mode)
+
+	if settled, err := executor.SyncFills(ctx); err != nil {
+		fmt.Printf("⚠️ Could not sync pending orders: %v\n", err)
+	} else if settled > 0 {
+		fmt.Printf("✅ %d pending order(s) settled\n", settled)
+	}
+
+	fmt.Println("1. Trade a symbol from its combined signal")
+	fmt.Println("2. View positions")
+	fmt.Println("3. Back")
+	fmt.Print("Enter choice (number): ")
+	var choice int
+	if _, err := fmt.Scanln(&choice); err != nil {
+		fmt.Println("❌ Invalid input")
+		return
+	}
+
+	switch choice {
+	case 1:
+		tradeFromSignal(ctx, executor)
+	case 2:
+	case 3:
+		return
+	default:
+		fmt.Println("❌ Invalid choice")
+		return
+	}
+
+	positions, err := q.GetPositions(ctx)
+	if err != nil {
+		fmt.Printf("❌ Failed to fetch positions: %v\n", err)
+		return
+	}
+	if len(positions) == 0 {
+		fmt.Println("📭 No open positions")
+		return
+	}
+	fmt.Println("\n📊 Open Positions:")
+	fmt.Println("Symbol | Quantity | Avg Entry | Last Price | Unrealized P&L")
+	fmt.Println("-------|----------|-----------|------------|---------------")
+	for _, p := range positions {
+		fmt.Printf("%s | %s | %s | %s | %s\n", p.Symbol, p.Quantity, p.AvgEntryPrice, p.CurrentPrice.String, p.UnrealizedPnl.String)
+	}
+}
+
+func tradeFromSignal(ctx context.Context, executor *execution.Executor) {
+	fmt.Print("Enter stock symbol (e.g., AAPL): ")
+	var symbol string
+	_, err := fmt.Scanln(&symbol)
+	if err != nil || symbol == "" {
+		fmt.Println("❌ Invalid symbol")
+		return
+	}
+	symbol = strings.ToUpper(symbol)
+
+	fetched, err := datafeed.GetBars(ctx, symbol, "1Day", 100, "")
+	if err != nil {
+		fmt.Printf("❌ Failed to fetch data: %v\n", err)
+		return
+	}
+	series, err := types.NewBarSeries(fetched)
+	if err != nil || series.Len() < 20 {
+		fmt.Printf("❌ Not enough usable bars for %s\n", symbol)
+		return
+	}
+
+	signal := backtest.DefaultSignalFunc(symbol, series)
+	fmt.Println(strategy.FormatSignal(signal))
+
+	price := series.Latest().Close
+	if quote, err := datafeed.GetLatestQuote(ctx, symbol); err == nil && quote.Price > 0 {
+		price = quote.Price
+	}
+	fmt.Printf("💵 Reference price: %.2f\n", price)
+
+	fmt.Print("Submit order for this signal? (y/n): ")
+	var confirm string
+	fmt.Scanln(&confirm)
+	if strings.ToLower(confirm) != "y" {
+		fmt.Println("Order not submitted")
+		return
+	}
+
+	result, err := executor.ExecuteCombinedSignal(ctx, symbol, signal, price)
+	if err != nil {
+		fmt.Printf("❌ Order not placed: %v\n", err)
+		return
+	}
+
+	if result.Risk != nil {
+		fmt.Printf("🛡️ Risk: %s %.0f of %.0f shares\n", result.Risk.Action, result.Risk.Quantity, result.Risk.RequestedQuantity)
+		for _, reason := range result.Risk.Reasons {
+			fmt.Printf("   • %s\n", reason)
+		}
+	}
+
+	order := result.Order
+	fmt.Printf("✅ %s %s %.0f %s - order %s is %s\n", order.Type, order.Side, order.Quantity, order.Symbol, order.ID, order.Status)
+	if order.Status == execution.StatusFilled {
+		fmt.Printf("   Filled at %.2f\n", order.FilledAvgPrice)
+	}
+	for _, leg := range order.Legs {
+		level := leg.LimitPrice
+		if leg.Type == execution.StopOrder {
+			level = leg.StopPrice
+		}
+		fmt.Printf("   ↳ %s %s @ %.2f (%s)\n", leg.Type, leg.Side, level, leg.Status)
+	}
+}
+
+func newRiskManager(cfg *config.Config, q *database.Queries, execCfg execution.Config) (*risk.Manager, error) {
+	profile := "balanced"
+	equity, lastEquity := 0.0, 0.0
+	if cfg != nil {
+		if cfg.Execution.RiskProfile != "" {
+			profile = cfg.Execution.RiskProfile
+		}
+		equity = cfg.Execution.AccountEquity
+	}
+	limits, err := risk.LimitsFromProfile(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if !execCfg.DryRun {
+		client, err := datafeed.NewAlpacaTradingClient()
+		if err != nil {
+			return nil, err
+		}
+		account, err := client.GetAccount()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch account equity: %w", err)
+		}
+		equity = account.Equity.InexactFloat64()
+		lastEquity = account.LastEquity.InexactFloat64()
+	}
+
+	return risk.NewManager(limits, risk.StorePortfolio(q, equity, lastEquity, risk.ProviderPrice, risk.ProviderPrevClose), risk.ProviderATR), nil
+}
@@ -0,0 +1,242 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+type Action string
+
+const (
+	Approve Action = "APPROVE"
+	Trim    Action = "TRIM"
+	Reject  Action = "REJECT"
+)
+
+// Limits are the per-profile guardrails. Percentages are of account equity (0-100).
+type Limits struct {
+	MaxPositionPct   float64 // value of a single position
+	MaxExposurePct   float64 // gross value of all positions
+	MaxOpenPositions int
+	MaxSymbolLossPct float64 // unrealized loss on one symbol before adding to it is blocked
+	MaxDailyLossPct  float64 // loss for the day before new risk is blocked
+	RiskPerTradePct  float64 // equity lost if a 1×ATR (times ATRMultiplier) stop is hit
+	ATRMultiplier    float64
+	ATRPeriod        int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxPositionPct:   10,
+		MaxExposurePct:   80,
+		MaxOpenPositions: 10,
+		MaxSymbolLossPct: 2,
+		MaxDailyLossPct:  3,
+		RiskPerTradePct:  1,
+		ATRMultiplier:    1,
+		ATRPeriod:        14,
+	}
+}
+
+// LimitsFromProfile reads the risk block of a profile, keeping defaults for anything unset
+func LimitsFromProfile(cfg *config.Config, profileName string) (Limits, error) {
+	limits := DefaultLimits()
+	if cfg == nil {
+		return limits, nil
+	}
+	profile := cfg.GetProfile(profileName)
+	if profile == nil {
+		return limits, fmt.Errorf("profile %s not found", profileName)
+	}
+
+	r := profile.Risk
+	setIfPositive(&limits.MaxPositionPct, r.MaxPositionPct)
+	setIfPositive(&limits.MaxExposurePct, r.MaxExposurePct)
+	setIfPositive(&limits.MaxSymbolLossPct, r.MaxSymbolLossPct)
+	setIfPositive(&limits.MaxDailyLossPct, r.MaxDailyLossPct)
+	setIfPositive(&limits.RiskPerTradePct, r.RiskPerTradePct)
+	setIfPositive(&limits.ATRMultiplier, r.ATRMultiplier)
+	if r.MaxOpenPositions > 0 {
+		limits.MaxOpenPositions = r.MaxOpenPositions
+	}
+	if r.ATRPeriod > 0 {
+		limits.ATRPeriod = r.ATRPeriod
+	}
+	return limits, nil
+}
+
+func setIfPositive(dst *float64, value float64) {
+	if value > 0 {
+		*dst = value
+	}
+}
+
+type Position struct {
+	Symbol        string
+	Quantity      float64 // negative for shorts
+	MarketValue   float64
+	UnrealizedPnL float64
+}
+
+type Portfolio struct {
+	Equity    float64
+	Positions []Position
+	DailyPnL  float64
+}
+
+func (p Portfolio) position(symbol string) (Position, bool) {
+	for _, pos := range p.Positions {
+		if pos.Symbol == symbol {
+			return pos, true
+		}
+	}
+	return Position{}, false
+}
+
+func (p Portfolio) grossExposure() float64 {
+	total := 0.0
+	for _, pos := range p.Positions {
+		total += math.Abs(pos.MarketValue)
+	}
+	return total
+}
+
+// Proposal is a trade about to be sent. Quantity 0 asks the manager to size it from ATR.
+type Proposal struct {
+	Symbol   string
+	Side     string // "BUY" or "SELL"
+	Quantity float64
+	Price    float64
+	ATR      float64
+}
+
+type Decision struct {
+	Action            Action
+	Quantity          float64
+	RequestedQuantity float64
+	Reasons           []string
+}
+
+func (d Decision) Approved() bool {
+	return d.Action != Reject && d.Quantity > 0
+}
+
+func (d Decision) String() string {
+	return fmt.Sprintf("%s %.0f/%.0f: %s", d.Action, d.Quantity, d.RequestedQuantity, strings.Join(d.Reasons, "; "))
+}
+
+type PortfolioFunc func(ctx context.Context) (Portfolio, error)
+
+type ATRFunc func(ctx context.Context, symbol string, period int) (float64, error)
+
+type Manager struct {
+	limits    Limits
+	rules     []Rule
+	portfolio PortfolioFunc
+	atr       ATRFunc
+}
+
+// creates a manager with the default rule set; atr may be nil if proposals carry their own ATR
+func NewManager(limits Limits, portfolio PortfolioFunc, atr ATRFunc) *Manager {
+	return &Manager{
+		limits:    limits,
+		rules:     DefaultRules(),
+		portfolio: portfolio,
+		atr:       atr,
+	}
+}
+
+func (m *Manager) Limits() Limits {
+	return m.limits
+}
+
+// Check loads the portfolio and ATR, then runs Evaluate
+func (m *Manager) Check(ctx context.Context, p Proposal) (Decision, error) {
+	if m.portfolio == nil {
+		return Decision{}, fmt.Errorf("risk manager has no portfolio source")
+	}
+	portfolio, err := m.portfolio(ctx)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to load portfolio: %w", err)
+	}
+	if p.ATR <= 0 && m.atr != nil {
+		atr, err := m.atr(ctx, p.Symbol, m.limits.ATRPeriod)
+		if err != nil {
+			return Decision{}, fmt.Errorf("failed to get ATR for %s: %w", p.Symbol, err)
+		}
+		p.ATR = atr
+	}
+	return m.Evaluate(p, portfolio), nil
+}
+
+// Evaluate runs every rule and keeps the smallest quantity any of them allows.
+// Orders that only shrink an existing position are never blocked.
+func (m *Manager) Evaluate(p Proposal, portfolio Portfolio) Decision {
+	decision := Decision{Action: Approve, Quantity: p.Quantity, RequestedQuantity: p.Quantity}
+
+	if p.Price <= 0 {
+		return rejectWith(decision, fmt.Sprintf("invalid price %.2f", p.Price))
+	}
+	if portfolio.Equity <= 0 {
+		return rejectWith(decision, "account equity unknown or zero")
+	}
+
+	if reducesPosition(p, portfolio) {
+		decision.Reasons = append(decision.Reasons, "reduces an existing position - risk limits not applied")
+		return decision
+	}
+
+	allowed := p.Quantity
+	capped := p.Quantity > 0
+	for _, rule := range m.rules {
+		result := rule.Evaluate(p, portfolio, m.limits)
+		if result.Reason != "" {
+			decision.Reasons = append(decision.Reasons, fmt.Sprintf("%s: %s", rule.Name(), result.Reason))
+		}
+		if result.Reject {
+			return rejectWith(decision, "")
+		}
+		if result.MaxQuantity >= 0 && (!capped || result.MaxQuantity < allowed) {
+			allowed = result.MaxQuantity
+			capped = true
+		}
+	}
+
+	allowed = math.Floor(allowed)
+	if allowed < 1 {
+		return rejectWith(decision, "no size left after applying limits")
+	}
+
+	decision.Quantity = allowed
+	if p.Quantity > 0 && allowed < p.Quantity {
+		decision.Action = Trim
+	}
+	return decision
+}
+
+func rejectWith(d Decision, reason string) Decision {
+	d.Action = Reject
+	d.Quantity = 0
+	if reason != "" {
+		d.Reasons = append(d.Reasons, reason)
+	}
+	return d
+}
+
+func reducesPosition(p Proposal, portfolio Portfolio) bool {
+	pos, ok := portfolio.position(p.Symbol)
+	if !ok || p.Quantity <= 0 {
+		return false
+	}
+	if p.Side == "SELL" && pos.Quantity > 0 {
+		return p.Quantity <= pos.Quantity
+	}
+	if p.Side == "BUY" && pos.Quantity < 0 {
+		return p.Quantity <= -pos.Quantity
+	}
+	return false
+}
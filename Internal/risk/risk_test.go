@@ -0,0 +1,228 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+func testLimits() Limits {
+	return Limits{
+		MaxPositionPct:   10,
+		MaxExposurePct:   50,
+		MaxOpenPositions: 3,
+		MaxSymbolLossPct: 2,
+		MaxDailyLossPct:  3,
+		RiskPerTradePct:  1,
+		ATRMultiplier:    1,
+		ATRPeriod:        14,
+	}
+}
+
+func TestSizeByATR(t *testing.T) {
+	// $100k equity, 1% risk = $1000; ATR 2.5 with a 2x stop = $5/share -> 200 shares
+	if got := SizeByATR(100000, 1, 2.5, 2); got != 200 {
+		t.Errorf("SizeByATR() = %v, want 200", got)
+	}
+	if got := SizeByATR(100000, 1, 0, 1); got != 0 {
+		t.Errorf("zero ATR should size to 0, got %v", got)
+	}
+}
+
+func TestEvaluateApprovesWithinLimits(t *testing.T) {
+	m := NewManager(testLimits(), nil, nil)
+	d := m.Evaluate(Proposal{Symbol: "AAPL", Side: "BUY", Quantity: 10, Price: 100, ATR: 2}, Portfolio{Equity: 100000})
+	if d.Action != Approve || d.Quantity != 10 {
+		t.Errorf("expected approve 10, got %s", d)
+	}
+}
+
+func TestEvaluateTrimsToTightestRule(t *testing.T) {
+	m := NewManager(testLimits(), nil, nil)
+
+	// ATR allows 1000/2 = 500, position cap allows 10000/100 = 100
+	d := m.Evaluate(Proposal{Symbol: "AAPL", Side: "BUY", Quantity: 300, Price: 100, ATR: 2}, Portfolio{Equity: 100000})
+	if d.Action != Trim || d.Quantity != 100 {
+		t.Fatalf("expected trim to 100, got %s", d)
+	}
+	if len(d.Reasons) == 0 {
+		t.Error("trim should explain itself")
+	}
+
+	// ATR is the binding constraint here: 1000/20 = 50
+	d = m.Evaluate(Proposal{Symbol: "AAPL", Side: "BUY", Quantity: 80, Price: 100, ATR: 20}, Portfolio{Equity: 100000})
+	if d.Action != Trim || d.Quantity != 50 {
+		t.Errorf("expected ATR trim to 50, got %s", d)
+	}
+}
+
+func TestEvaluateSizesFromATRWhenNoQuantity(t *testing.T) {
+	m := NewManager(testLimits(), nil, nil)
+	d := m.Evaluate(Proposal{Symbol: "AAPL", Side: "BUY", Price: 50, ATR: 25}, Portfolio{Equity: 100000})
+	if d.Action != Approve || d.Quantity != 40 {
+		t.Errorf("expected ATR-sized 40 shares, got %s", d)
+	}
+
+	d = m.Evaluate(Proposal{Symbol: "AAPL", Side: "BUY", Price: 50}, Portfolio{Equity: 100000})
+	if d.Action != Reject {
+		t.Errorf("no quantity and no ATR should reject, got %s", d)
+	}
+}
+
+func TestEvaluateRejections(t *testing.T) {
+	m := NewManager(testLimits(), nil, nil)
+	proposal := Proposal{Symbol: "NVDA", Side: "BUY", Quantity: 5, Price: 100, ATR: 2}
+
+	cases := map[string]Portfolio{
+		"daily loss": {Equity: 100000, DailyPnL: -3500},
+		"open positions": {Equity: 100000, Positions: []Position{
+			{Symbol: "A", Quantity: 1, MarketValue: 100},
+			{Symbol: "B", Quantity: 1, MarketValue: 100},
+			{Symbol: "C", Quantity: 1, MarketValue: 100},
+		}},
+		"exposure": {Equity: 100000, Positions: []Position{{Symbol: "SPY", Quantity: 100, MarketValue: 49950}}},
+		"symbol loss": {Equity: 100000, Positions: []Position{
+			{Symbol: "NVDA", Quantity: 10, MarketValue: 1000, UnrealizedPnL: -2500},
+		}},
+		"position size": {Equity: 100000, Positions: []Position{{Symbol: "NVDA", Quantity: 100, MarketValue: 9950}}},
+	}
+
+	for name, portfolio := range cases {
+		d := m.Evaluate(proposal, portfolio)
+		if d.Action != Reject || d.Quantity != 0 {
+			t.Errorf("%s: expected reject, got %s", name, d)
+		}
+		if len(d.Reasons) == 0 {
+			t.Errorf("%s: rejection has no reason", name)
+		}
+	}
+}
+
+func TestEvaluateAlwaysAllowsReducingPosition(t *testing.T) {
+	m := NewManager(testLimits(), nil, nil)
+	portfolio := Portfolio{
+		Equity:   100000,
+		DailyPnL: -10000,
+		Positions: []Position{
+			{Symbol: "TSLA", Quantity: 50, MarketValue: 10000, UnrealizedPnL: -5000},
+		},
+	}
+	d := m.Evaluate(Proposal{Symbol: "TSLA", Side: "SELL", Quantity: 50, Price: 200}, portfolio)
+	if d.Action != Approve || d.Quantity != 50 {
+		t.Errorf("closing a losing position must be allowed, got %s", d)
+	}
+}
+
+func TestCheckLoadsPortfolioAndATR(t *testing.T) {
+	portfolio := func(ctx context.Context) (Portfolio, error) {
+		return Portfolio{Equity: 50000}, nil
+	}
+	atr := func(ctx context.Context, symbol string, period int) (float64, error) {
+		return 5, nil
+	}
+	m := NewManager(testLimits(), portfolio, atr)
+
+	d, err := m.Check(context.Background(), Proposal{Symbol: "MSFT", Side: "BUY", Quantity: 500, Price: 10})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	// $500 risk / $5 ATR = 100 shares; 10% of 50k = $5000 / $10 = 500 shares
+	if d.Quantity != 100 {
+		t.Errorf("expected 100 shares, got %s", d)
+	}
+}
+
+type positionRows []database.Position
+
+func (p positionRows) GetPositions(ctx context.Context) ([]database.Position, error) {
+	return p, nil
+}
+
+func TestStorePortfolioDailyPnL(t *testing.T) {
+	// AAPL was bought at 120 two days ago and closed at 100 yesterday; the row still holds the
+	// fill-time price. It trades at 98 now: down $2200 overall but only $200 today.
+	// MSFT was opened today at 50 and trades at 51.
+	rows := positionRows{
+		{
+			Symbol:        "AAPL",
+			Quantity:      "100",
+			AvgEntryPrice: "120",
+			CurrentPrice:  sql.NullString{String: "120", Valid: true},
+			MarketValue:   sql.NullString{String: "9800", Valid: true},
+			UnrealizedPnl: sql.NullString{String: "-2200", Valid: true},
+			OpenedAt:      sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, -2), Valid: true},
+		},
+		{
+			Symbol:        "MSFT",
+			Quantity:      "10",
+			AvgEntryPrice: "50",
+			OpenedAt:      sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		},
+	}
+	latest := func(ctx context.Context, symbol string) (float64, error) {
+		return map[string]float64{"AAPL": 98, "MSFT": 51}[symbol], nil
+	}
+	prevClose := func(ctx context.Context, symbol string) (float64, error) {
+		return map[string]float64{"AAPL": 100, "MSFT": 45}[symbol], nil
+	}
+
+	dryRun, err := StorePortfolio(rows, 100000, 0, latest, prevClose)(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dryRun.DailyPnL != -190 || dryRun.Positions[0].UnrealizedPnL != -2200 {
+		t.Errorf("dry run portfolio = %+v, want DailyPnL -190 (-200 on AAPL, +10 on MSFT)", dryRun)
+	}
+
+	// the broker's equity change includes what was realized today
+	live, err := StorePortfolio(rows, 100000, 101500, latest, prevClose)(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.DailyPnL != -1500 {
+		t.Errorf("live DailyPnL = %v, want -1500", live.DailyPnL)
+	}
+}
+
+func TestLimitsFromProfile(t *testing.T) {
+	cfg := &config.Config{Profiles: map[string]config.ProfileConfig{
+		"custom": {Risk: config.RiskConfig{MaxPositionPct: 7, MaxOpenPositions: 4}},
+	}}
+	limits, err := LimitsFromProfile(cfg, "custom")
+	if err != nil {
+		t.Fatalf("LimitsFromProfile() error = %v", err)
+	}
+	if limits.MaxPositionPct != 7 || limits.MaxOpenPositions != 4 {
+		t.Errorf("profile values not applied: %+v", limits)
+	}
+	if limits.RiskPerTradePct != DefaultLimits().RiskPerTradePct {
+		t.Errorf("unset values should keep defaults: %+v", limits)
+	}
+	if _, err := LimitsFromProfile(cfg, "missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestLatestATR(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]types.Bar, 20)
+	for i := range bars {
+		bars[i] = types.Bar{Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339), High: 102, Low: 98, Close: 100}
+	}
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atr, err := LatestATR(series, 14)
+	if err != nil {
+		t.Fatalf("LatestATR() error = %v", err)
+	}
+	if atr != 4 {
+		t.Errorf("LatestATR() = %v, want 4", atr)
+	}
+}
@@ -0,0 +1,142 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// RuleResult caps the quantity (MaxQuantity < 0 means no cap) or rejects outright
+type RuleResult struct {
+	MaxQuantity float64
+	Reject      bool
+	Reason      string
+}
+
+type Rule interface {
+	Name() string
+	Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult
+}
+
+func DefaultRules() []Rule {
+	return []Rule{
+		DailyLossRule{},
+		SymbolLossRule{},
+		OpenPositionsRule{},
+		ATRSizingRule{},
+		PositionSizeRule{},
+		ExposureRule{},
+	}
+}
+
+func noCap() RuleResult {
+	return RuleResult{MaxQuantity: -1}
+}
+
+type DailyLossRule struct{}
+
+func (DailyLossRule) Name() string { return "daily loss" }
+
+func (DailyLossRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	limit := portfolio.Equity * limits.MaxDailyLossPct / 100
+	if limits.MaxDailyLossPct > 0 && portfolio.DailyPnL <= -limit {
+		return RuleResult{Reject: true, Reason: fmt.Sprintf("down $%.2f today, limit is $%.2f (%.1f%% of equity)", -portfolio.DailyPnL, limit, limits.MaxDailyLossPct)}
+	}
+	return noCap()
+}
+
+type SymbolLossRule struct{}
+
+func (SymbolLossRule) Name() string { return "symbol loss" }
+
+func (SymbolLossRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	pos, ok := portfolio.position(p.Symbol)
+	if !ok || limits.MaxSymbolLossPct <= 0 {
+		return noCap()
+	}
+	limit := portfolio.Equity * limits.MaxSymbolLossPct / 100
+	if pos.UnrealizedPnL <= -limit {
+		return RuleResult{Reject: true, Reason: fmt.Sprintf("%s already down $%.2f, limit is $%.2f", p.Symbol, -pos.UnrealizedPnL, limit)}
+	}
+	return noCap()
+}
+
+type OpenPositionsRule struct{}
+
+func (OpenPositionsRule) Name() string { return "open positions" }
+
+func (OpenPositionsRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	if _, ok := portfolio.position(p.Symbol); ok || limits.MaxOpenPositions <= 0 {
+		return noCap()
+	}
+	if len(portfolio.Positions) >= limits.MaxOpenPositions {
+		return RuleResult{Reject: true, Reason: fmt.Sprintf("%d positions open, max is %d", len(portfolio.Positions), limits.MaxOpenPositions)}
+	}
+	return noCap()
+}
+
+// ATRSizingRule risks RiskPerTradePct of equity against a stop ATRMultiplier×ATR away
+type ATRSizingRule struct{}
+
+func (ATRSizingRule) Name() string { return "ATR sizing" }
+
+func (ATRSizingRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	if p.ATR <= 0 || limits.RiskPerTradePct <= 0 {
+		if p.Quantity <= 0 {
+			return RuleResult{Reject: true, Reason: "no quantity given and no ATR to size from"}
+		}
+		return noCap()
+	}
+	shares := SizeByATR(portfolio.Equity, limits.RiskPerTradePct, p.ATR, limits.ATRMultiplier)
+	return RuleResult{
+		MaxQuantity: shares,
+		Reason: fmt.Sprintf("risking $%.2f against a %.2f stop (%.1f×ATR %.2f) allows %.0f shares",
+			portfolio.Equity*limits.RiskPerTradePct/100, p.ATR*multiplier(limits), multiplier(limits), p.ATR, shares),
+	}
+}
+
+type PositionSizeRule struct{}
+
+func (PositionSizeRule) Name() string { return "position size" }
+
+func (PositionSizeRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	if limits.MaxPositionPct <= 0 {
+		return noCap()
+	}
+	maxValue := portfolio.Equity * limits.MaxPositionPct / 100
+	existing := 0.0
+	if pos, ok := portfolio.position(p.Symbol); ok {
+		existing = math.Abs(pos.MarketValue)
+	}
+	room := maxValue - existing
+	if room < p.Price {
+		return RuleResult{Reject: true, Reason: fmt.Sprintf("%s already at $%.2f of a $%.2f cap (%.1f%% of equity)", p.Symbol, existing, maxValue, limits.MaxPositionPct)}
+	}
+	shares := math.Floor(room / p.Price)
+	result := RuleResult{MaxQuantity: shares}
+	if p.Quantity > shares {
+		result.Reason = fmt.Sprintf("cap of $%.2f (%.1f%% of equity) allows %.0f shares", maxValue, limits.MaxPositionPct, shares)
+	}
+	return result
+}
+
+type ExposureRule struct{}
+
+func (ExposureRule) Name() string { return "exposure" }
+
+func (ExposureRule) Evaluate(p Proposal, portfolio Portfolio, limits Limits) RuleResult {
+	if limits.MaxExposurePct <= 0 {
+		return noCap()
+	}
+	maxValue := portfolio.Equity * limits.MaxExposurePct / 100
+	current := portfolio.grossExposure()
+	room := maxValue - current
+	if room < p.Price {
+		return RuleResult{Reject: true, Reason: fmt.Sprintf("gross exposure $%.2f already at the $%.2f cap", current, maxValue)}
+	}
+	shares := math.Floor(room / p.Price)
+	result := RuleResult{MaxQuantity: shares}
+	if p.Quantity > shares {
+		result.Reason = fmt.Sprintf("$%.2f of exposure left allows %.0f shares", room, shares)
+	}
+	return result
+}
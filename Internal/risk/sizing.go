@@ -0,0 +1,162 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// SizeByATR returns whole shares such that a stop atrMultiplier×ATR away loses riskPct of equity
+func SizeByATR(equity float64, riskPct float64, atr float64, atrMultiplier float64) float64 {
+	if atrMultiplier <= 0 {
+		atrMultiplier = 1
+	}
+	stopDistance := atr * atrMultiplier
+	if equity <= 0 || riskPct <= 0 || stopDistance <= 0 {
+		return 0
+	}
+	return math.Floor(equity * riskPct / 100 / stopDistance)
+}
+
+func multiplier(limits Limits) float64 {
+	if limits.ATRMultiplier <= 0 {
+		return 1
+	}
+	return limits.ATRMultiplier
+}
+
+// LatestATR runs strategy.CalculateATR over the series and returns the newest value
+func LatestATR(series types.BarSeries, period int) (float64, error) {
+	atrBars := make([]strategy.ATRBar, series.Len())
+	for i := range atrBars {
+		bar := series.At(i)
+		atrBars[i] = strategy.ATRBar{High: bar.High, Low: bar.Low, Close: bar.Close}
+	}
+	values, err := strategy.CalculateATR(atrBars, period)
+	if err != nil {
+		return 0, err
+	}
+	return values[len(values)-1], nil
+}
+
+// ProviderATR computes daily ATR from the active market data provider
+func ProviderATR(ctx context.Context, symbol string, period int) (float64, error) {
+	bars, err := datafeed.GetBars(ctx, symbol, "1Day", period*3, "")
+	if err != nil {
+		return 0, err
+	}
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bars for %s: %w", symbol, err)
+	}
+	return LatestATR(series, period)
+}
+
+type PositionLister interface {
+	GetPositions(ctx context.Context) ([]database.Position, error)
+}
+
+// PriceFunc returns a price for symbol, see ProviderPrice and ProviderPrevClose
+type PriceFunc func(ctx context.Context, symbol string) (float64, error)
+
+// ProviderPrice reads the latest quote from the active market data provider
+func ProviderPrice(ctx context.Context, symbol string) (float64, error) {
+	quote, err := datafeed.GetLatestQuote(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if quote.Price <= 0 {
+		return 0, fmt.Errorf("no ask price for %s", symbol)
+	}
+	return quote.Price, nil
+}
+
+// ProviderPrevClose reads the previous session's close from the active market data provider
+func ProviderPrevClose(ctx context.Context, symbol string) (float64, error) {
+	bars, err := datafeed.GetBars(ctx, symbol, "1Day", 2, "")
+	if err != nil {
+		return 0, err
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, bar := range bars {
+		if !strings.HasPrefix(bar.Timestamp, today) {
+			return bar.Close, nil
+		}
+	}
+	return 0, fmt.Errorf("no previous close for %s", symbol)
+}
+
+// StorePortfolio builds the portfolio from the positions table. With the broker's equity at the
+// previous close (lastEquity > 0) the day's P&L is the change in equity since then, realized and
+// unrealized. Without it (dry runs) it is how far the open positions moved today: each is priced
+// by latest and measured from prevClose, or from its entry when it was opened today. Shares added
+// to an older position count from the previous close, and realized P&L isn't tracked there.
+func StorePortfolio(store PositionLister, equity, lastEquity float64, latest, prevClose PriceFunc) PortfolioFunc {
+	return func(ctx context.Context) (Portfolio, error) {
+		rows, err := store.GetPositions(ctx)
+		if err != nil {
+			return Portfolio{}, err
+		}
+
+		portfolio := Portfolio{Equity: equity}
+		if lastEquity > 0 {
+			portfolio.DailyPnL = equity - lastEquity
+		}
+		for _, row := range rows {
+			quantity, err := strconv.ParseFloat(row.Quantity, 64)
+			if err != nil {
+				return Portfolio{}, fmt.Errorf("invalid quantity for %s: %w", row.Symbol, err)
+			}
+			pos := Position{Symbol: row.Symbol, Quantity: quantity}
+			if row.MarketValue.Valid {
+				pos.MarketValue, _ = strconv.ParseFloat(row.MarketValue.String, 64)
+			}
+			if row.UnrealizedPnl.Valid {
+				pos.UnrealizedPnL, _ = strconv.ParseFloat(row.UnrealizedPnl.String, 64)
+			}
+			portfolio.Positions = append(portfolio.Positions, pos)
+
+			if lastEquity > 0 || latest == nil || prevClose == nil {
+				continue
+			}
+			move, err := movedToday(ctx, row, latest, prevClose)
+			if err != nil {
+				return Portfolio{}, err
+			}
+			portfolio.DailyPnL += quantity * move
+		}
+		return portfolio, nil
+	}
+}
+
+// movedToday is how far one share of the position moved since the previous close, or since
+// its entry when it was opened today
+func movedToday(ctx context.Context, row database.Position, latest, prevClose PriceFunc) (float64, error) {
+	current, err := latest(ctx, row.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to price %s: %w", row.Symbol, err)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	if opened := row.OpenedAt; opened.Valid && opened.Time.UTC().Format("2006-01-02") == today {
+		entry, err := strconv.ParseFloat(row.AvgEntryPrice, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid entry price for %s: %w", row.Symbol, err)
+		}
+		return current - entry, nil
+	}
+
+	closed, err := prevClose(ctx, row.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to price %s at the previous close: %w", row.Symbol, err)
+	}
+	return current - closed, nil
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+-- support_levels holds the latest clustered zones per symbol, replaced on each detection
+ALTER TABLE support_levels
+    ADD COLUMN zone_low DECIMAL(10, 4),
+    ADD COLUMN zone_high DECIMAL(10, 4),
+    ADD COLUMN touches INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN strength DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN last_touched TIMESTAMP,
+    ADD COLUMN flipped BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE support_levels
+    DROP COLUMN zone_low,
+    DROP COLUMN zone_high,
+    DROP COLUMN touches,
+    DROP COLUMN strength,
+    DROP COLUMN last_touched,
+    DROP COLUMN flipped;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- whale events are upserted per bar, so keep only the first copy of any duplicates
+DELETE FROM whale_events
+WHERE id NOT IN (SELECT MIN(id) FROM whale_events GROUP BY symbol, timestamp);
+CREATE UNIQUE INDEX idx_whale_events_symbol_timestamp ON whale_events(symbol, timestamp);
+
+-- percent move of the close 1, 5 and 20 bars after the event, filled in as the bars arrive
+ALTER TABLE whale_events
+    ADD COLUMN change_1 DECIMAL,
+    ADD COLUMN change_5 DECIMAL,
+    ADD COLUMN change_20 DECIMAL,
+    ADD COLUMN held BOOLEAN;
+
+CREATE UNIQUE INDEX idx_whale_alerts_unique ON whale_alerts(symbol, alert_type, alert_timestamp);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_whale_alerts_unique;
+ALTER TABLE whale_events
+    DROP COLUMN change_1,
+    DROP COLUMN change_5,
+    DROP COLUMN change_20,
+    DROP COLUMN held;
+DROP INDEX IF EXISTS idx_whale_events_symbol_timestamp;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- when the position was opened (or flipped through zero); the risk check prices positions
+-- opened today from their entry instead of the previous close
+ALTER TABLE positions ADD COLUMN opened_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE positions DROP COLUMN opened_at;
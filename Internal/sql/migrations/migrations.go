@@ -0,0 +1,8 @@
+// Package migrations embeds the numbered schema migrations. Each file is NNNNN_name.sql
+// with goose-style "-- +goose Up" and "-- +goose Down" sections.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
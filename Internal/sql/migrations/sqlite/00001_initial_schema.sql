@@ -0,0 +1,261 @@
+-- +goose Up
+-- MongelMaker schema for SQLite, equivalent to Postgres migrations 00001-00009.
+-- INTEGER PRIMARY KEY stands in for SERIAL; timestamps are stored as UTC text.
+
+CREATE TABLE historical_bars (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    timeframe VARCHAR(10) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    open_price DECIMAL(10, 4) NOT NULL,
+    high_price DECIMAL(10, 4) NOT NULL,
+    low_price DECIMAL(10, 4) NOT NULL,
+    close_price DECIMAL(10, 4) NOT NULL,
+    volume BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    price_change DECIMAL(10, 4),
+    price_change_percent DECIMAL(8, 4),
+    UNIQUE(symbol, timeframe, timestamp)
+);
+
+CREATE TABLE signals (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    signal_type VARCHAR(10) NOT NULL,
+    current_price DECIMAL(10, 4) NOT NULL,
+    sma_value DECIMAL(10, 4),
+    confidence DECIMAL(3, 2),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    executed BOOLEAN DEFAULT FALSE
+);
+
+CREATE TABLE trades (
+    id INTEGER PRIMARY KEY,
+    signal_id INTEGER REFERENCES signals(id),
+    symbol VARCHAR(10) NOT NULL,
+    side VARCHAR(4) NOT NULL,
+    quantity DECIMAL(10, 4) NOT NULL,
+    price DECIMAL(10, 4) NOT NULL,
+    total_value DECIMAL(12, 4) NOT NULL,
+    commission DECIMAL(8, 4) DEFAULT 0,
+    alpaca_order_id VARCHAR(50),
+    status VARCHAR(20) DEFAULT 'PENDING',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    filled_at TIMESTAMP
+);
+
+CREATE TABLE positions (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) UNIQUE NOT NULL,
+    quantity DECIMAL(10, 4) NOT NULL,
+    avg_entry_price DECIMAL(10, 4) NOT NULL,
+    current_price DECIMAL(10, 4),
+    market_value DECIMAL(12, 4),
+    unrealized_pnl DECIMAL(12, 4),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE portfolio_history (
+    id INTEGER PRIMARY KEY,
+    total_equity DECIMAL(12, 4) NOT NULL,
+    cash_balance DECIMAL(12, 4) NOT NULL,
+    positions_value DECIMAL(12, 4) NOT NULL,
+    day_change DECIMAL(12, 4),
+    total_return DECIMAL(12, 4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_historical_bars_symbol_timeframe ON historical_bars(symbol, timeframe);
+CREATE INDEX idx_historical_bars_timestamp ON historical_bars(timestamp);
+CREATE INDEX idx_signals_symbol_created ON signals(symbol, created_at);
+CREATE INDEX idx_trades_symbol_created ON trades(symbol, created_at);
+
+CREATE TABLE scout_list (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) UNIQUE NOT NULL,
+    reason VARCHAR(255),
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    trigger_price DECIMAL(10, 4),
+    trigger_type VARCHAR(10),
+    is_active BOOLEAN DEFAULT TRUE,
+    notes TEXT
+);
+
+CREATE TABLE candle_daily_bollinger (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    calculation_date DATE NOT NULL,
+    time_period INTEGER NOT NULL,
+    average_price DECIMAL(10, 4) NOT NULL,
+    standard_deviation DECIMAL(10, 4) NOT NULL,
+    upper_band DECIMAL(10, 4) NOT NULL,
+    lower_band DECIMAL(10, 4) NOT NULL,
+    current_price DECIMAL(10, 4) NOT NULL,
+    deviation_from_avg DECIMAL(10, 4) NOT NULL,
+    UNIQUE(symbol, calculation_date, time_period)
+);
+
+CREATE TABLE candles_daily (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    candle_date DATE NOT NULL,
+    open_price DECIMAL(10, 4) NOT NULL,
+    high_price DECIMAL(10, 4) NOT NULL,
+    low_price DECIMAL(10, 4) NOT NULL,
+    close_price DECIMAL(10, 4) NOT NULL,
+    volume BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(symbol, candle_date)
+);
+
+CREATE TABLE candles_12h (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    candle_timestamp TIMESTAMP NOT NULL,
+    open_price DECIMAL(10, 4) NOT NULL,
+    high_price DECIMAL(10, 4) NOT NULL,
+    low_price DECIMAL(10, 4) NOT NULL,
+    close_price DECIMAL(10, 4) NOT NULL,
+    volume BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(symbol, candle_timestamp)
+);
+
+CREATE TABLE news_articles (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    headline TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    published_at TIMESTAMP NOT NULL,
+    source VARCHAR(100),
+    sentiment VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE whale_alerts (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    amount DECIMAL(20, 4) NOT NULL,
+    price DECIMAL(10, 4) NOT NULL,
+    alert_timestamp TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE support_levels (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    level_price DECIMAL(10, 4) NOT NULL,
+    level_type VARCHAR(10) NOT NULL,
+    detected_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_bollinger_symbol_period ON candle_daily_bollinger(symbol, time_period, calculation_date);
+CREATE INDEX idx_news_symbol_date ON news_articles(symbol, published_at);
+CREATE INDEX idx_news_date ON news_articles(published_at DESC);
+CREATE INDEX idx_whale_symbol_time ON whale_alerts(symbol, alert_timestamp);
+CREATE INDEX idx_whale_time ON whale_alerts(alert_timestamp DESC);
+CREATE INDEX idx_support_symbol_type ON support_levels(symbol, level_type);
+
+CREATE TABLE rsi_calculation (
+    symbol TEXT NOT NULL,
+    calculation_timestamp TIMESTAMP NOT NULL,
+    rsi_value REAL NOT NULL,
+    UNIQUE (symbol, calculation_timestamp)
+);
+
+CREATE TABLE atr_calculation (
+    symbol TEXT NOT NULL,
+    calculation_timestamp TIMESTAMP NOT NULL,
+    atr_value REAL NOT NULL,
+    UNIQUE (symbol, calculation_timestamp)
+);
+
+CREATE TABLE whale_events (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    direction TEXT NOT NULL,
+    volume BIGINT NOT NULL,
+    z_score DECIMAL NOT NULL,
+    close_price DECIMAL NOT NULL,
+    price_change DECIMAL,
+    conviction TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_whale_events_symbol_time ON whale_events(symbol, timestamp DESC);
+
+CREATE TABLE watchlist (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT NOT NULL UNIQUE,
+    asset_type TEXT NOT NULL,
+    score REAL NOT NULL,
+    reason TEXT,
+    added_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status TEXT DEFAULT 'active'
+);
+
+CREATE TABLE watchlist_history (
+    id INTEGER PRIMARY KEY,
+    watchlist_id INTEGER NOT NULL,
+    old_score REAL,
+    new_score REAL NOT NULL,
+    analysis_data TEXT,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(watchlist_id) REFERENCES watchlist(id)
+);
+
+CREATE TABLE skip_backlog (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT NOT NULL UNIQUE,
+    asset_type TEXT NOT NULL,
+    reason TEXT,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    recheck_after TIMESTAMP NOT NULL
+);
+
+CREATE TABLE scan_log (
+    id INTEGER PRIMARY KEY,
+    profile_name VARCHAR(50) NOT NULL UNIQUE,
+    last_scan_timestamp TIMESTAMP NOT NULL,
+    next_scan_due TIMESTAMP NOT NULL,
+    symbols_scanned INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE scout_skip_list (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    asset_type TEXT NOT NULL,
+    reason TEXT,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    recheck_after TIMESTAMP NOT NULL,
+    CONSTRAINT unique_symbol_profile UNIQUE (symbol, profile_name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS scout_skip_list;
+DROP TABLE IF EXISTS scan_log;
+DROP TABLE IF EXISTS skip_backlog;
+DROP TABLE IF EXISTS watchlist_history;
+DROP TABLE IF EXISTS watchlist;
+DROP TABLE IF EXISTS whale_events;
+DROP TABLE IF EXISTS atr_calculation;
+DROP TABLE IF EXISTS rsi_calculation;
+DROP TABLE IF EXISTS support_levels;
+DROP TABLE IF EXISTS whale_alerts;
+DROP TABLE IF EXISTS news_articles;
+DROP TABLE IF EXISTS candles_12h;
+DROP TABLE IF EXISTS candles_daily;
+DROP TABLE IF EXISTS candle_daily_bollinger;
+DROP TABLE IF EXISTS scout_list;
+DROP TABLE IF EXISTS portfolio_history;
+DROP TABLE IF EXISTS positions;
+DROP TABLE IF EXISTS trades;
+DROP TABLE IF EXISTS signals;
+DROP TABLE IF EXISTS historical_bars;
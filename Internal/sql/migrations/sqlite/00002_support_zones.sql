@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE support_levels ADD COLUMN zone_low DECIMAL(10, 4);
+ALTER TABLE support_levels ADD COLUMN zone_high DECIMAL(10, 4);
+ALTER TABLE support_levels ADD COLUMN touches INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE support_levels ADD COLUMN strength DECIMAL(10, 4) NOT NULL DEFAULT 0;
+ALTER TABLE support_levels ADD COLUMN last_touched TIMESTAMP;
+ALTER TABLE support_levels ADD COLUMN flipped BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE support_levels DROP COLUMN flipped;
+ALTER TABLE support_levels DROP COLUMN last_touched;
+ALTER TABLE support_levels DROP COLUMN strength;
+ALTER TABLE support_levels DROP COLUMN touches;
+ALTER TABLE support_levels DROP COLUMN zone_high;
+ALTER TABLE support_levels DROP COLUMN zone_low;
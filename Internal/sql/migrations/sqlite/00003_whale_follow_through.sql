@@ -0,0 +1,19 @@
+-- +goose Up
+DELETE FROM whale_events
+WHERE id NOT IN (SELECT MIN(id) FROM whale_events GROUP BY symbol, timestamp);
+CREATE UNIQUE INDEX idx_whale_events_symbol_timestamp ON whale_events(symbol, timestamp);
+
+ALTER TABLE whale_events ADD COLUMN change_1 DECIMAL;
+ALTER TABLE whale_events ADD COLUMN change_5 DECIMAL;
+ALTER TABLE whale_events ADD COLUMN change_20 DECIMAL;
+ALTER TABLE whale_events ADD COLUMN held BOOLEAN;
+
+CREATE UNIQUE INDEX idx_whale_alerts_unique ON whale_alerts(symbol, alert_type, alert_timestamp);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_whale_alerts_unique;
+ALTER TABLE whale_events DROP COLUMN held;
+ALTER TABLE whale_events DROP COLUMN change_20;
+ALTER TABLE whale_events DROP COLUMN change_5;
+ALTER TABLE whale_events DROP COLUMN change_1;
+DROP INDEX IF EXISTS idx_whale_events_symbol_timestamp;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE positions ADD COLUMN opened_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE positions DROP COLUMN opened_at;
@@ -0,0 +1,9 @@
+// Package sqlite embeds the schema migrations for the SQLite backend. They follow the same
+// NNNNN_name.sql layout as the Postgres migrations one directory up, but start from the
+// current schema instead of replaying its history.
+package sqlite
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
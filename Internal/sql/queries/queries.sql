@@ -40,6 +40,18 @@ VALUES ($1, $2, $3)
 ON CONFLICT (symbol, calculation_timestamp)
 DO UPDATE SET atr_value = EXCLUDED.atr_value;
 
+-- name: SaveBollinger :exec
+INSERT INTO candle_daily_bollinger (symbol, calculation_date, time_period, average_price, standard_deviation,
+    upper_band, lower_band, current_price, deviation_from_avg)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (symbol, calculation_date, time_period) DO UPDATE SET
+    average_price = EXCLUDED.average_price,
+    standard_deviation = EXCLUDED.standard_deviation,
+    upper_band = EXCLUDED.upper_band,
+    lower_band = EXCLUDED.lower_band,
+    current_price = EXCLUDED.current_price,
+    deviation_from_avg = EXCLUDED.deviation_from_avg;
+
 -- name: GetRSIForDateRange :many
 SELECT calculation_timestamp, rsi_value
 FROM rsi_calculation
@@ -95,6 +107,16 @@ WHERE symbol = $1
 AND published_at > NOW() - INTERVAL '7 days'
 ORDER BY published_at DESC;
 
+-- name: ListNewsArticles :many
+SELECT id, symbol, headline, url, published_at, source, sentiment, created_at
+FROM news_articles
+WHERE symbol = $1
+ORDER BY published_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountNewsArticles :one
+SELECT COUNT(*) FROM news_articles WHERE symbol = $1;
+
 -- name: GetWhaleEventsBySymbol :many
 SELECT * FROM whale_events
 WHERE symbol = $1 AND timestamp > NOW() - INTERVAL '7 days'
@@ -107,15 +129,68 @@ WHERE symbol = $1 AND conviction = 'HIGH'
 ORDER BY z_score DESC
 LIMIT 10;
 
--- name: CreateWhaleEvent :exec
+-- name: ListWhaleEvents :many
+SELECT * FROM whale_events
+WHERE symbol = $1
+ORDER BY timestamp DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWhaleEvents :one
+SELECT COUNT(*) FROM whale_events WHERE symbol = $1;
+
+-- name: UpsertWhaleEvent :exec
+-- Follow-through already measured is kept when a later window can't see that far
 INSERT INTO whale_events (
-    symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+    symbol, timestamp, direction, volume, z_score, close_price, price_change, conviction, change_1, change_5, change_20, held
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (symbol, timestamp) DO UPDATE SET
+    direction = EXCLUDED.direction,
+    volume = EXCLUDED.volume,
+    z_score = EXCLUDED.z_score,
+    close_price = EXCLUDED.close_price,
+    price_change = EXCLUDED.price_change,
+    conviction = EXCLUDED.conviction,
+    change_1 = COALESCE(EXCLUDED.change_1, whale_events.change_1),
+    change_5 = COALESCE(EXCLUDED.change_5, whale_events.change_5),
+    change_20 = COALESCE(EXCLUDED.change_20, whale_events.change_20),
+    held = COALESCE(EXCLUDED.held, whale_events.held);
+
+-- name: CreateWhaleAlert :exec
+INSERT INTO whale_alerts (symbol, alert_type, amount, price, alert_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (symbol, alert_type, alert_timestamp) DO NOTHING;
+
+-- name: GetWhaleAlerts :many
+SELECT * FROM whale_alerts
+WHERE symbol = $1
+ORDER BY alert_timestamp DESC
+LIMIT $2;
+
+-- name: DeleteSupportLevels :exec
+DELETE FROM support_levels WHERE symbol = $1;
+
+-- name: CreateSupportLevel :exec
+INSERT INTO support_levels (
+    symbol, level_price, level_type, detected_at, zone_low, zone_high, touches, strength, last_touched, flipped
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetSupportLevels :many
+SELECT * FROM support_levels
+WHERE symbol = $1
+ORDER BY level_price;
 
 -- name: AddToWatchlist :one
--- Add a new candidate to watchlist and return the ID
+-- Add a new candidate to watchlist and return the ID (re-activates an archived symbol)
 INSERT INTO watchlist (symbol, asset_type, score, reason, added_date, last_updated, status)
 VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'active')
+ON CONFLICT (symbol) DO UPDATE SET
+    asset_type = EXCLUDED.asset_type,
+    score = EXCLUDED.score,
+    reason = EXCLUDED.reason,
+    added_date = CURRENT_TIMESTAMP,
+    last_updated = CURRENT_TIMESTAMP,
+    status = 'active'
+WHERE watchlist.status = 'archived'
 RETURNING id;
 
 -- name: GetWatchlist :many
@@ -137,11 +212,28 @@ UPDATE watchlist
 SET score = $1, last_updated = CURRENT_TIMESTAMP
 WHERE symbol = $2;
 
+-- name: RemoveFromWatchlist :execrows
+-- Archive an active symbol; history rows keep pointing at it
+UPDATE watchlist
+SET status = 'archived', last_updated = CURRENT_TIMESTAMP
+WHERE symbol = $1 AND status = 'active';
+
 -- name: AddWatchlistHistory :exec
 -- Log score change with full analysis data (as JSON)
 INSERT INTO watchlist_history (watchlist_id, old_score, new_score, analysis_data, timestamp)
 VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP);
 
+-- name: ListWatchlistHistory :many
+-- Score changes for one watchlist item, newest first
+SELECT id, watchlist_id, old_score, new_score, analysis_data, timestamp
+FROM watchlist_history
+WHERE watchlist_id = $1
+ORDER BY timestamp DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWatchlistHistory :one
+SELECT COUNT(*) FROM watchlist_history WHERE watchlist_id = $1;
+
 -- name: ArchiveOldWatchlist :exec
 -- Archive symbols with unchanged score for 30+ days
 UPDATE watchlist
@@ -149,13 +241,13 @@ SET status = 'archived'
 WHERE id IN (
   SELECT w.id FROM watchlist w
   WHERE w.status = 'active'
-  AND datetime(w.last_updated) < datetime('now', '-30 days')
+  AND w.last_updated < NOW() - INTERVAL '30 days'
 );
 
 -- name: SkipSymbol :exec
 -- Add to skip backlog (recheck in 30 days)
 INSERT INTO skip_backlog (symbol, asset_type, reason, timestamp, recheck_after)
-VALUES ($1, $2, $3, CURRENT_TIMESTAMP, datetime('now', '+30 days'));
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, NOW() + INTERVAL '30 days');
 
 -- name: GetRecheckableSymbols :many
 -- Get symbols from skip backlog that are ready for reconsideration
@@ -199,4 +291,88 @@ SELECT COUNT(*) > 0 as is_skipped
 FROM scout_skip_list
 WHERE symbol = $1 
   AND profile_name = $2 
-  AND recheck_after > NOW();
\ No newline at end of file
+  AND recheck_after > NOW();
+
+-- name: GetHistoricalBars :many
+SELECT timestamp, open_price, high_price, low_price, close_price, volume
+FROM historical_bars
+WHERE symbol = $1
+  AND timeframe = $2
+  AND timestamp >= $3
+  AND timestamp <= $4
+ORDER BY timestamp ASC;
+
+-- name: GetRecentHistoricalBars :many
+SELECT timestamp, open_price, high_price, low_price, close_price, volume
+FROM historical_bars
+WHERE symbol = $1
+  AND timeframe = $2
+ORDER BY timestamp DESC
+LIMIT $3;
+
+-- name: ListHistoricalBarSymbols :many
+SELECT DISTINCT symbol
+FROM historical_bars
+ORDER BY symbol;
+
+-- name: CreateSignal :one
+INSERT INTO signals (symbol, signal_type, current_price, confidence)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: MarkSignalExecuted :exec
+UPDATE signals SET executed = TRUE WHERE id = $1;
+
+-- name: CreateTrade :one
+INSERT INTO trades (signal_id, symbol, side, quantity, price, total_value, commission, alpaca_order_id, status, filled_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id;
+
+-- name: UpdateTradeFill :exec
+UPDATE trades
+SET status = $2, quantity = $3, price = $4, total_value = $5, filled_at = $6
+WHERE id = $1;
+
+-- name: GetPendingTrades :many
+SELECT * FROM trades
+WHERE status IN ('PENDING', 'PARTIALLY_FILLED')
+ORDER BY created_at ASC;
+
+-- name: GetRecentTrades :many
+SELECT * FROM trades
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: GetPosition :one
+SELECT * FROM positions WHERE symbol = $1;
+
+-- name: GetPositions :many
+SELECT * FROM positions ORDER BY symbol;
+
+-- name: UpsertPosition :exec
+INSERT INTO positions (symbol, quantity, avg_entry_price, current_price, market_value, unrealized_pnl, updated_at, opened_at)
+VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
+ON CONFLICT (symbol) DO UPDATE SET
+    quantity = EXCLUDED.quantity,
+    avg_entry_price = EXCLUDED.avg_entry_price,
+    current_price = EXCLUDED.current_price,
+    market_value = EXCLUDED.market_value,
+    unrealized_pnl = EXCLUDED.unrealized_pnl,
+    updated_at = CURRENT_TIMESTAMP,
+    opened_at = EXCLUDED.opened_at;
+
+-- name: DeletePosition :exec
+DELETE FROM positions WHERE symbol = $1;
+
+-- name: UpsertHistoricalBar :exec
+INSERT INTO historical_bars (symbol, timeframe, timestamp, open_price, high_price, low_price, close_price,
+    volume, price_change, price_change_percent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (symbol, timeframe, timestamp) DO UPDATE SET
+    open_price = EXCLUDED.open_price,
+    high_price = EXCLUDED.high_price,
+    low_price = EXCLUDED.low_price,
+    close_price = EXCLUDED.close_price,
+    volume = EXCLUDED.volume,
+    price_change = EXCLUDED.price_change,
+    price_change_percent = EXCLUDED.price_change_percent;
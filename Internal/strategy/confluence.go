@@ -0,0 +1,306 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+const (
+	BiasBullish = "BULLISH"
+	BiasBearish = "BEARISH"
+	BiasNeutral = "NEUTRAL"
+)
+
+// scores within this distance of zero count as neutral
+const confluenceNeutralBand = 0.5
+
+// DefaultConfluenceWeights leans on the slower timeframes, which carry the trend
+func DefaultConfluenceWeights() map[string]float64 {
+	return map[string]float64{"1Min": 0.1, "5Min": 0.2, "1Hour": 0.3, "1Day": 0.4}
+}
+
+// TimeframeReading is what RSI, VWAP, support/resistance and the latest candle say on one timeframe
+type TimeframeReading struct {
+	Timeframe  string   `json:"timeframe"`
+	Bars       int      `json:"bars"`
+	Close      float64  `json:"close"`
+	RSI        *float64 `json:"rsi,omitempty"`
+	RSISignal  string   `json:"rsi_signal,omitempty"` // oversold, neutral or overbought
+	VWAPTrend  int      `json:"vwap_trend"`           // 1 above VWAP, -1 below, 0 at it
+	Support    float64  `json:"support"`
+	Resistance float64  `json:"resistance"`
+	Level      string   `json:"level,omitempty"` // "support" or "resistance" when price sits on one
+	Pattern    string   `json:"pattern,omitempty"`
+	Score      float64  `json:"score"` // -3 to 3, the mean of the components above
+	Bias       string   `json:"bias"`
+	Weight     float64  `json:"weight"`
+}
+
+// Confluence compares the timeframe readings. Score is their weighted mean and Alignment the
+// share of the weight whose bias matches the overall one.
+type Confluence struct {
+	Symbol     string             `json:"symbol"`
+	Readings   []TimeframeReading `json:"readings"` // shortest timeframe first
+	Score      float64            `json:"score"`
+	Bias       string             `json:"bias"`
+	Alignment  float64            `json:"alignment"`
+	Agreements []string           `json:"agreements"`
+	Conflicts  []string           `json:"conflicts"`
+	Missing    []string           `json:"missing,omitempty"` // timeframes that could not be loaded
+}
+
+func biasFor(score float64) string {
+	if score > confluenceNeutralBand {
+		return BiasBullish
+	} else if score < -confluenceNeutralBand {
+		return BiasBearish
+	}
+	return BiasNeutral
+}
+
+// ReadTimeframe scores one timeframe. Each available component is scored on the signal
+// scale and averaged: RSI as in the combined signal, VWAP trend ±2, support/resistance ±2
+// and the candle pattern.
+func ReadTimeframe(timeframe string, series types.BarSeries) TimeframeReading {
+	r := TimeframeReading{Timeframe: timeframe, Bars: series.Len()}
+	if series.Len() == 0 {
+		r.Bias = BiasNeutral
+		return r
+	}
+	r.Close = series.Latest().Close
+
+	var scores []float64
+	if rsiValues, err := CalculateRSI(series.Closes(), 14); err == nil {
+		rsi := rsiValues[len(rsiValues)-1]
+		r.RSI = &rsi
+		r.RSISignal = DetermineRSISignal(rsi)
+		scores = append(scores, calculateRSIScore(rsi))
+	}
+
+	r.VWAPTrend = NewVWAPCalculator(series).GetVWAPTrend()
+	scores = append(scores, 2*float64(r.VWAPTrend))
+
+	levels := AnalyzeLevels(series)
+	r.Support = levels.Support.Price
+	r.Resistance = levels.Resistance.Price
+	if levels.Support.Near(r.Close) {
+		r.Level = "support"
+	} else if levels.Resistance.Near(r.Close) {
+		r.Level = "resistance"
+	}
+	scores = append(scores, 2*calculateSRScore(series))
+
+	classify, detect := currentPatternClassifier(), currentPatternDetector()
+	if classify != nil || detect != nil {
+		var matches []types.PatternMatch
+		if classify != nil {
+			r.Pattern = classify(series.Latest())
+		}
+		if detect != nil {
+			matches = detect(series)
+		}
+		if m, ok := strongestPattern(matches); ok {
+			r.Pattern = m.Name
+		}
+		scores = append(scores, calculatePatternMatchScore(matches, r.Pattern))
+	}
+
+	for _, s := range scores {
+		r.Score += s
+	}
+	r.Score /= float64(len(scores))
+	r.Bias = biasFor(r.Score)
+	return r
+}
+
+// AnalyzeConfluence reads every weighted timeframe in frames and reports where they agree or
+// conflict. A nil weights map uses DefaultConfluenceWeights; frames without a weight are ignored.
+func AnalyzeConfluence(symbol string, frames map[string]types.BarSeries, weights map[string]float64) Confluence {
+	if weights == nil {
+		weights = DefaultConfluenceWeights()
+	}
+	c := Confluence{Symbol: symbol, Bias: BiasNeutral, Agreements: []string{}, Conflicts: []string{}}
+
+	totalWeight := 0.0
+	for timeframe, series := range frames {
+		weight := weights[timeframe]
+		if weight <= 0 || series.Len() < 2 {
+			continue
+		}
+		r := ReadTimeframe(timeframe, series)
+		r.Weight = weight
+		c.Readings = append(c.Readings, r)
+		c.Score += r.Score * weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return c
+	}
+	sort.Slice(c.Readings, func(i, j int) bool {
+		return timeframeRank(c.Readings[i].Timeframe) < timeframeRank(c.Readings[j].Timeframe)
+	})
+
+	c.Score /= totalWeight
+	c.Bias = biasFor(c.Score)
+	for _, r := range c.Readings {
+		if r.Bias == c.Bias {
+			c.Alignment += r.Weight / totalWeight
+		}
+	}
+	c.compare()
+	return c
+}
+
+// compare fills Agreements and Conflicts from the trend, momentum and level of each reading
+func (c *Confluence) compare() {
+	if len(c.Readings) < 2 {
+		return
+	}
+	group := func(key func(TimeframeReading) string) map[string][]string {
+		groups := map[string][]string{}
+		for _, r := range c.Readings {
+			if k := key(r); k != "" {
+				groups[k] = append(groups[k], r.Timeframe)
+			}
+		}
+		return groups
+	}
+	all := len(c.Readings)
+
+	bias := group(func(r TimeframeReading) string { return r.Bias })
+	bullish, bearish := bias[BiasBullish], bias[BiasBearish]
+	switch {
+	case len(bullish) > 0 && len(bearish) > 0:
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("%s lean bullish while %s lean bearish", join(bullish), join(bearish)))
+	case len(bullish) == all:
+		c.Agreements = append(c.Agreements, "every timeframe leans bullish")
+	case len(bearish) == all:
+		c.Agreements = append(c.Agreements, "every timeframe leans bearish")
+	}
+
+	vwap := group(func(r TimeframeReading) string {
+		switch r.VWAPTrend {
+		case 1:
+			return "above"
+		case -1:
+			return "below"
+		}
+		return ""
+	})
+	above, below := vwap["above"], vwap["below"]
+	switch {
+	case len(above) > 0 && len(below) > 0:
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("price is above VWAP on %s but below on %s", join(above), join(below)))
+	case len(above) == all:
+		c.Agreements = append(c.Agreements, "price is above VWAP on every timeframe")
+	case len(below) == all:
+		c.Agreements = append(c.Agreements, "price is below VWAP on every timeframe")
+	}
+
+	rsi := group(func(r TimeframeReading) string { return r.RSISignal })
+	oversold, overbought := rsi["oversold"], rsi["overbought"]
+	if len(oversold) > 0 && len(overbought) > 0 {
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("RSI is oversold on %s but overbought on %s", join(oversold), join(overbought)))
+	} else if len(oversold) > 1 {
+		c.Agreements = append(c.Agreements, fmt.Sprintf("RSI is oversold on %s", join(oversold)))
+	} else if len(overbought) > 1 {
+		c.Agreements = append(c.Agreements, fmt.Sprintf("RSI is overbought on %s", join(overbought)))
+	}
+	// a stretched RSI against the other timeframes' trend is the classic pullback/exhaustion split
+	if trendUp := without(above, oversold); len(oversold) > 0 && len(overbought) == 0 && len(trendUp) > 0 {
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("RSI is oversold on %s while %s trend up", join(oversold), join(trendUp)))
+	}
+	if trendDown := without(below, overbought); len(overbought) > 0 && len(oversold) == 0 && len(trendDown) > 0 {
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("RSI is overbought on %s while %s trend down", join(overbought), join(trendDown)))
+	}
+
+	levels := group(func(r TimeframeReading) string { return r.Level })
+	atSupport, atResistance := levels["support"], levels["resistance"]
+	if len(atSupport) > 0 && len(atResistance) > 0 {
+		c.Conflicts = append(c.Conflicts, fmt.Sprintf("price is at support on %s but at resistance on %s", join(atSupport), join(atResistance)))
+	} else if len(atSupport) > 1 {
+		c.Agreements = append(c.Agreements, fmt.Sprintf("price is at support on %s", join(atSupport)))
+	} else if len(atResistance) > 1 {
+		c.Agreements = append(c.Agreements, fmt.Sprintf("price is at resistance on %s", join(atResistance)))
+	}
+}
+
+// FetchConfluence loads datafeed.MultiTimeframes for symbol and analyzes them with the default
+// weights. A nil provider uses the active one. Timeframes that fail are listed in Missing.
+func FetchConfluence(ctx context.Context, provider datafeed.MarketDataProvider, symbol string) (Confluence, error) {
+	if provider == nil {
+		provider = datafeed.GetProvider()
+	}
+	data, err := datafeed.FetchAllTimeframesFrom(ctx, provider, symbol, "1Day", 100)
+	if err != nil {
+		return Confluence{}, err
+	}
+
+	loaded := data.ByTimeframe()
+	frames := map[string]types.BarSeries{}
+	var missing []string
+	for _, timeframe := range datafeed.MultiTimeframes {
+		bars, ok := loaded[timeframe]
+		if !ok {
+			missing = append(missing, timeframe)
+			continue
+		}
+		series, err := types.NewBarSeries(bars)
+		if err != nil {
+			missing = append(missing, timeframe)
+			continue
+		}
+		frames[timeframe] = series
+	}
+
+	c := AnalyzeConfluence(symbol, frames, nil)
+	c.Missing = missing
+	if len(c.Readings) == 0 {
+		return c, fmt.Errorf("no timeframe had enough bars for %s", symbol)
+	}
+	return c, nil
+}
+
+// FormatConfluence summarises a confluence on one line
+func FormatConfluence(c Confluence) string {
+	return fmt.Sprintf("%s %+.2f across %d timeframes (%.0f%% aligned, %d conflicts)",
+		c.Bias, c.Score, len(c.Readings), c.Alignment*100, len(c.Conflicts))
+}
+
+// timeframeRank orders timeframes as datafeed.Timeframes does, unknown ones last
+func timeframeRank(timeframe string) int {
+	for i, tf := range datafeed.Timeframes {
+		if tf == timeframe {
+			return i
+		}
+	}
+	return len(datafeed.Timeframes)
+}
+
+func join(timeframes []string) string {
+	return strings.Join(timeframes, ", ")
+}
+
+func without(timeframes, drop []string) []string {
+	var kept []string
+	for _, tf := range timeframes {
+		if !contains(drop, tf) {
+			kept = append(kept, tf)
+		}
+	}
+	return kept
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
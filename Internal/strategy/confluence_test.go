@@ -0,0 +1,139 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+// trendBars moves the close by step every bar, oldest first
+func trendBars(n int, start, step float64, every time.Duration) types.BarSeries {
+	t0 := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+	bars := make([]types.Bar, n)
+	for i := range bars {
+		price := start + step*float64(i)
+		bars[i] = types.Bar{Timestamp: t0.Add(time.Duration(i) * every).Format(time.RFC3339),
+			Open: price - step/2, High: price + 0.1, Low: price - 0.1, Close: price, Volume: 1000}
+	}
+	return seriesOf(bars)
+}
+
+func TestConfluenceReportsConflicts(t *testing.T) {
+	// daily is sliding into support (oversold, below VWAP) while the hourly chart rallies
+	frames := map[string]types.BarSeries{
+		"1Day":  trendBars(40, 140, -1, 24*time.Hour),
+		"1Hour": trendBars(40, 100, 0.5, time.Hour),
+	}
+	c := AnalyzeConfluence("AAPL", frames, nil)
+
+	if len(c.Readings) != 2 || c.Readings[0].Timeframe != "1Hour" || c.Readings[1].Timeframe != "1Day" {
+		t.Fatalf("readings should run shortest timeframe first, got %+v", c.Readings)
+	}
+	daily, hourly := c.Readings[1], c.Readings[0]
+	if daily.RSISignal != "oversold" || daily.VWAPTrend != -1 || daily.Level != "support" || daily.Bias != BiasBullish {
+		t.Errorf("daily reading = %+v", daily)
+	}
+	if hourly.RSISignal != "overbought" || hourly.VWAPTrend != 1 || hourly.Level != "resistance" || hourly.Bias != BiasBearish {
+		t.Errorf("hourly reading = %+v", hourly)
+	}
+
+	want := (daily.Score*0.4 + hourly.Score*0.3) / 0.7
+	if !closeTo(c.Score, want) || c.Bias != biasFor(want) {
+		t.Errorf("score %v (%s), want the weighted mean %v", c.Score, c.Bias, want)
+	}
+	if len(c.Agreements) != 0 {
+		t.Errorf("unexpected agreements %v", c.Agreements)
+	}
+	conflicts := strings.Join(c.Conflicts, "\n")
+	for _, expect := range []string{
+		"1Day lean bullish while 1Hour lean bearish",
+		"price is above VWAP on 1Hour but below on 1Day",
+		"RSI is oversold on 1Day but overbought on 1Hour",
+		"price is at support on 1Day but at resistance on 1Hour",
+	} {
+		if !strings.Contains(conflicts, expect) {
+			t.Errorf("missing conflict %q in:\n%s", expect, conflicts)
+		}
+	}
+}
+
+func TestConfluenceAgreement(t *testing.T) {
+	falling := func(every time.Duration) types.BarSeries { return trendBars(40, 140, -1, every) }
+	frames := map[string]types.BarSeries{
+		"5Min":  falling(5 * time.Minute),
+		"1Hour": falling(time.Hour),
+		"1Day":  falling(24 * time.Hour),
+		"2Hour": falling(2 * time.Hour), // no default weight
+		"1Min":  trendBars(1, 100, 0, time.Minute),
+	}
+	c := AnalyzeConfluence("AAPL", frames, nil)
+
+	if len(c.Readings) != 3 {
+		t.Fatalf("unweighted and one-bar frames should be skipped, got %d readings", len(c.Readings))
+	}
+	if c.Bias != BiasBullish || !closeTo(c.Alignment, 1) || len(c.Conflicts) != 0 {
+		t.Errorf("identical frames should agree: %s alignment %v conflicts %v", c.Bias, c.Alignment, c.Conflicts)
+	}
+	agreements := strings.Join(c.Agreements, "\n")
+	for _, expect := range []string{"every timeframe leans bullish", "below VWAP on every timeframe", "RSI is oversold on 5Min, 1Hour, 1Day"} {
+		if !strings.Contains(agreements, expect) {
+			t.Errorf("missing agreement %q in:\n%s", expect, agreements)
+		}
+	}
+
+	if empty := AnalyzeConfluence("AAPL", nil, nil); empty.Bias != BiasNeutral || len(empty.Readings) != 0 {
+		t.Errorf("no frames should be neutral, got %+v", empty)
+	}
+}
+
+// intradayGapProvider has no minute bars, like a daily-only data source
+type intradayGapProvider struct {
+	uptrendProvider
+}
+
+func (p intradayGapProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+	if timeframe == "1Min" {
+		return nil, fmt.Errorf("no %s bars", timeframe)
+	}
+	return p.uptrendProvider.GetBars(ctx, symbol, timeframe, limit, startDate)
+}
+
+func TestFetchConfluenceSkipsMissingTimeframes(t *testing.T) {
+	c, err := FetchConfluence(context.Background(), intradayGapProvider{}, "MSFT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Missing) != 1 || c.Missing[0] != "1Min" || len(c.Readings) != 3 {
+		t.Errorf("missing %v with %d readings, want only 1Min missing", c.Missing, len(c.Readings))
+	}
+
+	if _, err := FetchConfluence(context.Background(), intradayGapProvider{}, "FAIL"); err == nil {
+		t.Error("expected an error when the daily bars fail")
+	}
+}
+
+func TestScreenerAddsConfluence(t *testing.T) {
+	criteria := DefaultScreenerCriteria()
+	criteria.MultiTimeframe = true
+	screener := &Screener{Provider: uptrendProvider{}, Pool: utils.DefaultPoolConfig()}
+	scores, err := screener.Screen(context.Background(), []string{"MSFT"}, "1Day", 60, criteria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 || scores[0].Confluence == nil || len(scores[0].Confluence.Readings) != 4 {
+		t.Fatalf("expected a four-timeframe confluence, got %+v", scores)
+	}
+	found := false
+	for _, signal := range scores[0].Signals {
+		found = found || strings.Contains(signal, "Timeframes:")
+	}
+	if !found {
+		t.Errorf("confluence summary missing from signals %v", scores[0].Signals)
+	}
+}
@@ -1,3 +1,377 @@
 package strategy
 
-// Placeholder for trading decision logic
\ No newline at end of file
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
+)
+
+// Decision actions, the same labels CalculateSignalWithConfig recommends
+const (
+	ActionBuy        = "BUY"
+	ActionAccumulate = "ACCUMULATE"
+	ActionWait       = "WAIT"
+	ActionDistribute = "DISTRIBUTE"
+	ActionSell       = "SELL"
+)
+
+// Features is everything the decision engine looks at for one symbol.
+// Nil or empty optional fields drop their factor, as in SignalInput.
+type Features struct {
+	Symbol        string
+	Timeframe     string
+	Series        types.BarSeries
+	RSI           *float64
+	ATR           *float64
+	Pattern       string               // candle analysis of the latest bar
+	Patterns      []types.PatternMatch // multi-candle patterns ending on the latest bar
+	VolumeRatio   *float64
+	MACD          *MACDResult
+	Bollinger     *BollingerBand
+	NewsSentiment newsscraping.SentimentScore
+	NewsImpact    float64
+	WhaleStats    *WhaleStats // nil scores whales without their track record
+}
+
+var (
+	patternMu         sync.RWMutex
+	patternClassifier func(types.Bar) string
+	patternDetector   func(types.BarSeries) []types.PatternMatch
+)
+
+// SetPatternClassifier installs the candle classifier BuildFeatures uses for Pattern.
+// The analyzer package registers AnalyzeCandlestick; strategy can't import it directly.
+func SetPatternClassifier(classify func(types.Bar) string) {
+	patternMu.Lock()
+	defer patternMu.Unlock()
+	patternClassifier = classify
+}
+
+func currentPatternClassifier() func(types.Bar) string {
+	patternMu.RLock()
+	defer patternMu.RUnlock()
+	return patternClassifier
+}
+
+// SetPatternDetector installs the multi-candle pattern library BuildFeatures uses for Patterns.
+// The analyzer package registers DetectPatterns.
+func SetPatternDetector(detect func(types.BarSeries) []types.PatternMatch) {
+	patternMu.Lock()
+	defer patternMu.Unlock()
+	patternDetector = detect
+}
+
+func currentPatternDetector() func(types.BarSeries) []types.PatternMatch {
+	patternMu.RLock()
+	defer patternMu.RUnlock()
+	return patternDetector
+}
+
+// BuildFeatures computes every indicator the engine uses: RSI(14), ATR, volume ratio,
+// MACD(12,26,9), 20-bar Bollinger Bands and the latest candle patterns. News is left for the caller.
+func BuildFeatures(symbol, timeframe string, series types.BarSeries) Features {
+	f := Features{Symbol: symbol, Timeframe: timeframe, Series: series}
+	if series.Len() == 0 {
+		return f
+	}
+
+	closes := series.Closes()
+	if rsiValues, err := CalculateRSI(closes, 14); err == nil {
+		rsi := rsiValues[len(rsiValues)-1]
+		f.RSI = &rsi
+	}
+	if series.Len() >= 14 {
+		atr := scoring.CalculateATRFromBars(series)
+		f.ATR = &atr
+	}
+	f.VolumeRatio = VolumeRatio(series.Volumes(), 20)
+	if macd, err := CalculateMACD(closes, MACDFast, MACDSlow, MACDSignal); err == nil {
+		f.MACD = &macd
+	}
+	if bands, err := CalculateBollingerBands(closes, BollingerPeriod, BollingerMultiplier); err == nil {
+		f.Bollinger = &bands[len(bands)-1]
+	}
+
+	if classify := currentPatternClassifier(); classify != nil {
+		f.Pattern = classify(series.Latest())
+	}
+	if detect := currentPatternDetector(); detect != nil {
+		f.Patterns = detect(series)
+	}
+	return f
+}
+
+// Factor is one node of a decision's explanation. Leaves are signal components with
+// their score (-3 to 3) and share of the total weight; groups average their children.
+type Factor struct {
+	Name    string   `json:"name"`
+	Score   float64  `json:"score"`
+	Weight  float64  `json:"weight"`
+	Detail  string   `json:"detail,omitempty"`
+	Factors []Factor `json:"factors,omitempty"`
+}
+
+// Decision is the single verdict for a symbol. Score and Confidence are the weighted
+// signal's; Rating puts Score on the 0-10 scale the watchlist and profile thresholds use.
+// Stop and Target are 0 when the action is WAIT.
+type Decision struct {
+	Symbol      string         `json:"symbol"`
+	Action      string         `json:"action"`
+	Score       float64        `json:"score"`
+	Rating      float64        `json:"rating"`
+	Confidence  float64        `json:"confidence"`
+	Price       float64        `json:"price"`
+	Stop        float64        `json:"stop,omitempty"`
+	Target      float64        `json:"target,omitempty"`
+	Horizon     string         `json:"horizon"`
+	Explanation Factor         `json:"explanation"`
+	Signal      CombinedSignal `json:"-"`
+}
+
+// DecisionEngine turns features into a Decision. Stops and targets are placed
+// StopATR and TargetATR average true ranges from the price.
+type DecisionEngine struct {
+	Signals   SignalConfig
+	StopATR   float64
+	TargetATR float64
+}
+
+func NewDecisionEngine(signals SignalConfig) *DecisionEngine {
+	return &DecisionEngine{Signals: signals, StopATR: 2, TargetATR: 3}
+}
+
+// Decide runs the engine with the active signal config (see SetSignalConfig)
+func Decide(f Features) Decision {
+	return NewDecisionEngine(GetSignalConfig()).Decide(f)
+}
+
+func (e *DecisionEngine) Decide(f Features) Decision {
+	signal := CalculateSignalWithConfig(SignalInput{
+		Symbol:        f.Symbol,
+		Series:        f.Series,
+		RSI:           f.RSI,
+		ATR:           f.ATR,
+		Analysis:      f.Pattern,
+		Patterns:      f.Patterns,
+		VolumeRatio:   f.VolumeRatio,
+		NewsSentiment: f.NewsSentiment,
+		NewsImpact:    f.NewsImpact,
+		WhaleStats:    f.WhaleStats,
+		MACD:          f.MACD,
+		Bollinger:     f.Bollinger,
+	}, e.Signals)
+
+	d := Decision{
+		Symbol:      f.Symbol,
+		Action:      signal.Recommendation,
+		Score:       signal.Score,
+		Rating:      math.Max(0, math.Min(10, 5+signal.Score*5/3)),
+		Confidence:  signal.Confidence,
+		Horizon:     horizonFor(f.Timeframe),
+		Explanation: explain(f, signal),
+		Signal:      signal,
+	}
+	if f.Series.Len() > 0 {
+		d.Price = f.Series.Latest().Close
+	}
+	d.Stop, d.Target = e.levels(d.Action, d.Price, f)
+	return d
+}
+
+// levels places the stop and target around price, falling back to the nearest
+// support and resistance zones when there is no ATR
+func (e *DecisionEngine) levels(action string, price float64, f Features) (stop, target float64) {
+	if price <= 0 {
+		return 0, 0
+	}
+	long := action == ActionBuy || action == ActionAccumulate
+	short := action == ActionSell || action == ActionDistribute
+	if !long && !short {
+		return 0, 0
+	}
+
+	if f.ATR != nil && *f.ATR > 0 {
+		if long {
+			return price - e.StopATR**f.ATR, price + e.TargetATR**f.ATR
+		}
+		return price + e.StopATR**f.ATR, price - e.TargetATR**f.ATR
+	}
+	if f.Series.Len() == 0 {
+		return 0, 0
+	}
+	// a side without a zone, or whose zone price has already crossed, gets no level
+	levels := AnalyzeLevels(f.Series)
+	var below, above float64
+	if support := levels.Support.Price; support > 0 && support < price {
+		below = support
+	}
+	if resistance := levels.Resistance.Price; resistance > price {
+		above = resistance
+	}
+	if long {
+		return below, above
+	}
+	return above, below
+}
+
+// horizonFor maps the bar timeframe to how long a decision is expected to play out
+func horizonFor(timeframe string) string {
+	switch {
+	case strings.HasSuffix(timeframe, "Min"):
+		return "intraday"
+	case strings.HasSuffix(timeframe, "Hour"):
+		return "days"
+	case strings.HasSuffix(timeframe, "Week"), strings.HasSuffix(timeframe, "Month"):
+		return "months"
+	}
+	return "weeks" // daily bars
+}
+
+// factorGroups arranges the signal components into the explanation tree
+var factorGroups = []struct {
+	name       string
+	components []string
+}{
+	{"Momentum", []string{"RSI", "MACD"}},
+	{"Volatility", []string{"ATR", "Bollinger"}},
+	{"Participation", []string{"Volume", "Whale"}},
+	{"Sentiment", []string{"News"}},
+	{"Price Action", []string{"Pattern", "Support/Resistance"}},
+}
+
+func explain(f Features, signal CombinedSignal) Factor {
+	totalWeight := 0.0
+	for _, c := range signal.Components {
+		totalWeight += c.Weight
+	}
+	root := Factor{Name: "Decision", Score: signal.Score, Weight: 1, Detail: signal.Reasoning}
+	if totalWeight == 0 {
+		return root
+	}
+
+	for _, group := range factorGroups {
+		node := Factor{Name: group.name}
+		for _, name := range group.components {
+			for _, c := range signal.Components {
+				if c.Name != name {
+					continue
+				}
+				share := c.Weight / totalWeight
+				node.Factors = append(node.Factors, Factor{Name: c.Name, Score: c.Score, Weight: share, Detail: describe(c.Name, f)})
+				node.Weight += share
+				node.Score += c.Score * share
+			}
+		}
+		if len(node.Factors) == 0 {
+			continue
+		}
+		node.Score /= node.Weight
+		root.Factors = append(root.Factors, node)
+	}
+	return root
+}
+
+// describe says what a component saw
+func describe(name string, f Features) string {
+	switch name {
+	case "RSI":
+		return fmt.Sprintf("RSI %.1f (%s)", *f.RSI, DetermineRSISignal(*f.RSI))
+	case "ATR":
+		return fmt.Sprintf("ATR %.2f (%.1f%% of price)", *f.ATR, *f.ATR/f.Series.Latest().Close*100)
+	case "Volume":
+		return fmt.Sprintf("volume %.1fx its 20-bar average", *f.VolumeRatio)
+	case "News":
+		return fmt.Sprintf("latest article %s, impact %.2f", f.NewsSentiment, f.NewsImpact)
+	case "Whale":
+		buys, sells := 0, 0
+		for _, w := range DetectWhales(f.Symbol, f.Series) {
+			if w.Conviction != "HIGH" {
+				continue
+			}
+			if w.Direction == "BUY" {
+				buys++
+			} else {
+				sells++
+			}
+		}
+		detail := fmt.Sprintf("%d high-conviction buy and %d sell whales", buys, sells)
+		if f.WhaleStats != nil {
+			if rate, ok := f.WhaleStats.HitRate("BUY"); ok {
+				detail += fmt.Sprintf(", buy whales held %.0f%% of the time", rate*100)
+			}
+			if rate, ok := f.WhaleStats.HitRate("SELL"); ok {
+				detail += fmt.Sprintf(", sell whales held %.0f%% of the time", rate*100)
+			}
+		}
+		return detail
+	case "Pattern":
+		if m, ok := strongestPattern(f.Patterns); ok {
+			detail := fmt.Sprintf("%s (%.0f%% confidence, after a %s trend)", m.Name, m.Confidence*100, m.Trend)
+			if f.Pattern != "" {
+				detail += ", latest candle " + f.Pattern
+			}
+			return detail
+		}
+		if f.Pattern == "" {
+			return "no candle pattern"
+		}
+		return f.Pattern
+	case "Support/Resistance":
+		levels := AnalyzeLevels(f.Series)
+		return fmt.Sprintf("support %s, resistance %s", describeLevel(levels.Support), describeLevel(levels.Resistance))
+	case "MACD":
+		_, _, histogram := f.MACD.Latest()
+		return fmt.Sprintf("histogram %+.4f", histogram)
+	case "Bollinger":
+		return fmt.Sprintf("%%B %.2f (%s)", f.Bollinger.PercentB, DetermineBollingerSignal(f.Bollinger.PercentB))
+	}
+	return ""
+}
+
+func describeLevel(level PriceLevel) string {
+	switch {
+	case level.Price == 0:
+		return "none"
+	case level.Touches > 1:
+		return fmt.Sprintf("$%.2f (%d touches)", level.Price, level.Touches)
+	}
+	return fmt.Sprintf("$%.2f", level.Price)
+}
+
+// FormatDecision is FormatSignal plus the levels and horizon
+func FormatDecision(d Decision) string {
+	s := FormatSignal(d.Signal)
+	if d.Stop != 0 {
+		s += fmt.Sprintf(" | stop $%.2f", d.Stop)
+	}
+	if d.Target != 0 {
+		s += fmt.Sprintf(" | target $%.2f", d.Target)
+	}
+	return s + fmt.Sprintf(" | horizon: %s", d.Horizon)
+}
+
+// Lines renders the explanation tree with one indented line per factor
+func (f Factor) Lines() []string {
+	var lines []string
+	var walk func(Factor, int)
+	walk = func(n Factor, depth int) {
+		line := fmt.Sprintf("%s%-20s %+.2f", strings.Repeat("  ", depth), n.Name, n.Score)
+		if depth > 0 {
+			line += fmt.Sprintf(" (%.0f%%)", n.Weight*100)
+		}
+		if n.Detail != "" {
+			line += " - " + n.Detail
+		}
+		lines = append(lines, line)
+		for _, child := range n.Factors {
+			walk(child, depth+1)
+		}
+	}
+	walk(f, 0)
+	return lines
+}
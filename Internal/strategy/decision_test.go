@@ -0,0 +1,180 @@
+package strategy
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+func TestDecideMatchesWeightedSignal(t *testing.T) {
+	bars := flatBars(10, 100)
+	rsi, atr := 30.0, 5.0
+	f := Features{Symbol: "AAPL", Timeframe: "1Day", Series: bars, RSI: &rsi, ATR: &atr, Pattern: "Strong Bullish"}
+
+	d := NewDecisionEngine(DefaultSignalConfig()).Decide(f)
+	signal := CalculateSignalWithConfig(SignalInput{Symbol: "AAPL", Series: bars, RSI: &rsi, ATR: &atr, Analysis: "Strong Bullish"}, DefaultSignalConfig())
+
+	if d.Action != signal.Recommendation || d.Score != signal.Score || d.Confidence != signal.Confidence {
+		t.Fatalf("decision %s %.2f disagrees with the signal %s %.2f", d.Action, d.Score, signal.Recommendation, signal.Score)
+	}
+	if d.Action != ActionAccumulate {
+		t.Fatalf("action = %s, want ACCUMULATE", d.Action)
+	}
+	if !closeTo(d.Rating, 5+d.Score*5/3) {
+		t.Errorf("rating %v does not match score %v", d.Rating, d.Score)
+	}
+	if d.Price != 100 || d.Stop != 90 || d.Target != 115 || d.Horizon != "weeks" {
+		t.Errorf("unexpected levels: price %v stop %v target %v horizon %s", d.Price, d.Stop, d.Target, d.Horizon)
+	}
+}
+
+func TestDecisionExplanationTree(t *testing.T) {
+	bars := flatBars(10, 100)
+	rsi, atr := 30.0, 5.0
+	d := NewDecisionEngine(DefaultSignalConfig()).Decide(Features{Symbol: "AAPL", Series: bars, RSI: &rsi, ATR: &atr, Pattern: "Weak Bearish"})
+
+	root := d.Explanation
+	if root.Score != d.Score || len(root.Factors) == 0 {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	weights, score, leaves := 0.0, 0.0, 0
+	for _, group := range root.Factors {
+		weights += group.Weight
+		score += group.Score * group.Weight
+		groupWeight := 0.0
+		for _, leaf := range group.Factors {
+			groupWeight += leaf.Weight
+			leaves++
+			if leaf.Detail == "" {
+				t.Errorf("%s has no detail", leaf.Name)
+			}
+		}
+		if !closeTo(groupWeight, group.Weight) {
+			t.Errorf("%s weight %v, children add to %v", group.Name, group.Weight, groupWeight)
+		}
+	}
+	if !closeTo(weights, 1) || !closeTo(score, root.Score) {
+		t.Errorf("groups should carry the whole weight and score, got %v and %v (want 1 and %v)", weights, score, root.Score)
+	}
+	if leaves != len(d.Signal.Components) {
+		t.Errorf("expected a leaf per component, got %d of %d", leaves, len(d.Signal.Components))
+	}
+	if lines := root.Lines(); len(lines) != 1+len(root.Factors)+leaves {
+		t.Errorf("unexpected rendering %v", lines)
+	}
+}
+
+func TestDecisionLevels(t *testing.T) {
+	cfg := SignalConfig{
+		Weights:    config.SignalWeights{RSIWeight: 1},
+		Thresholds: DefaultSignalThresholds(),
+	}
+	engine := NewDecisionEngine(cfg)
+	bars := flatBars(10, 100)
+	atr := 2.0
+
+	overbought := 80.0
+	d := engine.Decide(Features{Series: bars, RSI: &overbought, ATR: &atr, Timeframe: "5Min"})
+	if d.Action != ActionSell || d.Stop != 104 || d.Target != 94 || d.Horizon != "intraday" {
+		t.Errorf("short levels: %s stop %v target %v horizon %s", d.Action, d.Stop, d.Target, d.Horizon)
+	}
+
+	neutral := 50.0
+	d = engine.Decide(Features{Series: bars, RSI: &neutral, ATR: &atr})
+	if d.Action != ActionWait || d.Stop != 0 || d.Target != 0 {
+		t.Errorf("WAIT should carry no levels, got %s stop %v target %v", d.Action, d.Stop, d.Target)
+	}
+
+	// without ATR the window's support and resistance are used
+	oversold := 20.0
+	d = engine.Decide(Features{Series: bars, RSI: &oversold})
+	if d.Action != ActionBuy || d.Stop != 99 || d.Target != 101 {
+		t.Errorf("long levels without ATR: %s stop %v target %v", d.Action, d.Stop, d.Target)
+	}
+
+	// at the window high there is no resistance above to target, and no 0 target is shown
+	rising := make([]types.Bar, 10)
+	for i := range rising {
+		price := 100 + float64(i)
+		rising[i] = types.Bar{Open: price, High: price, Low: price - 1, Close: price, Volume: 1000}
+	}
+	d = engine.Decide(Features{Series: seriesOf(rising), RSI: &oversold})
+	if d.Action != ActionBuy || d.Stop != 99 || d.Target != 0 {
+		t.Errorf("long levels at the high: %s stop %v target %v", d.Action, d.Stop, d.Target)
+	}
+	if s := FormatDecision(d); !strings.Contains(s, "stop $99.00") || strings.Contains(s, "target") {
+		t.Errorf("FormatDecision() = %q, want the stop without a target", s)
+	}
+
+	// with zones, the stop and the explanation use the zone the S/R score was computed from
+	series := rangeThenBreakout()
+	support := AnalyzeLevels(series).Support
+	d = engine.Decide(Features{Series: series, RSI: &oversold})
+	if d.Stop != support.Price {
+		t.Errorf("stop %v, want the retested zone at %v rather than the window low", d.Stop, support.Price)
+	}
+	var detail string
+	d = NewDecisionEngine(DefaultSignalConfig()).Decide(Features{Series: series, RSI: &oversold})
+	for _, group := range d.Explanation.Factors {
+		for _, leaf := range group.Factors {
+			if leaf.Name == "Support/Resistance" {
+				detail = leaf.Detail
+			}
+		}
+	}
+	if want := describeLevel(support); detail == "" || !strings.Contains(detail, "support "+want) {
+		t.Errorf("S/R detail %q should name the zone %s", detail, want)
+	}
+}
+
+func TestBuildFeatures(t *testing.T) {
+	chronological := wavyBars(60, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	bars := make([]types.Bar, len(chronological))
+	for i, b := range chronological {
+		bars[len(bars)-1-i] = b
+	}
+
+	SetPatternClassifier(func(bar types.Bar) string {
+		if bar.Close > bar.Open {
+			return "Bullish"
+		}
+		return "Bearish"
+	})
+	defer SetPatternClassifier(nil)
+
+	series := seriesOf(bars)
+	if series.Latest() != chronological[len(chronological)-1] {
+		t.Fatalf("latest-first bars should end the series at the newest bar")
+	}
+	f := BuildFeatures("TEST", "1Day", series)
+	if f.RSI == nil || f.ATR == nil || f.VolumeRatio == nil || f.MACD == nil || f.Bollinger == nil {
+		t.Fatalf("expected every indicator from 60 bars, got %+v", f)
+	}
+	if f.Pattern != "Bullish" {
+		t.Errorf("pattern should classify the latest bar, got %q", f.Pattern)
+	}
+
+	closes := make([]float64, len(chronological))
+	for i, b := range chronological {
+		closes[i] = b.Close
+	}
+	rsi, _ := CalculateRSI(closes, 14)
+	if !closeTo(*f.RSI, rsi[len(rsi)-1]) {
+		t.Errorf("RSI %v, want the latest %v", *f.RSI, rsi[len(rsi)-1])
+	}
+	if f.Bollinger.Close != series.Latest().Close {
+		t.Errorf("bands should be for the latest close")
+	}
+
+	short := BuildFeatures("TEST", "1Day", series.Window(5))
+	if short.RSI != nil || short.MACD != nil || short.Bollinger != nil {
+		t.Errorf("short histories should leave indicators unset")
+	}
+	if d := Decide(short); math.IsNaN(d.Score) || d.Symbol != "TEST" {
+		t.Errorf("unexpected decision on a short history %+v", d)
+	}
+}
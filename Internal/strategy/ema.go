@@ -1,3 +1,175 @@
 package strategy
 
-// Placeholder for EMA strategy implementation
\ No newline at end of file
+import (
+	"fmt"
+	"math"
+
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+// the standard settings used by the scanner, analytics display and exports
+const (
+	MACDFast            = 12
+	MACDSlow            = 26
+	MACDSignal          = 9
+	BollingerPeriod     = 20
+	BollingerMultiplier = 2.0
+)
+
+// SMA, EMA and WMA take values oldest-first and return a slice of the same length.
+// Entries before the first full period are left at 0, like CalculateRSI.
+
+func SMA(values []float64, period int) ([]float64, error) {
+	if period < 1 || len(values) < period {
+		return nil, fmt.Errorf("not enough data")
+	}
+	sma := make([]float64, len(values))
+	for i := period - 1; i < len(values); i++ {
+		sma[i] = utils.Average(values[i-period+1 : i+1])
+	}
+	return sma, nil
+}
+
+// EMA is seeded with the SMA of the first period values, then weights each new value by 2/(period+1)
+func EMA(values []float64, period int) ([]float64, error) {
+	if period < 1 || len(values) < period {
+		return nil, fmt.Errorf("not enough data")
+	}
+	ema := make([]float64, len(values))
+	ema[period-1] = utils.Average(values[:period])
+	alpha := 2.0 / float64(period+1)
+	for i := period; i < len(values); i++ {
+		ema[i] = alpha*values[i] + (1-alpha)*ema[i-1]
+	}
+	return ema, nil
+}
+
+// WMA weights the newest value by period, the one before by period-1 and so on
+func WMA(values []float64, period int) ([]float64, error) {
+	if period < 1 || len(values) < period {
+		return nil, fmt.Errorf("not enough data")
+	}
+	wma := make([]float64, len(values))
+	denominator := float64(period*(period+1)) / 2
+	for i := period - 1; i < len(values); i++ {
+		sum := 0.0
+		for j := 0; j < period; j++ {
+			sum += values[i-period+1+j] * float64(j+1)
+		}
+		wma[i] = sum / denominator
+	}
+	return wma, nil
+}
+
+// MACDResult holds the MACD line, its signal line and the histogram between them, oldest-first.
+// Start is the first index where all three are defined.
+type MACDResult struct {
+	MACD      []float64
+	Signal    []float64
+	Histogram []float64
+	Start     int
+}
+
+// Latest returns the newest MACD, signal and histogram values
+func (m MACDResult) Latest() (macd, signal, histogram float64) {
+	last := len(m.MACD) - 1
+	return m.MACD[last], m.Signal[last], m.Histogram[last]
+}
+
+// CalculateMACD is the fast EMA minus the slow EMA, with an EMA of that as the signal line
+func CalculateMACD(closes []float64, fast, slow, signal int) (MACDResult, error) {
+	if fast < 1 || slow <= fast || signal < 1 {
+		return MACDResult{}, fmt.Errorf("invalid MACD periods %d/%d/%d", fast, slow, signal)
+	}
+	if len(closes) < slow+signal-1 {
+		return MACDResult{}, fmt.Errorf("not enough data")
+	}
+
+	fastEMA, err := EMA(closes, fast)
+	if err != nil {
+		return MACDResult{}, err
+	}
+	slowEMA, err := EMA(closes, slow)
+	if err != nil {
+		return MACDResult{}, err
+	}
+
+	macd := make([]float64, len(closes))
+	for i := slow - 1; i < len(closes); i++ {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	// the signal line only starts once the MACD line exists
+	signalTail, err := EMA(macd[slow-1:], signal)
+	if err != nil {
+		return MACDResult{}, err
+	}
+	start := slow + signal - 2
+	signalLine := make([]float64, len(closes))
+	histogram := make([]float64, len(closes))
+	for i := start; i < len(closes); i++ {
+		signalLine[i] = signalTail[i-slow+1]
+		histogram[i] = macd[i] - signalLine[i]
+	}
+
+	return MACDResult{MACD: macd, Signal: signalLine, Histogram: histogram, Start: start}, nil
+}
+
+// BollingerBand is one bar's bands. PercentB is where the close sits between the bands
+// (0 at the lower, 1 at the upper) and Bandwidth is the band width over the middle.
+type BollingerBand struct {
+	Middle    float64
+	Upper     float64
+	Lower     float64
+	StdDev    float64
+	Close     float64
+	PercentB  float64
+	Bandwidth float64
+}
+
+// CalculateBollingerBands puts bands multiplier population standard deviations around the period SMA.
+// It returns one band per close from index period-1 on, oldest-first.
+func CalculateBollingerBands(closes []float64, period int, multiplier float64) ([]BollingerBand, error) {
+	if period < 2 || len(closes) < period {
+		return nil, fmt.Errorf("not enough data")
+	}
+
+	bands := make([]BollingerBand, 0, len(closes)-period+1)
+	for i := period - 1; i < len(closes); i++ {
+		window := closes[i-period+1 : i+1]
+		mean := utils.Average(window)
+		variance := 0.0
+		for _, c := range window {
+			variance += (c - mean) * (c - mean)
+		}
+		stdDev := math.Sqrt(variance / float64(period))
+
+		band := BollingerBand{
+			Middle: mean,
+			Upper:  mean + multiplier*stdDev,
+			Lower:  mean - multiplier*stdDev,
+			StdDev: stdDev,
+			Close:  closes[i],
+		}
+		if width := band.Upper - band.Lower; width > 0 {
+			band.PercentB = (closes[i] - band.Lower) / width
+		} else {
+			band.PercentB = 0.5
+		}
+		if mean != 0 {
+			band.Bandwidth = (band.Upper - band.Lower) / mean
+		}
+		bands = append(bands, band)
+	}
+	return bands, nil
+}
+
+// DetermineBollingerSignal reads %B the way DetermineRSISignal reads RSI
+func DetermineBollingerSignal(percentB float64) string {
+	if percentB <= 0 {
+		return "below lower band"
+	} else if percentB >= 1 {
+		return "above upper band"
+	}
+	return "inside bands"
+}
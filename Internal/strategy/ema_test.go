@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+func TestMovingAverages(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6}
+
+	sma, err := SMA(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sma[1] != 0 || sma[2] != 2 || sma[5] != 5 {
+		t.Errorf("unexpected SMA %v", sma)
+	}
+
+	ema, err := EMA(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// seeded at 2, then alpha 0.5
+	want := []float64{0, 0, 2, 3, 4, 5}
+	for i := range want {
+		if !closeTo(ema[i], want[i]) {
+			t.Fatalf("EMA %v, want %v", ema, want)
+		}
+	}
+
+	wma, err := WMA(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (1*1 + 2*2 + 3*3) / 6
+	if !closeTo(wma[2], 14.0/6) || !closeTo(wma[5], (4+10+18)/6.0) {
+		t.Errorf("unexpected WMA %v", wma)
+	}
+
+	for name, fn := range map[string]func([]float64, int) ([]float64, error){"SMA": SMA, "EMA": EMA, "WMA": WMA} {
+		if _, err := fn(values, 7); err == nil {
+			t.Errorf("%s: expected an error with fewer values than the period", name)
+		}
+	}
+}
+
+func TestCalculateMACD(t *testing.T) {
+	bars := wavyBars(120, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+
+	result, err := CalculateMACD(closes, 12, 26, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Start != 33 {
+		t.Fatalf("Start = %d, want 33", result.Start)
+	}
+	fast, _ := EMA(closes, 12)
+	slow, _ := EMA(closes, 26)
+	signal, _ := EMA(result.MACD[25:], 9)
+	for i := result.Start; i < len(closes); i++ {
+		if !closeTo(result.MACD[i], fast[i]-slow[i]) {
+			t.Fatalf("bar %d: MACD %v, want %v", i, result.MACD[i], fast[i]-slow[i])
+		}
+		if !closeTo(result.Signal[i], signal[i-25]) {
+			t.Fatalf("bar %d: signal %v, want %v", i, result.Signal[i], signal[i-25])
+		}
+		if !closeTo(result.Histogram[i], result.MACD[i]-result.Signal[i]) {
+			t.Fatalf("bar %d: histogram is not MACD - signal", i)
+		}
+	}
+	if result.Histogram[result.Start-1] != 0 {
+		t.Error("histogram should be 0 before Start")
+	}
+
+	if _, err := CalculateMACD(closes[:33], 12, 26, 9); err == nil {
+		t.Error("expected an error with fewer than slow+signal-1 closes")
+	}
+	if _, err := CalculateMACD(closes, 26, 12, 9); err == nil {
+		t.Error("expected an error when fast is not shorter than slow")
+	}
+}
+
+func TestCalculateBollingerBands(t *testing.T) {
+	closes := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	bands, err := CalculateBollingerBands(closes, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bands) != 1 {
+		t.Fatalf("expected one band, got %d", len(bands))
+	}
+	// mean 5, population standard deviation 2
+	b := bands[0]
+	if b.Middle != 5 || b.StdDev != 2 || b.Upper != 9 || b.Lower != 1 {
+		t.Errorf("unexpected bands %+v", b)
+	}
+	if !closeTo(b.PercentB, 1) || !closeTo(b.Bandwidth, 8.0/5) {
+		t.Errorf("%%B %v and bandwidth %v, want 1 and 1.6", b.PercentB, b.Bandwidth)
+	}
+	if DetermineBollingerSignal(b.PercentB) != "above upper band" {
+		t.Errorf("unexpected signal %q", DetermineBollingerSignal(b.PercentB))
+	}
+
+	flat, err := CalculateBollingerBands([]float64{3, 3, 3}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat[0].PercentB != 0.5 || flat[0].Bandwidth != 0 {
+		t.Errorf("flat prices should sit mid-band, got %+v", flat[0])
+	}
+
+	if _, err := CalculateBollingerBands(closes, 9, 2); err == nil {
+		t.Error("expected an error with fewer closes than the period")
+	}
+}
+
+func TestMACDAndBollingerSignalComponents(t *testing.T) {
+	cfg := SignalConfig{
+		Weights:    config.SignalWeights{MACDWeight: 0.5, BollingerWeight: 0.5},
+		Thresholds: DefaultSignalThresholds(),
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	crossover := MACDResult{
+		MACD:      []float64{0, 1, 2},
+		Signal:    []float64{0, 1.5, 1.5},
+		Histogram: []float64{0, -0.5, 0.5},
+		Start:     1,
+	}
+	band := BollingerBand{PercentB: -0.1}
+	signal := CalculateSignalWithConfig(SignalInput{MACD: &crossover, Bollinger: &band}, cfg)
+	if len(signal.Components) != 2 {
+		t.Fatalf("expected MACD and Bollinger components, got %+v", signal.Components)
+	}
+	if math.Abs(signal.Score-2) > 1e-9 || signal.Recommendation != "BUY" {
+		t.Errorf("bullish crossover below the lower band should score 2 (BUY), got %.2f %s", signal.Score, signal.Recommendation)
+	}
+
+	// the default profile keeps both off
+	without := CalculateSignalWithConfig(SignalInput{MACD: &crossover, Bollinger: &band}, DefaultSignalConfig())
+	for _, c := range without.Components {
+		if c.Name == "MACD" || c.Name == "Bollinger" {
+			t.Errorf("%s should not be scored with a zero weight", c.Name)
+		}
+	}
+}
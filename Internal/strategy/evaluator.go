@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"context"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+// Evaluator builds a symbol's features and decides on them. The screener, scanner, scout,
+// analysis, API and menu all rate symbols through it, so a symbol gets the same verdict
+// whichever path asks. A nil News leaves out news sentiment and a nil Whales the whale track record.
+type Evaluator struct {
+	Signals SignalConfig // one that doesn't validate, such as the zero value, uses GetSignalConfig
+	News    repository.NewsRepository
+	Whales  repository.WhaleRepository
+}
+
+// Features is BuildFeatures plus the symbol's latest news and whale track record
+func (e *Evaluator) Features(ctx context.Context, symbol, timeframe string, series types.BarSeries) Features {
+	f := BuildFeatures(symbol, timeframe, series)
+	if e.News != nil {
+		if news, err := e.News.GetLatestNews(ctx, symbol, 1); err == nil && len(news) > 0 {
+			f.NewsSentiment = news[0].Sentiment
+			f.NewsImpact = news[0].Impact
+		}
+	}
+	if e.Whales != nil {
+		stats, err := LoadWhaleStats(ctx, e.Whales, symbol)
+		if err != nil {
+			logger.Component("strategy").Warn("whale history unavailable", logger.KeySymbol, symbol, logger.Err(err))
+		} else {
+			f.WhaleStats = &stats
+		}
+	}
+	return f
+}
+
+// Engine is the decision engine for e.Signals
+func (e *Evaluator) Engine() *DecisionEngine {
+	signals := e.Signals
+	if signals.Validate() != nil {
+		signals = GetSignalConfig()
+	}
+	return NewDecisionEngine(signals)
+}
+
+// Evaluate builds the symbol's features and decides on them
+func (e *Evaluator) Evaluate(ctx context.Context, symbol, timeframe string, series types.BarSeries) (Features, Decision) {
+	f := e.Features(ctx, symbol, timeframe, series)
+	return f, e.Engine().Decide(f)
+}
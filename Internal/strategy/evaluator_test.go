@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+func TestEvaluatorLoadsNewsAndWhaleHistory(t *testing.T) {
+	ctx := context.Background()
+	news := repository.NewMemoryNews()
+	err := news.SaveArticle(ctx, newsscraping.NewsArticle{
+		Symbol:      "MSFT",
+		Headline:    "MSFT beats earnings",
+		URL:         "https://example.com/msft",
+		PublishedAt: time.Now(),
+		Sentiment:   newsscraping.Positive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	whales := repository.NewMemoryWhales()
+	if err := RecordWhales(ctx, whales, "MSFT", whaleSeries(100, 102, 25, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := &Evaluator{News: news, Whales: whales}
+	bars, _ := uptrendProvider{}.GetBars(ctx, "MSFT", "1Day", 60, "")
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	features, decision := evaluator.Evaluate(ctx, "MSFT", "1Day", series)
+	if features.NewsSentiment != newsscraping.Positive {
+		t.Errorf("NewsSentiment = %q, want %q", features.NewsSentiment, newsscraping.Positive)
+	}
+	if features.WhaleStats == nil || features.WhaleStats.BuyEvents != 1 {
+		t.Errorf("WhaleStats = %+v, want the recorded buy", features.WhaleStats)
+	}
+
+	// the screener rates through the same evaluator, so it reaches the same verdict
+	screener := &Screener{Provider: uptrendProvider{}, News: news, Whales: whales, Pool: utils.DefaultPoolConfig()}
+	scores, err := screener.Screen(ctx, []string{"MSFT"}, "1Day", 60, DefaultScreenerCriteria())
+	if err != nil || len(scores) != 1 {
+		t.Fatalf("Screen() = %+v, %v", scores, err)
+	}
+	if got := scores[0].Decision; got.Rating != decision.Rating || got.Action != decision.Action {
+		t.Errorf("screener decided %s %.2f, evaluator %s %.2f", got.Action, got.Rating, decision.Action, decision.Rating)
+	}
+}
+
+func TestEvaluatorSignalsFallBackToActiveConfig(t *testing.T) {
+	if got := (&Evaluator{}).Engine().Signals; got != GetSignalConfig() {
+		t.Errorf("zero Signals decided with %+v, want the active config", got)
+	}
+	rsiOnly := SignalConfig{Weights: config.SignalWeights{RSIWeight: 1}, Thresholds: DefaultSignalThresholds()}
+	if got := (&Evaluator{Signals: rsiOnly}).Engine().Signals; got != rsiOnly {
+		t.Errorf("Engine().Signals = %+v, want %+v", got, rsiOnly)
+	}
+}
@@ -0,0 +1,230 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// Smoothing picks how RSIIndicator and ATRIndicator average their inputs
+type Smoothing int
+
+const (
+	// SmoothingWilder seeds with the simple average of the first period values,
+	// then moves as avg = (avg*(period-1) + x) / period
+	SmoothingWilder Smoothing = iota
+	// SmoothingSimple is the rolling mean of the last period values, as CalculateRSI and CalculateATR compute
+	SmoothingSimple
+)
+
+// smoother averages a series one value at a time
+type smoother struct {
+	period    int
+	smoothing Smoothing
+	window    []float64 // ring of the last period values, only for SmoothingSimple
+	next      int
+	count     int
+	sum       float64
+	avg       float64
+}
+
+func newSmoother(period int, smoothing Smoothing) *smoother {
+	if period < 1 {
+		period = 1
+	}
+	s := &smoother{period: period, smoothing: smoothing}
+	if smoothing == SmoothingSimple {
+		s.window = make([]float64, period)
+	}
+	return s
+}
+
+func (s *smoother) add(x float64) {
+	if s.smoothing == SmoothingSimple {
+		s.sum += x - s.window[s.next]
+		s.window[s.next] = x
+		s.next = (s.next + 1) % s.period
+		if s.count < s.period {
+			s.count++
+		}
+		s.avg = s.sum / float64(s.count)
+		return
+	}
+
+	if s.count < s.period {
+		s.count++
+		s.sum += x
+		s.avg = s.sum / float64(s.count)
+		return
+	}
+	s.avg = (s.avg*float64(s.period-1) + x) / float64(s.period)
+}
+
+func (s *smoother) ready() bool { return s.count >= s.period }
+
+// RSIIndicator updates RSI in O(1) per bar from closing prices
+type RSIIndicator struct {
+	gains     *smoother
+	losses    *smoother
+	prevClose float64
+	started   bool
+}
+
+func NewRSIIndicator(period int, smoothing Smoothing) *RSIIndicator {
+	return &RSIIndicator{gains: newSmoother(period, smoothing), losses: newSmoother(period, smoothing)}
+}
+
+func (r *RSIIndicator) Update(bar types.Bar) {
+	r.UpdateClose(bar.Close)
+}
+
+func (r *RSIIndicator) UpdateClose(close float64) {
+	if !r.started {
+		r.prevClose, r.started = close, true
+		return
+	}
+	change := close - r.prevClose
+	r.prevClose = close
+	r.gains.add(math.Max(change, 0))
+	r.losses.add(math.Max(-change, 0))
+}
+
+// Ready reports whether period price changes have been seen
+func (r *RSIIndicator) Ready() bool { return r.gains.ready() }
+
+// Value is the current RSI, or 0 before Ready (CalculateRSI leaves the warm-up at 0 too)
+func (r *RSIIndicator) Value() float64 {
+	if !r.Ready() {
+		return 0
+	}
+	if r.losses.avg == 0 {
+		return 100
+	}
+	return 100 - 100/(1+r.gains.avg/r.losses.avg)
+}
+
+// ATRIndicator updates the average true range in O(1) per bar
+type ATRIndicator struct {
+	ranges    *smoother
+	prevClose float64
+	started   bool
+}
+
+func NewATRIndicator(period int, smoothing Smoothing) *ATRIndicator {
+	return &ATRIndicator{ranges: newSmoother(period, smoothing)}
+}
+
+func (a *ATRIndicator) Update(bar types.Bar) {
+	if a.started {
+		a.ranges.add(CalculateTrueRange(bar.High, bar.Low, a.prevClose))
+	}
+	a.prevClose, a.started = bar.Close, true
+}
+
+// Ready reports whether period true ranges have been seen
+func (a *ATRIndicator) Ready() bool { return a.ranges.ready() }
+
+// Value is the current ATR. Before Ready it averages the true ranges seen so far,
+// the same fallback scoring.CalculateATRFromBars uses for short histories.
+func (a *ATRIndicator) Value() float64 {
+	return a.ranges.avg
+}
+
+// VWAPIndicator accumulates typical price * volume, restarting at each session.
+// Sessions are calendar days in loc; bars whose timestamps don't parse stay in the current session.
+type VWAPIndicator struct {
+	loc     *time.Location
+	session string
+	pv      float64
+	volume  float64
+}
+
+// NewVWAPIndicator resets at midnight in loc; a nil loc never resets, like VWAPCalculator
+func NewVWAPIndicator(loc *time.Location) *VWAPIndicator {
+	return &VWAPIndicator{loc: loc}
+}
+
+func (v *VWAPIndicator) Update(bar types.Bar) {
+	if v.loc != nil {
+		if ts, err := time.Parse(time.RFC3339, bar.Timestamp); err == nil {
+			if session := ts.In(v.loc).Format("2006-01-02"); session != v.session {
+				v.session, v.pv, v.volume = session, 0, 0
+			}
+		}
+	}
+	typical := (bar.High + bar.Low + bar.Close) / 3
+	v.pv += typical * float64(bar.Volume)
+	v.volume += float64(bar.Volume)
+}
+
+// Value is the session VWAP, or 0 before any volume has traded
+func (v *VWAPIndicator) Value() float64 {
+	if v.volume == 0 {
+		return 0
+	}
+	return v.pv / v.volume
+}
+
+// VolumeStatsIndicator keeps the mean and standard deviation of the last period volumes,
+// matching CalculateVolumeStats over the same window
+type VolumeStatsIndicator struct {
+	window []float64
+	next   int
+	count  int
+	shift  float64 // first volume seen; sums are taken around it to keep the variance precise
+	sum    float64
+	sumSq  float64
+}
+
+func NewVolumeStatsIndicator(period int) *VolumeStatsIndicator {
+	if period < 1 {
+		period = 1
+	}
+	return &VolumeStatsIndicator{window: make([]float64, period)}
+}
+
+func (v *VolumeStatsIndicator) Update(bar types.Bar) {
+	x := float64(bar.Volume)
+	if v.count == 0 && v.next == 0 {
+		v.shift = x
+	}
+	d := x - v.shift
+	if v.count == len(v.window) {
+		old := v.window[v.next] - v.shift
+		v.sum -= old
+		v.sumSq -= old * old
+	} else {
+		v.count++
+	}
+	v.sum += d
+	v.sumSq += d * d
+	v.window[v.next] = x
+	v.next = (v.next + 1) % len(v.window)
+}
+
+// Ready reports whether the window is full
+func (v *VolumeStatsIndicator) Ready() bool { return v.count == len(v.window) }
+
+// Value is the mean volume of the window
+func (v *VolumeStatsIndicator) Value() float64 {
+	if v.count == 0 {
+		return 0
+	}
+	return v.shift + v.sum/float64(v.count)
+}
+
+// StdDev is the population standard deviation of the window
+func (v *VolumeStatsIndicator) StdDev() float64 {
+	if v.count == 0 {
+		return 0
+	}
+	n := float64(v.count)
+	mean := v.sum / n
+	return math.Sqrt(math.Max(v.sumSq/n-mean*mean, 0))
+}
+
+// ZScore scores volume against the window; call it before Update to compare a bar with the ones before it
+func (v *VolumeStatsIndicator) ZScore(volume int64) float64 {
+	return CalculateZScore(volume, v.Value(), v.StdDev())
+}
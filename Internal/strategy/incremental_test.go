@@ -0,0 +1,199 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+// wavyBars is a chronological series with swings in both price and volume
+func wavyBars(n int, start time.Time, step time.Duration) []types.Bar {
+	bars := make([]types.Bar, n)
+	price := 50.0
+	for i := range bars {
+		price += math.Sin(float64(i)*0.9)*1.5 + 0.1
+		bars[i] = types.Bar{
+			Timestamp: start.Add(time.Duration(i) * step).Format(time.RFC3339),
+			Open:      price - 0.3,
+			High:      price + 1 + math.Abs(math.Cos(float64(i))),
+			Low:       price - 1,
+			Close:     price,
+			Volume:    int64(1_000_000 + 250_000*math.Sin(float64(i)*1.3) + float64(i%7)*10_000),
+		}
+	}
+	return bars
+}
+
+func TestRSIIndicatorSimpleMatchesCalculateRSI(t *testing.T) {
+	bars := wavyBars(120, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	want, err := CalculateRSI(closes, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsi := NewRSIIndicator(14, SmoothingSimple)
+	for i, b := range bars {
+		rsi.Update(b)
+		if rsi.Ready() != (i >= 14) {
+			t.Fatalf("bar %d: Ready() = %v", i, rsi.Ready())
+		}
+		if !closeTo(rsi.Value(), want[i]) {
+			t.Fatalf("bar %d: RSI %v, want %v", i, rsi.Value(), want[i])
+		}
+	}
+}
+
+func TestRSIIndicatorWilder(t *testing.T) {
+	bars := wavyBars(80, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	batch, _ := CalculateRSI(closes, 14)
+
+	// reference: seed with the simple averages, then Wilder's recurrence
+	var avgGain, avgLoss float64
+	rsi := NewRSIIndicator(14, SmoothingWilder)
+	for i, b := range bars {
+		rsi.Update(b)
+		if i == 0 {
+			continue
+		}
+		change := closes[i] - closes[i-1]
+		gain, loss := math.Max(change, 0), math.Max(-change, 0)
+		if i <= 14 {
+			avgGain += gain / 14
+			avgLoss += loss / 14
+		} else {
+			avgGain = (avgGain*13 + gain) / 14
+			avgLoss = (avgLoss*13 + loss) / 14
+		}
+		if i < 14 {
+			continue
+		}
+		want := 100 - 100/(1+avgGain/avgLoss)
+		if !closeTo(rsi.Value(), want) {
+			t.Fatalf("bar %d: Wilder RSI %v, want %v", i, rsi.Value(), want)
+		}
+		if i == 14 && !closeTo(rsi.Value(), batch[14]) {
+			t.Fatalf("Wilder seed %v should equal the simple RSI %v", rsi.Value(), batch[14])
+		}
+	}
+}
+
+func TestATRIndicatorMatchesCalculateATR(t *testing.T) {
+	bars := wavyBars(100, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	atrBars := make([]ATRBar, len(bars))
+	for i, b := range bars {
+		atrBars[i] = ATRBar{High: b.High, Low: b.Low, Close: b.Close}
+	}
+	want, err := CalculateATR(atrBars, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simple := NewATRIndicator(14, SmoothingSimple)
+	wilder := NewATRIndicator(14, SmoothingWilder)
+	var ref float64
+	for i, b := range bars {
+		simple.Update(b)
+		wilder.Update(b)
+		if i < 14 {
+			continue
+		}
+		if !closeTo(simple.Value(), want[i]) {
+			t.Fatalf("bar %d: simple ATR %v, want %v", i, simple.Value(), want[i])
+		}
+		tr := CalculateTrueRange(b.High, b.Low, bars[i-1].Close)
+		if i == 14 {
+			ref = want[14]
+		} else {
+			ref = (ref*13 + tr) / 14
+		}
+		if !closeTo(wilder.Value(), ref) {
+			t.Fatalf("bar %d: Wilder ATR %v, want %v", i, wilder.Value(), ref)
+		}
+	}
+
+	// warm-up averages what it has, like scoring.CalculateATRFromBars
+	short := NewATRIndicator(14, SmoothingSimple)
+	for _, b := range bars[:4] {
+		short.Update(b)
+	}
+	wantShort := (CalculateTrueRange(bars[1].High, bars[1].Low, bars[0].Close) +
+		CalculateTrueRange(bars[2].High, bars[2].Low, bars[1].Close) +
+		CalculateTrueRange(bars[3].High, bars[3].Low, bars[2].Close)) / 3
+	if short.Ready() || !closeTo(short.Value(), wantShort) {
+		t.Errorf("warm-up ATR %v (ready %v), want %v", short.Value(), short.Ready(), wantShort)
+	}
+}
+
+func TestVWAPIndicatorMatchesCalculatorWithinSession(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	day1 := wavyBars(390, time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC), time.Minute)
+	day2 := wavyBars(30, time.Date(2026, 3, 3, 14, 30, 0, 0, time.UTC), time.Minute)
+
+	vwap := NewVWAPIndicator(loc)
+	calc := NewVWAPCalculator(seriesOf(day1))
+	for i, b := range day1 {
+		vwap.Update(b)
+		if want := calc.CalculateAt(i); !closeTo(vwap.Value(), want) {
+			t.Fatalf("bar %d: VWAP %v, want %v", i, vwap.Value(), want)
+		}
+	}
+
+	calc = NewVWAPCalculator(seriesOf(day2))
+	for i, b := range day2 {
+		vwap.Update(b)
+		if want := calc.CalculateAt(i); !closeTo(vwap.Value(), want) {
+			t.Fatalf("day 2 bar %d: VWAP %v, want %v (session should reset)", i, vwap.Value(), want)
+		}
+	}
+
+	// without a location it never resets, like VWAPCalculator over both days
+	all := append(append([]types.Bar{}, day1...), day2...)
+	cumulative := NewVWAPIndicator(nil)
+	for _, b := range all {
+		cumulative.Update(b)
+	}
+	if want := NewVWAPCalculator(seriesOf(all)).Calculate(); !closeTo(cumulative.Value(), want) {
+		t.Errorf("cumulative VWAP %v, want %v", cumulative.Value(), want)
+	}
+}
+
+func TestVolumeStatsIndicatorMatchesCalculateVolumeStats(t *testing.T) {
+	bars := wavyBars(200, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	stats := NewVolumeStatsIndicator(20)
+	for i, b := range bars {
+		if i >= 20 {
+			volumes := make([]int64, 20)
+			for j := range volumes {
+				volumes[j] = bars[i-20+j].Volume
+			}
+			mean, stdDev := CalculateVolumeStats(volumes)
+			if !closeTo(stats.Value(), mean) || math.Abs(stats.StdDev()-stdDev) > 1e-6*stdDev {
+				t.Fatalf("bar %d: mean/std %v/%v, want %v/%v", i, stats.Value(), stats.StdDev(), mean, stdDev)
+			}
+			if want := CalculateZScore(b.Volume, mean, stdDev); math.Abs(stats.ZScore(b.Volume)-want) > 1e-6 {
+				t.Fatalf("bar %d: z-score %v, want %v", i, stats.ZScore(b.Volume), want)
+			}
+		}
+		stats.Update(b)
+	}
+	if !stats.Ready() {
+		t.Error("window should be full")
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"github.com/fazecat/mongelmaker/Internal/utils"
 )
 
+// CalculateInterestScore is the original multiplier model, roughly 5 to 10.
+//
+// Deprecated: the scanner, scout and screener rate symbols with Decide (see Decision.Rating).
 func CalculateInterestScore(input types.ScoringInput) float64 {
 	baseScore := 5.0
 
@@ -1,9 +1,12 @@
 package strategy
 
 import (
+	"context"
 	"testing"
 
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
 )
 
 func TestRSICalculation(t *testing.T) {
@@ -76,11 +79,11 @@ func TestCalculateAndStoreRSI(t *testing.T) {
 	}
 
 	// Setup database connection first
-	err := datafeed.InitDatabase()
+	store, err := datafeed.InitDatabase(nil)
 	if err != nil {
 		t.Skip("Database not available:", err)
 	}
-	defer datafeed.CloseDatabase()
+	defer store.Close()
 
 	symbol := "AAPL"
 	timeframe := "1Day"
@@ -92,7 +95,12 @@ func TestCalculateAndStoreRSI(t *testing.T) {
 		return
 	}
 
-	err = datafeed.CalculateAndStoreRSI(symbol, bars)
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		t.Fatalf("NewBarSeries() error = %v", err)
+	}
+
+	err = datafeed.CalculateAndStoreRSI(context.Background(), repository.FromDB(store.Conn).Indicators, symbol, series)
 	if err != nil {
 		t.Errorf("CalculateAndStoreRSI() error = %v", err)
 	}
@@ -3,16 +3,15 @@ package strategy
 import (
 	"context"
 	"fmt"
-	"log"
 	"sort"
-	"time"
-
-	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	. "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
 	"github.com/fazecat/mongelmaker/Internal/utils"
 	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 )
 
 type ScreenerCriteria struct {
@@ -20,6 +19,10 @@ type ScreenerCriteria struct {
 	MaxRSI         float64
 	MinATR         float64
 	MinVolumeRatio float64
+	Signals        SignalConfig
+	// MultiTimeframe adds a confluence of the 1Min to 1Day timeframes to every result.
+	// It costs four extra bar requests per symbol and leaves the ranking unchanged.
+	MultiTimeframe bool
 }
 
 type StockScore struct {
@@ -32,6 +35,8 @@ type StockScore struct {
 	NewsImpact     float64
 	FinalSignal    CombinedSignal
 	Recommendation string
+	Decision       Decision
+	Confluence     *Confluence // set when the criteria ask for MultiTimeframe
 }
 
 func DefaultScreenerCriteria() ScreenerCriteria {
@@ -40,168 +45,145 @@ func DefaultScreenerCriteria() ScreenerCriteria {
 		MaxRSI:         75,  // Avoid overbought
 		MinATR:         0.1, // Very low volatility threshold
 		MinVolumeRatio: 1.0, // Any volume above average
+		Signals:        GetSignalConfig(),
 	}
 }
 
-// screens a list of symbols based on criteria
-func ScreenStocks(symbols []string, timeframe string, numBars int, criteria ScreenerCriteria, newsStorage *NewsStorage) ([]StockScore, error) {
-	var results []StockScore
-
-	for _, symbol := range symbols {
-		score, signals, rsi, atr, err := scoreStock(symbol, timeframe, numBars, criteria, newsStorage)
-		if err != nil {
-			log.Printf("Error screening %s: %v", symbol, err)
-			continue
-		}
-		if score == 0 && len(signals) == 0 && rsi == nil && atr == nil {
-			log.Printf("Skipping %s: no data available", symbol)
-			continue
-		}
-		results = append(results, StockScore{
-			Symbol:  symbol,
-			Score:   score,
-			Signals: signals,
-			RSI:     rsi,
-			ATR:     atr,
-		})
-	}
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+// Screener scores symbols from a market data provider. A nil Provider uses the active
+// datafeed provider; a nil News skips the news sentiment and a nil Whales the whale track record.
+type Screener struct {
+	Provider datafeed.MarketDataProvider
+	News     repository.NewsRepository
+	Whales   repository.WhaleRepository
+	Pool     utils.PoolConfig
+}
 
-	return results, nil
+// screens a list of symbols based on criteria
+func ScreenStocks(symbols []string, timeframe string, numBars int, criteria ScreenerCriteria, news repository.NewsRepository) ([]StockScore, error) {
+	return ScreenStocksConcurrent(context.Background(), symbols, timeframe, numBars, criteria, news, utils.DefaultPoolConfig())
 }
 
-func scoreStock(symbol, timeframe string, numBars int, criteria ScreenerCriteria, newsStorage *NewsStorage) (score float64, signals []string, rsi, atr *float64, err error) {
+// ScreenStocksConcurrent screens with the active provider, see Screener.Screen
+func ScreenStocksConcurrent(ctx context.Context, symbols []string, timeframe string, numBars int, criteria ScreenerCriteria, news repository.NewsRepository, pool utils.PoolConfig) ([]StockScore, error) {
+	return (&Screener{News: news, Pool: pool}).Screen(ctx, symbols, timeframe, numBars, criteria)
+}
 
-	bars, err := datafeed.GetAlpacaBars(symbol, timeframe, numBars, "")
+// Screen scores symbols on a worker pool. Market data calls share the provider's rate
+// limiter, so more workers never exceed the API quota. Results are ranked by score with
+// ties broken by symbol, so the order doesn't depend on timing.
+func (s *Screener) Screen(ctx context.Context, symbols []string, timeframe string, numBars int, criteria ScreenerCriteria) ([]StockScore, error) {
+	provider := s.Provider
+	if provider == nil {
+		provider = datafeed.GetProvider()
+	}
+	prefetched, err := datafeed.PrefetchBarsFrom(ctx, provider, symbols, timeframe, numBars, "")
 	if err != nil {
-		return 0, nil, nil, nil, err
+		return nil, err
 	}
 
-	if len(bars) < 2 {
-		return 0, nil, nil, nil, fmt.Errorf("insufficient data for %s (need 2 bars, got %d)", symbol, len(bars))
+	evaluator := &Evaluator{Signals: criteria.Signals, News: s.News, Whales: s.Whales}
+	scored, err := utils.ProcessSymbols(ctx, symbols, s.Pool, func(ctx context.Context, symbol string) (StockScore, error) {
+		bars, err := prefetched.Get(ctx, symbol)
+		if err != nil {
+			return StockScore{}, err
+		}
+		var confluence *Confluence
+		if criteria.MultiTimeframe {
+			c, err := FetchConfluence(ctx, provider, symbol)
+			if err != nil {
+				logger.Component("screener").Warn("multi-timeframe confluence unavailable", logger.KeySymbol, symbol, logger.Err(err))
+			} else {
+				confluence = &c
+			}
+		}
+		return scoreStock(ctx, symbol, timeframe, bars, criteria, evaluator, confluence)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	startTime := time.Now().AddDate(0, 0, -180)
-	endTime := time.Now()
-
-	if len(bars) > 0 {
-		oldestTime, err := time.Parse(time.RFC3339, bars[len(bars)-1].Timestamp)
-		if err == nil {
-			startTime = oldestTime
+	var results []StockScore
+	for _, r := range scored {
+		if r.Err != nil {
+			logger.Component("screener").Warn("symbol skipped", logger.KeySymbol, r.Symbol, "reason", utils.Describe(r.Err), logger.Err(r.Err))
+			continue
 		}
+		results = append(results, r.Value)
 	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Symbol < results[j].Symbol
+	})
 
-	rsiMap, rsiErr := datafeed.FetchRSIByTimestampRange(symbol, startTime, endTime)
-	if rsiErr != nil {
-		log.Printf("RSI fetch failed for %s: %v (continuing with other signals)", symbol, rsiErr)
-	} else if len(rsiMap) > 0 {
-		rsi = findLatestValue(rsiMap)
-	}
+	return results, nil
+}
 
-	atrMap, atrErr := datafeed.FetchATRByTimestampRange(symbol, startTime, endTime)
-	if atrErr != nil {
-		log.Printf("ATR fetch failed for %s: %v (continuing with other signals)", symbol, atrErr)
-	} else if len(atrMap) > 0 {
-		atr = findLatestValue(atrMap)
+// scoreStock runs the decision engine over a symbol's bars. Score is the decision's rating;
+// the criteria only decide which observations are listed in Signals.
+func scoreStock(ctx context.Context, symbol, timeframe string, bars []datafeed.Bar, criteria ScreenerCriteria, evaluator *Evaluator, confluence *Confluence) (StockScore, error) {
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		return StockScore{}, fmt.Errorf("invalid bars for %s: %w", symbol, err)
 	}
-
-	latestBar := bars[0]
-	volumes := make([]int64, len(bars))
-	for i, bar := range bars {
-		volumes[i] = bar.Volume
+	if series.Len() < 2 {
+		return StockScore{}, fmt.Errorf("insufficient data for %s (need 2 bars, got %d)", symbol, series.Len())
 	}
-	avgVol20 := utils.CalculateAvgVolume(volumes, 20)
 
-	score = 0
-	signals = []string{}
+	// criteria built by hand without weights decide with the active signal config
+	features, decision := evaluator.Evaluate(ctx, symbol, timeframe, series)
 
-	if rsi != nil {
+	signals := []string{}
+	if rsi := features.RSI; rsi != nil {
 		if *rsi < criteria.MinOversoldRSI {
-			score += 20
 			signals = append(signals, fmt.Sprintf("RSI Oversold: %.2f", *rsi))
 		} else if *rsi > criteria.MaxRSI {
-			score -= 10
 			signals = append(signals, fmt.Sprintf("RSI Overbought: %.2f", *rsi))
-		} else {
-			score += 5
 		}
 	}
-
-	if atr != nil && *atr > criteria.MinATR {
-		score += 10
+	if atr := features.ATR; atr != nil && *atr > criteria.MinATR {
 		signals = append(signals, fmt.Sprintf("High Volatility ATR: %.2f", *atr))
 	}
-
-	if avgVol20 > 0 {
-		volRatio := float64(latestBar.Volume) / avgVol20
-		if volRatio > criteria.MinVolumeRatio {
-			score += 15
-			signals = append(signals, fmt.Sprintf("High Volume: %.1fx avg", volRatio))
-		}
-	}
-
-	if newsStorage != nil {
-		news, err := newsStorage.GetLatestNews(context.Background(), symbol, 1)
-		if err == nil && len(news) > 0 && news[0].Sentiment == Positive {
-			score += 10 // Boost score for positive sentiment
-		}
+	if ratio := features.VolumeRatio; ratio != nil && *ratio > criteria.MinVolumeRatio {
+		signals = append(signals, fmt.Sprintf("High Volume: %.1fx avg", *ratio))
 	}
-
-	whales := DetectWhales(symbol, bars)
-	if len(whales) > 0 {
-		for _, whale := range whales {
-			if whale.Conviction == "HIGH" {
-				score += 5
-				signals = append(signals, fmt.Sprintf("🐋 Whale %s: Z=%.2f", whale.Direction, whale.ZScore))
-			}
+	for _, whale := range DetectWhales(symbol, series) {
+		if whale.Conviction == "HIGH" {
+			signals = append(signals, fmt.Sprintf("🐋 Whale %s: Z=%.2f", whale.Direction, whale.ZScore))
 		}
 	}
 
-	support := FindSupport(bars)
-	resistance := FindResistance(bars)
-
-	currentPrice := latestBar.Close
-	if currentPrice < support*1.01 {
-		score += 15 // Strong buy signal
-		signals = append(signals, fmt.Sprintf("Near Support: $%.2f", support))
+	levels := AnalyzeLevels(series)
+	currentPrice := series.Latest().Close
+	if support := levels.Support; support.Near(currentPrice) {
+		signals = append(signals, fmt.Sprintf("Near Support: $%.2f (%d touches)", support.Price, support.Touches))
 	}
-	if currentPrice > resistance*0.99 {
-		score -= 10 // Sell signal
-		signals = append(signals, fmt.Sprintf("Near Resistance: $%.2f", resistance))
+	if resistance := levels.Resistance; resistance.Near(currentPrice) {
+		signals = append(signals, fmt.Sprintf("Near Resistance: $%.2f (%d touches)", resistance.Price, resistance.Touches))
 	}
 
-	combinedSignal := CalculateSignal(rsi, atr, bars, symbol, "")
-
-	signals = append(signals, fmt.Sprintf("\n🎯 FINAL: %s", FormatSignal(combinedSignal)))
-
-	return score, signals, rsi, atr, nil
-}
-
-func GetTradableAssets() ([]string, error) {
-	client := datafeed.GetAlpacaClient()
-	if client == nil {
-		return nil, fmt.Errorf("alpaca client not initialized - call InitAlpacaClient() first")
+	if confluence != nil {
+		signals = append(signals, fmt.Sprintf("⏱️  Timeframes: %s", FormatConfluence(*confluence)))
 	}
 
-	assets, err := client.GetAssets(alpaca.GetAssetsRequest{
-		Status: "active",
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch assets from Alpaca: %v", err)
-	}
-
-	symbols := make([]string, 0, len(assets))
-	for _, asset := range assets {
-		if asset.Class == "us_equity" && asset.Tradable {
-			symbols = append(symbols, asset.Symbol)
-		}
-	}
+	signals = append(signals, fmt.Sprintf("\n🎯 FINAL: %s", FormatDecision(decision)))
 
-	log.Printf("Fetched %d tradeable assets from Alpaca", len(symbols))
-	return symbols, nil
+	return StockScore{
+		Symbol:         symbol,
+		Score:          decision.Rating,
+		Signals:        signals,
+		RSI:            features.RSI,
+		ATR:            features.ATR,
+		NewsSentiment:  features.NewsSentiment,
+		NewsImpact:     features.NewsImpact,
+		FinalSignal:    decision.Signal,
+		Recommendation: decision.Action,
+		Decision:       decision,
+		Confluence:     confluence,
+	}, nil
 }
+
 func GetPopularStocks() []string {
 	return []string{
 		"AAPL", "MSFT", "GOOGL", "AMZN", "TSLA", "NVDA", "META", "NFLX", "BABA", "ORCL",
@@ -212,39 +194,31 @@ func GetPopularStocks() []string {
 	}
 }
 
-func findLatestValue(m map[string]float64) *float64 {
-	if len(m) == 0 {
-		return nil
-	}
-	var latestKey string
-	var latestVal float64
-	for k, v := range m {
-		if latestKey == "" || k > latestKey {
-			latestKey = k
-			latestVal = v
-		}
-	}
-	return &latestVal
-}
-
 func GetScreenerCriteriaFromProfile(cfg *config.Config, profilename string) (ScreenerCriteria, error) {
 	profile, exists := cfg.Profiles[profilename]
 	if !exists {
 		return ScreenerCriteria{}, fmt.Errorf("profile %s not found", profilename)
 	}
 
+	signals, err := SignalConfigFromProfile(cfg, profilename)
+	if err != nil {
+		return ScreenerCriteria{}, err
+	}
+
 	return ScreenerCriteria{
 		MinOversoldRSI: profile.Indicators.RSI.MinOversold,
 		MaxRSI:         profile.Indicators.RSI.MaxOverbought,
 		MinATR:         profile.Indicators.ATR.MinVolatility,
 		MinVolumeRatio: profile.Indicators.Volume.MinRatio,
+		Signals:        signals,
+		MultiTimeframe: profile.MultiTimeframe,
 	}, nil
 }
 
-func ScreenStockWithConfig(symbols []string, timeframe string, numBars int, cfg *config.Config, profileName string, newsStorage *NewsStorage) ([]StockScore, error) {
+func ScreenStockWithConfig(symbols []string, timeframe string, numBars int, cfg *config.Config, profileName string, news repository.NewsRepository) ([]StockScore, error) {
 	criteria, err := GetScreenerCriteriaFromProfile(cfg, profileName)
 	if err != nil {
 		return nil, err
 	}
-	return ScreenStocks(symbols, timeframe, numBars, criteria, newsStorage)
+	return ScreenStocks(symbols, timeframe, numBars, criteria, news)
 }
@@ -0,0 +1,84 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+// uptrendProvider returns limit daily bars of a steady uptrend, latest first
+type uptrendProvider struct{}
+
+func (uptrendProvider) Name() string { return "uptrend" }
+
+func (uptrendProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+	if symbol == "FAIL" {
+		return nil, fmt.Errorf("no data for %s", symbol)
+	}
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	bars := make([]datafeed.Bar, limit)
+	for i := range bars {
+		price := 100 + float64(i)
+		bars[limit-1-i] = datafeed.Bar{
+			Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open:      price - 0.5, High: price + 1, Low: price - 1, Close: price, Volume: 1000,
+		}
+	}
+	return bars, nil
+}
+
+func (uptrendProvider) GetLatestQuote(ctx context.Context, symbol string) (*datafeed.LastQuote, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (uptrendProvider) GetLatestTrade(ctx context.Context, symbol string) (*datafeed.LastTrade, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (uptrendProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func TestScreenerUsesInjectedProviderAndNews(t *testing.T) {
+	ctx := context.Background()
+	news := repository.NewMemoryNews()
+	err := news.SaveArticle(ctx, newsscraping.NewsArticle{
+		Symbol:      "MSFT",
+		Headline:    "MSFT beats earnings",
+		URL:         "https://example.com/msft",
+		PublishedAt: time.Now(),
+		Sentiment:   newsscraping.Positive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	screener := &Screener{Provider: uptrendProvider{}, News: news, Pool: utils.DefaultPoolConfig()}
+	scores, err := screener.Screen(ctx, []string{"MSFT", "FAIL", "AAPL"}, "1Day", 60, DefaultScreenerCriteria())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 {
+		t.Fatalf("expected FAIL to be skipped, got %d scores", len(scores))
+	}
+
+	bySymbol := map[string]StockScore{}
+	for _, score := range scores {
+		bySymbol[score.Symbol] = score
+		if score.RSI == nil || score.ATR == nil {
+			t.Errorf("%s is missing RSI or ATR", score.Symbol)
+		}
+	}
+	if got := bySymbol["MSFT"].NewsSentiment; got != newsscraping.Positive {
+		t.Errorf("MSFT news sentiment = %q, want %q", got, newsscraping.Positive)
+	}
+	if got := bySymbol["AAPL"].NewsSentiment; got != "" {
+		t.Errorf("AAPL has no news but got sentiment %q", got)
+	}
+}
@@ -3,23 +3,23 @@ package strategy
 import (
 	"fmt"
 
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
 	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
 )
 
-// comment for future (adjust the values instead of hardcoding)
-
 type SignalComponent struct {
-	Name   string
-	Score  float64
-	Weight float64
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
 }
 
 type CombinedSignal struct {
-	Recommendation string
-	Score          float64
-	Confidence     float64
-	Reasoning      string
-	Components     []SignalComponent
+	Recommendation string            `json:"recommendation"`
+	Score          float64           `json:"score"`
+	Confidence     float64           `json:"confidence"`
+	Reasoning      string            `json:"reasoning"`
+	Components     []SignalComponent `json:"components"`
 }
 
 // converts RSI value into score
@@ -49,9 +49,10 @@ func calculateATRScore(atr float64, closePrice float64) float64 {
 	return 0.0
 }
 
-// calculateWhaleScore determines whale signal from detected whale events
-func calculateWhaleScore(symbol string, bars []types.Bar) float64 {
-	whales := DetectWhales(symbol, bars)
+// calculateWhaleScore determines whale signal from detected whale events. With stats the
+// score is scaled by how reliable the symbol's whales in that direction have been.
+func calculateWhaleScore(symbol string, series types.BarSeries, stats *WhaleStats) float64 {
+	whales := DetectWhales(symbol, series)
 
 	if len(whales) == 0 {
 		return 0.0 // No whales detected
@@ -70,112 +71,244 @@ func calculateWhaleScore(symbol string, bars []types.Bar) float64 {
 		}
 	}
 
+	reliability := func(direction string) float64 {
+		if stats == nil {
+			return 1
+		}
+		return stats.Reliability(direction)
+	}
 	if buyCount > sellCount {
-		return 3.0 // Institutional buyers in control
+		return 3.0 * reliability("BUY") // Institutional buyers in control
 	} else if sellCount > buyCount {
-		return -3.0 // Institutional sellers in control
+		return -3.0 * reliability("SELL") // Institutional sellers in control
 	}
 	return 0.0
 }
 
+// converts volume against its average into score: heavy volume confirms the move, thin volume doesn't
+func calculateVolumeScore(ratio float64) float64 {
+	if ratio >= 2.0 {
+		return 2.0
+	} else if ratio >= 1.5 {
+		return 1.0
+	} else if ratio < 0.5 {
+		return -1.0
+	}
+	return 0.0
+}
+
+// converts the latest article's sentiment into score, stronger for high impact catalysts
+func calculateNewsScore(sentiment newsscraping.SentimentScore, impact float64) float64 {
+	score := 0.0
+	switch sentiment {
+	case newsscraping.Positive:
+		score = 2.0
+	case newsscraping.Negative:
+		score = -2.0
+	}
+	if impact >= 0.15 {
+		score *= 1.5
+	}
+	return score
+}
+
+// VolumeRatio compares the last volume (oldest first) with the average of the period before it
+func VolumeRatio(volumes []int64, period int) *float64 {
+	if len(volumes) < 2 {
+		return nil
+	}
+	avg := utils.CalculateAvgVolume(volumes[:len(volumes)-1], period)
+	if avg <= 0 {
+		return nil
+	}
+	ratio := float64(volumes[len(volumes)-1]) / avg
+	return &ratio
+}
+
+// calculatePatternScore scores a single-candle label from analyzer.AnalyzeCandlestick or PatternAnalyzeCandle
 func calculatePatternScore(analysis string) float64 {
 	switch analysis {
 	case "Strong Bullish", "Bullish Hammer":
 		return 2.0
-	case "Weak Bullish", "Bullish Engulfing":
+	case "Bullish", "Bullish Rejection":
 		return 1.0
-	case "Doji", "Neutral":
+	case "Doji (indecision)", "Neutral":
+		return 0.0
+	case "Bearish", "Bearish Rejection":
+		return -1.0
+	case "Strong Bearish", "Bearish Shooting Star":
+		return -2.0
+	}
+	return 0.0
+}
+
+// strongestPattern is the most confident match that leans one way
+func strongestPattern(matches []types.PatternMatch) (types.PatternMatch, bool) {
+	var best types.PatternMatch
+	found := false
+	for _, m := range matches {
+		if m.Direction != 0 && (!found || m.Confidence > best.Confidence) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// calculatePatternMatchScore scores the strongest multi-candle pattern as ±2 times its
+// confidence. Without one that leans either way it falls back to the single-candle label.
+func calculatePatternMatchScore(matches []types.PatternMatch, analysis string) float64 {
+	if m, ok := strongestPattern(matches); ok {
+		return 2.0 * float64(m.Direction) * m.Confidence
+	}
+	return calculatePatternScore(analysis)
+}
+
+// converts the MACD histogram into score: a fresh crossover counts more than an ongoing trend
+func calculateMACDScore(m MACDResult) float64 {
+	last := len(m.Histogram) - 1
+	if last < m.Start {
 		return 0.0
-	case "Weak Bearish", "Bearish Engulfing":
+	}
+	current := m.Histogram[last]
+	if last > m.Start {
+		previous := m.Histogram[last-1]
+		if previous <= 0 && current > 0 {
+			return 2.0 // Bullish crossover
+		}
+		if previous >= 0 && current < 0 {
+			return -2.0 // Bearish crossover
+		}
+	}
+	if current > 0 {
+		return 1.0
+	} else if current < 0 {
 		return -1.0
-	case "Strong Bearish", "Bearish Hammer":
+	}
+	return 0.0
+}
+
+// converts %B into score: closes outside the bands are treated as stretched and likely to revert
+func calculateBollingerScore(percentB float64) float64 {
+	if percentB <= 0 {
+		return 2.0
+	} else if percentB < 0.2 {
+		return 1.0
+	} else if percentB >= 1 {
 		return -2.0
+	} else if percentB > 0.8 {
+		return -1.0
 	}
 	return 0.0
 }
 
-func calculateSRScore(bars []types.Bar) float64 {
-	support := FindSupport(bars)
-	resistance := FindResistance(bars)
-	currentPrice := bars[len(bars)-1].Close
+func calculateSRScore(series types.BarSeries) float64 {
+	levels := AnalyzeLevels(series)
+	currentPrice := series.Latest().Close
 
-	if IsAtSupport(currentPrice, support) {
+	if levels.Support.Near(currentPrice) {
 		return 1.0 // At support = buy opportunity
 	}
-	if IsAtResistance(currentPrice, resistance) {
+	if levels.Resistance.Near(currentPrice) {
 		return -1.0 // At resistance = sell pressure
 	}
 	return 0.0
 }
 
+// SignalInput is everything CalculateSignalWithConfig can score. Nil or empty
+// optional fields drop their component and the remaining weights are rescaled.
+type SignalInput struct {
+	Symbol        string
+	Series        types.BarSeries
+	RSI           *float64
+	ATR           *float64
+	Analysis      string
+	Patterns      []types.PatternMatch        // multi-candle patterns ending on the latest bar
+	VolumeRatio   *float64                    // latest volume over its recent average, see VolumeRatio
+	NewsSentiment newsscraping.SentimentScore // empty when there is no recent article
+	NewsImpact    float64
+	WhaleStats    *WhaleStats    // the symbol's whale track record, see LoadWhaleStats
+	MACD          *MACDResult    // see CalculateMACD
+	Bollinger     *BollingerBand // the latest band, see CalculateBollingerBands
+}
+
+// CalculateSignal scores the inputs with the active signal config (see SetSignalConfig)
 func CalculateSignal(
 	rsiValue *float64,
 	atrValue *float64,
-	bars []types.Bar,
+	series types.BarSeries,
 	symbol string,
 	analysis string,
 ) CombinedSignal {
+	return CalculateSignalWithConfig(SignalInput{
+		Symbol:   symbol,
+		Series:   series,
+		RSI:      rsiValue,
+		ATR:      atrValue,
+		Analysis: analysis,
+	}, GetSignalConfig())
+}
 
+func CalculateSignalWithConfig(input SignalInput, cfg SignalConfig) CombinedSignal {
+	w := cfg.Weights
+	series := input.Series
 	components := []SignalComponent{}
 
-	rsiScore := 0.0
-	if rsiValue != nil {
-		rsiScore = calculateRSIScore(*rsiValue)
-		components = append(components, SignalComponent{
-			Name:   "RSI",
-			Score:  rsiScore,
-			Weight: 0.25,
-		})
-	}
-
-	atrScore := 0.0
-	if atrValue != nil && len(bars) > 0 {
-		atrScore = calculateATRScore(*atrValue, bars[0].Close)
-		components = append(components, SignalComponent{
-			Name:   "ATR",
-			Score:  atrScore,
-			Weight: 0.15,
-		})
-	}
-
-	whaleScore := calculateWhaleScore(symbol, bars)
-	components = append(components, SignalComponent{
-		Name:   "Whale",
-		Score:  whaleScore,
-		Weight: 0.30,
-	})
-
-	patternScore := calculatePatternScore(analysis)
-	components = append(components, SignalComponent{
-		Name:   "Pattern",
-		Score:  patternScore,
-		Weight: 0.20,
-	})
-
-	srScore := calculateSRScore(bars)
-	components = append(components, SignalComponent{
-		Name:   "Support/Resistance",
-		Score:  srScore,
-		Weight: 0.10,
-	})
-
-	// Calculate weighted ensemble score
-	ensembleScore := (rsiScore * 0.25) + (atrScore * 0.15) + (whaleScore * 0.30) + (patternScore * 0.20) + (srScore * 0.10)
+	add := func(name string, score, weight float64) {
+		if weight > 0 {
+			components = append(components, SignalComponent{Name: name, Score: score, Weight: weight})
+		}
+	}
+
+	if input.RSI != nil {
+		add("RSI", calculateRSIScore(*input.RSI), w.RSIWeight)
+	}
+	if input.ATR != nil && series.Len() > 0 {
+		add("ATR", calculateATRScore(*input.ATR, series.Latest().Close), w.ATRWeight)
+	}
+	if input.VolumeRatio != nil {
+		add("Volume", calculateVolumeScore(*input.VolumeRatio), w.VolumeWeight)
+	}
+	if input.NewsSentiment != "" {
+		add("News", calculateNewsScore(input.NewsSentiment, input.NewsImpact), w.NewsSentimentWeight)
+	}
+	add("Whale", calculateWhaleScore(input.Symbol, series, input.WhaleStats), w.WhaleActivityWeight)
+	add("Pattern", calculatePatternMatchScore(input.Patterns, input.Analysis), w.PatternWeight)
+	if series.Len() > 0 {
+		add("Support/Resistance", calculateSRScore(series), w.SRWeight)
+	}
+	if input.MACD != nil {
+		add("MACD", calculateMACDScore(*input.MACD), w.MACDWeight)
+	}
+	if input.Bollinger != nil {
+		add("Bollinger", calculateBollingerScore(input.Bollinger.PercentB), w.BollingerWeight)
+	}
+
+	// Calculate weighted ensemble score, rescaled over the components we actually have
+	totalWeight := 0.0
+	ensembleScore := 0.0
+	for _, c := range components {
+		totalWeight += c.Weight
+		ensembleScore += c.Score * c.Weight
+	}
+	if totalWeight > 0 {
+		ensembleScore /= totalWeight
+	}
 
 	// Map to recommendation
+	t := cfg.Thresholds
 	recommendation := "WAIT"
 	reasoning := "Neutral signals"
 
-	if ensembleScore >= 1.5 {
+	if ensembleScore >= t.StrongBuy {
 		recommendation = "BUY"
 		reasoning = "Strong buy signals"
-	} else if ensembleScore >= 0.5 {
+	} else if ensembleScore >= t.Buy {
 		recommendation = "ACCUMULATE"
 		reasoning = "Moderate buy signals"
-	} else if ensembleScore <= -1.5 {
+	} else if ensembleScore <= t.StrongSell {
 		recommendation = "SELL"
 		reasoning = "Strong sell signals"
-	} else if ensembleScore <= -0.5 {
+	} else if ensembleScore <= t.Sell {
 		recommendation = "DISTRIBUTE"
 		reasoning = "Moderate sell signals"
 	}
@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"gopkg.in/yaml.v3"
+)
+
+func flatBars(n int, price float64) types.BarSeries {
+	bars := make([]types.Bar, n)
+	for i := range bars {
+		bars[i] = types.Bar{Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 1000}
+	}
+	return seriesOf(bars)
+}
+
+// seriesOf wraps oldest-first fixture bars in a series, dating undated bars a day apart
+func seriesOf(bars []types.Bar) types.BarSeries {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	dated := make([]types.Bar, len(bars))
+	for i, bar := range bars {
+		if bar.Timestamp == "" {
+			bar.Timestamp = day.AddDate(0, 0, i).Format(time.RFC3339)
+		}
+		dated[i] = bar
+	}
+	series, err := types.NewBarSeries(dated)
+	if err != nil {
+		panic(err)
+	}
+	return series
+}
+
+func TestDefaultSignalConfigMatchesOriginalWeights(t *testing.T) {
+	bars := flatBars(10, 100)
+	rsi, atr := 30.0, 5.0
+
+	signal := CalculateSignalWithConfig(SignalInput{
+		Symbol: "AAPL", Series: bars, RSI: &rsi, ATR: &atr, Analysis: "Strong Bullish",
+	}, DefaultSignalConfig())
+
+	// RSI 3, ATR 1 (5% of price), whale 0, pattern 2
+	expected := 3*0.25 + 1*0.15 + 0*0.30 + 2*0.20 + calculateSRScore(bars)*0.10
+	if math.Abs(signal.Score-expected) > 1e-9 {
+		t.Errorf("score = %v, want %v", signal.Score, expected)
+	}
+	if len(signal.Components) != 5 {
+		t.Errorf("expected 5 components, got %d", len(signal.Components))
+	}
+	if signal.Recommendation != "ACCUMULATE" {
+		t.Errorf("recommendation = %s, want ACCUMULATE", signal.Recommendation)
+	}
+}
+
+func TestVolumeAndNewsComponents(t *testing.T) {
+	cfg := SignalConfig{
+		Weights:    config.SignalWeights{VolumeWeight: 0.5, NewsSentimentWeight: 0.5},
+		Thresholds: DefaultSignalThresholds(),
+	}
+	ratio := 2.5
+
+	signal := CalculateSignalWithConfig(SignalInput{
+		Symbol: "AAPL", Series: flatBars(5, 100), VolumeRatio: &ratio,
+		NewsSentiment: newsscraping.Positive, NewsImpact: 0.2,
+	}, cfg)
+
+	// volume 2, high impact positive news 3
+	if signal.Score != 2.5 || signal.Recommendation != "BUY" {
+		t.Errorf("expected BUY at 2.5, got %s at %v", signal.Recommendation, signal.Score)
+	}
+	names := map[string]bool{}
+	for _, c := range signal.Components {
+		names[c.Name] = true
+	}
+	if !names["Volume"] || !names["News"] || names["RSI"] {
+		t.Errorf("unexpected components: %+v", signal.Components)
+	}
+}
+
+func TestMissingComponentsAreRescaled(t *testing.T) {
+	cfg := SignalConfig{
+		Weights:    config.SignalWeights{RSIWeight: 0.5, PatternWeight: 0.5},
+		Thresholds: config.SignalThresholds{StrongBuy: 3, Buy: 1, Sell: -1, StrongSell: -3},
+	}
+
+	// no RSI: the pattern carries the full weight
+	signal := CalculateSignalWithConfig(SignalInput{Symbol: "AAPL", Series: flatBars(5, 100), Analysis: "Strong Bearish"}, cfg)
+	if signal.Score != -2 || signal.Recommendation != "DISTRIBUTE" {
+		t.Errorf("expected DISTRIBUTE at -2, got %s at %v", signal.Recommendation, signal.Score)
+	}
+}
+
+func TestSignalConfigValidate(t *testing.T) {
+	if err := DefaultSignalConfig().Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+
+	negative := DefaultSignalConfig()
+	negative.Weights.RSIWeight = -0.25
+	negative.Weights.ATRWeight = 0.65
+
+	overweight := DefaultSignalConfig()
+	overweight.Weights.VolumeWeight = 0.5
+
+	unordered := DefaultSignalConfig()
+	unordered.Thresholds.Buy = 2
+
+	for name, cfg := range map[string]SignalConfig{"negative": negative, "sum": overweight, "thresholds": unordered} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestSignalConfigFromProfile(t *testing.T) {
+	weights := config.SignalWeights{
+		RSIWeight: 0.2, ATRWeight: 0.1, VolumeWeight: 0.2, NewsSentimentWeight: 0.1,
+		WhaleActivityWeight: 0.2, PatternWeight: 0.1, SRWeight: 0.1,
+	}
+	cfg := &config.Config{Profiles: map[string]config.ProfileConfig{
+		"custom": {SignalWeights: weights},
+		"broken": {SignalWeights: config.SignalWeights{RSIWeight: 0.3}},
+	}}
+
+	sc, err := SignalConfigFromProfile(cfg, "custom")
+	if err != nil {
+		t.Fatalf("SignalConfigFromProfile() error = %v", err)
+	}
+	if sc.Weights != weights || sc.Thresholds != DefaultSignalThresholds() {
+		t.Errorf("unexpected config: %+v", sc)
+	}
+
+	if _, err := SignalConfigFromProfile(cfg, "broken"); err == nil {
+		t.Error("weights summing to 0.3 should be rejected")
+	}
+	if _, err := SignalConfigFromProfile(cfg, "missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+// TestShippedProfilesWeighPatternsAndLevels keeps the candle pattern and support/resistance
+// components switched on in config.yaml, in the 2:1 ratio DefaultSignalConfig gives them
+func TestShippedProfilesWeighPatternsAndLevels(t *testing.T) {
+	data, err := os.ReadFile("../utils/config/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultSignalConfig().Weights
+	for name := range cfg.Profiles {
+		sc, err := SignalConfigFromProfile(&cfg, name)
+		if err != nil {
+			t.Errorf("profile %s: %v", name, err)
+			continue
+		}
+		w := sc.Weights
+		if w.PatternWeight <= 0 || w.SRWeight <= 0 || !closeTo(w.PatternWeight/w.SRWeight, defaults.PatternWeight/defaults.SRWeight) {
+			t.Errorf("profile %s weighs patterns %.2f and S/R %.2f, want both on at the default ratio", name, w.PatternWeight, w.SRWeight)
+		}
+	}
+}
+
+func TestVolumeRatio(t *testing.T) {
+	ratio := VolumeRatio([]int64{100, 100, 100, 300}, 20)
+	if ratio == nil || *ratio != 3 {
+		t.Errorf("VolumeRatio() = %v, want 3", ratio)
+	}
+	if VolumeRatio([]int64{100}, 20) != nil {
+		t.Error("a single bar has nothing to compare against")
+	}
+}
+
+func TestPatternScoreUsesMultiCandleMatches(t *testing.T) {
+	// labels the analyzer actually emits
+	for label, want := range map[string]float64{"Strong Bullish": 2, "Bullish": 1, "Bullish Rejection": 1, "Doji (indecision)": 0, "Bearish": -1, "Bearish Shooting Star": -2} {
+		if got := calculatePatternScore(label); got != want {
+			t.Errorf("calculatePatternScore(%q) = %v, want %v", label, got, want)
+		}
+	}
+
+	matches := []types.PatternMatch{
+		{ID: types.PatternInsideBar, Confidence: 0.9},
+		{ID: types.PatternBearishHarami, Direction: -1, Confidence: 0.4},
+		{ID: types.PatternBullishEngulfing, Direction: 1, Confidence: 0.85},
+	}
+	if got := calculatePatternMatchScore(matches, "Bearish"); !closeTo(got, 1.7) {
+		t.Errorf("strongest directional match should win, got %v", got)
+	}
+	if got := calculatePatternMatchScore(matches[:1], "Bearish"); got != -1 {
+		t.Errorf("an inside bar alone should fall back to the candle label, got %v", got)
+	}
+
+	SetPatternDetector(func(series types.BarSeries) []types.PatternMatch { return matches })
+	defer SetPatternDetector(nil)
+	f := BuildFeatures("TEST", "1Day", flatBars(30, 100))
+	if len(f.Patterns) != 3 {
+		t.Fatalf("BuildFeatures should run the detector, got %+v", f.Patterns)
+	}
+	d := Decide(f)
+	for _, c := range d.Signal.Components {
+		if c.Name == "Pattern" && !closeTo(c.Score, 1.7) {
+			t.Errorf("pattern component %v, want 1.7", c.Score)
+		}
+	}
+}
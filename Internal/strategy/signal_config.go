@@ -0,0 +1,138 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+// how far the weights may drift from 1.0 before a profile is rejected
+const weightSumTolerance = 0.01
+
+// SignalConfig holds the component weights and recommendation cutoffs used by CalculateSignalWithConfig
+type SignalConfig struct {
+	Weights    config.SignalWeights
+	Thresholds config.SignalThresholds
+}
+
+// DefaultSignalConfig reproduces the weights CalculateSignal always used:
+// volume, news sentiment, MACD and Bollinger are off until a profile turns them on
+func DefaultSignalConfig() SignalConfig {
+	return SignalConfig{
+		Weights: config.SignalWeights{
+			RSIWeight:           0.25,
+			ATRWeight:           0.15,
+			WhaleActivityWeight: 0.30,
+			PatternWeight:       0.20,
+			SRWeight:            0.10,
+		},
+		Thresholds: DefaultSignalThresholds(),
+	}
+}
+
+func DefaultSignalThresholds() config.SignalThresholds {
+	return config.SignalThresholds{
+		StrongBuy:  1.5,
+		Buy:        0.5,
+		Sell:       -0.5,
+		StrongSell: -1.5,
+	}
+}
+
+// SignalConfigFromProfile reads a profile's signal_weights and signal_thresholds.
+// Profiles without thresholds keep the defaults.
+func SignalConfigFromProfile(cfg *config.Config, profileName string) (SignalConfig, error) {
+	profile := cfg.GetProfile(profileName)
+	if profile == nil {
+		return SignalConfig{}, fmt.Errorf("profile %s not found", profileName)
+	}
+
+	sc := SignalConfig{
+		Weights:    profile.SignalWeights,
+		Thresholds: profile.SignalThresholds,
+	}
+	if sc.Thresholds == (config.SignalThresholds{}) {
+		sc.Thresholds = DefaultSignalThresholds()
+	}
+	if err := sc.Validate(); err != nil {
+		return SignalConfig{}, fmt.Errorf("profile %s: %w", profileName, err)
+	}
+	return sc, nil
+}
+
+// Validate rejects negative weights, weights that don't add up to 1.0 and thresholds out of order
+func (c SignalConfig) Validate() error {
+	sum := 0.0
+	for _, w := range c.weightList() {
+		if w.weight < 0 {
+			return fmt.Errorf("%s weight is negative (%.2f)", w.name, w.weight)
+		}
+		sum += w.weight
+	}
+	if math.Abs(sum-1.0) > weightSumTolerance {
+		return fmt.Errorf("signal weights sum to %.2f, expected 1.0", sum)
+	}
+
+	t := c.Thresholds
+	if !(t.StrongBuy >= t.Buy && t.Buy > 0 && t.Sell < 0 && t.Sell >= t.StrongSell) {
+		return fmt.Errorf("signal thresholds must satisfy strong_buy >= buy > 0 > sell >= strong_sell, got %.2f/%.2f/%.2f/%.2f",
+			t.StrongBuy, t.Buy, t.Sell, t.StrongSell)
+	}
+	return nil
+}
+
+type namedWeight struct {
+	name   string
+	weight float64
+}
+
+func (c SignalConfig) weightList() []namedWeight {
+	w := c.Weights
+	return []namedWeight{
+		{"RSI", w.RSIWeight},
+		{"ATR", w.ATRWeight},
+		{"Volume", w.VolumeWeight},
+		{"News", w.NewsSentimentWeight},
+		{"Whale", w.WhaleActivityWeight},
+		{"Pattern", w.PatternWeight},
+		{"Support/Resistance", w.SRWeight},
+		{"MACD", w.MACDWeight},
+		{"Bollinger", w.BollingerWeight},
+	}
+}
+
+var (
+	signalConfigMu     sync.RWMutex
+	activeSignalConfig = DefaultSignalConfig()
+)
+
+// SetSignalConfig changes the config CalculateSignal uses
+func SetSignalConfig(sc SignalConfig) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	signalConfigMu.Lock()
+	defer signalConfigMu.Unlock()
+	activeSignalConfig = sc
+	return nil
+}
+
+func GetSignalConfig() SignalConfig {
+	signalConfigMu.RLock()
+	defer signalConfigMu.RUnlock()
+	return activeSignalConfig
+}
+
+// InitSignalConfig activates the profile named by signals.profile; an empty name keeps the defaults
+func InitSignalConfig(cfg *config.Config) error {
+	if cfg == nil || cfg.Signals.Profile == "" {
+		return nil
+	}
+	sc, err := SignalConfigFromProfile(cfg, cfg.Signals.Profile)
+	if err != nil {
+		return err
+	}
+	return SetSignalConfig(sc)
+}
@@ -1,62 +1,260 @@
 package strategy
 
-import "github.com/fazecat/mongelmaker/Internal/types"
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
 
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
+)
+
+const (
+	LevelSupport    = "SUPPORT"
+	LevelResistance = "RESISTANCE"
+)
+
+const (
+	swingStrength     = 2     // bars on each side a swing low/high must beat
+	zoneATRTolerance  = 0.5   // swing points within this many ATRs of a zone join it
+	zoneMinTolerance  = 0.002 // tolerance floor as a fraction of price, for very quiet series
+	touchHalfLife     = 20.0  // bars after which a touch counts half as much towards strength
+	levelNearFraction = 0.01  // price within 1% of a zone is at the level
+)
+
+// PriceLevel is a support or resistance zone built from clustered swing points
 type PriceLevel struct {
-	Price      float64
-	BouncCount int
-	Strength   float64
+	Price     float64 // mean of the clustered swing prices
+	Low       float64 // zone bounds
+	High      float64
+	Type      string  // LevelSupport or LevelResistance, relative to the latest close
+	Touches   int     // swing points in the zone
+	Strength  float64 // touches weighted by recency, the newest counting 1
+	LastTouch time.Time
+	Flipped   bool // the zone first formed in the other role, e.g. broken resistance now acting as support
+}
+
+// Meaningful is true for zones tested more than once or that have changed role
+func (l PriceLevel) Meaningful() bool {
+	return l.Touches >= 2 || l.Flipped
+}
+
+// Near reports whether price is within 1% of the zone
+func (l PriceLevel) Near(price float64) bool {
+	if l.Price == 0 {
+		return false
+	}
+	tolerance := l.Price * levelNearFraction
+	return price >= l.Low-tolerance && price <= l.High+tolerance
+}
+
+// Levels is the nearest meaningful support and resistance around the latest close.
+// When no zone qualifies on a side it falls back to the window's lowest low or highest high.
+type Levels struct {
+	Support    PriceLevel
+	Resistance PriceLevel
+	Zones      []PriceLevel // every zone, lowest first
+}
+
+type swingPoint struct {
+	price float64
+	index int
+	role  string
 }
 
-func FindSupport(bars []types.Bar) float64 {
-	if len(bars) < 3 {
+// FindLevels clusters the series' swing lows and highs into zones, lowest first. Zones are
+// ATR-relative: a swing joins a zone when it is within half an ATR of the zone's mean.
+func FindLevels(series types.BarSeries) []PriceLevel {
+	n := series.Len()
+	if n < 2*swingStrength+1 {
+		return nil
+	}
+	swings := findSwings(series)
+	if len(swings) == 0 {
+		return nil
+	}
+	sort.SliceStable(swings, func(i, j int) bool { return swings[i].price < swings[j].price })
+
+	lastClose := series.Latest().Close
+	tolerance := math.Max(zoneATRTolerance*scoring.CalculateATRFromBars(series), zoneMinTolerance*lastClose)
+
+	var clusters [][]swingPoint
+	sum := 0.0
+	for _, swing := range swings {
+		last := len(clusters) - 1
+		if last >= 0 && swing.price-sum/float64(len(clusters[last])) <= tolerance {
+			clusters[last] = append(clusters[last], swing)
+			sum += swing.price
+			continue
+		}
+		clusters = append(clusters, []swingPoint{swing})
+		sum = swing.price
+	}
+
+	levels := make([]PriceLevel, 0, len(clusters))
+	for _, cluster := range clusters {
+		levels = append(levels, buildLevel(series, cluster, lastClose))
+	}
+	return levels
+}
+
+func findSwings(series types.BarSeries) []swingPoint {
+	lows, highs := series.Lows(), series.Highs()
+	var swings []swingPoint
+	for i := swingStrength; i < len(lows)-swingStrength; i++ {
+		isLow, isHigh := true, true
+		for j := i - swingStrength; j <= i+swingStrength; j++ {
+			if j == i {
+				continue
+			}
+			// ties on the left belong to the earlier bar so a flat bottom counts once
+			if lows[j] < lows[i] || (j < i && lows[j] == lows[i]) {
+				isLow = false
+			}
+			if highs[j] > highs[i] || (j < i && highs[j] == highs[i]) {
+				isHigh = false
+			}
+		}
+		if isLow {
+			swings = append(swings, swingPoint{price: lows[i], index: i, role: LevelSupport})
+		}
+		if isHigh {
+			swings = append(swings, swingPoint{price: highs[i], index: i, role: LevelResistance})
+		}
+	}
+	return swings
+}
+
+func buildLevel(series types.BarSeries, cluster []swingPoint, lastClose float64) PriceLevel {
+	level := PriceLevel{Low: cluster[0].price, High: cluster[0].price, Touches: len(cluster)}
+	oldest, newest := cluster[0], cluster[0]
+	latest := series.Len() - 1
+	for _, swing := range cluster {
+		level.Price += swing.price
+		level.Low = math.Min(level.Low, swing.price)
+		level.High = math.Max(level.High, swing.price)
+		level.Strength += math.Pow(0.5, float64(latest-swing.index)/touchHalfLife)
+		if swing.index < oldest.index {
+			oldest = swing
+		}
+		if swing.index > newest.index {
+			newest = swing
+		}
+	}
+	level.Price /= float64(len(cluster))
+	level.LastTouch = series.Time(newest.index)
+
+	level.Type = LevelResistance
+	if level.Price <= lastClose {
+		level.Type = LevelSupport
+	}
+	level.Flipped = oldest.role != level.Type
+	return level
+}
+
+// AnalyzeLevels finds the zones in series and picks the nearest meaningful one on each side
+func AnalyzeLevels(series types.BarSeries) Levels {
+	var levels Levels
+	if series.Len() == 0 {
+		return levels
+	}
+	levels.Zones = FindLevels(series)
+	lastClose := series.Latest().Close
+
+	for _, zone := range levels.Zones {
+		if !zone.Meaningful() {
+			continue
+		}
+		if zone.Type == LevelSupport && zone.Price > levels.Support.Price {
+			levels.Support = zone
+		}
+		if zone.Type == LevelResistance && (levels.Resistance.Price == 0 || zone.Price < levels.Resistance.Price) {
+			levels.Resistance = zone
+		}
+	}
+
+	if levels.Support.Price == 0 {
+		if support := FindSupport(series); support > 0 && support <= lastClose {
+			levels.Support = PriceLevel{Price: support, Low: support, High: support, Type: LevelSupport, Touches: 1}
+		}
+	}
+	if levels.Resistance.Price == 0 {
+		if resistance := FindResistance(series); resistance > 0 && resistance >= lastClose {
+			levels.Resistance = PriceLevel{Price: resistance, Low: resistance, High: resistance, Type: LevelResistance, Touches: 1}
+		}
+	}
+	return levels
+}
+
+// StoreLevels replaces the stored zones for symbol with the ones found in series
+func StoreLevels(ctx context.Context, repo repository.LevelRepository, symbol string, series types.BarSeries) error {
+	if series.Len() == 0 {
+		return nil
+	}
+	zones := FindLevels(series)
+	stored := make([]repository.SupportLevel, 0, len(zones))
+	for _, zone := range zones {
+		stored = append(stored, repository.SupportLevel{
+			Symbol:      symbol,
+			Price:       zone.Price,
+			Type:        zone.Type,
+			ZoneLow:     zone.Low,
+			ZoneHigh:    zone.High,
+			Touches:     zone.Touches,
+			Strength:    zone.Strength,
+			LastTouched: zone.LastTouch,
+			Flipped:     zone.Flipped,
+			DetectedAt:  series.LatestTime(),
+		})
+	}
+	return repo.ReplaceLevels(ctx, symbol, stored)
+}
+
+// FindSupport returns the lowest low of the window
+func FindSupport(series types.BarSeries) float64 {
+	if series.Len() < 3 {
 		return 0
 	}
-	lowestLow := bars[0].Low
-	for _, bar := range bars {
-		if bar.Low < lowestLow {
-			lowestLow = bar.Low
+	lowestLow := series.At(0).Low
+	for _, low := range series.Lows() {
+		if low < lowestLow {
+			lowestLow = low
 		}
 	}
 	return lowestLow
 }
 
-func FindResistance(bars []types.Bar) float64 {
-	if len(bars) < 3 {
+// FindResistance returns the highest high of the window
+func FindResistance(series types.BarSeries) float64 {
+	if series.Len() < 3 {
 		return 0
 	}
-	highestHigh := bars[0].High
-	for _, bar := range bars {
-		if bar.High > highestHigh {
-			highestHigh = bar.High
+	highestHigh := series.At(0).High
+	for _, high := range series.Highs() {
+		if high > highestHigh {
+			highestHigh = high
 		}
 	}
 	return highestHigh
 }
 
-func GetSupportLevels(bars []types.Bar) []PriceLevel {
-	levels := []PriceLevel{}
+// GetSupportLevels returns the zones below the latest close, lowest first
+func GetSupportLevels(series types.BarSeries) []PriceLevel {
+	return levelsOfType(series, LevelSupport)
+}
 
-	for i := 1; i < len(bars)-1; i++ {
-		if bars[i].Low < bars[i-1].Low && bars[i].Low < bars[i+1].Low {
-			levels = append(levels, PriceLevel{
-				Price:      bars[i].Low,
-				BouncCount: 1,
-			})
-		}
-	}
-	return levels
+// GetResistanceLevels returns the zones above the latest close, lowest first
+func GetResistanceLevels(series types.BarSeries) []PriceLevel {
+	return levelsOfType(series, LevelResistance)
 }
 
-func GetResistanceLevels(bars []types.Bar) []PriceLevel {
+func levelsOfType(series types.BarSeries, levelType string) []PriceLevel {
 	levels := []PriceLevel{}
-
-	for i := 1; i < len(bars)-1; i++ {
-		if bars[i].High > bars[i-1].High && bars[i].High > bars[i+1].High {
-			levels = append(levels, PriceLevel{
-				Price:      bars[i].High,
-				BouncCount: 1,
-			})
+	for _, zone := range FindLevels(series) {
+		if zone.Type == levelType {
+			levels = append(levels, zone)
 		}
 	}
 	return levels
@@ -86,12 +284,12 @@ func DistanceToResistance(currentPrice float64, resistance float64) float64 {
 	return ((resistance - currentPrice) / resistance) * 100
 }
 
-func FindPivotPoint(bars []types.Bar) float64 {
-	if len(bars) == 0 {
+func FindPivotPoint(series types.BarSeries) float64 {
+	if series.Len() == 0 {
 		return 0
 	}
 
-	latestBar := bars[0]
+	latestBar := series.Latest()
 	return (latestBar.High + latestBar.Low + latestBar.Close) / 3
 }
 
@@ -0,0 +1,103 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// closesSeries builds daily bars a point either side of each close
+func closesSeries(closes ...float64) types.BarSeries {
+	t0 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	bars := make([]types.Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = types.Bar{Timestamp: t0.AddDate(0, 0, i).Format(time.RFC3339),
+			Open: c, High: c + 0.5, Low: c - 0.5, Close: c, Volume: 1000}
+	}
+	return seriesOf(bars)
+}
+
+// rangeThenBreakout bounces between 100 and 110, breaks out and retests 110 from above
+func rangeThenBreakout() types.BarSeries {
+	return closesSeries(
+		105, 103, 101, 103, 105, 107, 109, 107, 105, 103,
+		101, 103, 105, 107, 109, 107, 105, 103, 101.2, 103,
+		105, 107, 109.3, 111, 113, 115, 113, 111, 110, 112,
+		114, 116, 115, 114,
+	)
+}
+
+func TestFindLevelsClustersSwings(t *testing.T) {
+	series := rangeThenBreakout()
+	levels := AnalyzeLevels(series)
+
+	support := levels.Support
+	if support.Price < 109 || support.Price > 110 || support.Type != LevelSupport {
+		t.Fatalf("nearest support should be the retested 110 zone, got %+v", support)
+	}
+	if support.Touches != 3 || !support.Flipped {
+		t.Errorf("110 zone should hold both highs and the retest low and have flipped, got %+v", support)
+	}
+	if !support.LastTouch.Equal(series.Time(28)) {
+		t.Errorf("last touch %v, want the retest bar %v", support.LastTouch, series.Time(28))
+	}
+
+	var floor PriceLevel
+	for _, zone := range levels.Zones {
+		if zone.Price < 101 {
+			floor = zone
+		}
+	}
+	if floor.Touches != 3 || floor.Flipped || floor.Low != 100.5 || floor.High != 100.7 {
+		t.Errorf("100 zone = %+v, want three unflipped touches between 100.5 and 100.7", floor)
+	}
+	if floor.Strength >= support.Strength {
+		t.Errorf("older 100 zone strength %.2f should trail the recent 110 zone %.2f", floor.Strength, support.Strength)
+	}
+
+	if !support.Near(110.5) || support.Near(113) {
+		t.Error("Near should cover the zone plus 1%")
+	}
+	if levels.Resistance.Price <= series.Latest().Close {
+		t.Errorf("resistance %+v should sit above the close", levels.Resistance)
+	}
+}
+
+func TestAnalyzeLevelsFallsBackToExtremes(t *testing.T) {
+	// a steady slide has no swing points, so both sides fall back to the window
+	series := trendBars(30, 130, -1, 24*time.Hour)
+	levels := AnalyzeLevels(series)
+	if len(levels.Zones) != 0 {
+		t.Fatalf("expected no zones, got %+v", levels.Zones)
+	}
+	if levels.Support.Price != FindSupport(series) || levels.Resistance.Price != FindResistance(series) {
+		t.Errorf("fallback levels = %+v / %+v", levels.Support, levels.Resistance)
+	}
+	if calculateSRScore(series) != 1 {
+		t.Error("closing on the window low should score as support")
+	}
+}
+
+func TestStoreLevels(t *testing.T) {
+	repo := repository.NewMemoryLevels()
+	series := rangeThenBreakout()
+	if err := StoreLevels(context.Background(), repo, "AAPL", series); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.Levels(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zones := FindLevels(series)
+	if len(stored) != len(zones) || len(stored) == 0 {
+		t.Fatalf("stored %d levels, want %d", len(stored), len(zones))
+	}
+	for i, level := range stored {
+		if level.Price != zones[i].Price || level.Touches != zones[i].Touches || !level.DetectedAt.Equal(series.LatestTime()) {
+			t.Errorf("level %d = %+v, want %+v", i, level, zones[i])
+		}
+	}
+}
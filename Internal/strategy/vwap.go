@@ -8,13 +8,13 @@ import (
 
 // computes Volume Weighted Average Price
 type VWAPCalculator struct {
-	bars []types.Bar
+	bars []types.Bar // oldest first
 }
 
 // creates a new VWAP calculator
-func NewVWAPCalculator(bars []types.Bar) *VWAPCalculator {
+func NewVWAPCalculator(series types.BarSeries) *VWAPCalculator {
 	return &VWAPCalculator{
-		bars: bars,
+		bars: series.Bars(),
 	}
 }
 
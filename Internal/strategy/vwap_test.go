@@ -14,7 +14,7 @@ func TestVWAPCalculation(t *testing.T) {
 		{Open: 104, High: 106, Low: 103, Close: 105, Volume: 1800},
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	vwap := calc.Calculate()
 
 	if vwap <= 0 {
@@ -36,7 +36,7 @@ func TestVWAPCalculateAt(t *testing.T) {
 		{Open: 102, High: 105, Low: 101, Close: 104, Volume: 2000},
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 
 	vwap0 := calc.CalculateAt(0)
 	if vwap0 <= 0 {
@@ -63,7 +63,7 @@ func TestVWAPTrend(t *testing.T) {
 		{Open: 150, High: 150, Low: 150, Close: 150, Volume: 100},
 	}
 
-	calcAbove := NewVWAPCalculator(barsAbove)
+	calcAbove := NewVWAPCalculator(seriesOf(barsAbove))
 	trend := calcAbove.GetVWAPTrend()
 	if trend != 1 {
 		t.Errorf("Expected trend 1 (above VWAP), got %d", trend)
@@ -77,7 +77,7 @@ func TestVWAPTrend(t *testing.T) {
 		{Open: 100, High: 100, Low: 100, Close: 100, Volume: 100},
 	}
 
-	calcBelow := NewVWAPCalculator(barsBelow)
+	calcBelow := NewVWAPCalculator(seriesOf(barsBelow))
 	trend = calcBelow.GetVWAPTrend()
 	if trend != -1 {
 		t.Errorf("Expected trend -1 (below VWAP), got %d", trend)
@@ -92,7 +92,7 @@ func TestVWAPDistance(t *testing.T) {
 		{Open: 100, High: 100, Low: 100, Close: 110, Volume: 1000}, // 10% above
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	distance := calc.GetVWAPDistance()
 
 	if distance <= 0 {
@@ -113,7 +113,7 @@ func TestVWAPSupport(t *testing.T) {
 		{Open: 101, High: 101, Low: 101, Close: 100, Volume: 100}, // Return to VWAP
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	isSupport := calc.IsVWAPSupport(1.0)
 
 	if !isSupport {
@@ -130,7 +130,7 @@ func TestVWAPResistance(t *testing.T) {
 		{Open: 99, High: 99, Low: 99, Close: 100, Volume: 100}, // Return to VWAP
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	isResistance := calc.IsVWAPResistance(1.0)
 
 	if !isResistance {
@@ -148,7 +148,7 @@ func TestVWAPBounce(t *testing.T) {
 		{Open: 100, High: 100, Low: 100, Close: 102, Volume: 100}, // Bounce up
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	isBounce, bounceType := calc.GetVWAPBounce(1.0)
 
 	if isBounce && bounceType == "bullish_bounce" {
@@ -163,7 +163,7 @@ func TestVWAPAnalyze(t *testing.T) {
 		{Open: 102, High: 105, Low: 101, Close: 104, Volume: 2000},
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	analysis := calc.AnalyzeVWAP(1.0)
 
 	if analysis["error"] != nil {
@@ -179,7 +179,7 @@ func TestVWAPAnalyze(t *testing.T) {
 }
 
 func TestVWAPEmptyBars(t *testing.T) {
-	calc := NewVWAPCalculator([]types.Bar{})
+	calc := NewVWAPCalculator(types.BarSeries{})
 
 	vwap := calc.Calculate()
 	if vwap != 0 {
@@ -200,7 +200,7 @@ func TestVWAPCalculateRange(t *testing.T) {
 		{Open: 104, High: 106, Low: 103, Close: 105, Volume: 1800},
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 
 	vwapAll := calc.Calculate()
 	vwapRange := calc.CalculateRange(1, 2) // Only bars 1 and 2
@@ -222,7 +222,7 @@ func TestVWAPAllValues(t *testing.T) {
 		{Open: 102, High: 105, Low: 101, Close: 104, Volume: 2000},
 	}
 
-	calc := NewVWAPCalculator(bars)
+	calc := NewVWAPCalculator(seriesOf(bars))
 	values := calc.CalculateAllValues()
 
 	if len(values) != len(bars) {
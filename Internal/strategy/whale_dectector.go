@@ -14,6 +14,9 @@ type VolumeStats struct {
 	IsAnomalous bool
 }
 
+// a whale's move has held when price is still on its side this many bars later
+const whaleHoldBars = 5
+
 type WhaleEvent struct {
 	Timestamp   string
 	Symbol      string
@@ -21,8 +24,12 @@ type WhaleEvent struct {
 	Volume      int64
 	ZScore      float64
 	ClosePrice  float64
-	PriceChange float64
+	PriceChange float64 // percent move of the whale bar from the previous close
 	Conviction  string
+	Change1     *float64 // percent move of the close 1, 5 and 20 bars later, nil until those bars exist
+	Change5     *float64
+	Change20    *float64
+	Held        *bool // after whaleHoldBars bars price is still on the whale's side of its close
 }
 
 func CalculateVolumeStats(volumes []int64) (mean float64, stdDev float64) {
@@ -46,17 +53,19 @@ func CalculateZScore(currentVolume int64, meanVolume float64, stdDev float64) fl
 	return zScore
 }
 
-func DetectWhales(symbol string, bars []types.Bar) []WhaleEvent {
+// DetectWhales flags bars whose volume is more than 2 standard deviations above the
+// 20 bars before them, oldest first, with the follow-through the later bars show
+func DetectWhales(symbol string, series types.BarSeries) []WhaleEvent {
 	whales := make([]WhaleEvent, 0)
 
-	if len(bars) < 20 {
+	if series.Len() < 20 {
 		return whales
 	}
-	for i := 20; i < len(bars); i++ {
-		currentBar := bars[i]
+	allVolumes := series.Volumes()
+	for i := 20; i < series.Len(); i++ {
+		currentBar := series.At(i)
 
-		historicalBars := bars[i-20 : i]
-		volumes := extractVolumes(historicalBars)
+		volumes := allVolumes[i-20 : i]
 
 		meanVolume, stdDev := CalculateVolumeStats(volumes)
 
@@ -64,6 +73,10 @@ func DetectWhales(symbol string, bars []types.Bar) []WhaleEvent {
 
 		if zScore > 2.0 {
 			whale := createWhaleEvent(symbol, currentBar, zScore, meanVolume)
+			if prevClose := series.At(i - 1).Close; prevClose != 0 {
+				whale.PriceChange = (currentBar.Close - prevClose) / prevClose * 100
+			}
+			measureFollowThrough(&whale, series, i)
 			whales = append(whales, whale)
 		}
 	}
@@ -71,15 +84,6 @@ func DetectWhales(symbol string, bars []types.Bar) []WhaleEvent {
 	return whales
 }
 
-func extractVolumes(bars []types.Bar) []int64 {
-	volumes := make([]int64, len(bars))
-
-	for i, bar := range bars {
-		volumes[i] = bar.Volume
-	}
-	return volumes
-}
-
 func createWhaleEvent(symbol string, bar types.Bar, zScore float64, meanVolume float64) WhaleEvent {
 	direction := DetectDirection(bar)
 	conviction := DetermineConviction(zScore)
@@ -98,6 +102,25 @@ func createWhaleEvent(symbol string, bar types.Bar, zScore float64, meanVolume f
 	return whaleEvent
 
 }
+func measureFollowThrough(whale *WhaleEvent, series types.BarSeries, i int) {
+	whale.Change1 = changeAfter(series, i, 1)
+	whale.Change5 = changeAfter(series, i, whaleHoldBars)
+	whale.Change20 = changeAfter(series, i, 20)
+	if whale.Change5 != nil && whale.Direction != "NEUTRAL" {
+		held := (whale.Direction == "BUY" && *whale.Change5 > 0) || (whale.Direction == "SELL" && *whale.Change5 < 0)
+		whale.Held = &held
+	}
+}
+
+// changeAfter is the percent move from bar i's close to the close bars later, nil past the end
+func changeAfter(series types.BarSeries, i, bars int) *float64 {
+	if i+bars >= series.Len() || series.At(i).Close == 0 {
+		return nil
+	}
+	change := (series.At(i+bars).Close - series.At(i).Close) / series.At(i).Close * 100
+	return &change
+}
+
 func DetectDirection(bar types.Bar) string {
 	if bar.Close > bar.Open {
 		return "BUY"
@@ -0,0 +1,137 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+const (
+	minWhaleHistory   = 3   // resolved events a direction needs before its hit rate counts
+	whaleHistoryLimit = 200 // stored events LoadWhaleStats looks back over
+)
+
+// WhaleStats is how a symbol's past whales played out. Only events whose Held is known count.
+type WhaleStats struct {
+	Symbol     string
+	BuyEvents  int
+	BuyHits    int // BUY whales whose move held
+	SellEvents int
+	SellHits   int
+	AvgMove    float64 // mean 5-bar move in the whale's direction, percent
+}
+
+// HitRate is the share of direction's whales whose move held; ok is false below minWhaleHistory events
+func (s WhaleStats) HitRate(direction string) (rate float64, ok bool) {
+	events, hits := s.BuyEvents, s.BuyHits
+	if direction == "SELL" {
+		events, hits = s.SellEvents, s.SellHits
+	}
+	if events < minWhaleHistory {
+		return 0, false
+	}
+	return float64(hits) / float64(events), true
+}
+
+// Reliability scales a whale signal by its symbol's track record: 0 at a 25% hit rate or
+// worse, 0.5 for a coin flip and 1 from 75% up. Without enough history it is 1.
+func (s WhaleStats) Reliability(direction string) float64 {
+	rate, ok := s.HitRate(direction)
+	if !ok {
+		return 1
+	}
+	return math.Max(0, math.Min(1, 2*rate-0.5))
+}
+
+// ComputeWhaleStats summarises stored events for symbol
+func ComputeWhaleStats(symbol string, events []repository.WhaleEvent) WhaleStats {
+	stats := WhaleStats{Symbol: symbol}
+	moves := 0
+	for _, event := range events {
+		if event.Held == nil {
+			continue
+		}
+		switch event.Direction {
+		case "BUY":
+			stats.BuyEvents++
+			if *event.Held {
+				stats.BuyHits++
+			}
+		case "SELL":
+			stats.SellEvents++
+			if *event.Held {
+				stats.SellHits++
+			}
+		default:
+			continue
+		}
+		if event.Change5 != nil {
+			move := *event.Change5
+			if event.Direction == "SELL" {
+				move = -move
+			}
+			stats.AvgMove += move
+			moves++
+		}
+	}
+	if moves > 0 {
+		stats.AvgMove /= float64(moves)
+	}
+	return stats
+}
+
+// LoadWhaleStats summarises the symbol's stored whale history
+func LoadWhaleStats(ctx context.Context, repo repository.WhaleRepository, symbol string) (WhaleStats, error) {
+	events, err := repo.WhaleHistory(ctx, symbol, whaleHistoryLimit)
+	if err != nil {
+		return WhaleStats{}, fmt.Errorf("failed to load whale history for %s: %w", symbol, err)
+	}
+	return ComputeWhaleStats(symbol, events), nil
+}
+
+// RecordWhales upserts the whales detected in series, refreshing the follow-through of
+// earlier ones, and records a whale alert for each HIGH conviction buy or sell
+func RecordWhales(ctx context.Context, repo repository.WhaleRepository, symbol string, series types.BarSeries) error {
+	for _, whale := range DetectWhales(symbol, series) {
+		timestamp, err := time.Parse(time.RFC3339, whale.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid whale timestamp %q for %s: %w", whale.Timestamp, symbol, err)
+		}
+		err = repo.SaveWhaleEvent(ctx, repository.WhaleEvent{
+			Symbol:      symbol,
+			Timestamp:   timestamp,
+			Direction:   whale.Direction,
+			Volume:      whale.Volume,
+			ZScore:      whale.ZScore,
+			ClosePrice:  whale.ClosePrice,
+			PriceChange: whale.PriceChange,
+			Conviction:  whale.Conviction,
+			Change1:     whale.Change1,
+			Change5:     whale.Change5,
+			Change20:    whale.Change20,
+			Held:        whale.Held,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save whale event for %s: %w", symbol, err)
+		}
+
+		if whale.Conviction != "HIGH" || whale.Direction == "NEUTRAL" {
+			continue
+		}
+		err = repo.SaveWhaleAlert(ctx, repository.WhaleAlert{
+			Symbol:    symbol,
+			Type:      "LARGE_" + whale.Direction,
+			Amount:    float64(whale.Volume),
+			Price:     whale.ClosePrice,
+			Timestamp: timestamp,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save whale alert for %s: %w", symbol, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package strategy
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+// whaleSeries is 20 quiet bars at 100, a heavy bar from open to close, then after more
+// bars stepping by step from that close
+func whaleSeries(open, close float64, after int, step float64) types.BarSeries {
+	var bars []types.Bar
+	for i := 0; i < 20; i++ {
+		bars = append(bars, types.Bar{Open: 100, High: 101, Low: 99, Close: 100, Volume: 1000 + int64(i%2)*100})
+	}
+	bars = append(bars, types.Bar{Open: open, High: math.Max(open, close) + 1, Low: math.Min(open, close) - 1, Close: close, Volume: 100_000})
+	for i := 1; i <= after; i++ {
+		price := close + step*float64(i)
+		bars = append(bars, types.Bar{Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 1000})
+	}
+	return seriesOf(bars)
+}
+
+func TestDetectWhalesFollowThrough(t *testing.T) {
+	whales := DetectWhales("AAPL", whaleSeries(100, 102, 25, 1))
+	if len(whales) != 1 {
+		t.Fatalf("DetectWhales() = %d whales, want 1", len(whales))
+	}
+	w := whales[0]
+	if w.Direction != "BUY" || w.Conviction != "HIGH" || !closeEnough(w.PriceChange, 2) {
+		t.Errorf("whale = %+v, want a HIGH BUY up 2%%", w)
+	}
+	if w.Change1 == nil || !closeEnough(*w.Change1, 100.0/102) || w.Change5 == nil || !closeEnough(*w.Change5, 500.0/102) ||
+		w.Change20 == nil || !closeEnough(*w.Change20, 2000.0/102) {
+		t.Errorf("follow-through = %v %v %v", w.Change1, w.Change5, w.Change20)
+	}
+	if w.Held == nil || !*w.Held {
+		t.Errorf("a buy followed by a rally should have held, got %v", w.Held)
+	}
+
+	// near the end of the series only the horizons that exist are known
+	recent := DetectWhales("AAPL", whaleSeries(100, 98, 3, -1))[0]
+	if recent.Direction != "SELL" || recent.Change1 == nil || recent.Change5 != nil || recent.Change20 != nil || recent.Held != nil {
+		t.Errorf("whale 3 bars from the end = %+v", recent)
+	}
+}
+
+func TestWhaleStatsReliability(t *testing.T) {
+	yes, no := true, false
+	up, down := 2.0, -1.0
+	events := []repository.WhaleEvent{
+		{Direction: "BUY", Held: &yes, Change5: &up},
+		{Direction: "BUY", Held: &yes, Change5: &up},
+		{Direction: "BUY", Held: &yes, Change5: &up},
+		{Direction: "BUY", Held: &no, Change5: &down},
+		{Direction: "SELL", Held: &no, Change5: &up},
+		{Direction: "BUY"}, // not resolved yet
+	}
+	stats := ComputeWhaleStats("AAPL", events)
+	if stats.BuyEvents != 4 || stats.BuyHits != 3 || stats.SellEvents != 1 || stats.SellHits != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	// buys moved 2, 2, 2, -1 their way, the sell moved 2 against itself
+	if !closeEnough(stats.AvgMove, 0.6) {
+		t.Errorf("AvgMove = %v, want 0.6", stats.AvgMove)
+	}
+	if rate, ok := stats.HitRate("BUY"); !ok || rate != 0.75 {
+		t.Errorf("HitRate(BUY) = %v, %v", rate, ok)
+	}
+	if r := stats.Reliability("BUY"); r != 1 {
+		t.Errorf("Reliability(BUY) at 75%% = %v, want 1", r)
+	}
+	if _, ok := stats.HitRate("SELL"); ok {
+		t.Error("one sell is not enough history")
+	}
+	if r := stats.Reliability("SELL"); r != 1 {
+		t.Errorf("Reliability(SELL) without history = %v, want 1", r)
+	}
+	if r := (WhaleStats{BuyEvents: 4, BuyHits: 2}).Reliability("BUY"); r != 0.5 {
+		t.Errorf("Reliability at a coin flip = %v, want 0.5", r)
+	}
+}
+
+func TestRecordWhales(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryWhales()
+	series := whaleSeries(100, 102, 25, 1)
+	for i := 0; i < 2; i++ {
+		if err := RecordWhales(ctx, repo, "AAPL", series); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := repo.WhaleHistory(ctx, "AAPL", 10)
+	if err != nil || len(history) != 1 || history[0].Held == nil || !*history[0].Held {
+		t.Fatalf("WhaleHistory() = %+v, %v", history, err)
+	}
+	alerts, err := repo.WhaleAlerts(ctx, "AAPL", 10)
+	if err != nil || len(alerts) != 1 || alerts[0].Type != "LARGE_BUY" {
+		t.Errorf("WhaleAlerts() = %+v, %v", alerts, err)
+	}
+
+	stats, err := LoadWhaleStats(ctx, repo, "AAPL")
+	if err != nil || stats.BuyEvents != 1 || stats.BuyHits != 1 {
+		t.Errorf("LoadWhaleStats() = %+v, %v", stats, err)
+	}
+}
+
+func TestWhaleScoreScaledByTrackRecord(t *testing.T) {
+	series := whaleSeries(100, 102, 5, 1)
+	if score := calculateWhaleScore("AAPL", series, nil); score != 3 {
+		t.Errorf("score without history = %v, want 3", score)
+	}
+	poor := &WhaleStats{BuyEvents: 4, BuyHits: 1}
+	if score := calculateWhaleScore("AAPL", series, poor); score != 0 {
+		t.Errorf("score with 25%% hit rate = %v, want 0", score)
+	}
+	coinFlip := &WhaleStats{BuyEvents: 4, BuyHits: 2}
+	if score := calculateWhaleScore("AAPL", series, coinFlip); score != 1.5 {
+		t.Errorf("score with 50%% hit rate = %v, want 1.5", score)
+	}
+}
+
+func closeEnough(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,115 @@
+// Package stream follows trades, quotes and minute bars from Alpaca's market data websocket
+// (or a recorded replay) and keeps rolling per-symbol series with live indicators.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// message types in the "T" field of Alpaca's v2 stream
+const (
+	TypeTrade        = "t"
+	TypeQuote        = "q"
+	TypeBar          = "b"
+	TypeUpdatedBar   = "u"
+	TypeSuccess      = "success"
+	TypeError        = "error"
+	TypeSubscription = "subscription"
+)
+
+// Payloads declare Type because encoding/json matches keys case-insensitively,
+// so Alpaca's "T" would otherwise be decoded into the "t" timestamp.
+type Trade struct {
+	Type      string    `json:"T"`
+	Symbol    string    `json:"S"`
+	ID        int64     `json:"i"`
+	Exchange  string    `json:"x"`
+	Price     float64   `json:"p"`
+	Size      int64     `json:"s"`
+	Timestamp time.Time `json:"t"`
+}
+
+type Quote struct {
+	Type      string    `json:"T"`
+	Symbol    string    `json:"S"`
+	BidPrice  float64   `json:"bp"`
+	BidSize   int64     `json:"bs"`
+	AskPrice  float64   `json:"ap"`
+	AskSize   int64     `json:"as"`
+	Timestamp time.Time `json:"t"`
+}
+
+type Bar struct {
+	Type       string    `json:"T"`
+	Symbol     string    `json:"S"`
+	Open       float64   `json:"o"`
+	High       float64   `json:"h"`
+	Low        float64   `json:"l"`
+	Close      float64   `json:"c"`
+	Volume     int64     `json:"v"`
+	TradeCount int64     `json:"n"`
+	VWAP       float64   `json:"vw"`
+	Timestamp  time.Time `json:"t"`
+}
+
+// Control covers the success, error and subscription acknowledgements
+type Control struct {
+	Message string   `json:"msg"`
+	Code    int      `json:"code"`
+	Trades  []string `json:"trades"`
+	Quotes  []string `json:"quotes"`
+	Bars    []string `json:"bars"`
+}
+
+// Message is one element of a stream frame; exactly one payload is set for known types
+type Message struct {
+	Type    string
+	Trade   *Trade
+	Quote   *Quote
+	Bar     *Bar
+	Control *Control
+}
+
+// DecodeFrame splits a frame (a JSON array of messages) into typed messages.
+// Unknown types are returned with no payload so callers can skip them.
+func DecodeFrame(frame []byte) ([]Message, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(frame, &raw); err != nil {
+		return nil, fmt.Errorf("invalid stream frame: %w", err)
+	}
+
+	messages := make([]Message, 0, len(raw))
+	for _, item := range raw {
+		var head struct {
+			T         string          `json:"T"`
+			Timestamp json.RawMessage `json:"t"` // keeps "t" from being matched to T
+		}
+		if err := json.Unmarshal(item, &head); err != nil {
+			return nil, fmt.Errorf("invalid stream message: %w", err)
+		}
+
+		m := Message{Type: head.T}
+		var err error
+		switch head.T {
+		case TypeTrade:
+			m.Trade = &Trade{}
+			err = json.Unmarshal(item, m.Trade)
+		case TypeQuote:
+			m.Quote = &Quote{}
+			err = json.Unmarshal(item, m.Quote)
+		case TypeBar, TypeUpdatedBar:
+			m.Bar = &Bar{}
+			err = json.Unmarshal(item, m.Bar)
+		case TypeSuccess, TypeError, TypeSubscription:
+			m.Control = &Control{}
+			err = json.Unmarshal(item, m.Control)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q message: %w", head.T, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
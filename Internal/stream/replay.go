@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ReplaySource plays back frames recorded by WebsocketSource.Record, one JSON array per line
+type ReplaySource struct {
+	Path  string
+	Delay time.Duration // pause between frames; zero replays as fast as possible
+}
+
+func NewReplaySource(path string) *ReplaySource {
+	return &ReplaySource{Path: path}
+}
+
+// Run returns nil once every frame has been delivered
+func (r *ReplaySource) Run(ctx context.Context, handle func(Message)) error {
+	file, err := os.Open(r.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open replay %s: %w", r.Path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), readLimit)
+	line := 0
+	for scanner.Scan() {
+		line++
+		frame := bytes.TrimSpace(scanner.Bytes())
+		if len(frame) == 0 {
+			continue
+		}
+		messages, err := DecodeFrame(frame)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", r.Path, line, err)
+		}
+		for _, m := range messages {
+			handle(m)
+		}
+
+		if r.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(r.Delay):
+			}
+		} else if ctx.Err() != nil {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read replay %s: %w", r.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+const (
+	defaultWindow   = 390
+	rsiPeriod       = 14
+	volumeLookback  = 20 // same history DetectWhales compares against
+	whaleZThreshold = 2.0
+)
+
+// Update is the state of a symbol after a minute bar closes
+type Update struct {
+	Symbol       string    `json:"symbol"`
+	Time         time.Time `json:"time"`
+	Close        float64   `json:"close"`
+	Volume       int64     `json:"volume"`
+	VWAP         float64   `json:"vwap"`
+	RSI          *float64  `json:"rsi"`            // nil until rsiPeriod bars have closed
+	VolumeZScore *float64  `json:"volume_z_score"` // nil until volumeLookback bars have closed
+	Whale        string    `json:"whale,omitempty"`
+	Direction    string    `json:"direction,omitempty"`
+	LastPrice    float64   `json:"last_price,omitempty"`
+	Bid          float64   `json:"bid,omitempty"`
+	Ask          float64   `json:"ask,omitempty"`
+}
+
+// Series is one symbol's rolling minute bars and live indicators
+type Series struct {
+	symbol string
+	window int
+	bars   []types.Bar // oldest-first
+	last   time.Time
+	latest Update
+
+	rsi    *strategy.RSIIndicator
+	vwap   *strategy.VWAPIndicator
+	volume *strategy.VolumeStatsIndicator
+}
+
+func newSeries(symbol string, window int, loc *time.Location) *Series {
+	return &Series{
+		symbol: symbol,
+		window: window,
+		// the same simple-average RSI the scanner and analyzer compute
+		rsi:    strategy.NewRSIIndicator(rsiPeriod, strategy.SmoothingSimple),
+		vwap:   strategy.NewVWAPIndicator(loc),
+		volume: strategy.NewVolumeStatsIndicator(volumeLookback),
+	}
+}
+
+// addBar folds in a closed bar; bars at or before the last one (resent after a reconnect) are ignored
+func (s *Series) addBar(bar Bar) (Update, bool) {
+	if !s.last.IsZero() && !bar.Timestamp.After(s.last) {
+		return Update{}, false
+	}
+	s.last = bar.Timestamp
+
+	b := types.Bar{
+		Timestamp: bar.Timestamp.UTC().Format(time.RFC3339),
+		Open:      bar.Open,
+		High:      bar.High,
+		Low:       bar.Low,
+		Close:     bar.Close,
+		Volume:    bar.Volume,
+	}
+	s.bars = append(s.bars, b)
+	if len(s.bars) > s.window {
+		s.bars = append(s.bars[:0], s.bars[len(s.bars)-s.window:]...)
+	}
+
+	u := s.latest
+	u.Symbol = s.symbol
+	u.Time = bar.Timestamp
+	u.Close = bar.Close
+	u.Volume = bar.Volume
+	s.vwap.Update(b)
+	u.VWAP = s.vwap.Value()
+	s.rsi.Update(b)
+	u.RSI = nil
+	if s.rsi.Ready() {
+		rsi := s.rsi.Value()
+		u.RSI = &rsi
+	}
+	// the bar is scored against the volumes before it, then joins the window
+	u.VolumeZScore = nil
+	if s.volume.Ready() {
+		z := s.volume.ZScore(bar.Volume)
+		u.VolumeZScore = &z
+	}
+	s.volume.Update(b)
+	u.Whale, u.Direction = "", ""
+	if u.VolumeZScore != nil && *u.VolumeZScore > whaleZThreshold {
+		u.Whale = strategy.DetermineConviction(*u.VolumeZScore)
+		u.Direction = strategy.DetectDirection(b)
+	}
+	s.latest = u
+	return u, true
+}
+
+// Tracker routes stream messages to per-symbol series
+type Tracker struct {
+	window int
+	loc    *time.Location
+
+	// OnBar is called after every accepted bar, outside the tracker's lock
+	OnBar func(Update)
+
+	mu     sync.Mutex
+	series map[string]*Series
+}
+
+func NewTracker(window int) *Tracker {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.UTC
+	}
+	return &Tracker{window: window, loc: loc, series: map[string]*Series{}}
+}
+
+func (t *Tracker) get(symbol string) *Series {
+	s, ok := t.series[symbol]
+	if !ok {
+		s = newSeries(symbol, t.window, t.loc)
+		t.series[symbol] = s
+	}
+	return s
+}
+
+// Handle applies one message; it matches the func(Message) that sources deliver to
+func (t *Tracker) Handle(m Message) {
+	var update Update
+	accepted := false
+
+	t.mu.Lock()
+	switch {
+	case m.Trade != nil:
+		t.get(m.Trade.Symbol).latest.LastPrice = m.Trade.Price
+	case m.Quote != nil:
+		s := t.get(m.Quote.Symbol)
+		s.latest.Bid, s.latest.Ask = m.Quote.BidPrice, m.Quote.AskPrice
+	case m.Bar != nil && m.Type == TypeBar:
+		update, accepted = t.get(m.Bar.Symbol).addBar(*m.Bar)
+	}
+	onBar := t.OnBar
+	t.mu.Unlock()
+
+	if accepted && onBar != nil {
+		onBar(update)
+	}
+}
+
+// Latest returns the newest state of a symbol, including trades and quotes since its last bar
+func (t *Tracker) Latest(symbol string) (Update, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.series[symbol]
+	if !ok {
+		return Update{}, false
+	}
+	return s.latest, true
+}
+
+// Bars returns a copy of a symbol's rolling bars, oldest-first
+func (t *Tracker) Bars(symbol string) []types.Bar {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.series[symbol]
+	if !ok {
+		return nil
+	}
+	return append([]types.Bar(nil), s.bars...)
+}
@@ -0,0 +1,280 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestDecodeFrame(t *testing.T) {
+	frame := `[{"T":"t","S":"AAPL","p":190.01,"s":100,"t":"2026-03-02T14:30:02.5Z"},{"T":"x"},{"T":"error","code":402,"msg":"auth failed"}]`
+	messages, err := DecodeFrame([]byte(frame))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].Trade == nil || messages[0].Trade.Price != 190.01 || messages[0].Trade.Size != 100 {
+		t.Errorf("unexpected trade %+v", messages[0].Trade)
+	}
+	if messages[1].Trade != nil || messages[1].Bar != nil || messages[1].Control != nil {
+		t.Errorf("unknown type should carry no payload")
+	}
+	if messages[2].Control == nil || messages[2].Control.Code != 402 {
+		t.Errorf("unexpected control %+v", messages[2].Control)
+	}
+
+	if _, err := DecodeFrame([]byte(`{"T":"t"}`)); err == nil {
+		t.Error("expected an error for a frame that isn't an array")
+	}
+}
+
+// minute bars within one session with a volume spike at the end
+func syntheticBars(n int) []Bar {
+	start := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+	bars := make([]Bar, n)
+	price := 100.0
+	for i := range bars {
+		price += math.Sin(float64(i)*0.7) * 0.8
+		volume := int64(1000 + (i*37)%400)
+		if i == n-1 {
+			volume = 9000
+		}
+		bars[i] = Bar{
+			Symbol:    "TEST",
+			Open:      price - 0.2,
+			High:      price + 0.5,
+			Low:       price - 0.6,
+			Close:     price,
+			Volume:    volume,
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return bars
+}
+
+func TestTrackerMatchesBatchIndicators(t *testing.T) {
+	bars := syntheticBars(60)
+	tracker := NewTracker(50)
+	var updates []Update
+	tracker.OnBar = func(u Update) { updates = append(updates, u) }
+	for i := range bars {
+		tracker.Handle(Message{Type: TypeBar, Bar: &bars[i]})
+	}
+	if len(updates) != len(bars) {
+		t.Fatalf("expected %d updates, got %d", len(bars), len(updates))
+	}
+
+	closes := make([]float64, len(bars))
+	typeBars := make([]types.Bar, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+		typeBars[i] = types.Bar{Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume,
+			Timestamp: b.Timestamp.Format(time.RFC3339)}
+	}
+	rsi, err := strategy.CalculateRSI(closes, rsiPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := types.NewBarSeries(typeBars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vwap := strategy.NewVWAPCalculator(series)
+
+	for i, u := range updates {
+		if i < rsiPeriod {
+			if u.RSI != nil {
+				t.Fatalf("bar %d: RSI should be nil during warm-up", i)
+			}
+		} else if u.RSI == nil || !approxEqual(*u.RSI, rsi[i]) {
+			t.Fatalf("bar %d: RSI %v, want %v", i, u.RSI, rsi[i])
+		}
+		if want := vwap.CalculateAt(i); !approxEqual(u.VWAP, want) {
+			t.Fatalf("bar %d: VWAP %v, want %v", i, u.VWAP, want)
+		}
+	}
+
+	// the final spike is the only whale DetectWhales finds in the same bars
+	whales := strategy.DetectWhales("TEST", series)
+	last := updates[len(updates)-1]
+	if len(whales) == 0 || last.VolumeZScore == nil || !approxEqual(*last.VolumeZScore, whales[len(whales)-1].ZScore) {
+		t.Fatalf("z-score %v does not match DetectWhales %+v", last.VolumeZScore, whales)
+	}
+	if last.Whale != whales[len(whales)-1].Conviction {
+		t.Errorf("whale conviction %q, want %q", last.Whale, whales[len(whales)-1].Conviction)
+	}
+
+	if got := tracker.Bars("TEST"); len(got) != 50 || got[len(got)-1].Close != bars[len(bars)-1].Close {
+		t.Errorf("expected the newest 50 bars oldest-first, got %d", len(got))
+	}
+}
+
+func TestVWAPResetsEachSession(t *testing.T) {
+	tracker := NewTracker(10)
+	day1 := Bar{Symbol: "X", High: 10, Low: 10, Close: 10, Volume: 100, Timestamp: time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)}
+	day2 := Bar{Symbol: "X", High: 20, Low: 20, Close: 20, Volume: 100, Timestamp: time.Date(2026, 3, 3, 14, 30, 0, 0, time.UTC)}
+	tracker.Handle(Message{Type: TypeBar, Bar: &day1})
+	tracker.Handle(Message{Type: TypeBar, Bar: &day2})
+	if u, _ := tracker.Latest("X"); u.VWAP != 20 {
+		t.Errorf("VWAP should restart at the new session, got %v", u.VWAP)
+	}
+}
+
+func TestReplaySource(t *testing.T) {
+	tracker := NewTracker(0)
+	var bars []Update
+	tracker.OnBar = func(u Update) { bars = append(bars, u) }
+
+	if err := NewReplaySource("testdata/replay.jsonl").Run(context.Background(), tracker.Handle); err != nil {
+		t.Fatal(err)
+	}
+	// the resent 14:31 bar and the updated bar are not counted twice
+	if len(bars) != 3 {
+		t.Fatalf("expected 3 bars, got %d", len(bars))
+	}
+	aapl, ok := tracker.Latest("AAPL")
+	if !ok {
+		t.Fatal("expected AAPL state")
+	}
+	if aapl.Close != 190.8 || aapl.LastPrice != 190.95 || aapl.Bid != 189.98 || aapl.Ask != 190.02 {
+		t.Errorf("unexpected AAPL state %+v", aapl)
+	}
+	if len(tracker.Bars("AAPL")) != 2 || len(tracker.Bars("TSLA")) != 1 {
+		t.Errorf("unexpected bar counts")
+	}
+
+	if err := NewReplaySource("testdata/missing.jsonl").Run(context.Background(), tracker.Handle); err == nil {
+		t.Error("expected an error for a missing replay file")
+	}
+}
+
+// fakeStream is a local stand-in for Alpaca's stream endpoint
+type fakeStream struct {
+	t        *testing.T
+	authCode int // non-zero rejects auth with this error code
+
+	mu            sync.Mutex
+	connections   int
+	subscriptions [][]string
+}
+
+func (f *fakeStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		f.t.Errorf("accept: %v", err)
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+
+	f.mu.Lock()
+	f.connections++
+	n := f.connections
+	f.mu.Unlock()
+
+	send := func(frame string) { conn.Write(ctx, websocket.MessageText, []byte(frame)) }
+	send(`[{"T":"success","msg":"connected"}]`)
+
+	var auth map[string]string
+	if _, data, err := conn.Read(ctx); err != nil || json.Unmarshal(data, &auth) != nil || auth["action"] != "auth" {
+		f.t.Errorf("expected auth, got %v", err)
+		return
+	}
+	if f.authCode != 0 {
+		send(`[{"T":"error","code":402,"msg":"auth failed"}]`)
+		return
+	}
+	send(`[{"T":"success","msg":"authenticated"}]`)
+
+	var sub subscription
+	if _, data, err := conn.Read(ctx); err != nil || json.Unmarshal(data, &sub) != nil || sub.Action != "subscribe" {
+		f.t.Errorf("expected subscribe, got %v", err)
+		return
+	}
+	f.mu.Lock()
+	f.subscriptions = append(f.subscriptions, sub.Bars)
+	f.mu.Unlock()
+
+	minute := time.Date(2026, 3, 2, 14, 30+n, 0, 0, time.UTC).Format(time.RFC3339)
+	send(`[{"T":"b","S":"AAPL","o":1,"h":1,"l":1,"c":1,"v":10,"t":"` + minute + `"}]`)
+	if n == 1 {
+		// drop the first connection to force a reconnect
+		return
+	}
+	conn.Read(ctx)
+}
+
+func TestWebsocketSourceReconnectsAndResubscribes(t *testing.T) {
+	fake := &fakeStream{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	source := &WebsocketSource{
+		URL:        "ws" + strings.TrimPrefix(server.URL, "http"),
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+	}
+	source.Subscribe(context.Background(), "AAPL", "TSLA")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracker := NewTracker(0)
+	var bars int
+	tracker.OnBar = func(Update) {
+		bars++
+		if bars == 2 {
+			cancel()
+		}
+	}
+	if err := source.Run(ctx, tracker.Handle); err != nil {
+		t.Fatal(err)
+	}
+
+	if bars != 2 {
+		t.Fatalf("expected a bar from each connection, got %d", bars)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.connections != 2 || len(fake.subscriptions) != 2 {
+		t.Fatalf("expected 2 connections each subscribing, got %d and %v", fake.connections, fake.subscriptions)
+	}
+	for _, symbols := range fake.subscriptions {
+		if strings.Join(symbols, ",") != "AAPL,TSLA" {
+			t.Errorf("expected resubscription to AAPL,TSLA, got %v", symbols)
+		}
+	}
+}
+
+func TestWebsocketSourceStopsOnAuthFailure(t *testing.T) {
+	fake := &fakeStream{t: t, authCode: 402}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	source := &WebsocketSource{URL: "ws" + strings.TrimPrefix(server.URL, "http"), MinBackoff: time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := source.Run(ctx, func(Message) {})
+	if !errors.Is(err, ErrAuth) {
+		t.Fatalf("expected ErrAuth, got %v", err)
+	}
+	if fake.connections != 1 {
+		t.Errorf("auth failures should not reconnect, got %d connections", fake.connections)
+	}
+}
@@ -0,0 +1,258 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
+)
+
+const (
+	defaultStreamURL  = "wss://stream.data.alpaca.markets/v2/iex"
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+	readLimit         = 1 << 22 // frames during the open can carry thousands of messages
+)
+
+// Alpaca closes the session with these codes when retrying can't help
+const (
+	codeAuthFailed      = 402
+	codeInsufficientSub = 409
+)
+
+// ErrAuth means the stream rejected the credentials; Run stops instead of reconnecting
+var ErrAuth = errors.New("stream authentication failed")
+
+// Source delivers stream messages to handle until ctx is cancelled or the source is exhausted
+type Source interface {
+	Run(ctx context.Context, handle func(Message)) error
+}
+
+// WebsocketSource streams trades, quotes and minute bars for its symbols,
+// reconnecting with exponential backoff and resubscribing after every reconnect
+type WebsocketSource struct {
+	URL        string
+	Key        string
+	Secret     string
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Record     io.Writer // raw frames are appended one per line, readable by ReplaySource
+
+	mu      sync.Mutex
+	symbols map[string]bool
+	conn    *websocket.Conn
+}
+
+// NewWebsocketSource reads credentials from ALPACA_API_KEY and ALPACA_API_SECRET
+func NewWebsocketSource(cfg config.StreamConfig, symbols []string) *WebsocketSource {
+	s := &WebsocketSource{
+		URL:        cfg.URL,
+		Key:        os.Getenv("ALPACA_API_KEY"),
+		Secret:     os.Getenv("ALPACA_API_SECRET"),
+		MinBackoff: time.Duration(cfg.ReconnectMinSeconds) * time.Second,
+		MaxBackoff: time.Duration(cfg.ReconnectMaxSeconds) * time.Second,
+		symbols:    map[string]bool{},
+	}
+	if s.URL == "" {
+		s.URL = defaultStreamURL
+	}
+	for _, symbol := range symbols {
+		s.symbols[symbol] = true
+	}
+	return s
+}
+
+// Symbols returns the current subscription, sorted
+func (s *WebsocketSource) Symbols() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedSymbols()
+}
+
+func (s *WebsocketSource) sortedSymbols() []string {
+	symbols := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+type subscription struct {
+	Action string   `json:"action"`
+	Trades []string `json:"trades"`
+	Quotes []string `json:"quotes"`
+	Bars   []string `json:"bars"`
+}
+
+// Subscribe adds symbols; they are sent now if connected and on every reconnect
+func (s *WebsocketSource) Subscribe(ctx context.Context, symbols ...string) error {
+	return s.change(ctx, "subscribe", symbols, true)
+}
+
+// Unsubscribe drops symbols from the live session and from future reconnects
+func (s *WebsocketSource) Unsubscribe(ctx context.Context, symbols ...string) error {
+	return s.change(ctx, "unsubscribe", symbols, false)
+}
+
+func (s *WebsocketSource) change(ctx context.Context, action string, symbols []string, add bool) error {
+	s.mu.Lock()
+	if s.symbols == nil {
+		s.symbols = map[string]bool{}
+	}
+	for _, symbol := range symbols {
+		if add {
+			s.symbols[symbol] = true
+		} else {
+			delete(s.symbols, symbol)
+		}
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil || len(symbols) == 0 {
+		return nil
+	}
+	return writeJSON(ctx, conn, subscription{Action: action, Trades: symbols, Quotes: symbols, Bars: symbols})
+}
+
+func writeJSON(ctx context.Context, conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+// Run keeps a session open until ctx is cancelled. Lost connections are retried with
+// exponential backoff, which resets once a session authenticates; rejected credentials end Run.
+func (s *WebsocketSource) Run(ctx context.Context, handle func(Message)) error {
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	backoff := minBackoff
+	for {
+		authenticated, err := s.session(ctx, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrAuth) {
+			return err
+		}
+		if authenticated {
+			backoff = minBackoff
+		}
+		logger.Component("stream").Warn("stream disconnected, reconnecting", "backoff", backoff, logger.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// session runs one connection: connect, authenticate, subscribe, then read until it fails
+func (s *WebsocketSource) session(ctx context.Context, handle func(Message)) (authenticated bool, err error) {
+	conn, _, err := websocket.Dial(ctx, s.URL, nil)
+	if err != nil {
+		return false, fmt.Errorf("dial %s: %w", s.URL, err)
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(readLimit)
+
+	if err := s.expect(ctx, conn, "connected", handle); err != nil {
+		return false, err
+	}
+	if err := writeJSON(ctx, conn, map[string]string{"action": "auth", "key": s.Key, "secret": s.Secret}); err != nil {
+		return false, fmt.Errorf("send auth: %w", err)
+	}
+	if err := s.expect(ctx, conn, "authenticated", handle); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	symbols := s.sortedSymbols()
+	s.conn = conn
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
+	if len(symbols) > 0 {
+		sub := subscription{Action: "subscribe", Trades: symbols, Quotes: symbols, Bars: symbols}
+		if err := writeJSON(ctx, conn, sub); err != nil {
+			return true, fmt.Errorf("send subscribe: %w", err)
+		}
+	}
+
+	for {
+		messages, err := s.read(ctx, conn)
+		if err != nil {
+			return true, err
+		}
+		for _, m := range messages {
+			if m.Type == TypeError {
+				return true, controlError(m.Control)
+			}
+			handle(m)
+		}
+	}
+}
+
+// expect reads control frames until a success message with msg, passing everything along to handle
+func (s *WebsocketSource) expect(ctx context.Context, conn *websocket.Conn, msg string, handle func(Message)) error {
+	for {
+		messages, err := s.read(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if m.Type == TypeError {
+				return controlError(m.Control)
+			}
+			handle(m)
+			if m.Type == TypeSuccess && m.Control.Message == msg {
+				return nil
+			}
+		}
+	}
+}
+
+func controlError(c *Control) error {
+	if c.Code == codeAuthFailed || c.Code == codeInsufficientSub {
+		return fmt.Errorf("%w: %s", ErrAuth, c.Message)
+	}
+	return fmt.Errorf("stream error %d: %s", c.Code, c.Message)
+}
+
+func (s *WebsocketSource) read(ctx context.Context, conn *websocket.Conn) ([]Message, error) {
+	_, frame, err := conn.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if s.Record != nil {
+		s.Record.Write(append(frame, '\n'))
+	}
+	return DecodeFrame(frame)
+}
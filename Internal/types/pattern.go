@@ -0,0 +1,43 @@
+package types
+
+import "time"
+
+// PatternID identifies a multi-candle pattern. IDs are stable: scoring and stored
+// analysis key on them, so never rename one.
+type PatternID string
+
+const (
+	PatternBullishEngulfing   PatternID = "bullish_engulfing"
+	PatternBearishEngulfing   PatternID = "bearish_engulfing"
+	PatternBullishHarami      PatternID = "bullish_harami"
+	PatternBearishHarami      PatternID = "bearish_harami"
+	PatternPiercingLine       PatternID = "piercing_line"
+	PatternDarkCloudCover     PatternID = "dark_cloud_cover"
+	PatternMorningStar        PatternID = "morning_star"
+	PatternEveningStar        PatternID = "evening_star"
+	PatternThreeWhiteSoldiers PatternID = "three_white_soldiers"
+	PatternThreeBlackCrows    PatternID = "three_black_crows"
+	PatternInsideBar          PatternID = "inside_bar"
+	PatternOutsideBar         PatternID = "outside_bar"
+	PatternTweezerBottom      PatternID = "tweezer_bottom"
+	PatternTweezerTop         PatternID = "tweezer_top"
+)
+
+// Trend before a pattern
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+)
+
+// PatternMatch is a candle pattern ending on one bar of a series
+type PatternMatch struct {
+	ID         PatternID `json:"id"`
+	Name       string    `json:"name"`
+	Direction  int       `json:"direction"` // 1 bullish, -1 bearish, 0 no lean (inside bars)
+	Bars       int       `json:"bars"`      // candles in the pattern
+	End        time.Time `json:"end"`       // the pattern's last bar
+	Trend      string    `json:"trend"`     // TrendUp, TrendDown or TrendFlat before the pattern
+	TrendOK    bool      `json:"trend_ok"`  // the prior trend is the one the pattern needs, e.g. a downtrend for a bullish reversal
+	Confidence float64   `json:"confidence"`
+}
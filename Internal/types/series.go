@@ -0,0 +1,160 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// BarSeries is one symbol's bars in chronological order (oldest first) with parsed
+// timestamps. Providers hand bars back latest-first; NewBarSeries accepts either order,
+// so indicator, scoring and display code never has to guess which end is "now".
+// The zero value is an empty series.
+type BarSeries struct {
+	bars  []Bar
+	times []time.Time
+}
+
+// NewBarSeries validates bars and sorts them oldest first. Every bar needs an RFC3339
+// timestamp, no two bars may share one, prices and volume can't be negative and the
+// high can't be below the low.
+func NewBarSeries(bars []Bar) (BarSeries, error) {
+	s := BarSeries{bars: make([]Bar, len(bars)), times: make([]time.Time, len(bars))}
+	for i, bar := range bars {
+		t, err := time.Parse(time.RFC3339, bar.Timestamp)
+		if err != nil {
+			return BarSeries{}, fmt.Errorf("bar %d: invalid timestamp %q: %w", i, bar.Timestamp, err)
+		}
+		if err := validateBar(bar); err != nil {
+			return BarSeries{}, fmt.Errorf("bar at %s: %w", bar.Timestamp, err)
+		}
+		s.bars[i] = bar
+		s.times[i] = t
+	}
+
+	sort.Stable(byTime(s))
+	for i := 1; i < len(s.times); i++ {
+		if s.times[i].Equal(s.times[i-1]) {
+			return BarSeries{}, fmt.Errorf("duplicate bar at %s", s.bars[i].Timestamp)
+		}
+	}
+	return s, nil
+}
+
+func validateBar(bar Bar) error {
+	for _, price := range []float64{bar.Open, bar.High, bar.Low, bar.Close} {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+			return fmt.Errorf("invalid price %v", price)
+		}
+	}
+	if bar.High < bar.Low {
+		return fmt.Errorf("high %v is below low %v", bar.High, bar.Low)
+	}
+	if bar.Volume < 0 {
+		return fmt.Errorf("negative volume %d", bar.Volume)
+	}
+	return nil
+}
+
+// byTime sorts the bars and their parsed times together
+type byTime BarSeries
+
+func (s byTime) Len() int           { return len(s.bars) }
+func (s byTime) Less(i, j int) bool { return s.times[i].Before(s.times[j]) }
+func (s byTime) Swap(i, j int) {
+	s.bars[i], s.bars[j] = s.bars[j], s.bars[i]
+	s.times[i], s.times[j] = s.times[j], s.times[i]
+}
+
+func (s BarSeries) Len() int {
+	return len(s.bars)
+}
+
+// At returns the i-th bar, 0 being the oldest
+func (s BarSeries) At(i int) Bar {
+	return s.bars[i]
+}
+
+// Time returns the i-th bar's timestamp
+func (s BarSeries) Time(i int) time.Time {
+	return s.times[i]
+}
+
+// Latest returns the newest bar, or a zero Bar for an empty series
+func (s BarSeries) Latest() Bar {
+	if len(s.bars) == 0 {
+		return Bar{}
+	}
+	return s.bars[len(s.bars)-1]
+}
+
+// LatestTime returns the newest bar's timestamp, or the zero time for an empty series
+func (s BarSeries) LatestTime() time.Time {
+	if len(s.times) == 0 {
+		return time.Time{}
+	}
+	return s.times[len(s.times)-1]
+}
+
+// Window returns the newest n bars (all of them when n >= Len), sharing storage with s
+func (s BarSeries) Window(n int) BarSeries {
+	if n < 0 {
+		n = 0
+	}
+	if n >= len(s.bars) {
+		return s
+	}
+	return s.Slice(len(s.bars)-n, len(s.bars))
+}
+
+// Slice returns bars [from, to), sharing storage with s
+func (s BarSeries) Slice(from, to int) BarSeries {
+	return BarSeries{bars: s.bars[from:to:to], times: s.times[from:to:to]}
+}
+
+// Bars returns a copy of the bars, oldest first
+func (s BarSeries) Bars() []Bar {
+	return append([]Bar(nil), s.bars...)
+}
+
+// LatestFirst returns a copy of the bars, newest first, the order providers use
+func (s BarSeries) LatestFirst() []Bar {
+	bars := make([]Bar, len(s.bars))
+	for i, bar := range s.bars {
+		bars[len(bars)-1-i] = bar
+	}
+	return bars
+}
+
+func (s BarSeries) Opens() []float64 {
+	return s.prices(func(b Bar) float64 { return b.Open })
+}
+
+func (s BarSeries) Highs() []float64 {
+	return s.prices(func(b Bar) float64 { return b.High })
+}
+
+func (s BarSeries) Lows() []float64 {
+	return s.prices(func(b Bar) float64 { return b.Low })
+}
+
+func (s BarSeries) Closes() []float64 {
+	return s.prices(func(b Bar) float64 { return b.Close })
+}
+
+func (s BarSeries) Volumes() []int64 {
+	volumes := make([]int64, len(s.bars))
+	for i, bar := range s.bars {
+		volumes[i] = bar.Volume
+	}
+	return volumes
+}
+
+func (s BarSeries) prices(field func(Bar) float64) []float64 {
+	values := make([]float64, len(s.bars))
+	for i, bar := range s.bars {
+		values[i] = field(bar)
+	}
+	return values
+}
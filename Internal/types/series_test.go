@@ -0,0 +1,99 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func dailyBars(n int) []Bar {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bars := make([]Bar, n)
+	for i := range bars {
+		price := 100 + float64(i)
+		bars[i] = Bar{Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open: price, High: price + 1, Low: price - 1, Close: price, Volume: int64(1000 + i)}
+	}
+	return bars
+}
+
+func TestNewBarSeriesSortsOldestFirst(t *testing.T) {
+	bars := dailyBars(5)
+	latestFirst := make([]Bar, len(bars))
+	for i, bar := range bars {
+		latestFirst[len(bars)-1-i] = bar
+	}
+
+	series, err := NewBarSeries(latestFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Len() != 5 || series.At(0) != bars[0] || series.Latest() != bars[4] {
+		t.Fatalf("series should run oldest to newest, got %+v", series.Bars())
+	}
+	if !series.LatestTime().Equal(time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("LatestTime() = %v", series.LatestTime())
+	}
+	if got := series.LatestFirst(); got[0] != bars[4] || got[4] != bars[0] {
+		t.Errorf("LatestFirst() = %+v", got)
+	}
+	if closes := series.Closes(); len(closes) != 5 || closes[0] != 100 || closes[4] != 104 {
+		t.Errorf("Closes() = %v", closes)
+	}
+	if volumes := series.Volumes(); volumes[4] != 1004 {
+		t.Errorf("Volumes() = %v", volumes)
+	}
+
+	// the input slice is copied, not aliased
+	latestFirst[0].Close = -1
+	if series.Latest().Close != 104 {
+		t.Errorf("series changed with its input")
+	}
+}
+
+func TestBarSeriesWindow(t *testing.T) {
+	series, err := NewBarSeries(dailyBars(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	window := series.Window(3)
+	if window.Len() != 3 || window.At(0).Close != 107 || window.Latest() != series.Latest() {
+		t.Errorf("Window(3) = %+v, want the newest 3 bars", window.Bars())
+	}
+	if series.Window(50).Len() != 10 || series.Window(-1).Len() != 0 {
+		t.Errorf("Window should clamp n to the series length")
+	}
+	if slice := series.Slice(2, 4); slice.Len() != 2 || slice.At(0).Close != 102 || !slice.Time(1).Equal(series.Time(3)) {
+		t.Errorf("Slice(2, 4) = %+v", slice.Bars())
+	}
+
+	var empty BarSeries
+	if empty.Len() != 0 || empty.Latest() != (Bar{}) || !empty.LatestTime().IsZero() || len(empty.Closes()) != 0 {
+		t.Errorf("the zero series should be empty")
+	}
+}
+
+func TestNewBarSeriesRejectsBadBars(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(bars []Bar)
+		want   string
+	}{
+		{"missing timestamp", func(bars []Bar) { bars[1].Timestamp = "" }, "invalid timestamp"},
+		{"unparseable timestamp", func(bars []Bar) { bars[1].Timestamp = "2024-01-03" }, "invalid timestamp"},
+		{"duplicate timestamp", func(bars []Bar) { bars[2].Timestamp = bars[0].Timestamp }, "duplicate bar"},
+		{"high below low", func(bars []Bar) { bars[1].High, bars[1].Low = 90, 110 }, "below low"},
+		{"negative price", func(bars []Bar) { bars[1].Open = -5 }, "invalid price"},
+		{"negative volume", func(bars []Bar) { bars[1].Volume = -1 }, "negative volume"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(3)
+			tt.modify(bars)
+			if _, err := NewBarSeries(bars); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewBarSeries() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ type Bar struct {
 type Candidate struct {
 	Symbol         string
 	Score          float64
+	Action         string
 	RSI            float64
 	ATR            float64
 	Analysis       string
@@ -19,7 +20,7 @@ type Candidate struct {
 	BodyLowerRatio float64
 	VWAPPrice      float64
 	WhaleCount     int
-	Bars           []Bar
+	Bars           BarSeries
 }
 
 type ScoringInput struct {
@@ -129,7 +129,8 @@ func PatternAnalyzeCandle(bar types.Bar, atr *float64, avgVol20 float64, vol int
 		signal = "Strong Bullish"
 		confidence = 0.9
 	} else if common.BodyToLower > 2.0 && candle.Close > candle.Open {
-		signal = "Bullish Engulfing Potential"
+		// engulfing needs the previous candle, see DetectPatterns
+		signal = "Bullish"
 		confidence = 0.7
 	}
 
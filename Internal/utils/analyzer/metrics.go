@@ -5,77 +5,46 @@ import (
 	"fmt"
 
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	"github.com/fazecat/mongelmaker/Internal/strategy"
 	"github.com/fazecat/mongelmaker/Internal/types"
 )
 
-func extractClosingPrices(bars []types.Bar) []float64 {
-	closes := make([]float64, len(bars))
-	for i, bar := range bars {
-		closes[i] = bar.Close
-	}
-	return closes
-}
-
-func CalculateCandidateMetrics(ctx context.Context, symbol string, bars []types.Bar) (*types.Candidate, error) {
-	if len(bars) == 0 {
+// CalculateCandidateMetrics scores a scout candidate with the evaluator; Score is the decision's rating
+func CalculateCandidateMetrics(ctx context.Context, evaluator *strategy.Evaluator, symbol string, series types.BarSeries) (*types.Candidate, error) {
+	if series.Len() == 0 {
 		return nil, fmt.Errorf("no bars provided for %s", symbol)
 	}
-	closingPrices := extractClosingPrices(bars)
-	rsiValues, err := strategy.CalculateRSI(closingPrices, 14)
-	if err != nil {
-		return nil, err
-	}
-
-	atrMap, err := datafeed.FetchATRForDisplay(symbol, 1)
-	if err != nil {
-		return nil, err
+	features, decision := evaluator.Evaluate(ctx, symbol, "1Day", series)
+	if features.RSI == nil {
+		return nil, fmt.Errorf("not enough data for %s", symbol)
 	}
 
 	var atrValue float64
-	if len(atrMap) > 0 {
-		for _, v := range atrMap {
-			atrValue = v
-			break
-		}
-	}
-
-	interestScoreInput := types.ScoringInput{
-		CurrentPrice: bars[len(bars)-1].Close,
-		RSIValue:     rsiValues[len(rsiValues)-1],
-		ATRValue:     atrValue,
-		PriceDrop:    (bars[len(bars)-2].Close - bars[len(bars)-1].Close) / bars[len(bars)-2].Close * 100,
+	if features.ATR != nil {
+		atrValue = *features.ATR
 	}
 
-	interestScore := strategy.CalculateInterestScore(interestScoreInput)
-
-	latestPattern := GetLatestCandlePattern(bars, 5)
-
 	candidate := &types.Candidate{
 		Symbol:   symbol,
-		Score:    interestScore,
-		RSI:      rsiValues[len(rsiValues)-1],
+		Score:    decision.Rating,
+		Action:   decision.Action,
+		RSI:      *features.RSI,
 		ATR:      atrValue,
-		Analysis: latestPattern,
-		Bars:     bars,
+		Analysis: GetLatestCandlePattern(series, 5),
+		Bars:     series,
 	}
 
 	return candidate, nil
 }
 
-func analyzeRecentCandles(bars []types.Bar, numCandles int) (int, int, string) {
-	if len(bars) == 0 {
+func analyzeRecentCandles(series types.BarSeries, numCandles int) (int, int, string) {
+	if series.Len() == 0 {
 		return 0, 0, "N/A"
 	}
 
-	if numCandles > len(bars) {
-		numCandles = len(bars)
-	}
-
-	startIdx := len(bars) - numCandles
-	recentBars := bars[startIdx:]
-
-	latestBar := recentBars[len(recentBars)-1]
+	recentBars := series.Window(numCandles).Bars()
+	latestBar := series.Latest()
 	candle := Candlestick{
 		Open:  latestBar.Open,
 		Close: latestBar.Close,
@@ -99,12 +68,12 @@ func analyzeRecentCandles(bars []types.Bar, numCandles int) (int, int, string) {
 	return bullishCount, bearishCount, latestPattern
 }
 
-func GetLatestCandlePattern(bars []types.Bar, numCandles int) string {
-	if len(bars) == 0 {
+func GetLatestCandlePattern(series types.BarSeries, numCandles int) string {
+	if series.Len() == 0 {
 		return "N/A"
 	}
 
-	bullishCount, bearishCount, latestPattern := analyzeRecentCandles(bars, numCandles)
+	bullishCount, bearishCount, latestPattern := analyzeRecentCandles(series, numCandles)
 
 	if numCandles == 1 {
 		return latestPattern
@@ -119,14 +88,14 @@ func GetLatestCandlePattern(bars []types.Bar, numCandles int) string {
 	return fmt.Sprintf("Mixed Trend (Latest: %s)", latestPattern)
 }
 
-func GetPatternConfidence(ctx context.Context, symbol string, bars []types.Bar) (float64, error) {
-	if len(bars) == 0 {
+func GetPatternConfidence(ctx context.Context, indicators repository.IndicatorRepository, symbol string, series types.BarSeries) (float64, error) {
+	if series.Len() == 0 {
 		return 0, fmt.Errorf("no bars provided for %s", symbol)
 	}
 
-	latestBar := bars[len(bars)-1]
+	latestBar := series.Latest()
 
-	atrMap, err := datafeed.FetchATRForDisplay(symbol, 1)
+	atrMap, err := datafeed.FetchATRForDisplay(ctx, indicators, symbol, 1)
 	if err != nil {
 		return 0, err
 	}
@@ -139,11 +108,6 @@ func GetPatternConfidence(ctx context.Context, symbol string, bars []types.Bar)
 		}
 	}
 
-	volumes := make([]int64, len(bars))
-	for i, bar := range bars {
-		volumes[i] = bar.Volume
-	}
-
 	_, confidence := PatternAnalyzeCandle(latestBar, atrValue, 0, latestBar.Volume)
 
 	return confidence, nil
@@ -0,0 +1,288 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+const (
+	patternTrendLookback = 5  // bars before a pattern that set its trend context
+	patternAverageBars   = 10 // bars before a pattern used for the typical body and range
+)
+
+// patternDef is one entry of the library. Reversals need the opposite trend before them;
+// patterns that don't care about the trend leave needsTrend empty.
+type patternDef struct {
+	id         types.PatternID
+	name       string
+	bars       int
+	direction  int
+	needsTrend string
+	base       float64 // confidence when the shape matches, before trend and volume
+	match      func(w patternWindow) bool
+}
+
+var patternLibrary = []patternDef{
+	{types.PatternBullishEngulfing, "Bullish Engulfing", 2, 1, types.TrendDown, 0.7, bullishEngulfing},
+	{types.PatternBearishEngulfing, "Bearish Engulfing", 2, -1, types.TrendUp, 0.7, bearishEngulfing},
+	{types.PatternBullishHarami, "Bullish Harami", 2, 1, types.TrendDown, 0.5, bullishHarami},
+	{types.PatternBearishHarami, "Bearish Harami", 2, -1, types.TrendUp, 0.5, bearishHarami},
+	{types.PatternPiercingLine, "Piercing Line", 2, 1, types.TrendDown, 0.6, piercingLine},
+	{types.PatternDarkCloudCover, "Dark Cloud Cover", 2, -1, types.TrendUp, 0.6, darkCloudCover},
+	{types.PatternMorningStar, "Morning Star", 3, 1, types.TrendDown, 0.75, morningStar},
+	{types.PatternEveningStar, "Evening Star", 3, -1, types.TrendUp, 0.75, eveningStar},
+	{types.PatternThreeWhiteSoldiers, "Three White Soldiers", 3, 1, types.TrendDown, 0.75, threeWhiteSoldiers},
+	{types.PatternThreeBlackCrows, "Three Black Crows", 3, -1, types.TrendUp, 0.75, threeBlackCrows},
+	{types.PatternInsideBar, "Inside Bar", 2, 0, "", 0.4, insideBar},
+	{types.PatternOutsideBar, "Outside Bar", 2, 0, "", 0.5, outsideBar},
+	{types.PatternTweezerBottom, "Tweezer Bottom", 2, 1, types.TrendDown, 0.55, tweezerBottom},
+	{types.PatternTweezerTop, "Tweezer Top", 2, -1, types.TrendUp, 0.55, tweezerTop},
+}
+
+// patternWindow is the pattern's candles, oldest first, with the typical body and range before them
+type patternWindow struct {
+	bars     []types.Bar
+	avgBody  float64
+	avgRange float64
+}
+
+func (w patternWindow) last() types.Bar {
+	return w.bars[len(w.bars)-1]
+}
+
+func (w patternWindow) prev() types.Bar {
+	return w.bars[len(w.bars)-2]
+}
+
+func body(b types.Bar) float64       { return math.Abs(b.Close - b.Open) }
+func bodyTop(b types.Bar) float64    { return math.Max(b.Open, b.Close) }
+func bodyBottom(b types.Bar) float64 { return math.Min(b.Open, b.Close) }
+func midpoint(b types.Bar) float64   { return (b.Open + b.Close) / 2 }
+func bullish(b types.Bar) bool       { return b.Close > b.Open }
+func bearish(b types.Bar) bool       { return b.Close < b.Open }
+
+// long bodies are at least the typical body, small ones under a third of it
+func (w patternWindow) long(b types.Bar) bool  { return body(b) >= w.avgBody }
+func (w patternWindow) small(b types.Bar) bool { return body(b) < w.avgBody/3 }
+
+func bullishEngulfing(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bearish(p) && bullish(c) && c.Open <= p.Close && c.Close >= p.Open && body(c) > body(p)
+}
+
+func bearishEngulfing(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bullish(p) && bearish(c) && c.Open >= p.Close && c.Close <= p.Open && body(c) > body(p)
+}
+
+func bullishHarami(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bearish(p) && w.long(p) && bodyTop(c) < p.Open && bodyBottom(c) > p.Close && body(c) < body(p)*0.6
+}
+
+func bearishHarami(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bullish(p) && w.long(p) && bodyTop(c) < p.Close && bodyBottom(c) > p.Open && body(c) < body(p)*0.6
+}
+
+func piercingLine(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bearish(p) && w.long(p) && bullish(c) && c.Open < p.Close && c.Close > midpoint(p) && c.Close < p.Open
+}
+
+func darkCloudCover(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bullish(p) && w.long(p) && bearish(c) && c.Open > p.Close && c.Close < midpoint(p) && c.Close > p.Open
+}
+
+func morningStar(w patternWindow) bool {
+	first, star, c := w.bars[0], w.bars[1], w.last()
+	return bearish(first) && w.long(first) && w.small(star) && bodyTop(star) < first.Close &&
+		bullish(c) && c.Close > midpoint(first)
+}
+
+func eveningStar(w patternWindow) bool {
+	first, star, c := w.bars[0], w.bars[1], w.last()
+	return bullish(first) && w.long(first) && w.small(star) && bodyBottom(star) > first.Close &&
+		bearish(c) && c.Close < midpoint(first)
+}
+
+// each soldier opens inside the previous body, closes at a new high near its own high
+func threeWhiteSoldiers(w patternWindow) bool {
+	for i, b := range w.bars {
+		if !bullish(b) || body(b) < w.avgBody/2 || b.High-b.Close > body(b)/2 {
+			return false
+		}
+		if i > 0 {
+			p := w.bars[i-1]
+			if b.Close <= p.Close || b.Open < p.Open || b.Open > p.Close {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func threeBlackCrows(w patternWindow) bool {
+	for i, b := range w.bars {
+		if !bearish(b) || body(b) < w.avgBody/2 || b.Close-b.Low > body(b)/2 {
+			return false
+		}
+		if i > 0 {
+			p := w.bars[i-1]
+			if b.Close >= p.Close || b.Open > p.Open || b.Open < p.Close {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func insideBar(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return c.High < p.High && c.Low > p.Low
+}
+
+func outsideBar(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return c.High > p.High && c.Low < p.Low
+}
+
+// tweezers are two candles of opposite colour sharing an extreme within a tenth of the typical range
+func tweezerBottom(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bearish(p) && bullish(c) && math.Abs(c.Low-p.Low) <= w.avgRange*0.1
+}
+
+func tweezerTop(w patternWindow) bool {
+	p, c := w.prev(), w.last()
+	return bullish(p) && bearish(c) && math.Abs(c.High-p.High) <= w.avgRange*0.1
+}
+
+// DetectPatterns returns the patterns ending on the latest bar, most confident first
+func DetectPatterns(series types.BarSeries) []types.PatternMatch {
+	return DetectPatternsAt(series, series.Len()-1)
+}
+
+// DetectPatternsAt returns the patterns whose last candle is bar end of the series, most confident
+// first. Confidence starts at the pattern's base, gains 0.15 when the prior trend fits a reversal
+// (losing 0.25 when it doesn't) and 0.1 when the last candle trades at 1.2x the average volume.
+func DetectPatternsAt(series types.BarSeries, end int) []types.PatternMatch {
+	if end < 1 || end >= series.Len() {
+		return nil
+	}
+
+	var matches []types.PatternMatch
+	for _, def := range patternLibrary {
+		start := end - def.bars + 1
+		if start < 0 {
+			continue
+		}
+		w := newPatternWindow(series, start, end)
+		if !def.match(w) {
+			continue
+		}
+
+		m := types.PatternMatch{
+			ID:        def.id,
+			Name:      def.name,
+			Direction: def.direction,
+			Bars:      def.bars,
+			End:       series.Time(end),
+			Trend:     priorTrend(series, start),
+		}
+		if def.id == types.PatternOutsideBar {
+			// an outside bar leans the way it closes
+			m.Direction = candleDirection(w.last())
+		}
+
+		m.Confidence = def.base
+		m.TrendOK = def.needsTrend == "" || m.Trend == def.needsTrend
+		if def.needsTrend != "" {
+			if m.TrendOK {
+				m.Confidence += 0.15
+			} else {
+				m.Confidence -= 0.25
+			}
+		}
+		if avgVol := averageVolume(series, start); avgVol > 0 && float64(w.last().Volume) > avgVol*1.2 {
+			m.Confidence += 0.1
+		}
+		m.Confidence = math.Max(0, math.Min(1, m.Confidence))
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
+	return matches
+}
+
+func newPatternWindow(series types.BarSeries, start, end int) patternWindow {
+	w := patternWindow{bars: series.Slice(start, end+1).Bars()}
+	from := start - patternAverageBars
+	if from < 0 {
+		from = 0
+	}
+	// with no history before the pattern, its own candles set the scale
+	before := series.Slice(from, start).Bars()
+	if len(before) == 0 {
+		before = w.bars
+	}
+	for _, b := range before {
+		w.avgBody += body(b)
+		w.avgRange += b.High - b.Low
+	}
+	w.avgBody /= float64(len(before))
+	w.avgRange /= float64(len(before))
+	return w
+}
+
+// priorTrend compares the close before the pattern with the one patternTrendLookback bars
+// earlier. Moves under half the average range over those bars count as flat.
+func priorTrend(series types.BarSeries, start int) string {
+	last := start - 1
+	first := last - patternTrendLookback
+	if first < 0 {
+		return types.TrendFlat
+	}
+	avgRange := 0.0
+	for i := first; i <= last; i++ {
+		avgRange += series.At(i).High - series.At(i).Low
+	}
+	avgRange /= float64(patternTrendLookback + 1)
+
+	change := series.At(last).Close - series.At(first).Close
+	switch {
+	case change > avgRange/2:
+		return types.TrendUp
+	case change < -avgRange/2:
+		return types.TrendDown
+	}
+	return types.TrendFlat
+}
+
+func averageVolume(series types.BarSeries, start int) float64 {
+	from := start - patternAverageBars
+	if from < 0 {
+		from = 0
+	}
+	if from == start {
+		return 0
+	}
+	total := 0.0
+	for i := from; i < start; i++ {
+		total += float64(series.At(i).Volume)
+	}
+	return total / float64(start-from)
+}
+
+func candleDirection(b types.Bar) int {
+	switch {
+	case bullish(b):
+		return 1
+	case bearish(b):
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+type ohlc struct{ o, h, l, c float64 }
+
+// withPrefix puts the candles after eight falling (or rising) bars of 2 points each
+func withPrefix(falling bool, candles ...ohlc) types.BarSeries {
+	t0 := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+	var bars []types.Bar
+	add := func(k ohlc, volume int64) {
+		bars = append(bars, types.Bar{Timestamp: t0.AddDate(0, 0, len(bars)).Format(time.RFC3339),
+			Open: k.o, High: k.h, Low: k.l, Close: k.c, Volume: volume})
+	}
+	for i := 0; i < 8; i++ {
+		c := 120 - 2*float64(i)
+		k := ohlc{c + 1.5, c + 2, c - 0.5, c}
+		if !falling {
+			k = mirror(k)
+		}
+		add(k, 1000)
+	}
+	for _, k := range candles {
+		add(k, 1000)
+	}
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		panic(err)
+	}
+	return series
+}
+
+// mirror reflects a candle around 200, turning bullish shapes into their bearish twins
+func mirror(k ohlc) ohlc {
+	return ohlc{200 - k.o, 200 - k.l, 200 - k.h, 200 - k.c}
+}
+
+func mirrorAll(candles []ohlc) []ohlc {
+	out := make([]ohlc, len(candles))
+	for i, k := range candles {
+		out[i] = mirror(k)
+	}
+	return out
+}
+
+func findPattern(matches []types.PatternMatch, id types.PatternID) (types.PatternMatch, bool) {
+	for _, m := range matches {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return types.PatternMatch{}, false
+}
+
+func TestDetectPatterns(t *testing.T) {
+	tests := []struct {
+		bullish, bearish types.PatternID
+		candles          []ohlc
+	}{
+		{types.PatternBullishEngulfing, types.PatternBearishEngulfing, []ohlc{{105, 105.5, 102.5, 103}, {102.8, 106, 102.4, 105.5}}},
+		{types.PatternBullishHarami, types.PatternBearishHarami, []ohlc{{106, 106.2, 101.8, 102}, {103, 104.3, 102.8, 104}}},
+		{types.PatternPiercingLine, types.PatternDarkCloudCover, []ohlc{{106, 106.2, 101.8, 102}, {101.5, 104.7, 101.3, 104.5}}},
+		{types.PatternMorningStar, types.PatternEveningStar, []ohlc{{106, 106.2, 101.8, 102}, {101.5, 101.8, 101, 101.3}, {102, 105.2, 101.8, 105}}},
+		{types.PatternThreeWhiteSoldiers, types.PatternThreeBlackCrows, []ohlc{{104, 106.3, 103.8, 106}, {105, 107.7, 104.8, 107.5}, {106.5, 109.2, 106.3, 109}}},
+		{types.PatternTweezerBottom, types.PatternTweezerTop, []ohlc{{105, 105.2, 102.5, 103}, {103.2, 105, 102.52, 104.8}}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.bullish), func(t *testing.T) {
+			m, ok := findPattern(DetectPatterns(withPrefix(true, tt.candles...)), tt.bullish)
+			if !ok {
+				t.Fatalf("%s not detected", tt.bullish)
+			}
+			if m.Direction != 1 || m.Bars != len(tt.candles) || m.Trend != types.TrendDown || !m.TrendOK {
+				t.Errorf("match = %+v", m)
+			}
+			if m.Confidence <= 0.5 || m.Confidence > 1 {
+				t.Errorf("confidence %v after a downtrend", m.Confidence)
+			}
+		})
+		t.Run(string(tt.bearish), func(t *testing.T) {
+			m, ok := findPattern(DetectPatterns(withPrefix(false, mirrorAll(tt.candles)...)), tt.bearish)
+			if !ok {
+				t.Fatalf("%s not detected", tt.bearish)
+			}
+			if m.Direction != -1 || m.Trend != types.TrendUp || !m.TrendOK {
+				t.Errorf("match = %+v", m)
+			}
+		})
+	}
+}
+
+func TestPatternTrendContext(t *testing.T) {
+	engulfing := []ohlc{{105, 105.5, 102.5, 103}, {102.8, 106, 102.4, 105.5}}
+	afterDrop, _ := findPattern(DetectPatterns(withPrefix(true, engulfing...)), types.PatternBullishEngulfing)
+	// the same candles at the top of a rally are no reversal
+	rally := withPrefix(false, ohlc{95, 95.5, 92.5, 93}, ohlc{92.8, 96, 92.4, 95.5})
+	afterRally, ok := findPattern(DetectPatterns(rally), types.PatternBullishEngulfing)
+	if !ok {
+		t.Fatal("engulfing shape not detected after a rally")
+	}
+	if afterRally.TrendOK || afterRally.Trend != types.TrendUp || afterRally.Confidence >= afterDrop.Confidence {
+		t.Errorf("after a rally %+v should rank below after a drop %+v", afterRally, afterDrop)
+	}
+	if !closeTo(afterDrop.Confidence-afterRally.Confidence, 0.4) {
+		t.Errorf("trend context moved confidence by %v, want 0.4", afterDrop.Confidence-afterRally.Confidence)
+	}
+}
+
+func TestInsideAndOutsideBars(t *testing.T) {
+	inside := DetectPatterns(withPrefix(true, ohlc{104, 107, 101, 105}, ohlc{104.5, 106, 103, 104}))
+	m, ok := findPattern(inside, types.PatternInsideBar)
+	if !ok || m.Direction != 0 || !m.TrendOK {
+		t.Errorf("inside bar = %+v (found %v)", m, ok)
+	}
+
+	outside := DetectPatterns(withPrefix(true, ohlc{104, 105, 103, 104.5}, ohlc{104.8, 106, 102, 102.5}))
+	m, ok = findPattern(outside, types.PatternOutsideBar)
+	if !ok || m.Direction != -1 {
+		t.Errorf("outside bar closing down should lean bearish, got %+v (found %v)", m, ok)
+	}
+}
+
+func TestDetectPatternsOrderAndVolume(t *testing.T) {
+	series := withPrefix(true, ohlc{105, 105.5, 102.5, 103}, ohlc{102.8, 106, 102.4, 105.5})
+	matches := DetectPatterns(series)
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Confidence > matches[i-1].Confidence {
+			t.Fatalf("matches not most confident first: %+v", matches)
+		}
+	}
+	if !matches[0].End.Equal(series.LatestTime()) {
+		t.Errorf("match should end on the latest bar, got %v", matches[0].End)
+	}
+
+	bars := series.Bars()
+	bars[len(bars)-1].Volume = 5000
+	loud, _ := types.NewBarSeries(bars)
+	quiet, _ := findPattern(matches, types.PatternBullishEngulfing)
+	heavy, _ := findPattern(DetectPatterns(loud), types.PatternBullishEngulfing)
+	if !closeTo(heavy.Confidence, quiet.Confidence+0.1) {
+		t.Errorf("volume should add 0.1: %v vs %v", heavy.Confidence, quiet.Confidence)
+	}
+
+	if DetectPatterns(types.BarSeries{}) != nil || DetectPatternsAt(series, series.Len()) != nil {
+		t.Error("out of range bars should detect nothing")
+	}
+	if earlier := DetectPatternsAt(series, 8); len(earlier) != 0 && !earlier[0].End.Equal(series.Time(8)) {
+		t.Errorf("DetectPatternsAt should end on bar 8, got %+v", earlier)
+	}
+}
+
+func closeTo(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
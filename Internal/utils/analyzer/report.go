@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+)
+
+func init() {
+	// the decision engine classifies the latest candle the same way the analytics table does
+	strategy.SetPatternClassifier(LatestPattern)
+	strategy.SetPatternDetector(DetectPatterns)
+}
+
+// LatestPattern is AnalyzeCandlestick's label for one bar
+func LatestPattern(bar types.Bar) string {
+	_, results := AnalyzeCandlestick(Candlestick{Open: bar.Open, Close: bar.Close, High: bar.High, Low: bar.Low})
+	return results["Analysis"]
+}
+
+// Report is the single-stock analysis behind the "Analyze Single Stock" menu and the analyze command
+type Report struct {
+	Symbol    string                  `json:"symbol"`
+	Timeframe string                  `json:"timeframe"`
+	Bars      int                     `json:"bars"`
+	Timestamp string                  `json:"timestamp"` // latest bar
+	Close     float64                 `json:"close"`
+	RSI       *float64                `json:"rsi"`
+	ATR       *float64                `json:"atr"`
+	Pattern   string                  `json:"pattern"`
+	Patterns  []types.PatternMatch    `json:"patterns"` // multi-candle patterns ending on the latest bar
+	Signal    strategy.CombinedSignal `json:"signal"`
+	Decision  strategy.Decision       `json:"decision"`
+}
+
+// AnalyzeBars runs the evaluator over the series and reports its inputs and verdict
+func AnalyzeBars(ctx context.Context, evaluator *strategy.Evaluator, symbol, timeframe string, series types.BarSeries) (*Report, error) {
+	if series.Len() == 0 {
+		return nil, fmt.Errorf("no bars provided for %s", symbol)
+	}
+
+	features, decision := evaluator.Evaluate(ctx, symbol, timeframe, series)
+
+	return &Report{
+		Symbol:    symbol,
+		Timeframe: timeframe,
+		Bars:      series.Len(),
+		Timestamp: series.Latest().Timestamp,
+		Close:     series.Latest().Close,
+		RSI:       features.RSI,
+		ATR:       features.ATR,
+		Pattern:   features.Pattern,
+		Patterns:  features.Patterns,
+		Signal:    decision.Signal,
+		Decision:  decision,
+	}, nil
+}
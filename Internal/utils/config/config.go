@@ -18,14 +18,7 @@ type Config struct {
 		LiquidityMinimumUSD int `yaml:"liquidity_minimum_usd"`
 	} `yaml:"global"`
 
-	Notifications struct {
-		Channels struct {
-			Console bool `yaml:"console"`
-			FileLog bool `yaml:"file_log"`
-			Discord bool `yaml:"discord"`
-		} `yaml:"channels"`
-		BatchDigestTime string `yaml:"batch_digest_time"`
-	} `yaml:"notifications"`
+	Notifications NotificationsConfig `yaml:"notifications"`
 
 	Archive struct {
 		DaysBeforeArchive    int `yaml:"days_before_archive"`
@@ -34,17 +27,132 @@ type Config struct {
 
 	Profiles map[string]ProfileConfig `yaml:"profiles"`
 
+	DataProvider DataProviderConfig `yaml:"data_provider"`
+
+	Execution ExecutionConfig `yaml:"execution"`
+
+	Screener ScreenerConfig `yaml:"screener"`
+
+	Signals struct {
+		Profile string `yaml:"profile"` // whose signal weights drive CalculateSignal
+	} `yaml:"signals"`
+
+	API APIConfig `yaml:"api"`
+
+	Stream StreamConfig `yaml:"stream"`
+
+	Logging LoggingConfig `yaml:"logging"`
+
+	Database DatabaseConfig `yaml:"database"`
+
 	Features struct {
 		CryptoSupport      bool `yaml:"crypto_support"`
 		EnableShortSignals bool `yaml:"enable_short_signals"`
 	} `yaml:"features"`
 }
 
+type DataProviderConfig struct {
+	Source string `yaml:"source"` // alpaca, file or database
+	Cache  bool   `yaml:"cache"`  // serve bars from historical_bars, fetching only what's missing
+	Alpaca struct {
+		DataURL           string `yaml:"data_url"`
+		TradingURL        string `yaml:"trading_url"`
+		TimeoutSeconds    int    `yaml:"timeout_seconds"`
+		RequestsPerMinute int    `yaml:"requests_per_minute"`
+	} `yaml:"alpaca"`
+	File struct {
+		Directory string            `yaml:"directory"`
+		Files     map[string]string `yaml:"files"` // symbol -> path overrides
+	} `yaml:"file"`
+}
+
+// NotificationsConfig routes alerts; the Discord webhook URL comes from DISCORD_WEBHOOK_URL
+type NotificationsConfig struct {
+	Channels struct {
+		Console bool `yaml:"console"`
+		FileLog bool `yaml:"file_log"`
+		Discord bool `yaml:"discord"`
+	} `yaml:"channels"`
+	BatchDigestTime string `yaml:"batch_digest_time"`
+	PendingFile     string `yaml:"pending_file"`     // info alerts held for the digest across runs
+	CooldownMinutes int    `yaml:"cooldown_minutes"` // per rule and symbol
+	DedupHours      int    `yaml:"dedup_hours"`      // identical events are dropped for this long
+	LogFile         struct {
+		Path       string `yaml:"path"`
+		MaxSizeMB  int    `yaml:"max_size_mb"`
+		MaxBackups int    `yaml:"max_backups"`
+	} `yaml:"log_file"`
+}
+
+// ScreenerConfig sizes the worker pool used by the screener and scout
+type ScreenerConfig struct {
+	Workers              int `yaml:"workers"`
+	SymbolTimeoutSeconds int `yaml:"symbol_timeout_seconds"`
+}
+
+// APIConfig configures the HTTP server started by the serve command
+type APIConfig struct {
+	Address            string `yaml:"address"`
+	MaxPageSize        int    `yaml:"max_page_size"`
+	ScreenerMaxSymbols int    `yaml:"screener_max_symbols"`
+}
+
+// LoggingConfig configures the application log; the file sink is only written when
+// notifications.channels.file_log is on
+type LoggingConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // text or json
+	File   string `yaml:"file"`
+}
+
+// DatabaseConfig picks the storage backend and controls schema migrations. Postgres
+// connection settings come from the DB_* variables.
+type DatabaseConfig struct {
+	Backend     string `yaml:"backend"`      // postgres (default) or sqlite
+	SQLitePath  string `yaml:"sqlite_path"`  // database file for the sqlite backend
+	AutoMigrate bool   `yaml:"auto_migrate"` // apply pending migrations on startup
+}
+
+// StreamConfig configures the market data websocket used by the stream command
+type StreamConfig struct {
+	URL                 string `yaml:"url"`
+	WindowBars          int    `yaml:"window_bars"` // minute bars kept in memory per symbol
+	ReconnectMinSeconds int    `yaml:"reconnect_min_seconds"`
+	ReconnectMaxSeconds int    `yaml:"reconnect_max_seconds"`
+}
+
+type ExecutionConfig struct {
+	DryRun          bool    `yaml:"dry_run"`
+	OrderType       string  `yaml:"order_type"` // market, limit or bracket
+	PositionSizeUSD float64 `yaml:"position_size_usd"`
+	LimitOffsetPct  float64 `yaml:"limit_offset_pct"`
+	StopLossPct     float64 `yaml:"stop_loss_pct"`
+	TakeProfitPct   float64 `yaml:"take_profit_pct"`
+	TimeInForce     string  `yaml:"time_in_force"`
+	MinConfidence   float64 `yaml:"min_confidence"`
+	AccountEquity   float64 `yaml:"account_equity"` // used for risk checks in dry-run mode
+	RiskProfile     string  `yaml:"risk_profile"`
+}
+
 type ProfileConfig struct {
-	Threshold        float64         `yaml:"threshold"`
-	ScanIntervalDays int             `yaml:"scan_interval_days"`
-	Indicators       IndicatorConfig `yaml:"indicators"`
-	SignalWeights    SignalWeights   `yaml:"signal_weights"`
+	Threshold        float64          `yaml:"threshold"`
+	ScanIntervalDays int              `yaml:"scan_interval_days"`
+	Indicators       IndicatorConfig  `yaml:"indicators"`
+	SignalWeights    SignalWeights    `yaml:"signal_weights"`
+	SignalThresholds SignalThresholds `yaml:"signal_thresholds"`
+	Risk             RiskConfig       `yaml:"risk"`
+	MultiTimeframe   bool             `yaml:"multi_timeframe"` // screen with a 1Min-1Day confluence
+}
+
+type RiskConfig struct {
+	MaxPositionPct   float64 `yaml:"max_position_pct"`
+	MaxExposurePct   float64 `yaml:"max_exposure_pct"`
+	MaxOpenPositions int     `yaml:"max_open_positions"`
+	MaxSymbolLossPct float64 `yaml:"max_symbol_loss_pct"`
+	MaxDailyLossPct  float64 `yaml:"max_daily_loss_pct"`
+	RiskPerTradePct  float64 `yaml:"risk_per_trade_pct"`
+	ATRMultiplier    float64 `yaml:"atr_multiplier"`
+	ATRPeriod        int     `yaml:"atr_period"`
 }
 
 type IndicatorConfig struct {
@@ -72,6 +180,18 @@ type SignalWeights struct {
 	VolumeWeight        float64 `yaml:"volume_weight"`
 	NewsSentimentWeight float64 `yaml:"news_sentiment_weight"`
 	WhaleActivityWeight float64 `yaml:"whale_activity_weight"`
+	PatternWeight       float64 `yaml:"pattern_weight"`
+	SRWeight            float64 `yaml:"support_resistance_weight"`
+	MACDWeight          float64 `yaml:"macd_weight"`
+	BollingerWeight     float64 `yaml:"bollinger_weight"`
+}
+
+// SignalThresholds are the weighted score cutoffs for each recommendation
+type SignalThresholds struct {
+	StrongBuy  float64 `yaml:"strong_buy"`  // BUY at or above
+	Buy        float64 `yaml:"buy"`         // ACCUMULATE at or above
+	Sell       float64 `yaml:"sell"`        // DISTRIBUTE at or below
+	StrongSell float64 `yaml:"strong_sell"` // SELL at or below
 }
 
 func LoadConfig() (*Config, error) {
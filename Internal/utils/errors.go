@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// error kinds for calls to external APIs; test for them with errors.Is
+var (
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden (check the data subscription)")
+	ErrNotFound     = errors.New("not found")
+	ErrTransient    = errors.New("transient network error")
+	ErrDecode       = errors.New("could not decode response")
+)
+
+// APIError is a failed call. Kind is one of the Err* values above (nil for other 4xx
+// answers), StatusCode is 0 when no response arrived and RetryAfter is the server's
+// requested wait on a 429.
+type APIError struct {
+	Kind       error
+	StatusCode int
+	RetryAfter time.Duration
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	var parts []string
+	if e.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("status %d", e.StatusCode))
+	}
+	if e.Kind != nil {
+		parts = append(parts, e.Kind.Error())
+	}
+	if e.Message != "" {
+		parts = append(parts, e.Message)
+	}
+	if e.Err != nil {
+		parts = append(parts, e.Err.Error())
+	}
+	return strings.Join(parts, ": ")
+}
+
+func (e *APIError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPError classifies a non-2xx response, keeping the start of the body as the message.
+// It reads but does not close the body.
+func HTTPError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode == http.StatusUnauthorized:
+		e.Kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		e.Kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		e.Kind = ErrNotFound
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode >= 500:
+		e.Kind = ErrTransient
+	}
+	if resp.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// NetworkError marks a failed round trip as transient. Cancellation is returned as is,
+// it isn't worth retrying.
+func NetworkError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &APIError{Kind: ErrTransient, Err: err}
+}
+
+// DecodeError wraps a body that didn't parse
+func DecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &APIError{Kind: ErrDecode, Err: err}
+}
+
+// IsRetryable reports whether trying again could succeed: rate limits, 5xx answers and
+// network failures (including timeouts not caused by our own context).
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTransient) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryAfter is the wait a 429 asked for, if any
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// Describe says in a few words why a call failed, e.g. why a symbol was skipped
+func Describe(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "timed out or cancelled"
+	case errors.Is(err, ErrRateLimited):
+		return "rate limited"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized, check the API keys"
+	case errors.Is(err, ErrForbidden):
+		return "no access with the current data subscription"
+	case errors.Is(err, ErrNotFound):
+		return "not found"
+	case errors.Is(err, ErrTransient):
+		return "network or server error"
+	case errors.Is(err, ErrDecode):
+		return "unreadable response"
+	}
+	return err.Error()
+}
+
+// parseRetryAfter reads either form of the header: delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
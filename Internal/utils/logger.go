@@ -1,3 +0,0 @@
-package utils
-
-// Placeholder for future logging utilities
\ No newline at end of file
@@ -0,0 +1,200 @@
+// Package logger is the application log, built on log/slog. Diagnostics go to stderr
+// (and optionally a JSON file) so they never mix with tables or JSON written to stdout.
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+// field keys shared by every component
+const (
+	KeyComponent = "component"
+	KeySymbol    = "symbol"
+	KeyProfile   = "profile"
+	KeyRequestID = "request_id"
+	KeyError     = "error"
+)
+
+// Options describes a logger. File, when set, receives JSON lines alongside Output.
+type Options struct {
+	Level  slog.Level
+	Format string    // text or json
+	Output io.Writer // defaults to os.Stderr
+	File   string
+}
+
+// New builds a logger; the returned closer releases the file sink and is never nil
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q (expected text or json)", opts.Format)
+	}
+
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+			return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handler = fanout{handler, slog.NewJSONHandler(f, handlerOpts)}
+		closer = f
+	}
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel reads debug, info, warn or error; empty is info
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+	return l, nil
+}
+
+// OptionsFromConfig reads the logging section; the file sink follows notifications.channels.file_log
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	opts := Options{Level: slog.LevelInfo}
+	if cfg == nil {
+		return opts, nil
+	}
+	level, err := ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		return opts, err
+	}
+	opts.Level = level
+	opts.Format = cfg.Logging.Format
+	if cfg.Notifications.Channels.FileLog {
+		opts.File = cfg.Logging.File
+	}
+	return opts, nil
+}
+
+// Init builds the logger described by cfg and makes it the active one (and slog's default).
+// On error the active logger is left alone; the closer is never nil.
+func Init(cfg *config.Config) (io.Closer, error) {
+	opts, err := OptionsFromConfig(cfg)
+	if err != nil {
+		return nopCloser{}, err
+	}
+	l, closer, err := New(opts)
+	if err != nil {
+		return nopCloser{}, err
+	}
+	Set(l)
+	return closer, nil
+}
+
+var (
+	mu     sync.RWMutex
+	active = slog.New(slog.NewTextHandler(os.Stderr, nil))
+)
+
+// Set changes the logger every component writes to
+func Set(l *slog.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = l
+	slog.SetDefault(l)
+}
+
+func Get() *slog.Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// Component returns the active logger tagged with a component name, e.g. "datafeed"
+func Component(name string) *slog.Logger {
+	return Get().With(KeyComponent, name)
+}
+
+type contextKey struct{}
+
+// WithContext carries a logger (usually with a request id or symbol attached) through ctx
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored by WithContext, or the active one
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return Get()
+}
+
+// Err is the attribute for an error
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// fanout sends each record to every handler that accepts its level
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+func TestNewLevelsAndFormats(t *testing.T) {
+	var buf bytes.Buffer
+	l, _, err := New(Options{Level: slog.LevelWarn, Format: "json", Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hidden")
+	l.With(KeySymbol, "AAPL").Warn("shown", Err(errors.New("boom")))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the warning, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "shown" || entry[KeySymbol] != "AAPL" || entry[KeyError] != "boom" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	buf.Reset()
+	l, _, err = New(Options{Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hello", KeyProfile, "default")
+	if !strings.Contains(buf.String(), "msg=hello profile=default") {
+		t.Errorf("expected text output, got %q", buf.String())
+	}
+
+	if _, _, err := New(Options{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestFileSinkFollowsFileLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	cfg := &config.Config{}
+	cfg.Logging = config.LoggingConfig{Level: "debug", Format: "text", File: path}
+
+	opts, err := OptionsFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.File != "" || opts.Level != slog.LevelDebug {
+		t.Fatalf("file sink should be off without file_log, got %+v", opts)
+	}
+
+	cfg.Notifications.Channels.FileLog = true
+	opts, err = OptionsFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var console bytes.Buffer
+	opts.Output = &console
+	l, closer, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With(KeyComponent, "scanner").Debug("tick")
+	closer.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("file sink should write JSON, got %q", data)
+	}
+	if entry["msg"] != "tick" || entry[KeyComponent] != "scanner" {
+		t.Errorf("unexpected file entry %v", entry)
+	}
+	if !strings.Contains(console.String(), "component=scanner") {
+		t.Errorf("the console should still get text, got %q", console.String())
+	}
+}
+
+func TestActiveLoggerAndContext(t *testing.T) {
+	previous := Get()
+	defer Set(previous)
+
+	var buf bytes.Buffer
+	Set(slog.New(slog.NewJSONHandler(&buf, nil)))
+	Component("datafeed").Info("connected")
+	if !strings.Contains(buf.String(), `"component":"datafeed"`) {
+		t.Errorf("expected the component field, got %q", buf.String())
+	}
+
+	if FromContext(context.Background()) != Get() {
+		t.Error("an empty context should give the active logger")
+	}
+	tagged := Get().With(KeyRequestID, "abc")
+	if FromContext(WithContext(context.Background(), tagged)) != tagged {
+		t.Error("expected the logger stored in the context")
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by everything hitting the same API.
+// Tokens refill continuously at rate per second up to burst.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// creates a limiter that starts full; a rate <= 0 means unlimited
+func NewRateLimiter(ratePerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   ratePerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// NewPerMinuteLimiter matches quotas stated as requests per minute (Alpaca's free plan is 200)
+func NewPerMinuteLimiter(requestsPerMinute int) *RateLimiter {
+	burst := requestsPerMinute / 10
+	return NewRateLimiter(float64(requestsPerMinute)/60, burst)
+}
+
+// reserve takes a token if one is available, otherwise returns how long until one is
+func (r *RateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+	}
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+	return time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+}
+
+// Wait blocks until a request may be made or ctx is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil || r.rate <= 0 {
+		return ctx.Err()
+	}
+	for {
+		wait := r.reserve()
+		if wait == 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
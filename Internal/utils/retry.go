@@ -1,14 +1,22 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
 	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 )
 
 type RetryConfig struct {
 	MaxRetries int           // How many times to retry (e.g., 3)
 	Delay      time.Duration // Initial delay between retries (e.g., 2 seconds)
 	Backoff    float64       // Multiplier for exponential backoff (e.g., 2.0)
+	MaxDelay   time.Duration // Cap on a single wait, 0 means no cap
+	Jitter     float64       // Spread each wait by up to this fraction (e.g., 0.2 is ±20%)
+	// Retryable decides which errors are worth another attempt; nil means IsRetryable
+	Retryable func(error) bool
 }
 
 func DefaultRetryConfig() *RetryConfig {
@@ -16,23 +24,60 @@ func DefaultRetryConfig() *RetryConfig {
 		MaxRetries: 3,
 		Delay:      time.Second * 2,
 		Backoff:    2.0,
+		MaxDelay:   30 * time.Second,
+		Jitter:     0.2,
 	}
 }
 
-func RetryWithBackoff(operation func() error, config *RetryConfig) error {
+// RetryWithBackoff runs operation up to MaxRetries times, waiting longer after each retryable
+// failure. A 429's Retry-After replaces the computed wait. Errors that aren't retryable are
+// returned straight away, and the final error wraps the last cause.
+func RetryWithBackoff(ctx context.Context, operation func() error, config *RetryConfig) error {
+	retryable := config.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
 	delay := config.Delay
+	var err error
 	for i := 0; i < config.MaxRetries; i++ {
-		err := operation()
-		if err == nil {
+		if err = operation(); err == nil {
 			return nil
 		}
-		if i < config.MaxRetries-1 {
-			fmt.Printf("⚠️  Attempt %d failed: %v. Retrying in %s...\n", i+1, err, delay)
-			time.Sleep(delay)
-			delay = time.Duration(float64(delay) * config.Backoff)
+		if !retryable(err) {
+			return err
+		}
+		if i == config.MaxRetries-1 {
+			break
+		}
+
+		wait := config.wait(delay)
+		if after, ok := RetryAfter(err); ok {
+			wait = after
+		}
+		logger.Get().Warn("attempt failed, retrying", "attempt", i+1, "delay", wait, logger.Err(err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w after attempt %d: %w", ctx.Err(), i+1, err)
+		case <-timer.C:
 		}
+		delay = time.Duration(float64(delay) * config.Backoff)
+	}
+	return fmt.Errorf("operation failed after %d attempts: %w", config.MaxRetries, err)
+}
+
+// wait applies the cap and jitter to a backoff delay
+func (c *RetryConfig) wait(delay time.Duration) time.Duration {
+	if c.MaxDelay > 0 && delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	if c.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + c.Jitter*(2*rand.Float64()-1)))
 	}
-	return fmt.Errorf("operation failed after %d attempts", config.MaxRetries)
+	return delay
 }
 
 func TestRetryLogic() {
@@ -41,10 +86,10 @@ func TestRetryLogic() {
 	failCount := 0
 	config := DefaultRetryConfig()
 
-	err := RetryWithBackoff(func() error {
+	err := RetryWithBackoff(context.Background(), func() error {
 		failCount++
 		if failCount < 3 {
-			return fmt.Errorf("simulated failure %d", failCount)
+			return NetworkError(fmt.Errorf("simulated failure %d", failCount))
 		}
 		fmt.Println("✅ Success on attempt", failCount)
 		return nil
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func fastRetry(attempts int) *RetryConfig {
+	return &RetryConfig{MaxRetries: attempts, Delay: time.Millisecond, Backoff: 2}
+}
+
+func TestRetryOnlyRetryableErrors(t *testing.T) {
+	calls := 0
+	err := RetryWithBackoff(context.Background(), func() error {
+		calls++
+		return &APIError{Kind: ErrForbidden, StatusCode: http.StatusForbidden}
+	}, fastRetry(3))
+	if calls != 1 || !errors.Is(err, ErrForbidden) {
+		t.Errorf("a 403 should fail on the first attempt, got %d calls and %v", calls, err)
+	}
+
+	calls = 0
+	cause := errors.New("connection reset")
+	err = RetryWithBackoff(context.Background(), func() error {
+		calls++
+		return NetworkError(cause)
+	}, fastRetry(3))
+	if calls != 3 || !errors.Is(err, ErrTransient) || !errors.Is(err, cause) {
+		t.Errorf("expected 3 attempts and the last cause wrapped, got %d calls and %v", calls, err)
+	}
+
+	calls = 0
+	err = RetryWithBackoff(context.Background(), func() error {
+		calls++
+		if calls < 2 {
+			return NetworkError(cause)
+		}
+		return nil
+	}, fastRetry(3))
+	if err != nil || calls != 2 {
+		t.Errorf("expected success on the second attempt, got %d calls and %v", calls, err)
+	}
+}
+
+func TestRetryHonorsRetryAfterAndContext(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	// a delay that would time the test out unless Retry-After replaces it
+	config := &RetryConfig{MaxRetries: 2, Delay: time.Hour, Backoff: 2}
+	err := RetryWithBackoff(context.Background(), func() error {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			return NetworkError(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return HTTPError(resp)
+		}
+		return nil
+	}, config)
+	if err != nil || calls != 2 {
+		t.Fatalf("expected the 429 to be retried after a second, got %d calls and %v", calls, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RetryWithBackoff(ctx, func() error { return NetworkError(errors.New("down")) }, config)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrTransient) {
+		t.Errorf("cancellation should stop the wait and keep the cause, got %v", err)
+	}
+}
+
+func TestHTTPErrorClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		kind      error
+		retryable bool
+	}{
+		{http.StatusTooManyRequests, ErrRateLimited, true},
+		{http.StatusUnauthorized, ErrUnauthorized, false},
+		{http.StatusForbidden, ErrForbidden, false},
+		{http.StatusNotFound, ErrNotFound, false},
+		{http.StatusBadGateway, ErrTransient, true},
+		{http.StatusBadRequest, nil, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Retry-After", "7")
+		rec.WriteHeader(tt.status)
+		rec.WriteString(`{"message":"nope"}`)
+
+		var err error = HTTPError(rec.Result())
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != `{"message":"nope"}` {
+			t.Errorf("%d: unexpected error %#v", tt.status, err)
+		}
+		if tt.kind != nil && !errors.Is(err, tt.kind) {
+			t.Errorf("%d: expected %v, got %v", tt.status, tt.kind, err)
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("%d: retryable = %v, want %v", tt.status, !tt.retryable, tt.retryable)
+		}
+	}
+
+	wrapped := fmt.Errorf("AAPL: %w", HTTPError(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}}))
+	if after, ok := RetryAfter(wrapped); !ok || after != 7*time.Second {
+		t.Errorf("RetryAfter = %v, %v", after, ok)
+	}
+	if Describe(wrapped) != "rate limited" {
+		t.Errorf("unexpected description %q", Describe(wrapped))
+	}
+
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now); got != 90*time.Second {
+		t.Errorf("HTTP date Retry-After = %v, want 90s", got)
+	}
+	if DecodeError(nil) != nil || NetworkError(context.Canceled) != context.Canceled {
+		t.Error("nil and cancellation should pass through unwrapped")
+	}
+}
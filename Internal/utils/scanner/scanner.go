@@ -2,25 +2,59 @@ package scanner
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/fazecat/mongelmaker/Internal/alerts"
 	db "github.com/fazecat/mongelmaker/Internal/database"
-	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	"github.com/fazecat/mongelmaker/Internal/strategy"
 	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
 	"github.com/fazecat/mongelmaker/Internal/utils/analyzer"
 	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
 )
 
-func ShouldScan(ctx context.Context, profileName string, cfg *config.Config, q *database.Queries) (bool, error) {
-	scan, err := q.GetScanLog(ctx, profileName)
+// Scanner rescores the watchlist and scouts tradable assets. Provider may be left nil to
+// use the active datafeed provider; everything it stores goes through the repositories.
+type Scanner struct {
+	Config     *config.Config
+	Provider   db.MarketDataProvider
+	Watchlist  repository.WatchlistRepository
+	Indicators repository.IndicatorRepository
+	News       repository.NewsRepository
+	Levels     repository.LevelRepository
+	Whales     repository.WhaleRepository
+}
+
+func New(cfg *config.Config, repos repository.Repositories) *Scanner {
+	return &Scanner{
+		Config:     cfg,
+		Watchlist:  repos.Watchlist,
+		Indicators: repos.Indicators,
+		News:       repos.News,
+		Levels:     repos.Levels,
+		Whales:     repos.Whales,
+	}
+}
+
+func (s *Scanner) provider() db.MarketDataProvider {
+	if s.Provider != nil {
+		return s.Provider
+	}
+	return db.GetProvider()
+}
+
+// ShouldScan reports whether the profile's next scan is due
+func (s *Scanner) ShouldScan(ctx context.Context, profileName string) (bool, error) {
+	scan, err := s.Watchlist.ScanLog(ctx, profileName)
 	if err != nil {
 		return false, err
 	}
-	nextDue := GetNextScanDue(scan.LastScanTimestamp, profileName, cfg)
+	nextDue := GetNextScanDue(scan.LastScan, profileName, s.Config)
 	if time.Now().After(nextDue) || time.Now().Equal(nextDue) {
 		return true, nil
 	}
@@ -28,82 +62,71 @@ func ShouldScan(ctx context.Context, profileName string, cfg *config.Config, q *
 }
 
 // PerformScan scans all watchlist symbols and updates scores
-func PerformScan(ctx context.Context, profileName string, cfg *config.Config, q *database.Queries) (int, error) {
-	watchlist, err := q.GetWatchlist(ctx)
+func (s *Scanner) PerformScan(ctx context.Context, profileName string) (int, error) {
+	watchlist, err := s.Watchlist.Active(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	scannedCount := 0
+	symbols := make([]string, len(watchlist))
+	prevScores := make(map[string]float64, len(watchlist))
+	for i, item := range watchlist {
+		symbols[i] = item.Symbol
+		prevScores[item.Symbol] = item.Score
+	}
+	evaluator, err := s.evaluator(profileName)
+	if err != nil {
+		return 0, err
+	}
+	prefetched, err := db.PrefetchBarsFrom(ctx, s.provider(), symbols, "1Day", 100, "")
+	if err != nil {
+		return 0, err
+	}
 
-	for _, item := range watchlist {
-		symbol := item.Symbol
+	scannedCount := 0
+	alerting := alerts.GetDispatcher() != nil
+	rules := alerts.DefaultRules(s.Config, profileName)
+	var since time.Time
+	if scan, err := s.Watchlist.ScanLog(ctx, profileName); err == nil {
+		since = scan.LastScan
+	}
 
-		bars, err := db.GetAlpacaBars(symbol, "1Day", 100, "")
+	for _, symbol := range symbols {
+		bars, err := prefetched.Get(ctx, symbol)
 		if err != nil {
 			// Log error but continue scanning other symbols
 			continue
 		}
-
-		// Calculate indicators
-		vwapCalc := strategy.NewVWAPCalculator(bars)
-		vwapPrice := vwapCalc.Calculate()
-
-		closes := make([]float64, len(bars))
-		for i, bar := range bars {
-			closes[i] = bar.Close
-		}
-		rsiValues, err := strategy.CalculateRSI(closes, 14)
+		series, err := types.NewBarSeries(bars)
 		if err != nil {
-			rsiValues = []float64{50}
-		}
-		rsiValue := rsiValues[len(rsiValues)-1]
-
-		if len(rsiValues) > 0 && len(bars) >= 14 {
-			startIdx := len(bars) - len(rsiValues)
-			for i, rsi := range rsiValues {
-				barIdx := startIdx + i
-				if barIdx >= 0 && barIdx < len(bars) {
-					timestamp, _ := time.Parse(time.RFC3339, bars[barIdx].Timestamp)
-					db.SaveRSI(symbol, timestamp, rsi)
-				}
-			}
+			logger.Component("scanner").Warn("invalid bars", logger.KeySymbol, symbol, logger.Err(err))
+			continue
 		}
 
-		atrValue := scoring.CalculateATRFromBars(bars)
-		atrCategory := scoring.CategorizeATRValue(atrValue, bars)
+		s.storeIndicators(ctx, symbol, series)
+		s.recordWhales(ctx, symbol, series)
 
-		if len(bars) > 0 {
-			latestTimestamp, _ := time.Parse(time.RFC3339, bars[len(bars)-1].Timestamp)
-			db.SaveATR(symbol, latestTimestamp, atrValue)
-		}
+		// the watchlist keeps the decision's 0-10 rating, the same verdict the screener and analysis give
+		_, decision := evaluator.Evaluate(ctx, symbol, "1Day", series)
+		score := decision.Rating
 
-		whaleEvents := strategy.DetectWhales("", bars)
-		whaleCount := len(whaleEvents)
-
-		scoringInput, err := scoring.BuildScoringInput(bars, vwapPrice, rsiValue, whaleCount, atrValue, atrCategory)
-		if err != nil {
+		if err := s.Watchlist.UpdateScore(ctx, symbol, score); err != nil {
 			continue
 		}
 
-		score := strategy.CalculateInterestScore(scoringInput)
-
-		err = q.UpdateWatchlistScore(ctx, database.UpdateWatchlistScoreParams{
-			Score:  float32(score),
-			Symbol: symbol,
-		})
-		if err != nil {
-			continue
+		if alerting {
+			s.checkAlerts(ctx, rules, symbol, series, score, prevScores[symbol], since)
 		}
 
 		scannedCount++
 	}
 
-	err = q.UpsertScanLog(ctx, database.UpsertScanLogParams{
-		ProfileName:       profileName,
-		LastScanTimestamp: time.Now(),
-		NextScanDue:       GetNextScanDue(time.Now(), profileName, cfg),
-		SymbolsScanned:    sql.NullInt32{Int32: int32(scannedCount), Valid: true},
+	now := time.Now()
+	err = s.Watchlist.RecordScan(ctx, repository.ScanLog{
+		Profile:        profileName,
+		LastScan:       now,
+		NextScanDue:    GetNextScanDue(now, profileName, s.Config),
+		SymbolsScanned: scannedCount,
 	})
 	if err != nil {
 		return 0, err
@@ -112,6 +135,85 @@ func PerformScan(ctx context.Context, profileName string, cfg *config.Config, q
 	return scannedCount, nil
 }
 
+// storeIndicators saves RSI, ATR, Bollinger values and support/resistance zones for a scanned symbol; failures only cost the history
+func (s *Scanner) storeIndicators(ctx context.Context, symbol string, series types.BarSeries) {
+	if series.Len() == 0 {
+		return
+	}
+	closes := series.Closes()
+	rsiValues, err := strategy.CalculateRSI(closes, 14)
+	if err == nil {
+		// the RSI values line up with the newest bars
+		startIdx := series.Len() - len(rsiValues)
+		for i, rsi := range rsiValues {
+			s.Indicators.SaveRSI(ctx, symbol, series.Time(startIdx+i), rsi)
+		}
+	}
+
+	atrValue := scoring.CalculateATRFromBars(series)
+	latestTimestamp := series.LatestTime()
+	s.Indicators.SaveATR(ctx, symbol, latestTimestamp, atrValue)
+
+	if bands, err := strategy.CalculateBollingerBands(closes, strategy.BollingerPeriod, strategy.BollingerMultiplier); err == nil {
+		band := bands[len(bands)-1]
+		s.Indicators.SaveBollinger(ctx, symbol, repository.BollingerPoint{
+			Date:   latestTimestamp,
+			Period: strategy.BollingerPeriod,
+			Middle: band.Middle,
+			StdDev: band.StdDev,
+			Upper:  band.Upper,
+			Lower:  band.Lower,
+			Close:  band.Close,
+		})
+	}
+
+	if s.Levels != nil {
+		strategy.StoreLevels(ctx, s.Levels, symbol, series)
+	}
+}
+
+// recordWhales stores the symbol's whale events so the scan's decision sees their track record
+func (s *Scanner) recordWhales(ctx context.Context, symbol string, series types.BarSeries) {
+	if s.Whales == nil {
+		return
+	}
+	if err := strategy.RecordWhales(ctx, s.Whales, symbol, series); err != nil {
+		logger.Component("scanner").Warn("whale events not saved", logger.KeySymbol, symbol, logger.Err(err))
+	}
+}
+
+// evaluator rates symbols with the profile's signal weights and the stored news and whale
+// history. A profile the config doesn't define, like the background scan's "default",
+// uses the active signal config.
+func (s *Scanner) evaluator(profileName string) (*strategy.Evaluator, error) {
+	evaluator := &strategy.Evaluator{News: s.News, Whales: s.Whales}
+	if s.Config == nil || s.Config.GetProfile(profileName) == nil {
+		return evaluator, nil
+	}
+	signals, err := strategy.SignalConfigFromProfile(s.Config, profileName)
+	if err != nil {
+		return nil, err
+	}
+	evaluator.Signals = signals
+	return evaluator, nil
+}
+
+// checkAlerts runs the alert rules over a freshly scored symbol
+func (s *Scanner) checkAlerts(ctx context.Context, rules []alerts.Rule, symbol string, series types.BarSeries, score, prevScore float64, since time.Time) {
+	snapshot := alerts.NewSnapshot(symbol, series)
+	snapshot.Score = score
+	snapshot.PrevScore = &prevScore
+	snapshot.Since = since
+	if s.News != nil {
+		if news, err := s.News.GetLatestNews(ctx, symbol, 10); err == nil {
+			snapshot.News = news
+		}
+	}
+	if err := alerts.Check(ctx, rules, snapshot); err != nil {
+		logger.Component("scanner").Warn("alert delivery failed", logger.KeySymbol, symbol, logger.Err(err))
+	}
+}
+
 func CalculateScanInterval(profileName string, cfg *config.Config) time.Duration {
 	profile, exists := cfg.Profiles[profileName]
 	if !exists {
@@ -125,8 +227,16 @@ func GetNextScanDue(lastScan time.Time, profileName string, cfg *config.Config)
 	return lastScan.Add(interval)
 }
 
-func PerformProfileScan(ctx context.Context, profileName string, minScore float64, offset int, batchSize int) ([]types.Candidate, int, error) {
-	symbols, err := strategy.GetTradableAssets()
+// PerformProfileScanConcurrent evaluates one batch of tradable assets on a worker pool with
+// the profile's signal weights. It stores nothing; News and Whales are only read.
+// Candidates are ranked by score, ties by symbol.
+func (s *Scanner) PerformProfileScanConcurrent(ctx context.Context, profileName string, minScore float64, offset int, batchSize int, pool utils.PoolConfig) ([]types.Candidate, int, error) {
+	evaluator, err := s.evaluator(profileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	provider := s.provider()
+	symbols, err := provider.GetTradableAssets(ctx)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to fetch tradeable assets: %v", err)
 	}
@@ -142,29 +252,45 @@ func PerformProfileScan(ctx context.Context, profileName string, minScore float6
 		return []types.Candidate{}, totalSymbols, nil
 	}
 
-	candidates := []types.Candidate{}
-
-	for i := offset; i < end; i++ {
-		symbol := symbols[i]
+	batch := symbols[offset:end]
+	prefetched, err := db.PrefetchBarsFrom(ctx, provider, batch, "1Day", 100, "")
+	if err != nil {
+		return nil, totalSymbols, err
+	}
 
-		bars, err := db.GetAlpacaBars(symbol, "1Day", 100, "")
+	evaluated, err := utils.ProcessSymbols(ctx, batch, pool, func(ctx context.Context, symbol string) (*types.Candidate, error) {
+		bars, err := prefetched.Get(ctx, symbol)
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		if len(bars) == 0 {
-			continue
+		series, err := types.NewBarSeries(bars)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bars for %s: %w", symbol, err)
 		}
+		if series.Len() == 0 {
+			return nil, fmt.Errorf("no bars for %s", symbol)
+		}
+		return analyzer.CalculateCandidateMetrics(ctx, evaluator, symbol, series)
+	})
+	if err != nil {
+		return nil, totalSymbols, err
+	}
 
-		candidate, err := analyzer.CalculateCandidateMetrics(ctx, symbol, bars)
-		if err != nil {
+	candidates := []types.Candidate{}
+	for _, r := range evaluated {
+		if r.Err != nil {
 			continue
 		}
-
-		if candidate.Score >= minScore {
-			candidates = append(candidates, *candidate)
+		if r.Value.Score >= minScore {
+			candidates = append(candidates, *r.Value)
 		}
 	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Symbol < candidates[j].Symbol
+	})
 
 	return candidates, totalSymbols, nil
 }
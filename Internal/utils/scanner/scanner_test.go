@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+// trendProvider returns limit daily bars of a steady uptrend, latest first
+type trendProvider struct {
+	assets []string
+}
+
+func (trendProvider) Name() string { return "trend" }
+
+func (trendProvider) GetBars(ctx context.Context, symbol, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+	if symbol == "FAIL" {
+		return nil, fmt.Errorf("no data for %s", symbol)
+	}
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	bars := make([]datafeed.Bar, limit)
+	for i := range bars {
+		price := 100 + float64(i)
+		bars[limit-1-i] = datafeed.Bar{
+			Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+			Open:      price - 0.5, High: price + 1, Low: price - 1, Close: price, Volume: 1000,
+		}
+	}
+	return bars, nil
+}
+
+func (trendProvider) GetLatestQuote(ctx context.Context, symbol string) (*datafeed.LastQuote, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (trendProvider) GetLatestTrade(ctx context.Context, symbol string) (*datafeed.LastTrade, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (p trendProvider) GetTradableAssets(ctx context.Context) ([]string, error) {
+	return p.assets, nil
+}
+
+func testConfig() *config.Config {
+	return &config.Config{Profiles: map[string]config.ProfileConfig{
+		"swing":    {ScanIntervalDays: 3, SignalWeights: strategy.DefaultSignalConfig().Weights},
+		"rsi_only": {ScanIntervalDays: 1, SignalWeights: config.SignalWeights{RSIWeight: 1}},
+	}}
+}
+
+func TestPerformScanUpdatesWatchlistAndIndicators(t *testing.T) {
+	ctx := context.Background()
+	mem := repository.NewMemory()
+	for _, symbol := range []string{"AAPL", "MSFT", "FAIL"} {
+		mem.Watchlist.Add(symbol, "stock", -1, "test")
+	}
+	s := New(testConfig(), mem.Repositories())
+	s.Provider = trendProvider{}
+
+	if _, err := s.ShouldScan(ctx, "swing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("ShouldScan() before the first scan = %v, want ErrNotFound", err)
+	}
+
+	scanned, err := s.PerformScan(ctx, "swing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scanned != 2 {
+		t.Errorf("PerformScan() scanned %d symbols, want 2 (FAIL has no bars)", scanned)
+	}
+
+	items, err := mem.Watchlist.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		rescored := item.Score != -1
+		if rescored != (item.Symbol != "FAIL") {
+			t.Errorf("%s score = %v after the scan", item.Symbol, item.Score)
+		}
+	}
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if rsi, _ := mem.Indicators.LatestRSI(ctx, symbol, 100); len(rsi) == 0 {
+			t.Errorf("no RSI stored for %s", symbol)
+		}
+		if atr, _ := mem.Indicators.LatestATR(ctx, symbol, 1); len(atr) != 1 {
+			t.Errorf("no ATR stored for %s", symbol)
+		}
+		if bands := mem.Indicators.Bollinger(symbol); len(bands) != 1 {
+			t.Errorf("expected one day of Bollinger bands for %s, got %d", symbol, len(bands))
+		}
+	}
+
+	log, err := mem.Watchlist.ScanLog(ctx, "swing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.SymbolsScanned != 2 || !log.NextScanDue.Equal(log.LastScan.Add(72*time.Hour)) {
+		t.Errorf("scan log = %+v, want 2 symbols and the next scan 3 days out", log)
+	}
+	if due, err := s.ShouldScan(ctx, "swing"); err != nil || due {
+		t.Errorf("ShouldScan() right after a scan = %v, %v", due, err)
+	}
+}
+
+func TestPerformProfileScanRanksBatch(t *testing.T) {
+	s := New(testConfig(), repository.NewMemory().Repositories())
+	s.Provider = trendProvider{assets: []string{"MSFT", "FAIL", "AAPL", "NVDA"}}
+
+	candidates, total, err := s.PerformProfileScanConcurrent(context.Background(), "swing", 0, 0, 3, utils.DefaultPoolConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 {
+		t.Errorf("total = %d, want 4", total)
+	}
+	// the first batch is MSFT, FAIL and AAPL; FAIL drops out and the equal scores tie-break by symbol
+	if len(candidates) != 2 || candidates[0].Symbol != "AAPL" || candidates[1].Symbol != "MSFT" {
+		t.Errorf("candidates = %+v, want AAPL then MSFT", candidates)
+	}
+}
+
+func TestPerformScanUsesProfileWeights(t *testing.T) {
+	ctx := context.Background()
+	mem := repository.NewMemory()
+	mem.Watchlist.Add("AAPL", "stock", -1, "test")
+	s := New(testConfig(), mem.Repositories())
+	s.Provider = trendProvider{}
+
+	score := func(profile string) float64 {
+		if _, err := s.PerformScan(ctx, profile); err != nil {
+			t.Fatal(err)
+		}
+		items, err := mem.Watchlist.Active(ctx)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("Active() = %+v, %v", items, err)
+		}
+		return items[0].Score
+	}
+
+	bars, _ := trendProvider{}.GetBars(ctx, "AAPL", "1Day", 100, "")
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals, err := strategy.SignalConfigFromProfile(testConfig(), "rsi_only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strategy.NewDecisionEngine(signals).Decide(strategy.BuildFeatures("AAPL", "1Day", series)).Rating
+
+	if got := score("rsi_only"); got != want {
+		t.Errorf("rsi_only score = %v, want the RSI-only rating %v", got, want)
+	}
+	if got := score("swing"); got == want {
+		t.Errorf("swing scored %v like rsi_only; the profile weights were ignored", got)
+	}
+}
@@ -5,12 +5,12 @@ import (
 	"github.com/fazecat/mongelmaker/Internal/utils"
 )
 
-func BuildScoringInput(bars []types.Bar, vwapPrice float64, rsiValue float64, whaleCount int, atrValue float64, atrCategory string) (types.ScoringInput, error) {
-	if len(bars) < 2 {
+func BuildScoringInput(series types.BarSeries, vwapPrice float64, rsiValue float64, whaleCount int, atrValue float64, atrCategory string) (types.ScoringInput, error) {
+	if series.Len() < 2 {
 		return types.ScoringInput{}, nil
 	}
 
-	currentBar := bars[len(bars)-1]
+	currentBar := series.Latest()
 	currentPrice := currentBar.Close
 	openPrice := currentBar.Open
 
@@ -30,16 +30,17 @@ func BuildScoringInput(bars []types.Bar, vwapPrice float64, rsiValue float64, wh
 	}, nil
 }
 
-func CalculateATRFromBars(bars []types.Bar) float64 {
-	if len(bars) < 2 {
+// CalculateATRFromBars averages the last 14 true ranges (fewer for a short series)
+func CalculateATRFromBars(series types.BarSeries) float64 {
+	if series.Len() < 2 {
 		return 0
 	}
 
-	trueRanges := make([]float64, len(bars))
-	for i := 1; i < len(bars); i++ {
-		high := bars[i].High
-		low := bars[i].Low
-		prevClose := bars[i-1].Close
+	trueRanges := make([]float64, series.Len())
+	for i := 1; i < series.Len(); i++ {
+		high := series.At(i).High
+		low := series.At(i).Low
+		prevClose := series.At(i - 1).Close
 		tr := utils.Max(high-low, utils.Abs(high-prevClose), utils.Abs(low-prevClose))
 		trueRanges[i] = tr
 	}
@@ -52,16 +53,30 @@ func CalculateATRFromBars(bars []types.Bar) float64 {
 	return utils.Average(trueRanges[len(trueRanges)-period:])
 }
 
-func CategorizeATRValue(currentATR float64, bars []types.Bar) string {
-	if len(bars) < 15 {
+func CategorizeATRValue(currentATR float64, series types.BarSeries) string {
+	if series.Len() < 15 {
 		return "NORMAL"
 	}
+	bars := series.Bars()
 
-	atrValues := make([]float64, 0)
-	for i := len(bars) - 14; i < len(bars); i++ {
-		subset := bars[:i+1]
-		atr := CalculateATRFromBars(subset)
-		if atr > 0 {
+	// ATR of each of the last 14 prefixes from one pass of true-range prefix sums,
+	// giving what CalculateATRFromBars(bars[:n]) would for each prefix length n
+	prefix := make([]float64, len(bars)+1)
+	for i := range bars {
+		tr := 0.0 // CalculateATRFromBars leaves the first true range at 0
+		if i > 0 {
+			tr = utils.Max(bars[i].High-bars[i].Low, utils.Abs(bars[i].High-bars[i-1].Close), utils.Abs(bars[i].Low-bars[i-1].Close))
+		}
+		prefix[i+1] = prefix[i] + tr
+	}
+
+	atrValues := make([]float64, 0, 14)
+	for n := len(bars) - 13; n <= len(bars); n++ {
+		period := 14
+		if n < period {
+			period = n - 1
+		}
+		if atr := (prefix[n] - prefix[n-period]) / float64(period); atr > 0 {
 			atrValues = append(atrValues, atr)
 		}
 	}
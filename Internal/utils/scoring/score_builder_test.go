@@ -0,0 +1,57 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/types"
+	"github.com/fazecat/mongelmaker/Internal/utils"
+)
+
+// categorizeByPrefixes is the original O(14n) CategorizeATRValue, kept as the reference
+func categorizeByPrefixes(currentATR float64, series types.BarSeries) string {
+	if series.Len() < 15 {
+		return "NORMAL"
+	}
+	atrValues := []float64{}
+	for i := series.Len() - 14; i < series.Len(); i++ {
+		if atr := CalculateATRFromBars(series.Slice(0, i+1)); atr > 0 {
+			atrValues = append(atrValues, atr)
+		}
+	}
+	if len(atrValues) == 0 {
+		return "NORMAL"
+	}
+	avgATR := utils.Average(atrValues)
+	if currentATR < avgATR*0.5 {
+		return "LOW"
+	} else if currentATR > avgATR*1.5 {
+		return "HIGH"
+	}
+	return "NORMAL"
+}
+
+func TestCategorizeATRValueMatchesPrefixRecompute(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, n := range []int{15, 20, 27, 28, 60} {
+		bars := make([]types.Bar, n)
+		for i := range bars {
+			price := 100 + 10*math.Sin(float64(i)*0.4)
+			spread := 1 + float64(i%5)
+			bars[i] = types.Bar{Timestamp: start.AddDate(0, 0, i).Format(time.RFC3339),
+				Open: price, High: price + spread, Low: price - spread, Close: price + 0.5}
+		}
+		series, err := types.NewBarSeries(bars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		avg := CalculateATRFromBars(series)
+		for _, current := range []float64{0, avg * 0.3, avg, avg * 2, avg * 10} {
+			got, want := CategorizeATRValue(current, series), categorizeByPrefixes(current, series)
+			if got != want {
+				t.Errorf("%d bars, ATR %.2f: got %s, want %s", n, current, got, want)
+			}
+		}
+	}
+}
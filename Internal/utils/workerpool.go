@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fazecat/mongelmaker/Internal/utils/config"
+)
+
+type PoolConfig struct {
+	Workers  int                   // concurrent symbols (default 8)
+	Timeout  time.Duration         // per symbol, 0 means no limit
+	Progress func(done, total int) // called after every symbol, from one goroutine at a time
+}
+
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Workers: 8,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// ProgressLine reports progress as "<label> done/total symbols", rewriting one line of w
+func ProgressLine(w io.Writer, label string) func(done, total int) {
+	return func(done, total int) {
+		fmt.Fprintf(w, "\r%s %d/%d symbols", label, done, total)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// PoolConfigFromConfig sizes the pool from the screener section, keeping defaults for unset values
+func PoolConfigFromConfig(cfg *config.Config) PoolConfig {
+	pool := DefaultPoolConfig()
+	if cfg == nil {
+		return pool
+	}
+	if cfg.Screener.Workers > 0 {
+		pool.Workers = cfg.Screener.Workers
+	}
+	if cfg.Screener.SymbolTimeoutSeconds > 0 {
+		pool.Timeout = time.Duration(cfg.Screener.SymbolTimeoutSeconds) * time.Second
+	}
+	return pool
+}
+
+// SymbolResult is the outcome of one symbol; results keep the input order
+type SymbolResult[T any] struct {
+	Symbol string
+	Value  T
+	Err    error
+}
+
+// ProcessSymbols runs fn over symbols on a bounded worker pool. Results come back in
+// the same order as symbols no matter which finished first. If ctx is cancelled the
+// remaining symbols are skipped and ctx.Err() is returned with whatever completed.
+func ProcessSymbols[T any](ctx context.Context, symbols []string, cfg PoolConfig, fn func(ctx context.Context, symbol string) (T, error)) ([]SymbolResult[T], error) {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = DefaultPoolConfig().Workers
+	}
+	if workers > len(symbols) {
+		workers = len(symbols)
+	}
+
+	results := make([]SymbolResult[T], len(symbols))
+	ran := make([]bool, len(symbols))
+	jobs := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		progress sync.Mutex
+		done     int
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				value, err := runSymbol(ctx, cfg.Timeout, symbols[i], fn)
+				results[i] = SymbolResult[T]{Symbol: symbols[i], Value: value, Err: err}
+				ran[i] = true
+
+				if cfg.Progress != nil {
+					progress.Lock()
+					done++
+					cfg.Progress(done, len(symbols))
+					progress.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range symbols {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		// drop the symbols that never started
+		finished := []SymbolResult[T]{}
+		for i, r := range results {
+			if ran[i] {
+				finished = append(finished, r)
+			}
+		}
+		return finished, err
+	}
+	return results, nil
+}
+
+func runSymbol[T any](ctx context.Context, timeout time.Duration, symbol string, fn func(ctx context.Context, symbol string) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx, symbol)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx, symbol)
+}
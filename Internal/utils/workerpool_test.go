@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProcessSymbolsKeepsInputOrder(t *testing.T) {
+	symbols := []string{"AAPL", "MSFT", "NVDA", "TSLA", "AMD", "META"}
+	var inFlight, peak int32
+
+	var progressCalls int
+	pool := PoolConfig{Workers: 3, Progress: func(done, total int) { progressCalls++ }}
+
+	results, err := ProcessSymbols(context.Background(), symbols, pool, func(ctx context.Context, symbol string) (int, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// later symbols finish first
+		time.Sleep(time.Duration(10-len(symbol)) * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		if symbol == "AMD" {
+			return 0, fmt.Errorf("no data")
+		}
+		return len(symbol), nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessSymbols() error = %v", err)
+	}
+
+	for i, r := range results {
+		if r.Symbol != symbols[i] {
+			t.Errorf("result %d is %s, want %s", i, r.Symbol, symbols[i])
+		}
+	}
+	if results[4].Err == nil || results[0].Value != 4 {
+		t.Errorf("per-symbol values/errors not kept: %+v", results)
+	}
+	if peak > 3 {
+		t.Errorf("ran %d symbols at once with 3 workers", peak)
+	}
+	if progressCalls != len(symbols) {
+		t.Errorf("progress called %d times, want %d", progressCalls, len(symbols))
+	}
+}
+
+func TestProcessSymbolsTimeoutAndCancel(t *testing.T) {
+	slow := func(ctx context.Context, symbol string) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(time.Second):
+			return true, nil
+		}
+	}
+
+	results, err := ProcessSymbols(context.Background(), []string{"A", "B"}, PoolConfig{Workers: 2, Timeout: 10 * time.Millisecond}, slow)
+	if err != nil {
+		t.Fatalf("timeouts are per symbol, not fatal: %v", err)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected deadline exceeded, got %v", r.Symbol, r.Err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = ProcessSymbols(ctx, []string{"A", "B", "C"}, PoolConfig{Workers: 1}, slow)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(results) > 1 {
+		t.Errorf("cancelled run should skip unstarted symbols, got %d results", len(results))
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := NewRateLimiter(2, 2) // 2 per second, burst of 2
+	r.now = func() time.Time { return now }
+
+	if r.reserve() != 0 || r.reserve() != 0 {
+		t.Fatal("burst tokens should be available immediately")
+	}
+	if wait := r.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("empty bucket should wait 500ms, got %v", wait)
+	}
+
+	now = now.Add(time.Second)
+	if r.reserve() != 0 {
+		t.Error("bucket should have refilled after a second")
+	}
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	r := NewRateLimiter(0.001, 1)
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("first token should be free: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	var unlimited *RateLimiter
+	if err := unlimited.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter should never block: %v", err)
+	}
+}
@@ -3,10 +3,12 @@ package interactive
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/fazecat/mongelmaker/Internal/backtest"
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
-	sqlc "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
 	"github.com/fazecat/mongelmaker/Internal/export"
 	"github.com/fazecat/mongelmaker/Internal/strategy"
 	"github.com/fazecat/mongelmaker/Internal/types"
@@ -15,25 +17,30 @@ import (
 	"github.com/fazecat/mongelmaker/Internal/utils/scoring"
 )
 
-func FetchMarketData(symbol string, timeframe string, limit int, startDate string) ([]datafeed.Bar, error) {
+func FetchMarketData(symbol string, timeframe string, limit int, startDate string) (types.BarSeries, error) {
 	if timeframe == "" {
-		return nil, fmt.Errorf("timeframe cannot be empty")
+		return types.BarSeries{}, fmt.Errorf("timeframe cannot be empty")
 	}
 
 	if limit < 14 {
 		limit = 14
 	}
 
-	bars, err := datafeed.GetAlpacaBars(symbol, timeframe, limit, startDate)
+	bars, err := datafeed.GetBars(context.Background(), symbol, timeframe, limit, startDate)
 	if err != nil {
-		return nil, err
+		return types.BarSeries{}, err
+	}
+
+	series, err := types.NewBarSeries(bars)
+	if err != nil {
+		return types.BarSeries{}, fmt.Errorf("invalid bars for %s: %w", symbol, err)
 	}
 
-	if len(bars) < 14 {
-		return nil, fmt.Errorf("not enough data to calculate RSI/ATR: fetched %d bars, need at least 14", len(bars))
+	if series.Len() < 14 {
+		return types.BarSeries{}, fmt.Errorf("not enough data to calculate RSI/ATR: fetched %d bars, need at least 14", series.Len())
 	}
 
-	return bars, nil
+	return series, nil
 }
 
 func ShowMainMenu() (string, error) {
@@ -84,41 +91,35 @@ func PickStockFromResults(results []strategy.StockScore) (string, error) {
 	return results[choice-1].Symbol, nil
 }
 
-func DisplayBasicData(bars []datafeed.Bar, symbol string, timeframe string) {
+func DisplayBasicData(series types.BarSeries, symbol string, timeframe string) {
 	fmt.Printf("\n📊 Basic Data for %s (%s)\n", symbol, timeframe)
 	fmt.Println("Timestamp           | Close Price | Volume")
 	fmt.Println("--------------------|-------------|----------")
 
-	for _, bar := range bars {
+	for _, bar := range series.LatestFirst() {
 		fmt.Printf("%-20s | %11.2f | %8d\n", bar.Timestamp, bar.Close, bar.Volume)
 
 	}
 }
 
-func DisplayAdvancedData(bars []datafeed.Bar, symbol string, timeframe string) {
+func DisplayAdvancedData(series types.BarSeries, symbol string, timeframe string) {
 	fmt.Printf("\n📊 Advanced Data for %s (%s)\n", symbol, timeframe)
 	fmt.Println("Timestamp           | Open Price | High Price | Low Price | Close Price | Volume")
 	fmt.Println("--------------------|------------|------------|-----------|-------------|----------")
 
-	for _, bar := range bars {
+	for _, bar := range series.LatestFirst() {
 		fmt.Printf("%-20s | %11.2f | %11.2f | %9.2f | %11.2f | %8d\n",
 			bar.Timestamp, bar.Open, bar.High, bar.Low, bar.Close, bar.Volume)
 	}
 }
 
-func DisplayAnalyticsData(bars []datafeed.Bar, symbol string, timeframe string, tz *time.Location, queries *sqlc.Queries) {
+func DisplayAnalyticsData(series types.BarSeries, symbol string, timeframe string, tz *time.Location, repos repository.Repositories) {
 	fmt.Printf("\n📈 Analytics Data for %s (%s) - Timezone: %s\n", symbol, timeframe, tz.String())
 
 	var startTime, endTime time.Time
-	if len(bars) > 0 {
-		firstBar, err := time.Parse(time.RFC3339, bars[0].Timestamp)
-		if err == nil {
-			startTime = firstBar
-		}
-		lastBar, err := time.Parse(time.RFC3339, bars[len(bars)-1].Timestamp)
-		if err == nil {
-			endTime = lastBar
-		}
+	if series.Len() > 0 {
+		startTime = series.Time(0)
+		endTime = series.LatestTime()
 	}
 
 	var rsiMap map[string]float64
@@ -126,13 +127,13 @@ func DisplayAnalyticsData(bars []datafeed.Bar, symbol string, timeframe string,
 	var err error
 
 	// Try to fetch from database first
-	if !startTime.IsZero() && !endTime.IsZero() {
-		rsiMap, err = datafeed.FetchRSIByTimestampRange(symbol, startTime, endTime)
+	if repos.Indicators != nil && !startTime.IsZero() && !endTime.IsZero() {
+		rsiMap, err = datafeed.FetchRSIByTimestampRange(context.Background(), repos.Indicators, symbol, startTime, endTime)
 		if err != nil {
 			rsiMap = make(map[string]float64)
 		}
 
-		atrMap, err = datafeed.FetchATRByTimestampRange(symbol, startTime, endTime)
+		atrMap, err = datafeed.FetchATRByTimestampRange(context.Background(), repos.Indicators, symbol, startTime, endTime)
 		if err != nil {
 			atrMap = make(map[string]float64)
 		}
@@ -142,46 +143,30 @@ func DisplayAnalyticsData(bars []datafeed.Bar, symbol string, timeframe string,
 	}
 
 	// If database is empty or has insufficient data, calculate from bars
-	if len(rsiMap) == 0 && len(bars) >= 14 {
-		closes := make([]float64, len(bars))
-		for i, bar := range bars {
-			closes[i] = bar.Close
-		}
-
-		rsiValues, err := strategy.CalculateRSI(closes, 14)
+	if len(rsiMap) == 0 && series.Len() >= 14 {
+		rsiValues, err := strategy.CalculateRSI(series.Closes(), 14)
 		if err == nil && len(rsiValues) > 0 {
-			// Map RSI values to timestamps
-			startIdx := len(bars) - len(rsiValues)
+			// RSI values line up with the newest bars
+			startIdx := series.Len() - len(rsiValues)
 			for i, rsi := range rsiValues {
-				barIdx := startIdx + i
-				if barIdx >= 0 && barIdx < len(bars) {
-					t, _ := time.Parse(time.RFC3339, bars[barIdx].Timestamp)
-					timestampStr := t.Format("2006-01-02 15:04:05")
-					rsiMap[timestampStr] = rsi
-				}
+				rsiMap[series.Time(startIdx+i).Format("2006-01-02 15:04:05")] = rsi
 			}
 		}
 	}
 
 	// Calculate ATR from bars if not in database
-	if len(atrMap) == 0 && len(bars) >= 14 {
-		atrValue := scoring.CalculateATRFromBars(bars)
+	if len(atrMap) == 0 && series.Len() >= 14 {
+		atrValue := scoring.CalculateATRFromBars(series)
 		// Store same ATR for all recent bars (ATR is calculated for the full period)
-		for _, bar := range bars {
-			t, _ := time.Parse(time.RFC3339, bar.Timestamp)
-			timestampStr := t.Format("2006-01-02 15:04:05")
-			atrMap[timestampStr] = atrValue
+		for i := 0; i < series.Len(); i++ {
+			atrMap[series.Time(i).Format("2006-01-02 15:04:05")] = atrValue
 		}
 	}
 
 	fmt.Println("Timestamp           | Close Price | Price Chg | Chg %  | Volume   | RSI    | ATR    | B/U Ratio | B/L Ratio | Analysis                  | Signals             ")
 	fmt.Println("--------------------|-------------|-----------|--------|----------|--------|--------|-----------|-----------|--------------------------|---------------------")
 
-	var latestAnalysis string
-	var latestRSI *float64
-	var latestATR *float64
-
-	for i, bar := range bars {
+	for _, bar := range series.LatestFirst() {
 		priceChange := bar.Close - bar.Open
 		priceChangePercent := (bar.Close - bar.Open) / bar.Open * 100
 
@@ -226,18 +211,6 @@ func DisplayAnalyticsData(bars []datafeed.Bar, symbol string, timeframe string,
 		bodyToLowerStr := fmt.Sprintf("%9.2f", metrics["BodyToLower"])
 		analysisStr := results["Analysis"]
 
-		if i == 0 {
-			latestAnalysis = analysisStr
-			if hasRSI {
-				val := rsiVal
-				latestRSI = &val
-			}
-			if hasATR {
-				val := atrVal
-				latestATR = &val
-			}
-		}
-
 		signalStr := ""
 
 		if hasRSI {
@@ -306,54 +279,186 @@ func DisplayAnalyticsData(bars []datafeed.Bar, symbol string, timeframe string,
 			displayTimestamp, bar.Close, priceChange, priceChangePercent, bar.Volume, rsiStr, atrStr, bodyToUpperStr, bodyToLowerStr, analysisStr, signalStr)
 	}
 
+	trend := computeTrendIndicators(series)
+	displayTrendIndicators(trend)
+
+	displayConfluence(symbol)
+
 	// Display final signal recommendation (before whale events)
-	displayFinalSignal(bars, symbol, latestAnalysis, latestRSI, latestATR)
+	displayFinalSignal(series, symbol, timeframe, repos)
 
 	// Display whale events if database available
-	if queries != nil {
+	if repos.Whales != nil {
 		fmt.Println()
-		displayWhaleEventsInline(symbol, queries)
+		displayWhaleEventsInline(symbol, repos.Whales)
 	}
 
 	// Display support/resistance levels
-	displaySupportResistance(bars)
+	displaySupportResistance(series)
+}
+
+// trendIndicators holds MACD and Bollinger Bands over a series. Both are computed
+// oldest first, while the tables list bars latest first, so at() maps a display index back.
+type trendIndicators struct {
+	n         int
+	macd      *strategy.MACDResult
+	bollinger []strategy.BollingerBand // starts at chronological index BollingerPeriod-1
 }
 
-func displayFinalSignal(bars []datafeed.Bar, symbol string, analysis string, rsi, atr *float64) {
-	if len(bars) == 0 {
+func computeTrendIndicators(series types.BarSeries) trendIndicators {
+	closes := series.Closes()
+
+	trend := trendIndicators{n: series.Len()}
+	if macd, err := strategy.CalculateMACD(closes, strategy.MACDFast, strategy.MACDSlow, strategy.MACDSignal); err == nil {
+		trend.macd = &macd
+	}
+	if bands, err := strategy.CalculateBollingerBands(closes, strategy.BollingerPeriod, strategy.BollingerMultiplier); err == nil {
+		trend.bollinger = bands
+	}
+	return trend
+}
+
+// macdAt returns the MACD values for the i-th latest-first bar, if defined there
+func (t trendIndicators) macdAt(i int) (macd, signal, histogram float64, ok bool) {
+	idx := t.n - 1 - i
+	if t.macd == nil || idx < t.macd.Start {
+		return 0, 0, 0, false
+	}
+	return t.macd.MACD[idx], t.macd.Signal[idx], t.macd.Histogram[idx], true
+}
+
+// bollingerAt returns the bands for the i-th latest-first bar, or nil before the period is filled
+func (t trendIndicators) bollingerAt(i int) *strategy.BollingerBand {
+	idx := t.n - 1 - i - (strategy.BollingerPeriod - 1)
+	if idx < 0 || idx >= len(t.bollinger) {
+		return nil
+	}
+	return &t.bollinger[idx]
+}
+
+func displayTrendIndicators(trend trendIndicators) {
+	macd, signal, histogram, hasMACD := trend.macdAt(0)
+	band := trend.bollingerAt(0)
+	if !hasMACD && band == nil {
 		return
 	}
 
-	signal := strategy.CalculateSignal(rsi, atr, bars, symbol, analysis)
+	fmt.Println()
+	fmt.Println("📉 TREND INDICATORS:")
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+	if hasMACD {
+		direction := "🟢 bullish"
+		if histogram < 0 {
+			direction = "🔴 bearish"
+		}
+		fmt.Printf("MACD (%d,%d,%d):    %.4f  Signal: %.4f  Histogram: %+.4f  %s\n",
+			strategy.MACDFast, strategy.MACDSlow, strategy.MACDSignal, macd, signal, histogram, direction)
+	} else {
+		fmt.Printf("MACD (%d,%d,%d):    not enough data\n", strategy.MACDFast, strategy.MACDSlow, strategy.MACDSignal)
+	}
+	if band != nil {
+		fmt.Printf("Bollinger (%d, %.0fσ): Upper $%.2f  Middle $%.2f  Lower $%.2f\n",
+			strategy.BollingerPeriod, strategy.BollingerMultiplier, band.Upper, band.Middle, band.Lower)
+		fmt.Printf("                    %%B: %.2f  Bandwidth: %.2f%%  (%s)\n",
+			band.PercentB, band.Bandwidth*100, strategy.DetermineBollingerSignal(band.PercentB))
+	} else {
+		fmt.Printf("Bollinger (%d, %.0fσ): not enough data\n", strategy.BollingerPeriod, strategy.BollingerMultiplier)
+	}
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+}
+
+func displayConfluence(symbol string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
+	confluence, err := strategy.FetchConfluence(ctx, nil, symbol)
 	fmt.Println()
+	if err != nil {
+		fmt.Printf("⏱️  Multi-timeframe confluence unavailable: %v\n", err)
+		return
+	}
+
+	fmt.Println("⏱️  MULTI-TIMEFRAME CONFLUENCE:")
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+	fmt.Println("Timeframe | Close      | RSI    | VWAP  | Level      | Pattern              | Score | Bias")
+	fmt.Println("----------|------------|--------|-------|------------|----------------------|-------|---------")
+	for _, r := range confluence.Readings {
+		rsiStr := "  -   "
+		if r.RSI != nil {
+			rsiStr = fmt.Sprintf("%6.2f", *r.RSI)
+		}
+		vwapStr := "at"
+		if r.VWAPTrend > 0 {
+			vwapStr = "above"
+		} else if r.VWAPTrend < 0 {
+			vwapStr = "below"
+		}
+		level := r.Level
+		if level == "" {
+			level = "-"
+		}
+		fmt.Printf("%-9s | %10.2f | %6s | %-5s | %-10s | %-20s | %+5.2f | %s\n",
+			r.Timeframe, r.Close, rsiStr, vwapStr, level, r.Pattern, r.Score, r.Bias)
+	}
+	if len(confluence.Missing) > 0 {
+		fmt.Printf("Not loaded: %s\n", strings.Join(confluence.Missing, ", "))
+	}
+	for _, agreement := range confluence.Agreements {
+		fmt.Printf("  🟢 %s\n", agreement)
+	}
+	for _, conflict := range confluence.Conflicts {
+		fmt.Printf("  ⚠️  %s\n", conflict)
+	}
+	fmt.Printf("Overall: %s\n", strategy.FormatConfluence(confluence))
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+}
 
-	recommendationStr := strategy.FormatSignal(signal)
-	fmt.Printf("🎯 FINAL RECOMMENDATION: %s\n", recommendationStr)
+func displayFinalSignal(series types.BarSeries, symbol, timeframe string, repos repository.Repositories) {
+	if series.Len() == 0 {
+		return
+	}
 
-	fmt.Printf("Reason: %s\n", signal.Reasoning)
+	evaluator := &strategy.Evaluator{News: repos.News, Whales: repos.Whales}
+	_, decision := evaluator.Evaluate(context.Background(), symbol, timeframe, series)
+
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+
+	fmt.Printf("🎯 FINAL RECOMMENDATION: %s\n", strategy.FormatDecision(decision))
+
+	fmt.Printf("Reason: %s\n", decision.Signal.Reasoning)
 	fmt.Println("\nSignal Breakdown:")
-	for _, component := range signal.Components {
-		emoji := "🟢"
-		if component.Score < 0 {
-			emoji = "🔴"
+	for _, group := range decision.Explanation.Factors {
+		for _, factor := range group.Factors {
+			emoji := "🟢"
+			if factor.Score < 0 {
+				emoji = "🔴"
+			}
+			fmt.Printf("  %s %-20s %+.1f (weight: %.0f%%)  %s\n",
+				emoji,
+				factor.Name,
+				factor.Score,
+				factor.Weight*100,
+				factor.Detail)
 		}
-		fmt.Printf("  %s %-20s %+.1f (weight: %.0f%%)\n",
-			emoji,
-			component.Name,
-			component.Score,
-			component.Weight*100)
 	}
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
 }
 
-func displayWhaleEventsInline(symbol string, queries *sqlc.Queries) {
+// formatChange prints a follow-through percentage, "-" until it is known
+func formatChange(change *float64) string {
+	if change == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", *change)
+}
+
+func displayWhaleEventsInline(symbol string, whaleRepo repository.WhaleRepository) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Fetch recent whale events
-	whales, err := datafeed.GetRecentWhales(ctx, queries, symbol, 10)
+	whales, err := whaleRepo.RecentWhales(ctx, symbol, 10)
 	if err != nil {
 		fmt.Printf("⚠️  Could not fetch whale events: %v\n", err)
 		return
@@ -366,7 +471,7 @@ func displayWhaleEventsInline(symbol string, queries *sqlc.Queries) {
 
 	fmt.Println("🐋 WHALE ACTIVITY DETECTED:")
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
-	fmt.Println("Timestamp            | Direction | Z-Score | Volume (M)  | Price    | Conviction")
+	fmt.Println("Timestamp            | Direction | Z-Score | Volume (M)  | Price    | +1 bar  | +5 bars | +20 bars | Held | Conviction")
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
 
 	for _, whale := range whales {
@@ -389,34 +494,47 @@ func displayWhaleEventsInline(symbol string, queries *sqlc.Queries) {
 			convictionStr = "⚠️  MEDIUM"
 		}
 
-		fmt.Printf("%s | %s %-7s | %7s | %10.1f | %8s | %s\n",
+		heldStr := " -  "
+		if whale.Held != nil {
+			heldStr = "❌  "
+			if *whale.Held {
+				heldStr = "✅  "
+			}
+		}
+
+		fmt.Printf("%s | %s %-7s | %7.2f | %10.1f | %8.2f | %7s | %7s | %8s | %s | %s\n",
 			tsStr,
 			emoji,
 			whale.Direction,
 			whale.ZScore,
 			volM,
 			whale.ClosePrice,
+			formatChange(whale.Change1),
+			formatChange(whale.Change5),
+			formatChange(whale.Change20),
+			heldStr,
 			convictionStr,
 		)
 	}
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
 }
 
-func displaySupportResistance(bars []datafeed.Bar) {
-	if len(bars) == 0 {
+func displaySupportResistance(series types.BarSeries) {
+	if series.Len() == 0 {
 		return
 	}
 
-	support := strategy.FindSupport(bars)
-	resistance := strategy.FindResistance(bars)
-	pivot := strategy.FindPivotPoint(bars)
-	currentPrice := bars[0].Close
+	levels := strategy.AnalyzeLevels(series)
+	support := levels.Support.Price
+	resistance := levels.Resistance.Price
+	pivot := strategy.FindPivotPoint(series)
+	currentPrice := series.Latest().Close
 
 	distanceToSupport := strategy.DistanceToSupport(currentPrice, support)
 	distanceToResistance := strategy.DistanceToResistance(currentPrice, resistance)
 
-	isAtSupportLevel := strategy.IsAtSupport(currentPrice, support)
-	isAtResistanceLevel := strategy.IsAtResistance(currentPrice, resistance)
+	isAtSupportLevel := levels.Support.Near(currentPrice)
+	isAtResistanceLevel := levels.Resistance.Near(currentPrice)
 	isBreakoutUp := strategy.IsBreakoutAboveResistance(currentPrice, resistance)
 	isBreakoutDown := strategy.IsBreakoutBelowSupport(currentPrice, support)
 
@@ -441,6 +559,23 @@ func displaySupportResistance(bars []datafeed.Bar) {
 	fmt.Println()
 
 	fmt.Printf("Pivot Point:    $%.2f\n", pivot)
+
+	if len(levels.Zones) > 0 {
+		fmt.Println()
+		fmt.Printf("%-12s %-20s %-8s %-9s %-12s %s\n", "Type", "Zone", "Touches", "Strength", "Last Touch", "")
+		for i := len(levels.Zones) - 1; i >= 0; i-- {
+			zone := levels.Zones[i]
+			note := ""
+			if zone.Flipped {
+				note = "🔄 role reversal"
+			}
+			if zone == levels.Support || zone == levels.Resistance {
+				note = strings.TrimSpace("◀ nearest " + note)
+			}
+			fmt.Printf("%-12s $%-8.2f-$%-9.2f %-8d %-9.2f %-12s %s\n", zone.Type, zone.Low, zone.High,
+				zone.Touches, zone.Strength, zone.LastTouch.Format("2006-01-02"), note)
+		}
+	}
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
 }
 
@@ -573,32 +708,31 @@ func ShowTimezoneMenu() (*time.Location, error) {
 	}
 }
 
-func PrepareExportData(bars []datafeed.Bar, symbol string, timezone *time.Location) []export.ExportRecord {
+func PrepareExportData(series types.BarSeries, symbol string, timezone *time.Location, indicators repository.IndicatorRepository) []export.ExportRecord {
 	var records []export.ExportRecord
+	ctx := context.Background()
 
 	var rsiMap map[string]float64
 	var atrMap map[string]float64
 
 	var startTime, endTime time.Time
-	if len(bars) > 0 {
-		if t, err := time.Parse(time.RFC3339, bars[0].Timestamp); err == nil {
-			startTime = t
-		}
-		if t, err := time.Parse(time.RFC3339, bars[len(bars)-1].Timestamp); err == nil {
-			endTime = t
-		}
+	if series.Len() > 0 {
+		startTime = series.Time(0)
+		endTime = series.LatestTime()
 	}
 
 	if !startTime.IsZero() && !endTime.IsZero() {
-		rsiMap, _ = datafeed.FetchRSIByTimestampRange(symbol, startTime, endTime)
-		atrMap, _ = datafeed.FetchATRByTimestampRange(symbol, startTime, endTime)
+		rsiMap, _ = datafeed.FetchRSIByTimestampRange(ctx, indicators, symbol, startTime, endTime)
+		atrMap, _ = datafeed.FetchATRByTimestampRange(ctx, indicators, symbol, startTime, endTime)
 	} else {
-		fetchLimit := len(bars) * 10
-		rsiMap, _ = datafeed.FetchRSIForDisplay(symbol, fetchLimit)
-		atrMap, _ = datafeed.FetchATRForDisplay(symbol, fetchLimit)
+		fetchLimit := series.Len() * 10
+		rsiMap, _ = datafeed.FetchRSIForDisplay(ctx, indicators, symbol, fetchLimit)
+		atrMap, _ = datafeed.FetchATRForDisplay(ctx, indicators, symbol, fetchLimit)
 	}
 
-	for _, bar := range bars {
+	trend := computeTrendIndicators(series)
+
+	for i, bar := range series.LatestFirst() {
 		t, _ := time.Parse(time.RFC3339, bar.Timestamp)
 		timestampStr := t.In(timezone).Format("2006-01-02 15:04:05")
 
@@ -664,29 +798,34 @@ func PrepareExportData(bars []datafeed.Bar, symbol string, timezone *time.Locati
 			Analysis:  analysis,
 			Signals:   signals,
 		}
+		if macd, signal, histogram, ok := trend.macdAt(i); ok {
+			record.MACD, record.MACDSignal, record.MACDHistogram = &macd, &signal, &histogram
+		}
+		if band := trend.bollingerAt(i); band != nil {
+			record.BollingerUpper = &band.Upper
+			record.BollingerMiddle = &band.Middle
+			record.BollingerLower = &band.Lower
+			record.BollingerPercentB = &band.PercentB
+			record.BollingerBandwidth = &band.Bandwidth
+		}
 		records = append(records, record)
 	}
 
 	return records
 }
 
-func DisplayVWAPAnalysis(bars []datafeed.Bar, symbol string, timeframe string) {
-	if len(bars) == 0 {
+func DisplayVWAPAnalysis(series types.BarSeries, symbol string, timeframe string) {
+	if series.Len() == 0 {
 		fmt.Printf("⚠️  No data available for %s\n", symbol)
 		return
 	}
 
-	if len(bars) < 3 {
+	if series.Len() < 3 {
 		fmt.Printf("⚠️  Need at least 3 bars for complete vWAP analysis\n")
 		return
 	}
 
-	typesBars := make([]types.Bar, len(bars))
-	for i := range bars {
-		typesBars[i] = types.Bar(bars[i])
-	}
-
-	vwapCalc := strategy.NewVWAPCalculator(typesBars)
+	vwapCalc := strategy.NewVWAPCalculator(series)
 	analysis := vwapCalc.AnalyzeVWAP(1.0)
 
 	fmt.Printf("\n💰 vWAP (Volume Weighted Average Price) Analysis for %s (%s)\n", symbol, timeframe)
@@ -710,7 +849,8 @@ func DisplayVWAPAnalysis(bars []datafeed.Bar, symbol string, timeframe string) {
 	fmt.Println("Timestamp           | Close Price | vWAP       | Distance % | Trend")
 	fmt.Println("--------------------|-------------|------------|------------|---------")
 
-	for i, bar := range bars {
+	for i := series.Len() - 1; i >= 0; i-- {
+		bar := series.At(i)
 		vwap := vwapCalc.CalculateAt(i)
 		distance := ((bar.Close - vwap) / vwap) * 100
 		trend := "---"
@@ -778,3 +918,38 @@ func DisplayVWAPAnalysis(bars []datafeed.Bar, symbol string, timeframe string) {
 
 	fmt.Println("\n==========================================")
 }
+
+func DisplayBacktestResult(result *backtest.Result) {
+	if result == nil {
+		return
+	}
+
+	fmt.Printf("\n🧪 Backtest Results for %s (%s)\n", result.Symbol, result.Timeframe)
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+	fmt.Printf("Start Equity:   $%.2f\n", result.StartEquity)
+	fmt.Printf("End Equity:     $%.2f\n", result.EndEquity)
+	fmt.Printf("Total Return:   %+.2f%%\n", result.TotalReturnPct)
+	fmt.Printf("Trades:         %d (%d wins / %d losses)\n", result.NumTrades, result.Wins, result.Losses)
+	fmt.Printf("Win Rate:       %.1f%%\n", result.WinRate)
+	fmt.Printf("Max Drawdown:   %.2f%%\n", result.MaxDrawdownPct)
+	fmt.Printf("Sharpe Ratio:   %.2f\n", result.Sharpe)
+	fmt.Println("═══════════════════════════════════════════════════════════════════════════════════")
+
+	if len(result.Trades) == 0 {
+		fmt.Println("📭 No trades were triggered")
+		return
+	}
+
+	fmt.Println("\n📒 TRADE LOG:")
+	fmt.Println("#  | Entry Time           | Entry    | Exit Time            | Exit     | Qty    | P&L        | Return  | Bars | Entry/Exit Signal")
+	fmt.Println("---|----------------------|----------|----------------------|----------|--------|------------|---------|------|-------------------")
+	for i, trade := range result.Trades {
+		emoji := "🟢"
+		if trade.PnL <= 0 {
+			emoji = "🔴"
+		}
+		fmt.Printf("%2d | %-20s | %8.2f | %-20s | %8.2f | %6d | %s %8.2f | %+6.2f%% | %4d | %s → %s\n",
+			i+1, trade.EntryTime, trade.EntryPrice, trade.ExitTime, trade.ExitPrice,
+			trade.Quantity, emoji, trade.PnL, trade.ReturnPct, trade.BarsHeld, trade.EntrySignal, trade.ExitSignal)
+	}
+}
@@ -3,77 +3,128 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/fazecat/mongelmaker/Internal/alerts"
+	"github.com/fazecat/mongelmaker/Internal/cli"
 	datafeed "github.com/fazecat/mongelmaker/Internal/database"
+	"github.com/fazecat/mongelmaker/Internal/database/repository"
+	database "github.com/fazecat/mongelmaker/Internal/database/sqlc"
+	"github.com/fazecat/mongelmaker/Internal/database/storage"
 	"github.com/fazecat/mongelmaker/Internal/handlers"
 	newsscraping "github.com/fazecat/mongelmaker/Internal/news_scraping"
+	"github.com/fazecat/mongelmaker/Internal/strategy"
 	"github.com/fazecat/mongelmaker/Internal/utils"
 	"github.com/fazecat/mongelmaker/Internal/utils/config"
+	"github.com/fazecat/mongelmaker/Internal/utils/logger"
 	"github.com/fazecat/mongelmaker/Internal/utils/scanner"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
+	args := os.Args[1:]
+	app := &cli.App{Out: os.Stdout, Err: os.Stderr}
+	if cli.IsHelp(args) {
+		os.Exit(app.Run(context.Background(), args))
+	}
+
+	// headless commands write results to stdout, so everything else the app prints goes to stderr
+	headless := cli.IsHeadless(args)
+	if headless {
+		os.Stdout = os.Stderr
+	}
+
+	cfg, _ := config.LoadConfig()
+	logCloser, err := logger.Init(cfg)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		logger.Get().Warn("invalid logging config, using the default logger", logger.Err(err))
 	}
-	err = datafeed.InitDatabase()
+	log := logger.Component("main")
+
+	err = godotenv.Load()
+	if err != nil && !headless {
+		fatal(log, "Error loading .env file", err)
+	}
+	store, err := datafeed.InitDatabase(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal(log, "Failed to connect to database", err)
+	}
+	repos := repository.FromDB(store.Conn)
+	// the migrate command manages the schema itself, e.g. to inspect an edited migration
+	if cfg != nil && cfg.Database.AutoMigrate && !(len(args) > 0 && args[0] == "migrate") {
+		if err := applyMigrations(context.Background(), log, store); err != nil {
+			fatal(log, "Failed to migrate database", err)
+		}
 	}
-	defer datafeed.CloseDatabase()
 
 	// Test the retry logic
 	// utils.TestRetryLogic()
 	// "github.com/fazecat/mongelmaker/Internal/utils"
 
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	secretKey := os.Getenv("ALPACA_API_SECRET")
-
-	alpclient := alpaca.NewClient(alpaca.ClientOpts{
-		APIKey:    apiKey,
-		APISecret: secretKey,
-		BaseURL:   "https://paper-api.alpaca.markets",
-	})
-
-	req, _ := http.NewRequest("GET", "https://paper-api.alpaca.markets/v2/account", nil)
-	req.Header.Set("APCA-API-KEY-ID", apiKey)
-	req.Header.Set("APCA-API-SECRET-KEY", secretKey)
+	if err := datafeed.InitProvider(cfg, repos.Bars); err != nil {
+		fatal(log, "Failed to initialize market data provider", err)
+	}
+	fmt.Printf("📡 Market data provider: %s\n", datafeed.GetProvider().Name())
 
-	_, err = alpclient.GetAccount()
-	if err != nil {
-		log.Fatalln(err)
+	if err := strategy.InitSignalConfig(cfg); err != nil {
+		fatal(log, "Invalid signal configuration", err)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatalln(err)
+	// offline providers (file, database) and commands (migrate, watchlist) don't need a live Alpaca account
+	if datafeed.GetProvider().Name() == datafeed.ProviderAlpaca && cli.NeedsBroker(args) {
+		verifyAlpacaAccount()
 	}
-	defer resp.Body.Close()
 
-	cfg, _ := config.LoadConfig()
 	status, isOpen := utils.CheckMarketStatus(time.Now(), cfg)
 	fmt.Printf("📊 Market Status: %s (Open: %v)\n\n", status, isOpen)
 
-	// for the scouting feature
-	err = datafeed.InitAlpacaClient()
-	if err != nil {
-		log.Printf("Warning: Alpaca client initialization failed: %v\n", err)
-	}
-
 	finnhubClient := newsscraping.NewFinnhubClient()
 	_ = finnhubClient
 
 	ctx := context.Background()
-	go startBackgroundScanner(ctx, cfg)
+	if headless {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+
+	if cfg != nil {
+		dispatcher, err := alerts.NewDispatcherFromConfig(cfg, os.Stdout)
+		if err != nil {
+			log.Warn("alerts disabled", logger.Err(err))
+		} else {
+			alerts.SetDispatcher(dispatcher)
+			go dispatcher.Run(ctx)
+		}
+	}
 
+	app.Config = cfg
+	app.Queries = store.Queries
+	app.Store = store
+	app.Menu = func(ctx context.Context) error {
+		go startBackgroundScanner(ctx, scanner.New(cfg, repos))
+		runMenu(ctx, cfg, store.Queries, repos)
+		return nil
+	}
+
+	code := app.Run(ctx, args)
+	if dispatcher := alerts.GetDispatcher(); dispatcher != nil {
+		if err := dispatcher.Close(context.Background()); err != nil {
+			log.Warn("failed to close alerts", logger.Err(err))
+		}
+	}
+	store.Close()
+	logCloser.Close()
+	os.Exit(code)
+}
+
+func runMenu(ctx context.Context, cfg *config.Config, q *database.Queries, repos repository.Repositories) {
 	for {
 		fmt.Println("\n--- MongelMaker Menu ---")
 		fmt.Println("1. Scan Watchlist")
@@ -81,8 +132,11 @@ func main() {
 		fmt.Println("3. Run Screener")
 		fmt.Println("4. View Watchlist")
 		fmt.Println("5. Scout Symbols")
-		fmt.Println("6. Exit")
-		fmt.Print("Enter choice (1-6): ")
+		fmt.Println("6. Backtest Strategy")
+		fmt.Println("7. Trade Signals")
+		fmt.Println("8. Sync Bar Cache")
+		fmt.Println("9. Exit")
+		fmt.Print("Enter choice (1-9): ")
 
 		var choice int
 		_, err := fmt.Scanln(&choice)
@@ -93,16 +147,22 @@ func main() {
 
 		switch choice {
 		case 1:
-			handlers.HandleScan(ctx, cfg, datafeed.Queries)
+			handlers.HandleScan(ctx, cfg, repos)
 		case 2:
-			handlers.HandleAnalyzeSingle(ctx, datafeed.Queries)
+			handlers.HandleAnalyzeSingle(ctx, repos)
 		case 3:
-			handlers.HandleScreener(ctx, cfg, datafeed.Queries)
+			handlers.HandleScreener(ctx, cfg, q, repos)
 		case 4:
-			handlers.HandleWatchlist(ctx, datafeed.Queries)
+			handlers.HandleWatchlist(ctx, q)
 		case 5:
-			handlers.HandleScout(ctx, cfg, datafeed.Queries)
+			handlers.HandleScout(ctx, cfg, q, repos)
 		case 6:
+			handlers.HandleBacktest(ctx, q)
+		case 7:
+			handlers.HandleTrade(ctx, cfg, q)
+		case 8:
+			handlers.HandleSync(ctx, q)
+		case 9:
 			fmt.Println("Goodbye!")
 			return
 		default:
@@ -111,26 +171,73 @@ func main() {
 	}
 }
 
-func startBackgroundScanner(ctx context.Context, cfg *config.Config) {
-	log.Println("Background scanner started...")
+func startBackgroundScanner(ctx context.Context, s *scanner.Scanner) {
+	log := logger.Component("scanner").With(logger.KeyProfile, "default")
+	log.Info("background scanner started")
 	ticker := time.NewTicker(15 * time.Minute)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		select {
 		case <-ctx.Done():
-			log.Println("Background scanner stopped")
+			log.Info("background scanner stopped")
 			return
 		default:
-			log.Println("Background scanner tick - checking for scans...")
-			_, err := scanner.PerformScan(ctx, "default", cfg, datafeed.Queries)
+			log.Debug("background scanner tick")
+			_, err := s.PerformScan(ctx, "default")
 			if err != nil {
-				log.Printf("Background scan error: %v", err)
+				log.Error("background scan failed", logger.Err(err))
 			} else {
-				log.Println("Background scan completed successfully")
+				log.Info("background scan completed")
 			}
-			scanner.PerformScan(ctx, "default", cfg, datafeed.Queries)
+			s.PerformScan(ctx, "default")
 
 		}
 	}
 }
+
+func verifyAlpacaAccount() {
+	apiKey := os.Getenv("ALPACA_API_KEY")
+	secretKey := os.Getenv("ALPACA_API_SECRET")
+
+	alpclient := alpaca.NewClient(alpaca.ClientOpts{
+		APIKey:    apiKey,
+		APISecret: secretKey,
+		BaseURL:   "https://paper-api.alpaca.markets",
+	})
+
+	req, _ := http.NewRequest("GET", "https://paper-api.alpaca.markets/v2/account", nil)
+	req.Header.Set("APCA-API-KEY-ID", apiKey)
+	req.Header.Set("APCA-API-SECRET-KEY", secretKey)
+
+	log := logger.Component("main")
+	_, err := alpclient.GetAccount()
+	if err != nil {
+		fatal(log, "Alpaca account check failed", err)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fatal(log, "Alpaca account request failed", err)
+	}
+	defer resp.Body.Close()
+}
+
+func applyMigrations(ctx context.Context, log *slog.Logger, store *storage.Store) error {
+	migrator, err := store.Migrator()
+	if err != nil {
+		return err
+	}
+	ran, err := migrator.Up(ctx)
+	for _, m := range ran {
+		log.Info("applied migration", "migration", m.String())
+	}
+	return err
+}
+
+// fatal logs err and exits, the slog counterpart of log.Fatal
+func fatal(log *slog.Logger, msg string, err error) {
+	log.Error(msg, logger.Err(err))
+	os.Exit(1)
+}